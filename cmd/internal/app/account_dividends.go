@@ -1,7 +1,6 @@
 package app
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
 	"github.com/kpearce2430/stock-tools/model"
@@ -13,12 +12,26 @@ import (
 
 func (a *App) AccountDividends(c *gin.Context) {
 
-	if a.LookupSet == nil {
+	if a.lookupSet(c.Request.Context()) == nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
 		return
 	}
 
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
 	today := time.Now()
+	if !asOf.IsZero() {
+		today = asOf
+	}
 	septFirst23 := time.Date(2023, 9, 01, 00, 00, 00, 00, time.UTC)
 
 	years := (today.Year() - septFirst23.Year()) * 12
@@ -28,10 +41,12 @@ func (a *App) AccountDividends(c *gin.Context) {
 	worksheetName := c.DefaultQuery("name", "worksheet")
 
 	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
-	ws.Lookups = a.LookupSet
+	ws.Context = c.Request.Context()
+	ws.Lookups = lookups
 	ws.StockCache = a.StockCache
+	ws.AsOf = asOf
 
-	if err := ws.AccountDividends("Account Dividends", time.Now(), monthsAgo); err != nil {
+	if err := ws.AccountDividends("Account Dividends", today, monthsAgo); err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
@@ -46,6 +61,5 @@ func (a *App) AccountDividends(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", worksheetName))
-	c.Data(http.StatusOK, "application/octet-stream", buff.Bytes())
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
 }
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// readBody reads the request body and closes it.
+func readBody(c *gin.Context) ([]byte, error) {
+	defer func() {
+		if c != nil && c.Request != nil && c.Request.Body != nil {
+			if err := c.Request.Body.Close(); err != nil {
+				logrus.Error(err.Error())
+			}
+		}
+	}()
+	return io.ReadAll(c.Request.Body)
+}
+
+// LoadAccountsHandler stores the metadata of an account, or a list of them, from a JSON body.
+func (a *App) LoadAccountsHandler(c *gin.Context) {
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	accounts, err := model.NewAccountMetadataFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	for _, m := range accounts {
+		if err := m.ToDB(c.Request.Context(), a.PGXConn); err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+	}
+	if err := model.AccountRegistryLoad(c.Request.Context(), a.PGXConn); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, accounts)
+}
+
+// GetAccountHandler returns the metadata of the named account.
+func (a *App) GetAccountHandler(c *gin.Context) {
+	m, err := model.AccountMetadataGet(c.Request.Context(), a.PGXConn, c.Param("name"))
+	switch {
+	case model.IsAccountNotFound(err):
+		c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: err.Error()})
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, m)
+}
+
+// UpdateAccountHandler replaces the metadata of the named account from a JSON body.
+func (a *App) UpdateAccountHandler(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := model.AccountMetadataGet(c.Request.Context(), a.PGXConn, name); err != nil {
+		status := http.StatusInternalServerError
+		if model.IsAccountNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	accounts, err := model.NewAccountMetadataFromJSON(rawData)
+	switch {
+	case err != nil:
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	case len(accounts) != 1 || accounts[0].Name != name:
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "account name does not match"})
+		return
+	}
+
+	if err := accounts[0].ToDB(c.Request.Context(), a.PGXConn); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	if err := model.AccountRegistryLoad(c.Request.Context(), a.PGXConn); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, accounts[0])
+}
+
+// DeleteAccountHandler removes the metadata of the named account.
+func (a *App) DeleteAccountHandler(c *gin.Context) {
+	err := model.AccountMetadataDelete(c.Request.Context(), a.PGXConn, c.Param("name"))
+	switch {
+	case model.IsAccountNotFound(err):
+		c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: err.Error()})
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	if err := model.AccountRegistryLoad(c.Request.Context(), a.PGXConn); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, model.StatusObject{Status: "deleted"})
+}
+
+// loadAccounts adds the accounts in the transactions to the accounts table and loads the account registry
+// and the account events.
+func (a *App) loadAccounts(ctx context.Context) error {
+	if err := model.AccountsSeed(ctx, a.PGXConn); err != nil {
+		return err
+	}
+	if err := model.AccountEventsLoad(ctx, a.PGXConn); err != nil {
+		return err
+	}
+	return model.AccountRegistryLoad(ctx, a.PGXConn)
+}
+
+// GetAccountsHandler returns the metadata of every account.
+func (a *App) GetAccountsHandler(c *gin.Context) {
+	accounts, err := model.AccountMetadataGetAll(c.Request.Context(), a.PGXConn)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	list := make([]*model.AccountMetadata, 0, len(accounts))
+	for _, m := range accounts {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	c.IndentedJSON(http.StatusOK, list)
+}
+
+// LoadContributionLimitsHandler stores a contribution limit, or a list of them, from a JSON body.
+func (a *App) LoadContributionLimitsHandler(c *gin.Context) {
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	limits, err := model.NewContributionLimitsFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	for _, l := range limits {
+		if err := l.ToDB(c.Request.Context(), a.PGXConn); err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+	}
+	c.IndentedJSON(http.StatusOK, limits)
+}
+
+// GetContributionLimitsHandler returns the contribution limits for every year.
+func (a *App) GetContributionLimitsHandler(c *gin.Context) {
+	limits, err := model.ContributionLimitsGet(c.Request.Context(), a.PGXConn)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, limits)
+}
+
+// GetContributionReport returns the contributions against their limits and the distributions, for the
+// year and owner query parameters when they are given.
+func (a *App) GetContributionReport(c *gin.Context) {
+	year := 0
+	if value := c.DefaultQuery("year", ""); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: fmt.Sprintf("invalid year: %s", value)})
+			return
+		}
+	}
+
+	report, err := model.ContributionReportGet(c.Request.Context(), a.PGXConn, year, c.DefaultQuery("owner", ""))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/cmd/internal/handlers/indicators"
+	"github.com/kpearce2430/stock-tools/cmd/internal/handlers/symbollist"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiV1Prefix  = "/api/v1"
+	openAPIRoute = "/openapi.json"
+	apiVersion   = "1.0.0"
+)
+
+// APIError is the body of every error returned by the /api/v1 routes.
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// ErrorEnvelope wraps the APIError so an error is never mistaken for a resource.
+type ErrorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+// apiErrorCode returns the code of the status, not_found for a 404.
+func apiErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+// NewAPIError returns the error for the status with the message, or the text of the status without one.
+func NewAPIError(status int, message string, details any) ErrorEnvelope {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return ErrorEnvelope{Error: APIError{Code: apiErrorCode(status), Message: message, Details: details}}
+}
+
+// apiErrorFromBody returns the error for a body written by one of the handlers, which write a
+// StatusObject, a bare string or, for an error without exported fields, an empty object.
+func apiErrorFromBody(status int, body []byte) ErrorEnvelope {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return NewAPIError(status, "", nil)
+	}
+
+	var message string
+	if err := json.Unmarshal(body, &message); err == nil {
+		return NewAPIError(status, message, nil)
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal(body, &object); err == nil {
+		if envelope, ok := object["error"].(map[string]any); ok {
+			message, _ = envelope["message"].(string)
+			return NewAPIError(status, message, envelope["details"])
+		}
+		message, _ = object["status"].(string)
+		delete(object, "status")
+		if len(object) == 0 {
+			return NewAPIError(status, message, nil)
+		}
+		return NewAPIError(status, message, object)
+	}
+	return NewAPIError(status, string(body), nil)
+}
+
+// apiErrorWriter holds back the body of an error response so it can be rewritten as an ErrorEnvelope,
+// the other responses, including the worksheets, are written through.
+type apiErrorWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *apiErrorWriter) WriteHeader(code int) {
+	// As with gin, a status that is not positive, such as the -1 of an event of a stream, is not written.
+	if code <= 0 {
+		return
+	}
+	w.status = code
+	if code < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *apiErrorWriter) Write(data []byte) (int, error) {
+	if w.status >= http.StatusBadRequest {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *apiErrorWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *apiErrorWriter) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *apiErrorWriter) Written() bool {
+	return w.status >= http.StatusBadRequest || w.ResponseWriter.Written()
+}
+
+// errorEnvelope rewrites the error responses of the handlers as an ErrorEnvelope.
+func errorEnvelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &apiErrorWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.status < http.StatusBadRequest {
+			return
+		}
+		envelope := apiErrorFromBody(w.status, w.body.Bytes())
+		if envelope.Error.Details == nil && len(c.Errors) > 0 {
+			envelope.Error.Details = c.Errors.Errors()
+		}
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Writer.WriteHeader(w.status)
+		body, _ := json.MarshalIndent(envelope, "", "    ")
+		_, _ = c.Writer.Write(body)
+	}
+}
+
+// apiNotFound returns the error for a route that is not in the api.
+func apiNotFound(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, apiV1Prefix+"/") {
+		c.IndentedJSON(http.StatusNotFound, NewAPIError(http.StatusNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path, nil))
+	}
+}
+
+// apiRoute is a route of the /api/v1 group, which is registered with gin and described in the OpenAPI
+// document from the same table so the two cannot differ.
+type apiRoute struct {
+	method    string
+	path      string
+	tag       string
+	summary   string
+	query     []string
+	body      bool     // takes a JSON or csv body
+	worksheet bool     // returns an xlsx worksheet
+	produces  []string // media types of the response other than JSON
+	public    bool     // served without a bearer token
+	handler   gin.HandlerFunc
+}
+
+// apiV1Routes returns the routes of the api. The resources are plural nouns, a name of more than one word is
+// hyphenated, and a worksheet of a resource is at its /worksheet.
+func (a *App) apiV1Routes(s *symbollist.SymbolList) []apiRoute {
+	return []apiRoute{
+		{method: http.MethodGet, path: "/account-events", tag: "accounts", summary: "Account events of the portfolio", handler: a.GetAccountEventsHandler},
+		{method: http.MethodPost, path: "/account-events", tag: "accounts", summary: "Store account events", body: true, handler: a.LoadAccountEventsHandler},
+		{method: http.MethodGet, path: "/accounts", tag: "accounts", summary: "List the accounts", handler: a.GetAccountsHandler},
+		{method: http.MethodPost, path: "/accounts", tag: "accounts", summary: "Store accounts", body: true, handler: a.LoadAccountsHandler},
+		{method: http.MethodGet, path: "/accounts/names", tag: "accounts", summary: "List the account names of the lookups", handler: s.AccountListGet},
+		{method: http.MethodGet, path: "/accounts/dividends", tag: "accounts", summary: "Dividends by account", query: []string{"name", "as_of"}, worksheet: true, handler: a.AccountDividends},
+		{method: http.MethodGet, path: "/accounts/totals", tag: "accounts", summary: "Totals of each account", query: []string{"currency"}, handler: a.GetAccountTotals},
+		{method: http.MethodGet, path: "/accounts/totals/worksheet", tag: "accounts", summary: "Totals of each account as a worksheet", query: []string{"name", "currency"}, worksheet: true, handler: a.AccountTotalsWorksheetHandler},
+		{method: http.MethodGet, path: "/accounts/:name", tag: "accounts", summary: "Get an account", handler: a.GetAccountHandler},
+		{method: http.MethodPut, path: "/accounts/:name", tag: "accounts", summary: "Update an account", body: true, handler: a.UpdateAccountHandler},
+		{method: http.MethodDelete, path: "/accounts/:name", tag: "accounts", summary: "Delete an account", handler: a.DeleteAccountHandler},
+		{method: http.MethodGet, path: "/allocations/:group", tag: "rebalance", summary: "Get an allocation group", handler: a.GetAllocationHandler},
+		{method: http.MethodPut, path: "/allocations/:group", tag: "rebalance", summary: "Store an allocation group", body: true, handler: a.LoadAllocationHandler},
+		{method: http.MethodGet, path: "/allocations/:group/rebalance", tag: "rebalance", summary: "Trades to rebalance the group", query: []string{"account", "cash", "min", "as_of"}, handler: a.RebalanceHandler},
+		{method: http.MethodGet, path: "/allocations/:group/rebalance/worksheet", tag: "rebalance", summary: "Trades to rebalance the group as a worksheet", query: []string{"account", "cash", "min", "name", "as_of"}, worksheet: true, handler: a.RebalanceWorksheetHandler},
+		{method: http.MethodGet, path: "/cash", tag: "cash", summary: "Cash ledgers of the accounts", query: []string{"account"}, handler: a.GetCashLedgers},
+		{method: http.MethodGet, path: "/contributions", tag: "contributions", summary: "Contributions by owner and year", query: []string{"owner", "year"}, handler: a.GetContributionReport},
+		{method: http.MethodGet, path: "/contributions/limits", tag: "contributions", summary: "List the contribution limits", handler: a.GetContributionLimitsHandler},
+		{method: http.MethodPost, path: "/contributions/limits", tag: "contributions", summary: "Store contribution limits", body: true, handler: a.LoadContributionLimitsHandler},
+		{method: http.MethodPost, path: "/corporate-actions", tag: "corporate actions", summary: "Store corporate actions", body: true, handler: a.LoadCorporateActionsHandler},
+		{method: http.MethodGet, path: "/corporate-actions/:symbol", tag: "corporate actions", summary: "Corporate actions of a symbol", handler: a.GetCorporateActionsHandler},
+		{method: http.MethodPost, path: "/corporate-actions/:symbol/splits", tag: "corporate actions", summary: "Store the splits of a symbol", body: true, handler: a.LoadSplitsHandler},
+		{method: http.MethodGet, path: "/dividends", tag: "dividends", summary: "Dividends of every symbol", query: []string{"since"}, handler: a.GetAllDividends},
+		{method: http.MethodGet, path: "/dividends/calendar", tag: "dividends", summary: "Projected dividend calendar", query: []string{"symbol", "start", "months", "as_of"}, handler: a.GetDividendCalendar},
+		{method: http.MethodGet, path: "/dividends/calendar/worksheet", tag: "dividends", summary: "Projected dividend calendar as a worksheet", query: []string{"symbol", "start", "months", "name", "as_of"}, worksheet: true, handler: a.DividendCalendarWorksheetHandler},
+		{method: http.MethodGet, path: "/dividends/growth", tag: "dividends", summary: "Dividend growth of the symbols", query: []string{"symbol", "as_of"}, handler: a.GetDividendGrowth},
+		{method: http.MethodGet, path: "/dividends/growth/worksheet", tag: "dividends", summary: "Dividend growth as a worksheet", query: []string{"symbol", "as_of", "name"}, worksheet: true, handler: a.DividendGrowthWorksheetHandler},
+		{method: http.MethodGet, path: "/dividends/reconcile", tag: "dividends", summary: "Reconcile the dividends received with the declared", query: []string{"symbol", "as_of", "window", "status"}, handler: a.GetDividendReconciliation},
+		{method: http.MethodGet, path: "/dividends/:symbol", tag: "dividends", summary: "Dividends of a symbol", handler: a.GetDividendsFromDB},
+		{method: http.MethodGet, path: "/drip/:symbol", tag: "dividends", summary: "Project dividend reinvestment", query: []string{"years", "reinvest", "divgrowth", "pricegrowth"}, handler: a.GetDripProjection},
+		{method: http.MethodGet, path: "/drip/:symbol/backtest", tag: "dividends", summary: "Backtest dividend reinvestment", handler: a.GetDripBacktest},
+		{method: http.MethodGet, path: "/fixed-income", tag: "fixed income", summary: "List the fixed income terms", handler: a.GetFixedIncomeHandler},
+		{method: http.MethodPost, path: "/fixed-income", tag: "fixed income", summary: "Store fixed income terms", body: true, handler: a.LoadFixedIncomeHandler},
+		{method: http.MethodGet, path: "/fixed-income/cash-flows", tag: "fixed income", summary: "Cash flows of the bonds held", handler: a.GetBondCashFlows},
+		{method: http.MethodGet, path: "/fixed-income/ladder", tag: "fixed income", summary: "Maturity ladder of the bonds held", query: []string{"bucket"}, handler: a.GetBondLadder},
+		{method: http.MethodGet, path: "/fixed-income/ladder/worksheet", tag: "fixed income", summary: "Maturity ladder as a worksheet", query: []string{"bucket", "name"}, worksheet: true, handler: a.BondLadderWorksheetHandler},
+		{method: http.MethodGet, path: "/fixed-income/:cusip", tag: "fixed income", summary: "Get the terms of a bond", handler: a.GetFixedIncomeHandler},
+		{method: http.MethodGet, path: "/funds/expenses", tag: "funds", summary: "Expenses of the funds held", handler: a.GetFundExpenses},
+		{method: http.MethodGet, path: "/funds/gaps", tag: "funds", summary: "Missing NAVs of the funds", query: []string{"symbol", "start", "end"}, handler: a.GetFundGaps},
+		{method: http.MethodPost, path: "/funds/nav", tag: "funds", summary: "Ingest fund NAVs", query: []string{"symbol", "provider", "start", "end"}, handler: a.IngestFundNAVsHandler},
+		{method: http.MethodGet, path: "/funds/profiles", tag: "funds", summary: "List the fund profiles", handler: a.GetFundProfilesHandler},
+		{method: http.MethodPost, path: "/funds/profiles", tag: "funds", summary: "Store fund profiles", body: true, handler: a.LoadFundProfilesHandler},
+		{method: http.MethodGet, path: "/funds/:symbol/distributions", tag: "funds", summary: "Distributions of a fund", handler: a.GetFundDistributions},
+		{method: http.MethodPost, path: "/fx-rates", tag: "currencies", summary: "Load the rates of a currency pair", query: []string{"base", "quote", "source"}, body: true, handler: a.LoadFXRatesHandler},
+		{method: http.MethodGet, path: "/fx-rates/:base/:quote", tag: "currencies", summary: "Rate of a currency pair", query: []string{"date"}, handler: a.GetFXRate},
+		{method: http.MethodGet, path: "/graphql", tag: "graphql", summary: "Run a GraphQL query", query: []string{"query", "operationName", "variables"}, handler: a.GraphQLHandler},
+		{method: http.MethodPost, path: "/graphql", tag: "graphql", summary: "Run a GraphQL query", body: true, handler: a.GraphQLHandler},
+		{method: http.MethodPost, path: "/historical-prices", tag: "prices", summary: "Load historical prices", query: []string{"symbol", "source", "database"}, body: true, handler: a.LoadHistoricalData},
+		{method: http.MethodGet, path: "/holdings", tag: "holdings", summary: "Open lots of every symbol", query: []string{"as_of"}, handler: a.GetHoldingsHandler},
+		{method: http.MethodGet, path: "/holdings/:symbol", tag: "holdings", summary: "Open lots of a symbol", query: []string{"as_of"}, handler: a.GetSymbolHoldingsHandler},
+		{method: http.MethodGet, path: "/indicators/macd", tag: "prices", summary: "MACD of a symbol", handler: indicators.GetMACDRouter},
+		{method: http.MethodGet, path: "/indicators/rsi", tag: "prices", summary: "RSI of a symbol", handler: indicators.GetRsiRouter},
+		{method: http.MethodGet, path: "/lookups", tag: "lookups", summary: "Get the lookups", handler: a.GetLookupsFromPostgres},
+		{method: http.MethodPost, path: "/lookups", tag: "lookups", summary: "Load the lookups", body: true, handler: a.LoadLookupsToPostgres},
+		{method: http.MethodGet, path: "/me", tag: "users", summary: "The caller and their portfolio", handler: a.GetUserHandler},
+		{method: http.MethodGet, path: "/portfolios", tag: "portfolios", summary: "List the portfolios of the caller", handler: a.GetPortfoliosHandler},
+		{method: http.MethodPost, path: "/portfolios", tag: "portfolios", summary: "Create a portfolio", body: true, handler: a.CreatePortfolioHandler},
+		{method: http.MethodPost, path: "/portfolio-values", tag: "portfolio values", summary: "Load portfolio values", query: []string{"database", "juldate"}, body: true, handler: a.LoadDBPortfolioValueHandler},
+		{method: http.MethodGet, path: "/portfolio-values/:symbol", tag: "portfolio values", summary: "Portfolio value of a symbol", query: []string{"database", "juldate"}, handler: a.GetPortfolioValueHandler},
+		{method: http.MethodGet, path: "/quotes/:symbol", tag: "prices", summary: "Cached quote of a symbol", query: []string{"date"}, handler: a.GetStockCache},
+		{method: http.MethodGet, path: "/status", tag: "status", summary: "Status of the backends", public: true, handler: a.Status},
+		{method: http.MethodGet, path: "/stream", tag: "stream", summary: "Server-sent events of the portfolio", query: []string{"type"}, produces: []string{"text/event-stream"}, handler: a.StreamHandler},
+		{method: http.MethodGet, path: "/symbols", tag: "symbols", summary: "List the symbols", handler: s.SymbolListGet},
+		{method: http.MethodGet, path: "/symbols/detail/worksheet", tag: "symbols", summary: "Symbol detail as a worksheet", query: []string{"symbols", "table", "name", "as_of"}, worksheet: true, handler: a.CreateSymbolDetailHandler},
+		{method: http.MethodGet, path: "/symbols/:symbol", tag: "symbols", summary: "Holdings of a symbol", query: []string{"currency", "as_of"}, handler: s.TickerInfoGet},
+		{method: http.MethodGet, path: "/tokens", tag: "users", summary: "List the API tokens of the caller", handler: a.GetAPITokensHandler},
+		{method: http.MethodPost, path: "/tokens", tag: "users", summary: "Create an API token for the caller", query: []string{"name"}, handler: a.CreateAPITokenHandler},
+		{method: http.MethodGet, path: "/transactions", tag: "transactions", summary: "Query the transactions", query: []string{"account", "symbol", "type", "start", "end", "min", "max", "q", "sort", "limit", "cursor", "format", "database"}, produces: []string{"text/csv", "application/x-ndjson"}, handler: a.GetTransactionsHandler},
+		{method: http.MethodPost, path: "/transactions", tag: "transactions", summary: "Load transactions", query: []string{"database"}, body: true, handler: a.LoadTransactionsHandler},
+		{method: http.MethodGet, path: "/transactions/:id", tag: "transactions", summary: "Get a transaction", query: []string{"database"}, handler: a.GetTransactionHandler},
+		{method: http.MethodPost, path: "/transactions/:id", tag: "transactions", summary: "Enter a transaction by hand", query: []string{"database"}, body: true, handler: a.CreateTransactionHandler},
+		{method: http.MethodPatch, path: "/transactions/:id", tag: "transactions", summary: "Correct the fields of a transaction", query: []string{"database"}, body: true, handler: a.UpdateTransactionHandler},
+		{method: http.MethodDelete, path: "/transactions/:id", tag: "transactions", summary: "Delete a transaction", query: []string{"database"}, handler: a.DeleteTransactionHandler},
+		{method: http.MethodGet, path: "/transactions/:id/audit", tag: "transactions", summary: "Changes to a transaction", query: []string{"database"}, handler: a.GetTransactionAuditHandler},
+		{method: http.MethodGet, path: "/worksheet", tag: "worksheets", summary: "Portfolio worksheet", query: []string{"name", "juldate", "currency", "as_of"}, worksheet: true, handler: a.CreateWorksheetHandler},
+	}
+}
+
+// apiV1 registers the /api/v1 group and its OpenAPI document.
+func (a *App) apiV1(router *gin.Engine, s *symbollist.SymbolList) {
+	routes := a.apiV1Routes(s)
+	doc := newOpenAPIDocument(routes)
+
+	v1 := router.Group(apiV1Prefix, errorEnvelope())
+	authenticate := a.authenticate()
+	for _, r := range routes {
+		if r.public {
+			v1.Handle(r.method, r.path, r.handler)
+			continue
+		}
+		v1.Handle(r.method, r.path, authenticate, r.handler)
+	}
+	v1.GET(openAPIRoute, func(c *gin.Context) {
+		c.IndentedJSON(http.StatusOK, doc)
+	})
+}
@@ -0,0 +1,213 @@
+package app_test
+
+import (
+	"encoding/json"
+	"github.com/kpearce2430/stock-tools/cmd/internal/app"
+	"github.com/kpearce2430/stock-tools/cmd/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ginParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+func apiRequest(t *testing.T, a *app.App, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.Router().ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// TestAPIV1_OpenAPI checks every route registered in /api/v1 against the OpenAPI document, and every
+// operation of the document against the routes.
+func TestAPIV1_OpenAPI(t *testing.T) {
+	a := &app.App{}
+	w := apiRequest(t, a, http.MethodGet, "/api/v1/openapi.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.Code)
+	}
+
+	var doc app.OpenAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || len(doc.Servers) != 2 || doc.Servers[0].URL != "/api/v1" || doc.Servers[1].URL != "/portfolios/{portfolio}/api/v1" {
+		t.Fatalf("unexpected document %s %+v", doc.OpenAPI, doc.Servers)
+	}
+
+	registered := make(map[string]bool)
+	for _, r := range a.Router().Routes() {
+		path, ok := strings.CutPrefix(r.Path, "/api/v1")
+		if !ok {
+			continue
+		}
+		path = ginParam.ReplaceAllString(path, "{$1}")
+		method := strings.ToLower(r.Method)
+		registered[method+" "+path] = true
+
+		op, ok := doc.Paths[path][method]
+		if !ok {
+			t.Errorf("%s %s is not in the OpenAPI document", r.Method, r.Path)
+			continue
+		}
+		for _, m := range ginParam.FindAllStringSubmatch(r.Path, -1) {
+			found := false
+			for _, p := range op.Parameters {
+				found = found || (p.In == "path" && p.Name == m[1] && p.Required)
+			}
+			if !found {
+				t.Errorf("%s %s: the path parameter %s is not in the document", r.Method, r.Path, m[1])
+			}
+		}
+		if public := path == "/status" || path == "/openapi.json"; public != (len(op.Security) == 0) {
+			t.Errorf("%s %s: got security %v", r.Method, r.Path, op.Security)
+		}
+		if resp := op.Responses["default"]; resp == nil || resp.Content["application/json"] == nil ||
+			resp.Content["application/json"].Schema.Ref != "#/components/schemas/Error" {
+			t.Errorf("%s %s: the error response is not the error envelope", r.Method, r.Path)
+		}
+	}
+
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			if !registered[method+" "+path] {
+				t.Errorf("%s %s is in the OpenAPI document but not registered", method, path)
+			}
+		}
+	}
+}
+
+func TestAPIV1_ErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		method  string
+		path    string
+		status  int
+		code    string
+		message string
+	}{
+		{http.MethodGet, "/api/v1/unknown", http.StatusNotFound, "not_found", "no route for GET /api/v1/unknown"},
+		// A StatusObject.
+		{http.MethodGet, "/api/v1/symbols/detail/worksheet", http.StatusInternalServerError, "internal_server_error", "Lookup Not Loaded"},
+		// A bare string.
+		{http.MethodPost, "/api/v1/historical-prices", http.StatusBadRequest, "bad_request", "Missing Symbol"},
+		{http.MethodGet, "/api/v1/transactions?format=xml", http.StatusBadRequest, "bad_request", "invalid format: xml"},
+		{http.MethodGet, "/api/v1/transactions?database=pg_user", http.StatusBadRequest, "bad_request", "invalid database: pg_user"},
+		{http.MethodGet, "/api/v1/transactions?sort=description", http.StatusBadRequest, "bad_request", "invalid transaction sort: description"},
+		{http.MethodPatch, "/api/v1/transactions/first", http.StatusBadRequest, "bad_request", "invalid id: first"},
+		{http.MethodDelete, "/api/v1/transactions/12?database=lookups", http.StatusBadRequest, "bad_request", "invalid database: lookups"},
+		{http.MethodGet, "/api/v1/holdings?as_of=2024-12-32", http.StatusBadRequest, "bad_request", "invalid as_of: 2024-12-32"},
+		// The earlier asof of the dividend endpoints is read as as_of.
+		{http.MethodGet, "/api/v1/holdings/CSX?asof=12/31/2024", http.StatusBadRequest, "bad_request", "invalid asof: 12/31/2024"},
+		{http.MethodGet, "/api/v1/symbols/CSX?as_of=yesterday", http.StatusBadRequest, "bad_request", "invalid as_of: yesterday"},
+		{http.MethodGet, "/api/v1/graphql", http.StatusBadRequest, "bad_request", "missing query"},
+	}
+
+	for _, tt := range tests {
+		w := apiRequest(t, &app.App{}, tt.method, tt.path)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.status)
+			continue
+		}
+		var envelope app.ErrorEnvelope
+		if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+			t.Errorf("%s %s: %s in %s", tt.method, tt.path, err.Error(), w.Body.String())
+			continue
+		}
+		if envelope.Error.Code != tt.code || envelope.Error.Message != tt.message {
+			t.Errorf("%s %s: got %+v, want %s %s", tt.method, tt.path, envelope.Error, tt.code, tt.message)
+		}
+	}
+
+	// The earlier routes are unchanged.
+	if w := apiRequest(t, &app.App{}, http.MethodGet, "/symbol/detail"); !strings.Contains(w.Body.String(), `"status"`) {
+		t.Errorf("got %s, want a StatusObject", w.Body.String())
+	}
+}
+
+// TestAPIV1_Unauthorized checks the routes need a bearer token when there is an authenticator, which is
+// checked before the database is used.
+func TestAPIV1_Unauthorized(t *testing.T) {
+	keys, err := auth.ParseKeySet([]byte(`{"keys":[{"kty":"EC","kid":"ec-1","crv":"P-256",` +
+		`"x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU","y":"x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0"}]}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	a := &app.App{Auth: &app.Authenticator{Verifier: &auth.Verifier{Keys: keys}}}
+
+	tests := []struct {
+		path          string
+		authorization string
+	}{
+		{"/api/v1/holdings", ""},
+		{"/api/v1/transactions/12", "Basic dXNlcjpwYXNz"},
+		{"/api/v1/me", "Bearer "},
+		{"/api/v1/tokens", "Bearer eyJhbGciOiJub25lIn0.eyJzdWIiOiJ1c2VyLTEifQ."},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.authorization != "" {
+			r.Header.Set("Authorization", tt.authorization)
+		}
+		a.Router().ServeHTTP(w, r)
+
+		var envelope app.ErrorEnvelope
+		if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+			t.Errorf("%s: %s in %s", tt.path, err.Error(), w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusUnauthorized || envelope.Error.Code != "unauthorized" || w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: got %d %+v, want 401", tt.path, w.Code, envelope.Error)
+		}
+	}
+
+	// The document and the earlier routes.
+	if w := apiRequest(t, a, http.MethodGet, "/api/v1/openapi.json"); w.Code != http.StatusOK {
+		t.Errorf("got %d for the OpenAPI document, want 200", w.Code)
+	}
+	if w := apiRequest(t, a, http.MethodGet, "/holdings"); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"unauthorized"`) {
+		t.Errorf("got %d %s, want a 401 StatusObject", w.Code, w.Body.String())
+	}
+}
+
+// TestAPIV1_Portfolio checks the portfolio of a request is taken from the X-Portfolio header or the
+// /portfolios/{portfolio} prefix of the api and the earlier routes.
+func TestAPIV1_Portfolio(t *testing.T) {
+	tests := []struct {
+		path      string
+		header    string
+		status    int
+		portfolio string
+	}{
+		{"/api/v1/me", "", http.StatusOK, "default"},
+		{"/api/v1/me", "default", http.StatusOK, "default"},
+		{"/portfolios/default/api/v1/me", "", http.StatusOK, "default"},
+		{"/portfolios/default/me", "", http.StatusOK, "default"},
+		{"/api/v1/me", "Paper Trading", http.StatusBadRequest, ""},
+		{"/portfolios/kids%20utma/api/v1/me", "", http.StatusBadRequest, ""},
+		{"/portfolios/Kids/me", "", http.StatusBadRequest, ""},
+		{"/portfolios/default/api/v1/unknown", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			r.Header.Set("X-Portfolio", tt.header)
+		}
+		(&app.App{}).Router().ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: got %d %s, want %d", tt.path, tt.header, w.Code, w.Body.String(), tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var user struct {
+			Portfolio string `json:"portfolio"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil || user.Portfolio != tt.portfolio {
+			t.Errorf("%s %s: got %s, want portfolio %s", tt.path, tt.header, w.Body.String(), tt.portfolio)
+		}
+	}
+}
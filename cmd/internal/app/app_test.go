@@ -41,7 +41,7 @@ func createTestApp() (*app.App, error) {
 		return nil, err
 	}
 
-	if err := model.TransactionSetLoadToDB(a.PGXConn, a.LookupSet, app.TransactionTable, testTransactions); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), a.PGXConn, a.LookupSet, app.TransactionTable, testTransactions); err != nil {
 		logrus.Error(err.Error())
 		return nil, err
 	}
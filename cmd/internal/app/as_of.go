@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"time"
+)
+
+// asOfParam is the date (YYYY-MM-DD) the positions, prices and lookups of an endpoint are reconstructed as of.
+const asOfParam = "as_of"
+
+// queryAsOf returns the as_of date, or the asof date the dividend endpoints took before it. It is zero when
+// neither is given, which is the latest.
+func queryAsOf(c *gin.Context) (time.Time, error) {
+	if c.Query(asOfParam) == "" && c.Query("asof") != "" {
+		return queryDate(c, "asof", time.Time{})
+	}
+	return queryDate(c, asOfParam, time.Time{})
+}
+
+// lookupsAsOf returns the lookups as they were on the date, or the loaded lookups for a zero date.
+func (a *App) lookupsAsOf(ctx context.Context, asOf time.Time) (*model.LookUpSet, error) {
+	if asOf.IsZero() {
+		return a.lookupSet(ctx), nil
+	}
+	return model.GetLookUpsAsOf(ctx, a.PGXConn, lookupTableName, asOf)
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/kpearce2430/stock-tools/cmd/internal/auth"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// userKey is the authenticated model.User of a request in the gin context.
+	userKey = "user"
+
+	bearerPrefix = "Bearer "
+)
+
+var (
+	errNoBearerToken = errors.New("missing bearer token")
+	errNoAudience    = errors.New("AUTH_AUDIENCE is required with AUTH_JWKS_FILE")
+)
+
+// Authenticator takes the user of a request from its bearer token, an API token of the user or an OIDC
+// token signed by a key of the JWKS. Verifier is nil when only API tokens are accepted.
+type Authenticator struct {
+	Verifier *auth.Verifier
+}
+
+// newAuthenticator returns the authenticator from the environment, or nil when AUTH_DISABLED is true, which
+// serves every request from the default portfolio as before there were users. OIDC tokens are accepted when
+// AUTH_JWKS_FILE is set, for the AUTH_AUDIENCE that must be set with it.
+//
+// The first user is AUTH_BOOTSTRAP_USER, admin by default, with the API token AUTH_BOOTSTRAP_TOKEN, and is
+// added when the server starts without users. Until there is a user, and without a JWKS, authentication is
+// left off as with AUTH_DISABLED, so a server that is upgraded keeps serving its clients.
+func newAuthenticator(ctx context.Context, pg *pgxpool.Pool) (*Authenticator, error) {
+	if utils.GetEnv("AUTH_DISABLED", "false") == "true" {
+		logrus.Warn("Authentication disabled, every request is in the ", model.DefaultPortfolio, " portfolio")
+		return nil, nil
+	}
+
+	authenticator := Authenticator{}
+	if path := utils.GetEnv("AUTH_JWKS_FILE", ""); path != "" {
+		if utils.GetEnv("AUTH_AUDIENCE", "") == "" {
+			return nil, errNoAudience
+		}
+		keys, err := auth.LoadKeySet(path)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.Verifier = &auth.Verifier{
+			Keys:     keys,
+			Issuer:   utils.GetEnv("AUTH_ISSUER", ""),
+			Audience: utils.GetEnv("AUTH_AUDIENCE", ""),
+		}
+	}
+
+	if token := utils.GetEnv("AUTH_BOOTSTRAP_TOKEN", ""); token != "" {
+		name := utils.GetEnv("AUTH_BOOTSTRAP_USER", "admin")
+		added, err := model.BootstrapUser(ctx, pg, name, token)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			logrus.Info("Added the first user ", name, " with the AUTH_BOOTSTRAP_TOKEN")
+		}
+		return &authenticator, nil
+	}
+
+	exists, err := model.UsersExist(ctx, pg)
+	switch {
+	case err != nil:
+		return nil, err
+	case !exists && authenticator.Verifier == nil:
+		logrus.Warn("Authentication off until there is a user, start the server with AUTH_BOOTSTRAP_TOKEN to add one")
+		return nil, nil
+	case !exists:
+		logrus.Warn("No users, every request is refused until one is added with AUTH_BOOTSTRAP_TOKEN")
+	}
+	return &authenticator, nil
+}
+
+// user returns the user of the bearer token of the authorization header.
+func (au *Authenticator) user(ctx context.Context, a *App, authorization string) (*model.User, error) {
+	token, ok := strings.CutPrefix(authorization, bearerPrefix)
+	if !ok || strings.TrimSpace(token) == "" {
+		return nil, errNoBearerToken
+	}
+	token = strings.TrimSpace(token)
+
+	if model.IsAPIToken(token) || au.Verifier == nil {
+		return model.UserGetByToken(ctx, a.PGXConn, token)
+	}
+	claims, err := au.Verifier.Verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return model.UserGetBySubject(ctx, a.PGXConn, claims.Subject)
+}
+
+// requestScope returns the context of the queries of a request with the authorization header and the
+// portfolio it selects, and its user when there is an authenticator. The status is the one to respond with
+// when the request cannot be served, http.StatusUnauthorized for a missing or invalid token.
+func (a *App) requestScope(ctx context.Context, authorization, portfolio string) (context.Context, *model.User, int, error) {
+	var user *model.User
+	if a.Auth != nil {
+		var err error
+		user, err = a.Auth.user(ctx, a, authorization)
+		switch {
+		case errors.Is(err, errNoBearerToken), auth.IsTokenError(err), model.IsUserNotFound(err):
+			return nil, nil, http.StatusUnauthorized, err
+		case err != nil:
+			return nil, nil, http.StatusInternalServerError, err
+		}
+	}
+
+	portfolio, status, err := a.requestPortfolio(ctx, portfolio, user)
+	if err != nil {
+		return nil, nil, status, err
+	}
+	ctx = model.WithPortfolio(ctx, portfolio)
+	if err := a.loadPortfolio(ctx); err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+	return ctx, user, http.StatusOK, nil
+}
+
+// authenticate keeps the queries of the request to the portfolio of the user of its bearer token, or to
+// the portfolio the request selects when the user is a member of it. Every request is in the default
+// portfolio, or the one it selects, when there is no authenticator.
+func (a *App) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, user, status, err := a.requestScope(c.Request.Context(), c.GetHeader("Authorization"), c.GetHeader(portfolioHeader))
+		switch {
+		case status == http.StatusUnauthorized:
+			logrus.Info("Unauthorized ", c.Request.Method, " ", c.Request.URL.Path, ": ", err.Error())
+			c.Header("WWW-Authenticate", `Bearer realm="stock-tools"`)
+			c.IndentedJSON(http.StatusUnauthorized, model.StatusObject{Status: "unauthorized"})
+			c.Abort()
+			return
+		case err != nil:
+			c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+			c.Abort()
+			return
+		}
+		if user != nil {
+			c.Set(userKey, user)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// loadPortfolio reads the lookups, accounts and account events of the portfolio of the context the first
+// time it is used. The default portfolio is read when the server starts.
+func (a *App) loadPortfolio(ctx context.Context) error {
+	portfolio := model.PortfolioFromContext(ctx)
+	if portfolio == model.DefaultPortfolio || a.lookupSet(ctx) != nil && model.AccountRegistryLoaded(portfolio) {
+		return nil
+	}
+
+	lookups, err := model.GetLookUpsFromDB(ctx, a.PGXConn, lookupTableName)
+	if err != nil {
+		return err
+	}
+	a.setLookupSet(ctx, lookups)
+	if err := model.AccountEventsLoad(ctx, a.PGXConn); err != nil {
+		return err
+	}
+	return model.AccountRegistryLoad(ctx, a.PGXConn)
+}
+
+// lookupSet returns the lookups of the portfolio of the context.
+func (a *App) lookupSet(ctx context.Context) *model.LookUpSet {
+	portfolio := model.PortfolioFromContext(ctx)
+	if portfolio == model.DefaultPortfolio {
+		return a.LookupSet
+	}
+	a.lookupsLock.RLock()
+	defer a.lookupsLock.RUnlock()
+	return a.portfolioLookups[portfolio]
+}
+
+// setLookupSet sets the lookups of the portfolio of the context.
+func (a *App) setLookupSet(ctx context.Context, lookups *model.LookUpSet) {
+	portfolio := model.PortfolioFromContext(ctx)
+	if portfolio == model.DefaultPortfolio {
+		a.LookupSet = lookups
+		return
+	}
+	a.lookupsLock.Lock()
+	defer a.lookupsLock.Unlock()
+	if a.portfolioLookups == nil {
+		a.portfolioLookups = make(map[string]*model.LookUpSet)
+	}
+	a.portfolioLookups[portfolio] = lookups
+}
+
+// requestUser returns the name of the user making the request for the audit, the X-User header when
+// authentication is disabled.
+func requestUser(c *gin.Context) string {
+	if user, ok := c.Get(userKey); ok {
+		return user.(*model.User).Name
+	}
+	if user := strings.TrimSpace(c.GetHeader(userHeader)); user != "" {
+		return user
+	}
+	return defaultUser
+}
+
+// GetUserHandler returns the user of the request.
+func (a *App) GetUserHandler(c *gin.Context) {
+	user, ok := c.Get(userKey)
+	if !ok {
+		c.IndentedJSON(http.StatusOK, model.User{Name: requestUser(c), Portfolio: model.PortfolioFromContext(c.Request.Context())})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, user)
+}
+
+// GetAPITokensHandler returns the API tokens of the user of the request.
+func (a *App) GetAPITokensHandler(c *gin.Context) {
+	tokens, err := model.APITokensGet(c.Request.Context(), a.PGXConn, requestUser(c))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, tokens)
+}
+
+// CreateAPITokenHandler creates an API token for the user of the request, the token is only in the response.
+func (a *App) CreateAPITokenHandler(c *gin.Context) {
+	if _, ok := c.Get(userKey); !ok {
+		c.IndentedJSON(http.StatusForbidden, model.StatusObject{Status: "authentication is disabled"})
+		return
+	}
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "missing name"})
+		return
+	}
+
+	token, err := model.CreateAPIToken(c.Request.Context(), a.PGXConn, requestUser(c), name)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusCreated, token)
+}
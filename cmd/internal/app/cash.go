@@ -0,0 +1,75 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+	"net/http"
+)
+
+// GetCashLedgers returns the cash ledger of every account, or the account in the query parameter.
+func (a *App) GetCashLedgers(c *gin.Context) {
+	ledgers, err := model.CashLedgersGet(c.Request.Context(), a.PGXConn, c.DefaultQuery("account", ""))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	if len(ledgers) == 0 {
+		c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: "Account Not Found"})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, ledgers)
+}
+
+// GetAccountTotals returns the cash, market value and contributions of each account as JSON in the
+// currency query parameter (default USD).
+func (a *App) GetAccountTotals(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	totals, err := model.AccountTotalsGet(c.Request.Context(), a.PGXConn, a.lookupSet(c.Request.Context()), c.DefaultQuery("currency", model.DefaultCurrency))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, totals)
+}
+
+// AccountTotalsWorksheetHandler returns the cash, market value and contributions of each account as a worksheet.
+func (a *App) AccountTotalsWorksheetHandler(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	worksheetName := c.DefaultQuery("name", "account-totals")
+	totals, err := model.AccountTotalsGet(c.Request.Context(), a.PGXConn, a.lookupSet(c.Request.Context()), c.DefaultQuery("currency", model.DefaultCurrency))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
+	ws.Context = c.Request.Context()
+	ws.Lookups = a.lookupSet(c.Request.Context())
+	if err := ws.AccountTotals("Account Totals", totals); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if err := ws.File.DeleteSheet("Sheet1"); err != nil {
+		logrus.Error(err.Error())
+	}
+
+	buff, err := ws.File.WriteToBuffer()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
+}
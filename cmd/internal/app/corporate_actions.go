@@ -0,0 +1,77 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	polygonclient "github.com/kpearce2430/stock-tools/polygon-client"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+)
+
+// storeCorporateActions stores the actions and responds with them.
+func (a *App) storeCorporateActions(c *gin.Context, actions []*model.CorporateAction) {
+	for _, ca := range actions {
+		if err := ca.ToDB(c.Request.Context(), a.PGXConn); err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+	}
+	c.IndentedJSON(http.StatusOK, actions)
+}
+
+// LoadCorporateActionsHandler stores a corporate action, or a list of them, from a JSON body.
+func (a *App) LoadCorporateActionsHandler(c *gin.Context) {
+	defer func() {
+		if c != nil && c.Request != nil && c.Request.Body != nil {
+			if err := c.Request.Body.Close(); err != nil {
+				logrus.Error(err.Error())
+			}
+		}
+	}()
+
+	rawData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	actions, err := model.NewCorporateActionsFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.storeCorporateActions(c, actions)
+}
+
+// LoadSplitsHandler stores the splits for the symbol from Polygon as corporate actions.
+func (a *App) LoadSplitsHandler(c *gin.Context) {
+	symbol := c.Param("symbol")
+	if symbol == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "Missing Symbol"})
+		return
+	}
+
+	data, err := polygonclient.NewPolygonClient("").GetSplits(symbol)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	actions, err := model.NewCorporateActionsFromSplits(data)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.storeCorporateActions(c, actions)
+}
+
+// GetCorporateActionsHandler returns the corporate actions for the symbol or that give shares in it.
+func (a *App) GetCorporateActionsHandler(c *gin.Context) {
+	actions, err := model.CorporateActionsGetBySymbol(c.Request.Context(), a.PGXConn, c.Param("symbol"))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, actions)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"net/http"
+	"time"
+)
+
+// LoadFXRatesHandler loads the csv of the rates of the base in the quote currency, with the Date and the
+// Rate or Close columns, into fx_rates.
+func (a *App) LoadFXRatesHandler(c *gin.Context) {
+	base, quote := c.DefaultQuery("base", ""), c.DefaultQuery("quote", model.DefaultCurrency)
+	if base == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "Missing Base"})
+		return
+	}
+
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	count, err := model.FXRatesLoadDB(c.Request.Context(), a.PGXConn, base, quote, c.DefaultQuery("source", "csv"), string(rawData))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, model.StatusObject{Status: fmt.Sprintf("completed: %d loaded", count)})
+}
+
+// GetFXRate returns the rate from the base to the quote currency on the date query parameter (default
+// today), crossed through USD when the pair has no rates.
+func (a *App) GetFXRate(c *gin.Context) {
+	date, err := queryDate(c, "date", time.Now())
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	base, err := model.NormalizeCurrency(c.Param("base"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	quote, err := model.NormalizeCurrency(c.Param("quote"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	rates, err := model.FXRateSetGet(c.Request.Context(), a.PGXConn, base, quote, model.DefaultCurrency)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	rate, err := rates.Rate(base, quote, date)
+	switch {
+	case model.IsFXRateNotFound(err):
+		c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: err.Error()})
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, model.FXRate{Base: base, Quote: quote, Date: date, Rate: rate})
+}
@@ -3,17 +3,23 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
 	"github.com/kpearce2430/stock-tools/model"
 	polygonclient "github.com/kpearce2430/stock-tools/polygon-client"
 	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func (a *App) getDividends(symbol string) (model.DividendsSet, error) {
+func (a *App) getDividends(symbol string, since string) (model.DividendsSet, error) {
 	client := polygonclient.NewPolygonClient("")
 
-	set, err := client.GetDataSet(symbol)
+	set, err := client.GetDataSet(symbol, since)
 	if err != nil {
 		logrus.Error(err.Error())
 		return model.DividendsSet{}, err
@@ -42,7 +48,7 @@ func (a *App) GetDividendsFromDB(c *gin.Context) {
 	var ds model.DividendsSet
 	err := ds.FromDBbySymbol(context.Background(), a.PGXConn, "dividends", symbol)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, err)
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
 	c.IndentedJSON(http.StatusOK, ds)
@@ -57,9 +63,9 @@ func (a *App) GetDividends(c *gin.Context) {
 		return
 	}
 
-	ds, err := a.getDividends(symbol)
+	ds, err := a.getDividends(symbol, c.DefaultQuery("since", ""))
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, err)
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
 	c.IndentedJSON(http.StatusOK, ds)
@@ -75,9 +81,9 @@ func (a *App) GetDividends(c *gin.Context) {
 }
 
 func (a *App) GetAllDividends(c *gin.Context) {
-	symbolMap, err := model.PortfolioValueGetTypes(a.PGXConn, PortfolioValueDB)
+	symbolMap, err := model.PortfolioValueGetTypes(c.Request.Context(), a.PGXConn, PortfolioValueDB)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, err)
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
 
@@ -89,9 +95,9 @@ func (a *App) GetAllDividends(c *gin.Context) {
 			continue
 		}
 
-		ds, err := a.getDividends(symbol)
+		ds, err := a.getDividends(symbol, c.DefaultQuery("since", ""))
 		if err != nil {
-			c.IndentedJSON(http.StatusInternalServerError, err)
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 			return
 		}
 
@@ -100,7 +106,7 @@ func (a *App) GetAllDividends(c *gin.Context) {
 		//go func() {
 		err = ds.ToDB(context.Background(), a.PGXConn, "dividends")
 		if err != nil {
-			c.IndentedJSON(http.StatusInternalServerError, err)
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 			return
 		}
 		logrus.Info("loaded ", len(ds.Dividends), " for ", symbol)
@@ -109,3 +115,200 @@ func (a *App) GetAllDividends(c *gin.Context) {
 	}
 	c.IndentedJSON(http.StatusOK, symbolMap)
 }
+
+// dividendCalendar projects the dividend income using the symbol, start (YYYY-MM-DD), months and as_of query
+// parameters. The shares are those held on as_of, which is also the default start.
+func (a *App) dividendCalendar(c *gin.Context) (*model.DividendCalendar, int, error) {
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	start := time.Now()
+	if !asOf.IsZero() {
+		start = asOf
+	}
+	if startDate := c.DefaultQuery("start", ""); startDate != "" {
+		if start, err = time.Parse("2006-01-02", startDate); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	months, err := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if err != nil || months < 1 {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid months: %s", c.Query("months"))
+	}
+
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	calendar, err := model.DividendCalendarGet(c.Request.Context(), a.PGXConn, lookups, c.DefaultQuery("symbol", ""), start, months, asOf)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return calendar, http.StatusOK, nil
+}
+
+// GetDividendCalendar returns the expected dividend income for each month as JSON.
+func (a *App) GetDividendCalendar(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	calendar, status, err := a.dividendCalendar(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, calendar)
+}
+
+// DividendCalendarWorksheetHandler returns the expected dividend income for each month as a worksheet.
+func (a *App) DividendCalendarWorksheetHandler(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	worksheetName := c.DefaultQuery("name", "dividend-calendar")
+	calendar, status, err := a.dividendCalendar(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
+	ws.Context = c.Request.Context()
+	ws.Lookups = a.lookupSet(c.Request.Context())
+	if err := ws.DividendCalendar("Dividend Calendar", calendar); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if err := ws.File.DeleteSheet("Sheet1"); err != nil {
+		logrus.Error(err.Error())
+	}
+
+	buff, err := ws.File.WriteToBuffer()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
+}
+
+// GetDividendReconciliation compares the declared dividends with the dividends received, using the
+// symbol, status (comma separated), window (days) and as_of (YYYY-MM-DD) query parameters.
+func (a *App) GetDividendReconciliation(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	opts := model.DefaultReconcileOptions()
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	if !asOf.IsZero() {
+		opts.AsOf = asOf
+	}
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if window := c.DefaultQuery("window", ""); window != "" {
+		days, err := strconv.Atoi(window)
+		if err != nil || days < 0 {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: fmt.Sprintf("invalid window: %s", window)})
+			return
+		}
+		opts.WindowDays = days
+	}
+
+	var statuses []string
+	if status := c.DefaultQuery("status", ""); status != "" {
+		statuses = strings.Split(status, ",")
+	}
+
+	results, err := model.DividendReconciliationGet(c.Request.Context(), a.PGXConn, lookups, c.DefaultQuery("symbol", ""), statuses, opts)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, results)
+}
+
+// dividendGrowth computes the dividend growth using the symbol and as_of (YYYY-MM-DD) query parameters.
+func (a *App) dividendGrowth(c *gin.Context) ([]*model.DividendGrowth, int, error) {
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	growth, err := model.DividendGrowthGet(c.Request.Context(), a.PGXConn, lookups, c.DefaultQuery("symbol", ""), asOf)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return growth, http.StatusOK, nil
+}
+
+// GetDividendGrowth returns the dividend growth rates and yields for each symbol as JSON.
+func (a *App) GetDividendGrowth(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	growth, status, err := a.dividendGrowth(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, growth)
+}
+
+// DividendGrowthWorksheetHandler returns the dividend growth rates and yields for each symbol as a worksheet.
+func (a *App) DividendGrowthWorksheetHandler(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	worksheetName := c.DefaultQuery("name", "dividend-growth")
+	growth, status, err := a.dividendGrowth(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
+	ws.Context = c.Request.Context()
+	ws.Lookups = a.lookupSet(c.Request.Context())
+	if err := ws.DividendGrowth("Dividend Growth", growth); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if err := ws.File.DeleteSheet("Sheet1"); err != nil {
+		logrus.Error(err.Error())
+	}
+
+	buff, err := ws.File.WriteToBuffer()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
+}
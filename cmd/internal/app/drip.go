@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"net/http"
+	"strconv"
+)
+
+// floatQuery returns the query parameter as a float, or the default when it is not set.
+func floatQuery(c *gin.Context, key string, defaultValue float64) (float64, error) {
+	value := c.DefaultQuery(key, "")
+	if value == "" {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0.00, fmt.Errorf("invalid %s: %s", key, value)
+	}
+	return f, nil
+}
+
+// GetDripProjection projects the shares and income of the symbol forward using the years (default 10),
+// divgrowth, pricegrowth and reinvest (default true) query parameters.
+func (a *App) GetDripProjection(c *gin.Context) {
+	symbol := c.Param("symbol")
+	if symbol == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "Missing Symbol"})
+		return
+	}
+
+	years, err := strconv.Atoi(c.DefaultQuery("years", "10"))
+	if err != nil || years < 1 {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: fmt.Sprintf("invalid years: %s", c.Query("years"))})
+		return
+	}
+
+	reinvest, err := strconv.ParseBool(c.DefaultQuery("reinvest", "true"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: fmt.Sprintf("invalid reinvest: %s", c.Query("reinvest"))})
+		return
+	}
+
+	assumptions := model.DripAssumptions{Years: years, Reinvest: reinvest}
+	if assumptions.DividendGrowth, err = floatQuery(c, "divgrowth", 0.00); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	if assumptions.PriceGrowth, err = floatQuery(c, "pricegrowth", 0.00); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	projection, err := model.DripProjectionGet(c.Request.Context(), a.PGXConn, symbol, assumptions)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, projection)
+}
+
+// GetDripBacktest compares reinvesting the dividends of the symbol against taking cash over the holding period.
+func (a *App) GetDripBacktest(c *gin.Context) {
+	symbol := c.Param("symbol")
+	if symbol == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "Missing Symbol"})
+		return
+	}
+
+	backtest, err := model.DripBacktestGet(c.Request.Context(), a.PGXConn, symbol)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, backtest)
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+	"net/http"
+	"time"
+)
+
+// LoadFixedIncomeHandler stores the terms of a bond, or a list of them, from a JSON body. A bond with
+// only the cusip and description reads the terms from the description of a brokered CD.
+func (a *App) LoadFixedIncomeHandler(c *gin.Context) {
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	bonds, err := model.NewFixedIncomeFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	for _, f := range bonds {
+		if err := f.ToDB(c.Request.Context(), a.PGXConn); err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+	}
+	c.IndentedJSON(http.StatusOK, bonds)
+}
+
+// GetFixedIncomeHandler returns the terms of every bond, or the bond in the cusip parameter.
+func (a *App) GetFixedIncomeHandler(c *gin.Context) {
+	if cusip := c.Param("cusip"); cusip != "" {
+		f, err := model.FixedIncomeGet(c.Request.Context(), a.PGXConn, cusip)
+		switch {
+		case model.IsFixedIncomeNotFound(err):
+			c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: err.Error()})
+			return
+		case err != nil:
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, f)
+		return
+	}
+
+	bonds, err := model.FixedIncomeGetAll(c.Request.Context(), a.PGXConn)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, bonds)
+}
+
+// bondLadder builds the maturity ladder of the bonds held using the bucket (default year) query parameter.
+func (a *App) bondLadder(c *gin.Context) ([]*model.LadderRung, int, error) {
+	holdings, err := model.BondHoldingsGet(c.Request.Context(), a.PGXConn, time.Now())
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	ladder, err := model.MaturityLadder(holdings, c.DefaultQuery("bucket", model.LadderByYear))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return ladder, http.StatusOK, nil
+}
+
+// GetBondLadder returns the bonds held grouped by when they mature as JSON.
+func (a *App) GetBondLadder(c *gin.Context) {
+	ladder, status, err := a.bondLadder(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, ladder)
+}
+
+// BondLadderWorksheetHandler returns the bonds held grouped by when they mature as a worksheet.
+func (a *App) BondLadderWorksheetHandler(c *gin.Context) {
+	worksheetName := c.DefaultQuery("name", "bond-ladder")
+	ladder, status, err := a.bondLadder(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
+	ws.Context = c.Request.Context()
+	if err := ws.BondLadder("Bond Ladder", ladder); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if err := ws.File.DeleteSheet("Sheet1"); err != nil {
+		logrus.Error(err.Error())
+	}
+
+	buff, err := ws.File.WriteToBuffer()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
+}
+
+// GetBondCashFlows returns the coupons and principal expected from the bonds held.
+func (a *App) GetBondCashFlows(c *gin.Context) {
+	now := time.Now()
+	holdings, err := model.BondHoldingsGet(c.Request.Context(), a.PGXConn, now)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, model.BondCashFlows(holdings, now))
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/cmd/internal/graph"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+const graphqlRoute = "/graphql"
+
+// graphqlRequest is a GraphQL query, the JSON body of a POST or the query parameters of a GET.
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// graphSchema returns the GraphQL schema, which is parsed the first time it is used.
+func (a *App) graphSchema() *graph.Schema {
+	a.graphOnce.Do(func() {
+		var err error
+		if a.graph, err = graph.NewSchema(a.PGXConn, a.lookupSet); err != nil {
+			logrus.Fatal("Error parsing the GraphQL schema:", err.Error())
+		}
+	})
+	return a.graph
+}
+
+// GraphQLHandler runs a GraphQL query on the portfolio of the request. A query is read from a JSON body, a
+// body of type application/graphql, or the query, operationName and variables query parameters of a GET.
+// The errors of a query that runs are in the response with the data, as GraphQL clients expect them.
+func (a *App) GraphQLHandler(c *gin.Context) {
+	var req graphqlRequest
+	switch {
+	case c.Request.Method == http.MethodGet:
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid variables: " + err.Error()})
+				return
+			}
+		}
+	default:
+		rawData, err := readBody(c)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+			return
+		}
+		if strings.HasPrefix(c.ContentType(), "application/graphql") {
+			req.Query = string(rawData)
+		} else if err := json.Unmarshal(rawData, &req); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+			return
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "missing query"})
+		return
+	}
+
+	c.JSON(http.StatusOK, a.graphSchema().Exec(c.Request.Context(), req.Query, req.OperationName, req.Variables))
+}
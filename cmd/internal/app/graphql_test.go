@@ -0,0 +1,53 @@
+package app_test
+
+import (
+	"encoding/json"
+	"github.com/kpearce2430/stock-tools/cmd/internal/app"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestApp_GraphQLHandler checks a query that does not match the schema is answered with its errors, as
+// GraphQL clients expect, and a request without a query is refused.
+func TestApp_GraphQLHandler(t *testing.T) {
+	a := &app.App{}
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+		errors      bool
+	}{
+		{"application/json", `{"query":"{ symbols { price } }"}`, http.StatusOK, true},
+		{"application/graphql", `{ accounts { holdings(asOf: 20240101) { shares } } }`, http.StatusOK, true},
+		{"application/json", `{"query":""}`, http.StatusBadRequest, false},
+		{"application/json", `query`, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		a.Router().ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d: %s", tt.body, w.Code, tt.status, w.Body.String())
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s: %s", tt.body, err.Error())
+			continue
+		}
+		if tt.errors != (len(resp.Errors) > 0) {
+			t.Errorf("%s: got %s", tt.body, w.Body.String())
+		}
+	}
+}
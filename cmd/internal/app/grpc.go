@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	business_days "github.com/kpearce2430/keputils/business-days"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/kpearce2430/stock-tools/model"
+	stocktoolsv1 "github.com/kpearce2430/stock-tools/proto/stocktools/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// grpcCodes are the codes of the statuses of requestScope.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:   codes.InvalidArgument,
+	http.StatusUnauthorized: codes.Unauthenticated,
+	http.StatusForbidden:    codes.PermissionDenied,
+	http.StatusNotFound:     codes.NotFound,
+}
+
+// NewGRPCServer returns the gRPC server of the StockTools service of stocktools.proto, with the reflection
+// service so clients such as grpcurl can list and call it.
+func (a *App) NewGRPCServer() *grpc.Server {
+	s := grpc.NewServer(grpc.UnaryInterceptor(a.grpcAuthenticate))
+	stocktoolsv1.RegisterStockToolsServer(s, &stockToolsServer{a: a})
+	reflection.Register(s)
+	return s
+}
+
+// grpcAuthenticate keeps the queries of a call to a portfolio as authenticate does for a request, from the
+// authorization and x-portfolio metadata.
+func (a *App) grpcAuthenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	ctx, _, code, err := a.requestScope(ctx, first("authorization"), first(strings.ToLower(portfolioHeader)))
+	if err != nil {
+		grpcCode, ok := grpcCodes[code]
+		if !ok {
+			grpcCode = codes.Internal
+		}
+		if grpcCode == codes.Unauthenticated {
+			logrus.Info("Unauthenticated ", info.FullMethod, ": ", err.Error())
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		return nil, status.Error(grpcCode, err.Error())
+	}
+	return handler(ctx, req)
+}
+
+type stockToolsServer struct {
+	stocktoolsv1.UnimplementedStockToolsServer
+	a *App
+}
+
+// grpcDate returns the date of a field of a request, or the zero time when it is empty.
+func grpcDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "invalid %s: %s", name, value)
+	}
+	return date, nil
+}
+
+// decimalString returns the decimal, or an empty string for zero when it is not set.
+func decimalString(d model.Decimal, omitZero bool) string {
+	if omitZero && d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
+
+func (s *stockToolsServer) ListHoldings(ctx context.Context, req *stocktoolsv1.ListHoldingsRequest) (*stocktoolsv1.ListHoldingsResponse, error) {
+	asOf, err := grpcDate("as_of", req.AsOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var holdings []*model.Holding
+	if req.Symbol == "" {
+		holdings, err = model.HoldingsGetAll(ctx, s.a.PGXConn, asOf)
+	} else {
+		holdings, err = model.HoldingsGet(ctx, s.a.PGXConn, req.Symbol, asOf)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.a.setHoldingPrices(holdings, asOf)
+
+	resp := stocktoolsv1.ListHoldingsResponse{Holdings: make([]*stocktoolsv1.Holding, len(holdings))}
+	for i, h := range holdings {
+		resp.Holdings[i] = &stocktoolsv1.Holding{
+			Symbol:          h.Symbol,
+			Security:        h.Security,
+			Account:         h.Account,
+			Type:            string(h.Type),
+			Acquired:        timestamppb.New(h.Acquired),
+			OriginalShares:  decimalString(h.OriginalShares, false),
+			RemainingShares: decimalString(h.RemainingShares, false),
+			CostPerShare:    decimalString(h.CostPerShare, false),
+			Cost:            decimalString(h.Cost, false),
+			HoldingPeriod:   h.HoldingPeriod,
+			DaysHeld:        int32(h.DaysHeld),
+			LatestPrice:     decimalString(h.LatestPrice, true),
+			MarketValue:     decimalString(h.MarketValue, true),
+			UnrealizedGain:  decimalString(h.UnrealizedGain, h.LatestPrice.IsZero()),
+			Currency:        h.Currency,
+		}
+	}
+	return &resp, nil
+}
+
+func (s *stockToolsServer) ListTransactions(ctx context.Context, req *stocktoolsv1.ListTransactionsRequest) (*stocktoolsv1.ListTransactionsResponse, error) {
+	q := model.TransactionQuery{
+		Accounts: req.Accounts,
+		Symbols:  req.Symbols,
+		Types:    req.Types,
+		Text:     req.Text,
+		Sort:     req.Sort,
+		Limit:    int(req.Limit),
+		Cursor:   req.Cursor,
+	}
+	var err error
+	if q.Start, err = grpcDate("start", req.Start); err != nil {
+		return nil, err
+	}
+	if q.End, err = grpcDate("end", req.End); err != nil {
+		return nil, err
+	}
+
+	page, err := model.QueryTransactions(ctx, s.a.PGXConn, TransactionTable, &q)
+	switch {
+	case model.IsTransactionQueryError(err):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := stocktoolsv1.ListTransactionsResponse{
+		Transactions: make([]*stocktoolsv1.Transaction, len(page.Transactions)),
+		NextCursor:   page.NextCursor,
+	}
+	for i, tr := range page.Transactions {
+		resp.Transactions[i] = &stocktoolsv1.Transaction{
+			Id:               int64(tr.Id),
+			Date:             timestamppb.New(tr.Date),
+			Type:             string(tr.Type),
+			Security:         tr.Security,
+			Symbol:           tr.Symbol,
+			SecurityPayee:    tr.SecurityPayee,
+			Description:      tr.Description,
+			Shares:           decimalString(tr.Shares, false),
+			InvestmentAmount: decimalString(tr.InvestmentAmount, false),
+			Amount:           decimalString(tr.Amount, false),
+			Account:          tr.Account,
+			Currency:         tr.Currency,
+		}
+	}
+	return &resp, nil
+}
+
+func (s *stockToolsServer) GetQuote(ctx context.Context, req *stocktoolsv1.GetQuoteRequest) (*stocktoolsv1.Quote, error) {
+	if req.Symbol == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing symbol")
+	}
+	date, err := grpcDate("date", req.Date)
+	if err != nil {
+		return nil, err
+	}
+	if s.a.StockCache == nil {
+		return nil, status.Error(codes.Unavailable, "quote cache not configured")
+	}
+
+	var args []string
+	if !date.IsZero() {
+		args = append(args, utils.JulDateFromTime(business_days.GetBusinessDay(date)))
+	}
+	quote, err := s.a.StockCache.GetCache(req.Symbol, args...)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if quote == nil {
+		return nil, status.Errorf(codes.NotFound, "no quote for %s", req.Symbol)
+	}
+	return &stocktoolsv1.Quote{
+		Symbol:     quote.Symbol,
+		From:       quote.From,
+		Open:       quote.Open,
+		High:       quote.High,
+		Low:        quote.Low,
+		Close:      quote.Close,
+		Volume:     quote.Volume,
+		AfterHours: quote.AfterHours,
+		PreMarket:  quote.PreMarket,
+	}, nil
+}
+
+func (s *stockToolsServer) GetDividendHistory(ctx context.Context, req *stocktoolsv1.GetDividendHistoryRequest) (*stocktoolsv1.DividendHistory, error) {
+	if req.Symbol == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing symbol")
+	}
+	dh, err := model.DividendHistoryFromDB(ctx, s.a.PGXConn, req.Symbol, int(req.Year), int(req.Month))
+	switch {
+	case model.IsDividendHistoryArgumentError(err):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := stocktoolsv1.DividendHistory{Symbol: req.Symbol, Entries: make([]*stocktoolsv1.DividendEntry, len(dh.DividendEntries))}
+	for i, d := range dh.DividendEntries {
+		resp.Entries[i] = &stocktoolsv1.DividendEntry{Year: int32(d.Year), Month: int32(d.Month), Amount: d.Amount}
+	}
+	return &resp, nil
+}
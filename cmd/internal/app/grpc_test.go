@@ -0,0 +1,107 @@
+package app_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/cmd/internal/app"
+	"github.com/kpearce2430/stock-tools/cmd/internal/auth"
+	stocktoolsv1 "github.com/kpearce2430/stock-tools/proto/stocktools/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+// grpcClient returns a client of the gRPC server of the app, served in memory until the test ends.
+func grpcClient(t *testing.T, a *app.App) stocktoolsv1.StockToolsClient {
+	listener := bufconn.Listen(1 << 20)
+	s := a.NewGRPCServer()
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	t.Cleanup(s.GracefulStop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return stocktoolsv1.NewStockToolsClient(conn)
+}
+
+// TestApp_GRPC checks the requests that are refused before the database or the quote cache is used.
+func TestApp_GRPC(t *testing.T) {
+	client := grpcClient(t, &app.App{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+		code codes.Code
+	}{
+		{"quote without a symbol", func(ctx context.Context) error {
+			_, err := client.GetQuote(ctx, &stocktoolsv1.GetQuoteRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"quote without the cache", func(ctx context.Context) error {
+			_, err := client.GetQuote(ctx, &stocktoolsv1.GetQuoteRequest{Symbol: "CSX", Date: "2024-12-31"})
+			return err
+		}, codes.Unavailable},
+		{"holdings as of an invalid date", func(ctx context.Context) error {
+			_, err := client.ListHoldings(ctx, &stocktoolsv1.ListHoldingsRequest{AsOf: "2024-12-32"})
+			return err
+		}, codes.InvalidArgument},
+		{"transactions from an invalid date", func(ctx context.Context) error {
+			_, err := client.ListTransactions(ctx, &stocktoolsv1.ListTransactionsRequest{Start: "yesterday"})
+			return err
+		}, codes.InvalidArgument},
+		{"dividend history without a symbol", func(ctx context.Context) error {
+			_, err := client.GetDividendHistory(ctx, &stocktoolsv1.GetDividendHistoryRequest{Year: 2024})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid portfolio", func(ctx context.Context) error {
+			_, err := client.GetQuote(metadata.AppendToOutgoingContext(ctx, "x-portfolio", "Kids UTMA"), &stocktoolsv1.GetQuoteRequest{Symbol: "CSX"})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if got := status.Code(tt.call(ctx)); got != tt.code {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.code)
+		}
+	}
+}
+
+// TestApp_GRPC_Unauthenticated checks a call needs a bearer token when there is an authenticator.
+func TestApp_GRPC_Unauthenticated(t *testing.T) {
+	keys, err := auth.ParseKeySet([]byte(`{"keys":[{"kty":"EC","kid":"ec-1","crv":"P-256",` +
+		`"x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU","y":"x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0"}]}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client := grpcClient(t, &app.App{Auth: &app.Authenticator{Verifier: &auth.Verifier{Keys: keys}}})
+
+	for _, authorization := range []string{"", "Basic dXNlcjpwYXNz", "Bearer eyJhbGciOiJub25lIn0.eyJzdWIiOiJ1c2VyLTEifQ."} {
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+		_, err := client.ListHoldings(ctx, &stocktoolsv1.ListHoldingsRequest{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%q: got %v, want Unauthenticated", authorization, err)
+		}
+	}
+}
+
+func TestApp_NewGRPCServer(t *testing.T) {
+	services := (&app.App{}).NewGRPCServer().GetServiceInfo()
+	for _, name := range []string{"stocktools.v1.StockTools", "grpc.reflection.v1.ServerReflection"} {
+		if _, ok := services[name]; !ok {
+			t.Errorf("%s is not registered: %v", name, services)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	business_days "github.com/kpearce2430/keputils/business-days"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// holdingPrice returns the close of the symbol on the business day of asOf from the quote cache, or the
+// latest close for a zero asOf. A symbol the cache has no quote for, such as a fund, takes its close in
+// fund_history on or before asOf, and nil is returned without either.
+func (a *App) holdingPrice(symbol string, asOf time.Time) *model.Decimal {
+	if model.IsOptionSymbol(symbol) {
+		return nil
+	}
+	if a.StockCache != nil {
+		var args []string
+		if !asOf.IsZero() {
+			args = append(args, utils.JulDateFromTime(business_days.GetBusinessDay(asOf)))
+		}
+		quote, err := a.StockCache.GetCache(symbol, args...)
+		if err == nil && quote != nil && quote.Close != 0 {
+			price := model.NewDecimal(quote.Close)
+			return &price
+		}
+		logrus.Debug("No quote for ", symbol, ": ", err)
+	}
+	if a.PGXConn == nil {
+		return nil
+	}
+
+	date := asOf
+	if date.IsZero() {
+		date = time.Now()
+	}
+	hist, err := model.NewHistoricalDataSet(a.PGXConn, fundHistoryTable).OnOrBefore(symbol, date)
+	if err != nil {
+		logrus.Error("No price for ", symbol, ": ", err.Error())
+		return nil
+	}
+	price := model.NewDecimal(hist.Close)
+	return &price
+}
+
+// setHoldingPrices values the lots at the prices of their symbols.
+func (a *App) setHoldingPrices(holdings []*model.Holding, asOf time.Time) {
+	prices := make(map[string]*model.Decimal)
+	for _, h := range holdings {
+		price, ok := prices[h.Symbol]
+		if !ok {
+			price = a.holdingPrice(h.Symbol, asOf)
+			prices[h.Symbol] = price
+		}
+		if price != nil {
+			h.SetPrice(*price)
+		}
+	}
+}
+
+// GetHoldingsHandler returns the open lots of every symbol as of the as_of date, or today.
+func (a *App) GetHoldingsHandler(c *gin.Context) {
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	holdings, err := model.HoldingsGetAll(c.Request.Context(), a.PGXConn, asOf)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.setHoldingPrices(holdings, asOf)
+	c.IndentedJSON(http.StatusOK, holdings)
+}
+
+// GetSymbolHoldingsHandler returns the open lots of the symbol as of the as_of date, or today.
+func (a *App) GetSymbolHoldingsHandler(c *gin.Context) {
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	holdings, err := model.HoldingsGet(c.Request.Context(), a.PGXConn, c.Param("symbol"), asOf)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.setHoldingPrices(holdings, asOf)
+	c.IndentedJSON(http.StatusOK, holdings)
+}
@@ -120,7 +120,7 @@ func (a *App) GetLookupsFromPostgres(c *gin.Context) {
 	//	status := model.StatusObject{Status: err.Error()}
 	//	c.IndentedJSON(http.StatusInternalServerError, status)
 	//}
-	c.IndentedJSON(http.StatusOK, a.LookupSet)
+	c.IndentedJSON(http.StatusOK, a.lookupSet(c.Request.Context()))
 }
 
 // GetLookups is the Handler to retrieve the LookupSet from CouchDB
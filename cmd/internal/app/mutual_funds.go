@@ -0,0 +1,151 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"net/http"
+	"time"
+)
+
+// queryDate returns the date (YYYY-MM-DD) in the query parameter, or the default when it is missing.
+func queryDate(c *gin.Context, name string, defaultDate time.Time) (time.Time, error) {
+	value := c.DefaultQuery(name, "")
+	if value == "" {
+		return defaultDate, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return date, nil
+}
+
+// IngestFundNAVsHandler loads the NAVs and distributions of the symbol from the provider (default directory)
+// between the start and end query parameters. Without a symbol every fund dropped in the directory is loaded.
+func (a *App) IngestFundNAVsHandler(c *gin.Context) {
+	provider, err := model.NAVProviderGet(c.DefaultQuery("provider", model.NAVProviderDirectory))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	symbol := c.DefaultQuery("symbol", "")
+	if symbol == "" {
+		directory, ok := provider.(*model.NAVDirectoryProvider)
+		if !ok {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "Missing Symbol"})
+			return
+		}
+		ingests, err := model.FundIngestDirectory(c.Request.Context(), a.PGXConn, directory)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, ingests)
+		return
+	}
+
+	start, err := queryDate(c, "start", time.Time{})
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	end, err := queryDate(c, "end", time.Now())
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	ingest, err := model.FundIngestNAVs(c.Request.Context(), a.PGXConn, provider, symbol, start, end)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, ingest)
+}
+
+// GetFundGaps returns the runs of business days without a NAV for the symbol between the start (default
+// a year ago) and end query parameters.
+func (a *App) GetFundGaps(c *gin.Context) {
+	symbol := c.DefaultQuery("symbol", "")
+	if symbol == "" {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "Missing Symbol"})
+		return
+	}
+	end, err := queryDate(c, "end", time.Now())
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	start, err := queryDate(c, "start", end.AddDate(-1, 0, 0))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	gaps, err := model.FundHistoryGaps(a.PGXConn, symbol, start, end)
+	if err != nil {
+		c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gaps)
+}
+
+// GetFundDistributions returns the dividend and capital gain distributions of the fund.
+func (a *App) GetFundDistributions(c *gin.Context) {
+	distributions, err := model.FundDistributionsGet(c.Request.Context(), a.PGXConn, c.Param("symbol"))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, distributions)
+}
+
+// LoadFundProfilesHandler stores the expense ratios of a fund, or a list of them, from a JSON body.
+func (a *App) LoadFundProfilesHandler(c *gin.Context) {
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	profiles, err := model.NewFundProfilesFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	for _, f := range profiles {
+		if err := f.ToDB(c.Request.Context(), a.PGXConn); err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+			return
+		}
+	}
+	c.IndentedJSON(http.StatusOK, profiles)
+}
+
+// GetFundProfilesHandler returns the fund profiles.
+func (a *App) GetFundProfilesHandler(c *gin.Context) {
+	profiles, err := model.FundProfilesGet(c.Request.Context(), a.PGXConn)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, profiles)
+}
+
+// GetFundExpenses returns the yearly cost of the funds held at their expense ratios.
+func (a *App) GetFundExpenses(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	report, err := model.FundExpenseReportGet(c.Request.Context(), a.PGXConn, a.lookupSet(c.Request.Context()))
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
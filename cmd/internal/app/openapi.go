@@ -0,0 +1,192 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	worksheetContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	errorSchemaRef       = "#/components/schemas/Error"
+	bearerScheme         = "bearer"
+)
+
+// OpenAPIDocument is the OpenAPI 3 description of the /api/v1 routes.
+type OpenAPIDocument struct {
+	OpenAPI    string                         `json:"openapi"`
+	Info       OpenAPIInfo                    `json:"info"`
+	Servers    []OpenAPIServer                `json:"servers"`
+	Paths      map[string]map[string]*OpenAPI `json:"paths"`
+	Components OpenAPIComponents              `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIServer struct {
+	URL         string                           `json:"url"`
+	Description string                           `json:"description,omitempty"`
+	Variables   map[string]OpenAPIServerVariable `json:"variables,omitempty"`
+}
+
+type OpenAPIServerVariable struct {
+	Default     string `json:"default"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPI is an operation of a path.
+type OpenAPI struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref        string                   `json:"$ref,omitempty"`
+	Type       string                   `json:"type,omitempty"`
+	Format     string                   `json:"format,omitempty"`
+	Required   []string                 `json:"required,omitempty"`
+	Properties map[string]OpenAPISchema `json:"properties,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]OpenAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+// openAPIPath returns the path of the gin route in OpenAPI form, /accounts/{name} for /accounts/:name,
+// and the names of its parameters.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// operationID returns the id of the operation, getAccountsName for GET /accounts/:name.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == ':' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// newOpenAPIDocument describes the routes. Every operation can return the error envelope, and every
+// operation but the public ones takes a bearer token.
+func newOpenAPIDocument(routes []apiRoute) *OpenAPIDocument {
+	doc := OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: "stock-tools", Version: apiVersion},
+		Servers: []OpenAPIServer{
+			{URL: apiV1Prefix, Description: "The portfolio of the caller, or the one in the X-Portfolio header"},
+			{
+				URL:         portfoliosRoute + "/{portfolio}" + apiV1Prefix,
+				Description: "A portfolio of the caller",
+				Variables:   map[string]OpenAPIServerVariable{"portfolio": {Default: "default"}},
+			},
+		},
+		Paths: make(map[string]map[string]*OpenAPI),
+		Components: OpenAPIComponents{
+			Schemas: map[string]OpenAPISchema{
+				"Error": {
+					Type:     "object",
+					Required: []string{"error"},
+					Properties: map[string]OpenAPISchema{
+						"error": {
+							Type:     "object",
+							Required: []string{"code", "message"},
+							Properties: map[string]OpenAPISchema{
+								"code":    {Type: "string"},
+								"message": {Type: "string"},
+								"details": {},
+							},
+						},
+					},
+				},
+			},
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", Description: "An API token of the user, or a token of the OIDC provider signed by a key of its JWKS"},
+			},
+		},
+	}
+
+	routes = append(routes, apiRoute{method: http.MethodGet, path: openAPIRoute, tag: "status", summary: "This OpenAPI document", public: true})
+	for _, r := range routes {
+		path, params := openAPIPath(r.path)
+		op := OpenAPI{
+			OperationID: operationID(r.method, r.path),
+			Summary:     r.summary,
+			Tags:        []string{r.tag},
+			Responses: map[string]*OpenAPIResponse{
+				"200":     {Description: "OK", Content: map[string]*OpenAPIMediaType{"application/json": {Schema: OpenAPISchema{Type: "object"}}}},
+				"default": {Description: "Error", Content: map[string]*OpenAPIMediaType{"application/json": {Schema: OpenAPISchema{Ref: errorSchemaRef}}}},
+			},
+		}
+		if !r.public {
+			op.Security = []map[string][]string{{bearerScheme: {}}}
+		}
+		if r.worksheet {
+			op.Responses["200"].Content = map[string]*OpenAPIMediaType{worksheetContentType: {Schema: OpenAPISchema{Type: "string", Format: "binary"}}}
+		}
+		for _, media := range r.produces {
+			op.Responses["200"].Content[media] = &OpenAPIMediaType{Schema: OpenAPISchema{Type: "string"}}
+		}
+		for _, p := range params {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: p, In: "path", Required: true, Schema: OpenAPISchema{Type: "string"}})
+		}
+		for _, q := range r.query {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: q, In: "query", Schema: OpenAPISchema{Type: "string"}})
+		}
+		if r.body {
+			op.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]*OpenAPIMediaType{
+				"application/json": {Schema: OpenAPISchema{Type: "object"}},
+				"text/csv":         {Schema: OpenAPISchema{Type: "string"}},
+			}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPI)
+		}
+		doc.Paths[path][strings.ToLower(r.method)] = &op
+	}
+	return &doc
+}
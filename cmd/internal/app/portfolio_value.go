@@ -36,7 +36,7 @@ var (
 // LoadPortfolioValueHandler handler for loading Portfolio Value exported from Quicken
 func (a *App) LoadPortfolioValueHandler(c *gin.Context) {
 	logrus.Debug("In LoadPortfolioValueHandler ")
-	if a.LookupSet == nil {
+	if a.lookupSet(c.Request.Context()) == nil {
 		logrus.Error(errMissingLookups.Error())
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: errMissingLookups.Error()})
 		return
@@ -60,10 +60,11 @@ func (a *App) LoadPortfolioValueHandler(c *gin.Context) {
 	julDate := c.DefaultQuery("juldate", "")
 	logrus.Debugln("julDate:,", julDate, " dbName:", databaseName)
 
-	if err := model.LoadPortfolioValues(a.PGXConn, databaseName, string(rawData), julDate, a.LookupSet); err != nil {
+	if err := model.LoadPortfolioValues(a.PGXConn, databaseName, string(rawData), julDate, a.lookupSet(c.Request.Context())); err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
+	a.publishPortfolioValues(c.Request.Context(), julDate, 0)
 	c.IndentedJSON(http.StatusOK, model.StatusObject{Status: "ok"})
 }
 
@@ -89,7 +90,7 @@ func (a *App) GetPortfolioValueHandler(c *gin.Context) {
 
 func (a *App) LoadDBPortfolioValueHandler(c *gin.Context) {
 	logrus.Debug("In LoadDBPortfolioValueHandler ")
-	if a.LookupSet == nil {
+	if a.lookupSet(c.Request.Context()) == nil {
 		logrus.Error(errMissingLookups.Error())
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: errMissingLookups.Error()})
 		return
@@ -115,11 +116,12 @@ func (a *App) LoadDBPortfolioValueHandler(c *gin.Context) {
 	julDate := c.DefaultQuery("juldate", "")
 	logrus.Debugln("julDate:,", julDate, " dbName:", databaseName)
 
-	count, err := model.PortfolioValuesLoadDB(a.PGXConn, PortfolioValueDB, string(rawData), julDate, a.LookupSet)
+	count, err := model.PortfolioValuesLoadDB(c.Request.Context(), a.PGXConn, PortfolioValueDB, string(rawData), julDate, a.lookupSet(c.Request.Context()))
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
 	logrus.Info("Loaded ", count, " records.")
+	a.publishPortfolioValues(c.Request.Context(), julDate, count)
 	c.IndentedJSON(http.StatusOK, model.StatusObject{Status: "ok"})
 }
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"net/http"
+	"strings"
+)
+
+const (
+	// portfolioHeader selects the portfolio of a request, as does the /portfolios/{portfolio} prefix.
+	portfolioHeader = "X-Portfolio"
+
+	portfoliosRoute    = "/portfolios"
+	accountEventsRoute = "/accountevents"
+)
+
+// requestPortfolio returns the portfolio the request selects in its X-Portfolio header, the portfolio of the
+// user when it selects none, with the status to respond with when it cannot be used.
+func (a *App) requestPortfolio(ctx context.Context, portfolio string, user *model.User) (string, int, error) {
+	portfolio = strings.TrimSpace(portfolio)
+	switch {
+	case portfolio == "" && user != nil:
+		return user.Portfolio, http.StatusOK, nil
+	case portfolio == "":
+		return model.DefaultPortfolio, http.StatusOK, nil
+	}
+	if err := model.ValidatePortfolio(portfolio); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+
+	if user != nil {
+		member, err := model.PortfolioMember(ctx, a.PGXConn, user, portfolio)
+		if err != nil {
+			return "", http.StatusInternalServerError, err
+		}
+		if !member {
+			return "", http.StatusForbidden, fmt.Errorf("%s is not a member of portfolio %s", user.Name, portfolio)
+		}
+		return portfolio, http.StatusOK, nil
+	}
+
+	// Without users any portfolio may be selected, once it has been created.
+	if portfolio != model.DefaultPortfolio && a.lookupSet(model.WithPortfolio(ctx, portfolio)) == nil {
+		if _, err := model.PortfolioGet(ctx, a.PGXConn, portfolio); model.IsPortfolioNotFound(err) {
+			return "", http.StatusNotFound, err
+		} else if err != nil {
+			return "", http.StatusInternalServerError, err
+		}
+	}
+	return portfolio, http.StatusOK, nil
+}
+
+// portfolioPath returns the portfolio and the rest of a path with the /portfolios/{portfolio} prefix.
+func portfolioPath(path string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(path, portfoliosRoute+"/")
+	if !ok {
+		return "", "", false
+	}
+	portfolio, rest, ok := strings.Cut(rest, "/")
+	if !ok || portfolio == "" || rest == "" {
+		return "", "", false
+	}
+	return portfolio, "/" + rest, true
+}
+
+// noRoute serves a path with the /portfolios/{portfolio} prefix as the route after the prefix with the
+// portfolio in the X-Portfolio header, any other path is not found.
+func (a *App) noRoute(router *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		portfolio, path, ok := portfolioPath(c.Request.URL.Path)
+		if !ok {
+			apiNotFound(c)
+			return
+		}
+		c.Request.Header.Set(portfolioHeader, portfolio)
+		c.Request.URL.Path = path
+		c.Request.URL.RawPath = ""
+		router.HandleContext(c)
+		// The handlers of the route replaced those of the not found chain, so none of them run again.
+		c.Abort()
+	}
+}
+
+// GetPortfoliosHandler returns the portfolios the caller may select, every portfolio without users.
+func (a *App) GetPortfoliosHandler(c *gin.Context) {
+	user := ""
+	if _, ok := c.Get(userKey); ok {
+		user = requestUser(c)
+	}
+	portfolios, err := model.PortfoliosGet(c.Request.Context(), a.PGXConn, user)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, portfolios)
+}
+
+// CreatePortfolioHandler creates a portfolio from a JSON body with its id and description, the caller
+// becomes a member of it.
+func (a *App) CreatePortfolioHandler(c *gin.Context) {
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	var p model.Portfolio
+	if err := json.Unmarshal(rawData, &p); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	user := ""
+	if _, ok := c.Get(userKey); ok {
+		user = requestUser(c)
+	}
+	err = model.CreatePortfolio(c.Request.Context(), a.PGXConn, &p, user)
+	switch {
+	case model.IsPortfolioNameError(err):
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+	case model.IsPortfolioExists(err):
+		c.IndentedJSON(http.StatusConflict, model.StatusObject{Status: err.Error()})
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+	default:
+		c.IndentedJSON(http.StatusCreated, p)
+	}
+}
+
+// GetAccountEventsHandler returns the account events the tickers of the portfolio are loaded with.
+func (a *App) GetAccountEventsHandler(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, model.AccountEvents(c.Request.Context()))
+}
+
+// LoadAccountEventsHandler stores the account events of the portfolio from a JSON list.
+func (a *App) LoadAccountEventsHandler(c *gin.Context) {
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	events, err := model.NewAccountEventsFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	if err := model.StoreAccountEvents(c.Request.Context(), a.PGXConn, events); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, model.AccountEvents(c.Request.Context()))
+}
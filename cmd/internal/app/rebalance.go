@@ -0,0 +1,172 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"net/http"
+)
+
+// LoadAllocationHandler stores the accounts and target weights of an allocation group from a JSON body.
+func (a *App) LoadAllocationHandler(c *gin.Context) {
+	defer func() {
+		if c != nil && c.Request != nil && c.Request.Body != nil {
+			if err := c.Request.Body.Close(); err != nil {
+				logrus.Error(err.Error())
+			}
+		}
+	}()
+
+	rawData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	group, err := model.NewAllocationGroupFromJSON(rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if name := c.Param("group"); name != "" && name != group.Name {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "group name does not match"})
+		return
+	}
+
+	if err := group.ToDB(c.Request.Context(), a.PGXConn); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, group)
+}
+
+// GetAllocationHandler returns the accounts and target weights of an allocation group.
+func (a *App) GetAllocationHandler(c *gin.Context) {
+	group, err := model.AllocationGroupFromDB(c.Request.Context(), a.PGXConn, c.Param("group"))
+	switch {
+	case model.IsAllocationGroupNotFound(err):
+		c.IndentedJSON(http.StatusNotFound, model.StatusObject{Status: err.Error()})
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, group)
+}
+
+// rebalancePlan builds the rebalance plan for the group using the cash, min, account and as_of query parameters.
+func (a *App) rebalancePlan(c *gin.Context) (*model.RebalancePlan, int, error) {
+	group, err := model.AllocationGroupFromDB(c.Request.Context(), a.PGXConn, c.Param("group"))
+	switch {
+	case model.IsAllocationGroupNotFound(err):
+		return nil, http.StatusNotFound, err
+	case err != nil:
+		return nil, http.StatusInternalServerError, err
+	}
+
+	opts := model.RebalanceOptions{
+		CashAccount: c.DefaultQuery("account", ""),
+		Prices:      make(map[string]float64),
+	}
+
+	if opts.Cash, err = utils.FloatParse(c.DefaultQuery("cash", "0")); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid cash: %w", err)
+	}
+	if opts.MinTradeAmount, err = utils.FloatParse(c.DefaultQuery("min", "0")); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid min: %w", err)
+	}
+
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	holdings, err := model.RebalanceHoldingsGetAsOf(c.Request.Context(), a.PGXConn, lookups, asOf)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// Symbols that are targeted but not held need a price to be bought.
+	for _, t := range group.Targets {
+		if t.TargetType != model.TargetTypeSymbol {
+			continue
+		}
+		var pv model.PortfolioValueRecord
+		if asOf.IsZero() {
+			err = pv.GetLastDB(c.Request.Context(), a.PGXConn, t.Target, PortfolioValueDB)
+		} else {
+			err = pv.GetOnOrBeforeDB(c.Request.Context(), a.PGXConn, t.Target, PortfolioValueDB, asOf)
+		}
+		if err != nil {
+			logrus.Error(err.Error())
+			continue
+		}
+		opts.Prices[t.Target] = pv.Quote
+	}
+
+	plan, err := model.Rebalance(c.Request.Context(), group, holdings, opts)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return plan, http.StatusOK, nil
+}
+
+// RebalanceHandler returns the trades needed to rebalance an allocation group as JSON.
+func (a *App) RebalanceHandler(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	plan, status, err := a.rebalancePlan(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, plan)
+}
+
+// RebalanceWorksheetHandler returns the trades needed to rebalance an allocation group as a worksheet.
+func (a *App) RebalanceWorksheetHandler(c *gin.Context) {
+	if a.lookupSet(c.Request.Context()) == nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
+		return
+	}
+
+	worksheetName := c.DefaultQuery("name", "rebalance")
+	plan, status, err := a.rebalancePlan(c)
+	if err != nil {
+		c.IndentedJSON(status, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
+	ws.Context = c.Request.Context()
+	ws.Lookups = a.lookupSet(c.Request.Context())
+	if err := ws.Rebalance("Rebalance", plan); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if err := ws.File.DeleteSheet("Sheet1"); err != nil {
+		logrus.Error(err.Error())
+	}
+
+	buff, err := ws.File.WriteToBuffer()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
+}
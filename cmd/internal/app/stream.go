@@ -0,0 +1,345 @@
+package app
+
+import (
+	"context"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	streamRoute = "/stream"
+
+	StreamTransactions    = "transactions"
+	StreamPortfolioValues = "portfolio-values"
+	StreamQuote           = "quote"
+	StreamWorksheet       = "worksheet"
+
+	// streamBuffer is the events held for a subscriber that is slow to read them, the events after are
+	// dropped for it.
+	streamBuffer = 64
+	// streamHistory is the events kept for a client that reconnects with the Last-Event-ID header.
+	streamHistory = 256
+	// streamKeepAlive is how often a comment is sent, so proxies do not close an idle stream.
+	streamKeepAlive = 30 * time.Second
+)
+
+// StreamEvent is an event of the /stream route. A quote is for every portfolio, the other events are for
+// the portfolio they happened in.
+type StreamEvent struct {
+	Id        uint64            `json:"id"`
+	Type      string            `json:"type"`
+	Portfolio string            `json:"portfolio,omitempty"`
+	Time      time.Time         `json:"time"`
+	Symbol    string            `json:"symbol,omitempty"`
+	Symbols   []string          `json:"symbols,omitempty"`
+	Price     *model.Decimal    `json:"price,omitempty"`
+	Date      string            `json:"date,omitempty"`
+	Count     int               `json:"count,omitempty"`
+	Worksheet string            `json:"worksheet,omitempty"`
+	Holdings  []*StreamPosition `json:"holdings,omitempty"`
+}
+
+// StreamPosition is the shares of a symbol held in an account after a change. The positions of a symbol in
+// an event replace the positions held for it before, an account without one no longer holds the symbol.
+type StreamPosition struct {
+	Symbol  string        `json:"symbol"`
+	Account string        `json:"account"`
+	Shares  model.Decimal `json:"shares"`
+	Cost    model.Decimal `json:"cost"`
+}
+
+// streamBroker sends the events to the subscribers of their portfolio. The zero value has no subscribers.
+type streamBroker struct {
+	lock        sync.Mutex
+	subscribers map[chan *StreamEvent]string // the portfolio of each subscriber
+	recent      []*StreamEvent
+	lastId      uint64
+	closed      bool
+}
+
+// active returns true when there is a subscriber, so the holdings of an event are only read for one.
+func (b *streamBroker) active() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.subscribers) > 0
+}
+
+// subscribe returns the events of the portfolio, starting with the events kept after lastId. The channel
+// is closed when the broker is.
+func (b *streamBroker) subscribe(portfolio string, lastId uint64) chan *StreamEvent {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan *StreamEvent]string)
+	}
+	events := make(chan *StreamEvent, streamBuffer+streamHistory)
+	if b.closed {
+		close(events)
+		return events
+	}
+	if lastId > 0 {
+		for _, ev := range b.recent {
+			if ev.Id > lastId && streamFor(ev, portfolio) {
+				events <- ev
+			}
+		}
+	}
+	b.subscribers[events] = portfolio
+	return events
+}
+
+func (b *streamBroker) unsubscribe(events chan *StreamEvent) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.subscribers, events)
+}
+
+// close closes the channels of the subscribers and of the ones that subscribe after.
+func (b *streamBroker) close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.closed = true
+	for events := range b.subscribers {
+		close(events)
+		delete(b.subscribers, events)
+	}
+}
+
+// publish numbers the event and sends it to the subscribers of its portfolio.
+func (b *streamBroker) publish(ev *StreamEvent) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.lastId++
+	ev.Id = b.lastId
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > streamHistory {
+		b.recent = b.recent[len(b.recent)-streamHistory:]
+	}
+
+	for events, portfolio := range b.subscribers {
+		if !streamFor(ev, portfolio) {
+			continue
+		}
+		select {
+		case events <- ev:
+		default:
+			logrus.Warn("Dropped ", ev.Type, " event ", ev.Id, " for a slow subscriber of ", portfolio)
+		}
+	}
+}
+
+// streamFor returns true when the event is for a subscriber of the portfolio.
+func streamFor(ev *StreamEvent, portfolio string) bool {
+	return ev.Portfolio == "" || ev.Portfolio == portfolio
+}
+
+// streamPositions returns the position of each symbol in each account of the lots.
+func streamPositions(holdings []*model.Holding) []*StreamPosition {
+	byKey := make(map[[2]string]*StreamPosition)
+	positions := []*StreamPosition{}
+	for _, h := range holdings {
+		key := [2]string{h.Symbol, h.Account}
+		p, ok := byKey[key]
+		if !ok {
+			p = &StreamPosition{Symbol: h.Symbol, Account: h.Account}
+			byKey[key] = p
+			positions = append(positions, p)
+		}
+		p.Shares = p.Shares.Add(h.RemainingShares)
+		p.Cost = p.Cost.Add(h.Cost)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Symbol != positions[j].Symbol {
+			return positions[i].Symbol < positions[j].Symbol
+		}
+		return positions[i].Account < positions[j].Account
+	})
+	return positions
+}
+
+// changedSymbols returns the symbols with a position in one list that differs from, or is not in, the other.
+func changedSymbols(before, after []*StreamPosition) []string {
+	positions := func(list []*StreamPosition) map[[2]string]*StreamPosition {
+		m := make(map[[2]string]*StreamPosition, len(list))
+		for _, p := range list {
+			m[[2]string{p.Symbol, p.Account}] = p
+		}
+		return m
+	}
+	b, a := positions(before), positions(after)
+
+	changed := make(map[string]bool)
+	for key, p := range a {
+		if q, ok := b[key]; !ok || q.Shares.Cmp(p.Shares) != 0 || q.Cost.Cmp(p.Cost) != 0 {
+			changed[key[0]] = true
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			changed[key[0]] = true
+		}
+	}
+
+	symbols := make([]string, 0, len(changed))
+	for symbol := range changed {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// allPositions returns the positions of every symbol in the portfolio of the context, or nil when there are
+// no subscribers to send them to.
+func (a *App) allPositions(ctx context.Context) []*StreamPosition {
+	if !a.stream.active() {
+		return nil
+	}
+	holdings, err := model.HoldingsGetAll(ctx, a.PGXConn, time.Time{})
+	if err != nil {
+		logrus.Error("Positions for the stream: ", err.Error())
+		return nil
+	}
+	return streamPositions(holdings)
+}
+
+// publishTransactions sends the positions of the symbols whose positions changed from the positions before
+// the transactions were loaded, which are nil when there were no subscribers then.
+func (a *App) publishTransactions(ctx context.Context, tableName string, before []*StreamPosition) {
+	ev := StreamEvent{Type: StreamTransactions, Portfolio: model.PortfolioFromContext(ctx)}
+	// The holdings are of the transactions table, the loads into another table have none.
+	if tableName == TransactionTable && before != nil {
+		after := a.allPositions(ctx)
+		if after == nil {
+			a.stream.publish(&ev)
+			return
+		}
+		ev.Symbols = changedSymbols(before, after)
+		for _, p := range after {
+			if slices.Contains(ev.Symbols, p.Symbol) {
+				ev.Holdings = append(ev.Holdings, p)
+			}
+		}
+	}
+	a.stream.publish(&ev)
+}
+
+// publishTransaction sends the positions of the symbols of a transaction entered, corrected or deleted.
+func (a *App) publishTransaction(ctx context.Context, tableName string, audit *model.TransactionAudit) {
+	ev := StreamEvent{Type: StreamTransactions, Portfolio: model.PortfolioFromContext(ctx), Count: 1}
+	for _, tr := range []*model.Transaction{audit.Before, audit.After} {
+		if tr != nil && tr.Symbol != "" && !slices.Contains(ev.Symbols, tr.Symbol) {
+			ev.Symbols = append(ev.Symbols, tr.Symbol)
+		}
+	}
+	if tableName == TransactionTable && a.stream.active() {
+		for _, symbol := range ev.Symbols {
+			holdings, err := model.HoldingsGet(ctx, a.PGXConn, symbol, time.Time{})
+			if err != nil {
+				logrus.Error("Positions of ", symbol, " for the stream: ", err.Error())
+				continue
+			}
+			ev.Holdings = append(ev.Holdings, streamPositions(holdings)...)
+		}
+	}
+	a.stream.publish(&ev)
+}
+
+// CloseStreams ends the responses of /stream, so the server can shut down while clients are connected.
+func (a *App) CloseStreams() {
+	a.stream.close()
+}
+
+// Publish sends the event to the subscribers of /stream in its portfolio, or every portfolio for an event
+// without one.
+func (a *App) Publish(ev *StreamEvent) {
+	a.stream.publish(ev)
+}
+
+// publishPortfolioValues sends the number of portfolio values imported for the date.
+func (a *App) publishPortfolioValues(ctx context.Context, julDate string, count int) {
+	a.stream.publish(&StreamEvent{Type: StreamPortfolioValues, Portfolio: model.PortfolioFromContext(ctx), Date: julDate, Count: count})
+}
+
+// publishQuote sends a quote read into the quote cache to every portfolio.
+func (a *App) publishQuote(symbol string, quote *models.GetDailyOpenCloseAggResponse) {
+	if quote == nil || quote.Close == 0 {
+		return
+	}
+	price := model.NewDecimal(quote.Close)
+	a.stream.publish(&StreamEvent{Type: StreamQuote, Symbol: symbol, Price: &price, Date: quote.From})
+}
+
+// sendWorksheet responds with the worksheet and tells the subscribers of the portfolio it is ready.
+func (a *App) sendWorksheet(c *gin.Context, worksheetName string, data []byte) {
+	c.Header("Content-Disposition", "attachment; filename="+worksheetName)
+	c.Data(http.StatusOK, "application/octet-stream", data)
+	a.stream.publish(&StreamEvent{Type: StreamWorksheet, Portfolio: model.PortfolioFromContext(c.Request.Context()), Worksheet: worksheetName})
+}
+
+// StreamHandler sends the events of the portfolio as server-sent events until the client goes away or the
+// streams are closed. The type query parameter keeps the events of the types, and a client that reconnects
+// with Last-Event-ID gets the events it missed while they are kept.
+func (a *App) StreamHandler(c *gin.Context) {
+	types := queryList(c, "type")
+	for _, t := range types {
+		switch t {
+		case StreamTransactions, StreamPortfolioValues, StreamQuote, StreamWorksheet:
+		default:
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid type: " + t})
+			return
+		}
+	}
+	var lastId uint64
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		var err error
+		if lastId, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid Last-Event-ID: " + value})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	events := a.stream.subscribe(model.PortfolioFromContext(ctx), lastId)
+	defer a.stream.unsubscribe(events)
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	// The headers are sent before the first event, which may be a while.
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			if len(types) > 0 && !slices.Contains(types, ev.Type) {
+				return true
+			}
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(ev.Id, 10), Event: ev.Type, Data: ev})
+			return true
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package app_test
+
+import (
+	"bufio"
+	"github.com/kpearce2430/stock-tools/cmd/internal/app"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvents returns the id and event lines of the first n events of the stream.
+func readEvents(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var lines []string
+	for len(lines) < 2*n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("after %v: %s", lines, err.Error())
+		}
+		if strings.HasPrefix(line, "id:") || strings.HasPrefix(line, "event:") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+func TestApp_StreamHandler(t *testing.T) {
+	a := &app.App{}
+	server := httptest.NewServer(a.Router())
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL + "/api/v1/stream?type=prices"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %v %v for an unknown type, want 400", resp, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/stream?type=quote,worksheet", nil)
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	a.Publish(&app.StreamEvent{Type: app.StreamTransactions, Portfolio: "default"})
+	a.Publish(&app.StreamEvent{Type: app.StreamWorksheet, Portfolio: "paper-trading", Worksheet: "other"})
+	a.Publish(&app.StreamEvent{Type: app.StreamQuote, Symbol: "CSX"})
+	a.Publish(&app.StreamEvent{Type: app.StreamWorksheet, Portfolio: "default", Worksheet: "worksheet"})
+
+	// The transactions are not of the types, and the worksheet of another portfolio is not sent.
+	got := strings.Join(readEvents(t, bufio.NewReader(resp.Body), 2), " ")
+	if want := "id:3 event:quote id:4 event:worksheet"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// A client that reconnects gets the events after the last it read.
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/portfolios/default/stream", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	replay, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer replay.Body.Close()
+	got = strings.Join(readEvents(t, bufio.NewReader(replay.Body), 2), " ")
+	if want := "id:3 event:quote id:4 event:worksheet"; got != want {
+		t.Errorf("got %s after a reconnect, want %s", got, want)
+	}
+}
+
+func TestApp_CloseStreams(t *testing.T) {
+	a := &app.App{}
+	server := httptest.NewServer(a.Router())
+	defer server.Close()
+
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(server.URL + "/api/v1/stream")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer resp.Body.Close()
+
+	// The response ends when the streams are closed, and a stream opened after ends at once.
+	a.CloseStreams()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Errorf("got %s, want the stream to end", err.Error())
+	}
+	after, err := client.Get(server.URL + "/api/v1/stream")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer after.Body.Close()
+	if _, err := io.ReadAll(after.Body); err != nil {
+		t.Errorf("got %s, want the stream to end", err.Error())
+	}
+}
@@ -15,25 +15,46 @@ import (
 
 func (a *App) CreateSymbolDetailHandler(c *gin.Context) {
 	//
-	if a.LookupSet == nil {
+	if a.lookupSet(c.Request.Context()) == nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
 		return
 	}
 
 	worksheetName := c.DefaultQuery("name", "Symbol Detail")
 	symbolsList := c.DefaultQuery("symbols", "")
-	tableName := c.DefaultQuery("table", "fund_history")
+	tableName := c.DefaultQuery("table", fundHistoryTable)
 
+	if tableName != fundHistoryTable {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid table: " + tableName})
+		return
+	}
 	if symbolsList == "" {
 		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "symbol required"})
 		return
 	}
 
-	currDay := business_days.GetBusinessDay(time.Now())
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	end := time.Now()
+	if !asOf.IsZero() {
+		end = asOf
+	}
+	currDay := business_days.GetBusinessDay(end)
 
 	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
-	ws.Lookups = a.LookupSet
+	ws.Context = c.Request.Context()
+	ws.Lookups = lookups
 	ws.StockCache = a.StockCache
+	ws.AsOf = asOf
 	// ws.DividendCache = a.DividendCache
 
 	symbols := strings.Split(symbolsList, ",")
@@ -54,6 +75,5 @@ func (a *App) CreateSymbolDetailHandler(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", worksheetName))
-	c.Data(http.StatusOK, "application/octet-stream", buff.Bytes())
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
 }
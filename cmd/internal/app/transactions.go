@@ -1,23 +1,42 @@
 package app
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/kpearce2430/stock-tools/model"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	nextCursorHeader = "X-Next-Cursor"
+
+	// userHeader names the user recorded in the audit of a change when authentication is disabled.
+	userHeader  = "X-User"
+	defaultUser = "api"
 )
 
 // var errUnexpectedNumberOfTransactions = fmt.Errorf("unexpected number of transactions found")
 
 func (a *App) LoadTransactionsHandler(c *gin.Context) {
 	//
-	if a.LookupSet == nil {
+	if a.lookupSet(c.Request.Context()) == nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
 		return
 	}
 
-	databaseName := c.DefaultQuery("database", TransactionTable)
+	databaseName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
 
 	defer func() {
 		if c != nil && c.Request != nil && c.Request.Body != nil {
@@ -33,10 +52,295 @@ func (a *App) LoadTransactionsHandler(c *gin.Context) {
 		return
 	}
 
-	if err := model.TransactionSetLoadToDB(a.PGXConn, a.LookupSet, databaseName, rawData); err != nil {
+	var before []*StreamPosition
+	if databaseName == TransactionTable {
+		before = a.allPositions(c.Request.Context())
+	}
+	if err := model.TransactionSetLoadToDB(c.Request.Context(), a.PGXConn, a.lookupSet(c.Request.Context()), databaseName, rawData); err != nil {
 		c.IndentedJSON(http.StatusBadRequest, err.Error())
 		return
 	}
 
+	if err := a.loadAccounts(c.Request.Context()); err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	a.publishTransactions(c.Request.Context(), databaseName, before)
 	c.IndentedJSON(http.StatusOK, model.StatusObject{Status: "completed"})
 }
+
+// queryList returns the values of the query parameter, which is repeated or comma separated.
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, value := range c.QueryArray(name) {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// queryDecimal returns the query parameter as a Decimal, or nil when it is not set.
+func queryDecimal(c *gin.Context, name string) (*model.Decimal, error) {
+	value := c.DefaultQuery(name, "")
+	if value == "" {
+		return nil, nil
+	}
+	d, err := model.ParseDecimal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return &d, nil
+}
+
+// transactionQuery reads the filters, sort and page of the query parameters.
+func transactionQuery(c *gin.Context) (*model.TransactionQuery, error) {
+	q := model.TransactionQuery{
+		Accounts: queryList(c, "account"),
+		Symbols:  queryList(c, "symbol"),
+		Types:    queryList(c, "type"),
+		Text:     c.DefaultQuery("q", ""),
+		Sort:     c.DefaultQuery("sort", ""),
+		Cursor:   c.DefaultQuery("cursor", ""),
+	}
+
+	var err error
+	if q.Start, err = queryDate(c, "start", time.Time{}); err != nil {
+		return nil, err
+	}
+	if q.End, err = queryDate(c, "end", time.Time{}); err != nil {
+		return nil, err
+	}
+	if q.MinAmount, err = queryDecimal(c, "min"); err != nil {
+		return nil, err
+	}
+	if q.MaxAmount, err = queryDecimal(c, "max"); err != nil {
+		return nil, err
+	}
+	if limit := c.DefaultQuery("limit", ""); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit < 1 {
+			return nil, fmt.Errorf("invalid limit: %s", limit)
+		}
+	}
+	return &q, nil
+}
+
+// transactionFormat returns the format of the response from the format query parameter or the Accept header.
+func transactionFormat(c *gin.Context) (string, error) {
+	if format := c.DefaultQuery("format", ""); format != "" {
+		switch format {
+		case formatJSON, formatCSV, formatNDJSON:
+			return format, nil
+		}
+		return "", fmt.Errorf("invalid format: %s", format)
+	}
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return formatCSV, nil
+	case strings.Contains(accept, "application/x-ndjson"):
+		return formatNDJSON, nil
+	}
+	return formatJSON, nil
+}
+
+// GetTransactionsHandler returns a page of the transactions filtered by the account, symbol, type, start,
+// end, min and max amount and q, the text in the description, sorted by sort and paged by limit and the
+// cursor of the previous page. The format is json, csv or ndjson, the next cursor of a csv or ndjson page
+// is in the X-Next-Cursor header.
+func (a *App) GetTransactionsHandler(c *gin.Context) {
+	tableName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
+
+	format, err := transactionFormat(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	q, err := transactionQuery(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	page, err := model.QueryTransactions(c.Request.Context(), a.PGXConn, tableName, q)
+	switch {
+	case model.IsTransactionQueryError(err):
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	if page.NextCursor != "" {
+		c.Header(nextCursorHeader, page.NextCursor)
+	}
+	switch format {
+	case formatCSV:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		err = model.WriteTransactionsCSV(c.Writer, page.Transactions)
+	case formatNDJSON:
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		err = model.WriteTransactionsNDJSON(c.Writer, page.Transactions)
+	default:
+		c.IndentedJSON(http.StatusOK, page)
+	}
+	if err != nil {
+		logrus.Error(err.Error())
+	}
+}
+
+// transactionTableQuery returns the transaction table of the database query parameter, or writes the error
+// and returns false for a table that does not hold transactions.
+func transactionTableQuery(c *gin.Context) (string, bool) {
+	tableName := c.DefaultQuery("database", TransactionTable)
+	if tableName != TransactionTable && tableName != TransactionAllTable {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid database: " + tableName})
+		return "", false
+	}
+	return tableName, true
+}
+
+// transactionIdParam returns the id path parameter, or writes the error and returns false.
+func transactionIdParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid id: " + c.Param("id")})
+		return 0, false
+	}
+	return id, true
+}
+
+// transactionEditStatus returns the status of the error of a change to a transaction.
+func transactionEditStatus(err error) int {
+	switch {
+	case model.IsTransactionNotFound(err):
+		return http.StatusNotFound
+	case model.IsTransactionExists(err):
+		return http.StatusConflict
+	case model.IsTransactionInvalid(err):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetTransactionHandler returns the transaction with the id.
+func (a *App) GetTransactionHandler(c *gin.Context) {
+	tableName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
+	id, ok := transactionIdParam(c)
+	if !ok {
+		return
+	}
+
+	tr, err := model.TransactionGet(c.Request.Context(), a.PGXConn, tableName, id)
+	if err != nil {
+		c.IndentedJSON(transactionEditStatus(err), model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, tr)
+}
+
+// CreateTransactionHandler stores the transaction in the JSON body with the id, for an entry by hand.
+func (a *App) CreateTransactionHandler(c *gin.Context) {
+	tableName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
+	id, ok := transactionIdParam(c)
+	if !ok {
+		return
+	}
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	tr, err := model.NewTransactionFromJSON(id, rawData)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	audit, err := model.CreateTransaction(c.Request.Context(), a.PGXConn, tableName, tr, requestUser(c))
+	if err != nil {
+		c.IndentedJSON(transactionEditStatus(err), model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.publishTransaction(c.Request.Context(), tableName, audit)
+	c.IndentedJSON(http.StatusCreated, tr)
+}
+
+// UpdateTransactionHandler replaces the fields of the transaction with the fields in the JSON body.
+func (a *App) UpdateTransactionHandler(c *gin.Context) {
+	tableName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
+	id, ok := transactionIdParam(c)
+	if !ok {
+		return
+	}
+	rawData, err := readBody(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	audit, err := model.UpdateTransaction(c.Request.Context(), a.PGXConn, tableName, id, rawData, requestUser(c))
+	if err != nil {
+		c.IndentedJSON(transactionEditStatus(err), model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.publishTransaction(c.Request.Context(), tableName, audit)
+	c.IndentedJSON(http.StatusOK, audit.After)
+}
+
+// DeleteTransactionHandler removes the transaction.
+func (a *App) DeleteTransactionHandler(c *gin.Context) {
+	tableName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
+	id, ok := transactionIdParam(c)
+	if !ok {
+		return
+	}
+
+	audit, err := model.DeleteTransaction(c.Request.Context(), a.PGXConn, tableName, id, requestUser(c))
+	if err != nil {
+		c.IndentedJSON(transactionEditStatus(err), model.StatusObject{Status: err.Error()})
+		return
+	}
+	a.publishTransaction(c.Request.Context(), tableName, audit)
+	c.IndentedJSON(http.StatusOK, model.StatusObject{Status: "deleted"})
+}
+
+// GetTransactionAuditHandler returns the changes to the transaction, oldest first.
+func (a *App) GetTransactionAuditHandler(c *gin.Context) {
+	tableName, ok := transactionTableQuery(c)
+	if !ok {
+		return
+	}
+	id, ok := transactionIdParam(c)
+	if !ok {
+		return
+	}
+
+	audits, err := model.TransactionAuditGet(c.Request.Context(), a.PGXConn, tableName, id)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, audits)
+}
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/kpearce2430/stock-tools/cmd/internal/app"
+	"github.com/kpearce2430/stock-tools/model"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -67,3 +70,41 @@ func TestBuySellTHD(t *testing.T) {
 		fmt.Println(ticker)
 	}
 }
+
+func TestApp_GetTransactionsHandler(t *testing.T) {
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/transactions?"+query, nil)
+		testApp.GetTransactionsHandler(c)
+		return w
+	}
+
+	// Two pages of the transactions newest first do not overlap.
+	w := get("sort=-date&limit=5")
+	assert.Equal(t, 200, w.Code)
+	var first model.TransactionPage
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 5, len(first.Transactions))
+	assert.NotEmpty(t, first.NextCursor)
+
+	w = get("sort=-date&limit=5&cursor=" + first.NextCursor)
+	assert.Equal(t, 200, w.Code)
+	var second model.TransactionPage
+	if err := json.Unmarshal(w.Body.Bytes(), &second); err != nil {
+		t.Fatal(err.Error())
+	}
+	last := first.Transactions[4]
+	for _, tr := range second.Transactions {
+		assert.False(t, tr.Date.After(last.Date), "transaction %d is newer than the first page", tr.Id)
+		assert.NotEqual(t, last.Id, tr.Id)
+	}
+
+	w = get("type=Buy&format=csv&limit=2")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("X-Next-Cursor"))
+	assert.Equal(t, 3, len(strings.Split(strings.TrimSpace(w.Body.String()), "\n")))
+}
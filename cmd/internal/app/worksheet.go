@@ -1,7 +1,6 @@
 package app
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	business_days "github.com/kpearce2430/keputils/business-days"
 	"github.com/kpearce2430/keputils/utils"
@@ -15,19 +14,39 @@ import (
 
 func (a *App) CreateWorksheetHandler(c *gin.Context) {
 	//
-	if a.LookupSet == nil {
+	if a.lookupSet(c.Request.Context()) == nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: "Lookup Not Loaded"})
 		return
 	}
 
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: err.Error()})
+		return
+	}
+	lookups, err := a.lookupsAsOf(c.Request.Context(), asOf)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
+		return
+	}
+
+	// The report of a year end is as of its last day.
+	end := time.Now()
+	if !asOf.IsZero() {
+		end = asOf
+	}
+
 	worksheetName := c.DefaultQuery("name", "worksheet")
-	currDay := business_days.GetBusinessDay(time.Now())
+	currDay := business_days.GetBusinessDay(end)
 	julDate := c.DefaultQuery("juldate", utils.JulDateFromTime(currDay))
 	logrus.Info("Worksheet ", worksheetName, " Julian Date is:", julDate)
 
 	ws := worksheets.NewWorkSheet(excelize.NewFile(), a.PGXConn)
-	ws.Lookups = a.LookupSet
+	ws.Context = c.Request.Context()
+	ws.Lookups = lookups
 	ws.StockCache = a.StockCache
+	ws.Currency = c.DefaultQuery("currency", model.DefaultCurrency)
+	ws.AsOf = asOf
 	// ws.DividendCache = a.DividendCache
 
 	if err := ws.StockAnalysis("Stock Analysis", julDate); err != nil {
@@ -35,7 +54,7 @@ func (a *App) CreateWorksheetHandler(c *gin.Context) {
 		return
 	}
 
-	if err := ws.DividendAnalysis("Dividend Analysis", time.Now(), 48); err != nil {
+	if err := ws.DividendAnalysis("Dividend Analysis", end, 48); err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.StatusObject{Status: err.Error()})
 		return
 	}
@@ -60,6 +79,5 @@ func (a *App) CreateWorksheetHandler(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", worksheetName))
-	c.Data(http.StatusOK, "application/octet-stream", buff.Bytes())
+	a.sendWorksheet(c, worksheetName, buff.Bytes())
 }
@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// leeway allows for the clocks of the provider and the server being apart.
+const leeway = time.Minute
+
+var (
+	errKeySet       = errors.New("invalid key set")
+	errToken        = errors.New("invalid token")
+	errTokenExpired = errors.New("token expired")
+)
+
+// IsTokenError returns true when the error is from a token that is not valid.
+func IsTokenError(err error) bool {
+	return errors.Is(err, errToken) || errors.Is(err, errTokenExpired)
+}
+
+// jwk is a key of a JSON Web Key Set, RFC 7517. Only the RSA and EC signing keys are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet is the public keys of an OIDC provider by key id.
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// Claims are the registered claims of a token the server checks.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Name      string   `json:"name,omitempty"`
+	Email     string   `json:"email,omitempty"`
+}
+
+// audience is the aud claim, which is a string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verifier checks the tokens signed by the keys of the key set for the issuer and audience. The issuer is
+// not checked when it is empty, but every token is refused without an audience, so a token the provider
+// issued for another application is never accepted.
+type Verifier struct {
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// ParseKeySet reads the signing keys of a JSON Web Key Set.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %s", errKeySet, err.Error())
+	}
+
+	ks := KeySet{keys: make(map[string]crypto.PublicKey)}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %s", errKeySet, k.Kid, err.Error())
+		}
+		ks.keys[k.Kid] = key
+	}
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("%w: no signing keys", errKeySet)
+	}
+	return &ks, nil
+}
+
+// LoadKeySet reads the JSON Web Key Set in the file.
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeySet(data)
+}
+
+// key returns the key with the id, or the only key when the token has no key id.
+func (ks *KeySet) key(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// verifySignature checks the signature of the signed part of a token with the key for the algorithm.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		// none and the HMAC algorithms are never accepted.
+		return fmt.Errorf("%w: unsupported algorithm %s", errToken, alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+			return fmt.Errorf("%w: bad signature", errToken)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size {
+			break
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("%w: bad signature", errToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: the key is not for %s", errToken, alg)
+}
+
+// Verify checks the signature, dates, issuer and audience of the token and returns its claims.
+func (v *Verifier) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", errToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(b, &header) != nil {
+		return nil, fmt.Errorf("%w: bad header", errToken)
+	}
+	key, ok := v.Keys.key(header.Kid)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %s", errToken, header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature", errToken)
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	b, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(b, &claims) != nil {
+		return nil, fmt.Errorf("%w: bad claims", errToken)
+	}
+	switch {
+	case claims.ExpiresAt == 0:
+		return nil, fmt.Errorf("%w: no expiry", errToken)
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)):
+		return nil, errTokenExpired
+	case claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)):
+		return nil, fmt.Errorf("%w: not valid yet", errToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: no subject", errToken)
+	case v.Issuer != "" && claims.Issuer != v.Issuer:
+		return nil, fmt.Errorf("%w: issuer %s", errToken, claims.Issuer)
+	case v.Audience == "":
+		return nil, fmt.Errorf("%w: no audience to check", errToken)
+	}
+	found := false
+	for _, aud := range claims.Audience {
+		found = found || aud == v.Audience
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: audience %v", errToken, []string(claims.Audience))
+	}
+	return &claims, nil
+}
@@ -0,0 +1,121 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/kpearce2430/stock-tools/cmd/internal/auth"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding.EncodeToString
+
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	body, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(body)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err.Error())
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa-1","use":"sig","alg":"RS256","n":%q,"e":%q},
+		{"kty":"EC","kid":"ec-1","crv":"P-256","x":%q,"y":%q},
+		{"kty":"RSA","kid":"enc-1","use":"enc","n":"AQAB","e":"AQAB"}]}`,
+		b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b64(ecKey.X.FillBytes(make([]byte, 32))), b64(ecKey.Y.FillBytes(make([]byte, 32))))
+	keys, err := auth.ParseKeySet([]byte(jwks))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	v := auth.Verifier{Keys: keys, Issuer: "https://id.example.com", Audience: "stock-tools"}
+
+	now := time.Date(2025, time.January, 6, 12, 0, 0, 0, time.UTC)
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{"sub": "user-1", "iss": "https://id.example.com", "aud": []string{"stock-tools"},
+			"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(-time.Minute).Unix()}
+		for k, value := range changes {
+			c[k] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", signToken(t, "RS256", "rsa-1", rsaKey, claims(nil)), true},
+		{"ES256", signToken(t, "ES256", "ec-1", ecKey, claims(map[string]any{"aud": "stock-tools"})), true},
+		{"expired", signToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), false},
+		{"not yet valid", signToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), false},
+		{"issuer", signToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"iss": "https://other.example.com"})), false},
+		{"audience", signToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"aud": "other"})), false},
+		{"another key", signToken(t, "RS256", "rsa-1", otherKey, claims(nil)), false},
+		{"unknown key", signToken(t, "RS256", "rsa-2", rsaKey, claims(nil)), false},
+		{"encryption key", signToken(t, "RS256", "enc-1", rsaKey, claims(nil)), false},
+		{"algorithm of another key", signToken(t, "ES256", "rsa-1", ecKey, claims(nil)), false},
+		{"none", b64([]byte(`{"alg":"none","kid":"rsa-1"}`)) + "." + b64([]byte(`{"sub":"user-1"}`)) + ".", false},
+		{"not a JWT", "st_0123", false},
+	}
+
+	for _, tt := range tests {
+		got, err := v.Verify(tt.token, now)
+		switch {
+		case tt.valid && err != nil:
+			t.Errorf("%s: %s", tt.name, err.Error())
+		case tt.valid && got.Subject != "user-1":
+			t.Errorf("%s: got subject %s", tt.name, got.Subject)
+		case !tt.valid && !auth.IsTokenError(err):
+			t.Errorf("%s: got %v, want an invalid token", tt.name, err)
+		}
+	}
+
+	// Without an audience a token for any application of the provider would be accepted.
+	v.Audience = ""
+	if _, err := v.Verify(signToken(t, "RS256", "rsa-1", rsaKey, claims(nil)), now); !auth.IsTokenError(err) {
+		t.Errorf("no audience: got %v, want an invalid token", err)
+	}
+}
+
+func TestParseKeySet_Invalid(t *testing.T) {
+	for _, jwks := range []string{`not json`, `{"keys":[]}`, `{"keys":[{"kty":"oct","kid":"hmac","k":"c2VjcmV0"}]}`} {
+		if _, err := auth.ParseKeySet([]byte(jwks)); err == nil {
+			t.Errorf("%s: expected an error", jwks)
+		}
+	}
+}
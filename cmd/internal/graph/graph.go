@@ -0,0 +1,200 @@
+// Package graph serves the portfolio entities as a GraphQL schema, so a view of accounts, holdings, lots,
+// dividends and prices is one query rather than a call for each entity.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/stock-tools/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	transactionTable    = "transactions"
+	dividendsTable      = "dividends"
+	portfolioValueTable = "portfolio_value"
+	fundHistoryTable    = "fund_history"
+
+	// maxDepth keeps a query from nesting the holdings of symbols of holdings without end.
+	maxDepth = 12
+)
+
+var errNoLookups = errors.New("lookups not loaded")
+
+//go:embed schema.graphql
+var schemaText string
+
+// Schema runs GraphQL queries against the tables of the portfolio of their context.
+type Schema struct {
+	schema  *graphql.Schema
+	pg      *pgxpool.Pool
+	lookups func(ctx context.Context) *model.LookUpSet
+}
+
+// NewSchema returns the schema over the tables of the pool. Lookups returns the lookups of the portfolio
+// of the context, the symbols of DEAD securities are left out of the symbols.
+func NewSchema(pg *pgxpool.Pool, lookups func(ctx context.Context) *model.LookUpSet) (*Schema, error) {
+	schema, err := graphql.ParseSchema(schemaText, &queryResolver{}, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: schema, pg: pg, lookups: lookups}, nil
+}
+
+// Exec runs the query with loaders of its own, so the values it reads are batched for it alone.
+func (s *Schema) Exec(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response {
+	return s.schema.Exec(context.WithValue(ctx, loadersKey{}, newLoaders(s.pg, s.lookups)), query, operationName, variables)
+}
+
+type loadersKey struct{}
+
+// loaders are the loaders of a query by symbol. The symbols primed are added to the loaders made after.
+type loaders struct {
+	pg              *pgxpool.Pool
+	lookups         func(ctx context.Context) *model.LookUpSet
+	listLock        sync.Mutex
+	securities      map[string]string // the security of each symbol with a transaction
+	lock            sync.Mutex
+	symbols         []string
+	transactions    *Loader[*model.TransactionSet]
+	dividends       *Loader[[]model.Dividends]
+	dividendHistory *Loader[*model.DividendHistory]
+	portfolioValues *Loader[*model.PortfolioValueRecord]
+	holdings        map[time.Time]*Loader[[]*model.Holding]
+	prices          map[[2]time.Time]*Loader[[]*model.Historical]
+}
+
+func newLoaders(pg *pgxpool.Pool, lookups func(ctx context.Context) *model.LookUpSet) *loaders {
+	l := loaders{
+		pg:       pg,
+		lookups:  lookups,
+		holdings: make(map[time.Time]*Loader[[]*model.Holding]),
+		prices:   make(map[[2]time.Time]*Loader[[]*model.Historical]),
+	}
+	l.transactions = NewLoader(func(ctx context.Context, symbols []string) (map[string]*model.TransactionSet, error) {
+		return model.TransactionSetsWithOptionsBySymbols(ctx, pg, transactionTable, symbols)
+	})
+	l.dividends = NewLoader(func(ctx context.Context, symbols []string) (map[string][]model.Dividends, error) {
+		return model.DividendsBySymbols(ctx, pg, dividendsTable, symbols)
+	})
+	l.dividendHistory = NewLoader(func(ctx context.Context, symbols []string) (map[string]*model.DividendHistory, error) {
+		return model.DividendHistoriesFromDB(ctx, pg, symbols)
+	})
+	l.portfolioValues = NewLoader(func(ctx context.Context, symbols []string) (map[string]*model.PortfolioValueRecord, error) {
+		return model.PortfolioValuesLastDB(ctx, pg, portfolioValueTable, symbols)
+	})
+	return &l
+}
+
+// loadersFrom returns the loaders of the query of the context.
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// symbolSecurities returns the security of each symbol with a transaction, read once for the query, and
+// primes the loaders with the symbols.
+func (l *loaders) symbolSecurities(ctx context.Context) (map[string]string, error) {
+	l.listLock.Lock()
+	defer l.listLock.Unlock()
+	if l.securities != nil {
+		return l.securities, nil
+	}
+	lookups := l.lookups(ctx)
+	if lookups == nil {
+		return nil, errNoLookups
+	}
+	securities, err := model.SymbolList(ctx, l.pg, lookups)
+	if err != nil {
+		return nil, err
+	}
+	delete(securities, "")
+	l.securities = securities
+	l.prime(sortedKeys(securities)...)
+	return securities, nil
+}
+
+// prime adds the symbols of a list to the next read of every loader.
+func (l *loaders) prime(symbols ...string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.symbols = append(l.symbols, symbols...)
+	l.transactions.Prime(symbols...)
+	l.dividends.Prime(symbols...)
+	l.dividendHistory.Prime(symbols...)
+	l.portfolioValues.Prime(symbols...)
+	for _, h := range l.holdings {
+		h.Prime(symbols...)
+	}
+	for _, p := range l.prices {
+		p.Prime(symbols...)
+	}
+}
+
+// holdingsAsOf returns the loader of the open lots on asOf, which are read from the transactions loaded.
+func (l *loaders) holdingsAsOf(asOf time.Time) *Loader[[]*model.Holding] {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if h, ok := l.holdings[asOf]; ok {
+		return h
+	}
+	h := NewLoader(func(ctx context.Context, symbols []string) (map[string][]*model.Holding, error) {
+		holdings := make(map[string][]*model.Holding, len(symbols))
+		for _, symbol := range symbols {
+			ts, err := l.transactions.Load(ctx, symbol)
+			if err != nil {
+				return nil, err
+			}
+			if holdings[symbol], err = model.HoldingsFromTransactions(ctx, l.pg, symbol, asOf, ts); err != nil {
+				return nil, err
+			}
+		}
+		return holdings, nil
+	})
+	h.Prime(l.symbols...)
+	l.holdings[asOf] = h
+	return h
+}
+
+// pricesBetween returns the loader of the fund history from start to end.
+func (l *loaders) pricesBetween(start, end time.Time) *Loader[[]*model.Historical] {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := [2]time.Time{start, end}
+	if p, ok := l.prices[key]; ok {
+		return p
+	}
+	p := NewLoader(func(ctx context.Context, symbols []string) (map[string][]*model.Historical, error) {
+		return model.NewHistoricalDataSet(l.pg, fundHistoryTable).BetweenBySymbols(ctx, symbols, start, end)
+	})
+	p.Prime(l.symbols...)
+	l.prices[key] = p
+	return p
+}
+
+// parseDate returns the date of an argument, or the zero time when it is not set.
+func parseDate(name string, value *string) (time.Time, error) {
+	if value == nil || *value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s", name, *value)
+	}
+	return date, nil
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package graph_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/cmd/internal/graph"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestNewSchema(t *testing.T) {
+	if _, err := graph.NewSchema(nil, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestSchema_Exec_Invalid(t *testing.T) {
+	s, err := graph.NewSchema(nil, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, query := range []string{
+		`{ symbols { price } }`,
+		`{ account { name } }`,
+		`{ symbol(symbol: "T") { priceHistory { close } } }`,
+	} {
+		if resp := s.Exec(context.Background(), query, "", nil); len(resp.Errors) == 0 {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestLoader_Batches(t *testing.T) {
+	var fetched [][]string
+	var lock sync.Mutex
+	l := graph.NewLoader(func(ctx context.Context, keys []string) (map[string]int, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		fetched = append(fetched, slices.Clone(keys))
+		values := make(map[string]int)
+		for _, k := range keys {
+			values[k] = len(k)
+		}
+		return values, nil
+	})
+
+	symbols := []string{"T", "VZ", "MSFT", "AAPL"}
+	l.Prime(symbols...)
+	var wg sync.WaitGroup
+	for _, symbol := range symbols {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			if got, err := l.Load(context.Background(), symbol); err != nil || got != len(symbol) {
+				t.Errorf("%s: got %d, %v", symbol, got, err)
+			}
+		}(symbol)
+	}
+	wg.Wait()
+
+	if l.Batches() != 1 {
+		t.Fatalf("got %d batches, want 1: %v", l.Batches(), fetched)
+	}
+	got := slices.Clone(fetched[0])
+	sort.Strings(got)
+	if !slices.Equal(got, []string{"AAPL", "MSFT", "T", "VZ"}) {
+		t.Errorf("got keys %v", got)
+	}
+
+	// A key that was not primed is read on its own, one read is not read again.
+	if _, err := l.Load(context.Background(), "IBM"); err != nil {
+		t.Fatal(err.Error())
+	}
+	l.Prime("T")
+	if _, err := l.Load(context.Background(), "T"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if l.Batches() != 2 || !slices.Equal(fetched[1], []string{"IBM"}) {
+		t.Errorf("got %d batches: %v", l.Batches(), fetched)
+	}
+}
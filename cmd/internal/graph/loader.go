@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Loader reads values by key in batches for the resolvers of a query. A resolver of a list primes the keys
+// of its items, and the first item loaded reads the values of every key primed with it, so the fields of a
+// list of N items take one read rather than N.
+type Loader[V any] struct {
+	fetch   func(ctx context.Context, keys []string) (map[string]V, error)
+	lock    sync.Mutex
+	pending []string
+	values  map[string]V
+	batches int
+}
+
+// NewLoader returns the loader of the values fetch reads. A key fetch returns no value for has the zero value.
+func NewLoader[V any](fetch func(ctx context.Context, keys []string) (map[string]V, error)) *Loader[V] {
+	return &Loader[V]{fetch: fetch, values: make(map[string]V)}
+}
+
+// Prime adds the keys to the next read.
+func (l *Loader[V]) Prime(keys ...string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, key := range keys {
+		if _, ok := l.values[key]; !ok && !slices.Contains(l.pending, key) {
+			l.pending = append(l.pending, key)
+		}
+	}
+}
+
+// Load returns the value of the key, reading it with the keys primed when it has not been read. The loads
+// of a batch wait for the read of the first.
+func (l *Loader[V]) Load(ctx context.Context, key string) (V, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if value, ok := l.values[key]; ok {
+		return value, nil
+	}
+
+	keys := l.pending
+	if !slices.Contains(keys, key) {
+		keys = append(keys, key)
+	}
+	l.pending = nil
+	l.batches++
+	values, err := l.fetch(ctx, keys)
+	if err != nil {
+		var none V
+		return none, err
+	}
+	for _, k := range keys {
+		l.values[k] = values[k]
+	}
+	return l.values[key], nil
+}
+
+// Batches returns the number of reads.
+func (l *Loader[V]) Batches() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.batches
+}
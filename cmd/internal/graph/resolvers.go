@@ -0,0 +1,446 @@
+package graph
+
+import (
+	"context"
+	graphql "github.com/graph-gophers/graphql-go"
+	business_days "github.com/kpearce2430/keputils/business-days"
+	"github.com/kpearce2430/stock-tools/model"
+	"slices"
+	"time"
+)
+
+type queryResolver struct{}
+
+func (q *queryResolver) Accounts(ctx context.Context) ([]*accountResolver, error) {
+	names, err := model.AccountList(ctx, loadersFrom(ctx).pg)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]*accountResolver, 0, len(names))
+	for _, name := range names {
+		if name != "" {
+			accounts = append(accounts, &accountResolver{name: name})
+		}
+	}
+	return accounts, nil
+}
+
+func (q *queryResolver) Account(ctx context.Context, args struct{ Name string }) (*accountResolver, error) {
+	names, err := model.AccountList(ctx, loadersFrom(ctx).pg)
+	if err != nil || !slices.Contains(names, args.Name) {
+		return nil, err
+	}
+	return &accountResolver{name: args.Name}, nil
+}
+
+func (q *queryResolver) Symbols(ctx context.Context) ([]*symbolResolver, error) {
+	securities, err := loadersFrom(ctx).symbolSecurities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]*symbolResolver, 0, len(securities))
+	for _, symbol := range sortedKeys(securities) {
+		symbols = append(symbols, &symbolResolver{symbol: symbol, security: securities[symbol]})
+	}
+	return symbols, nil
+}
+
+func (q *queryResolver) Symbol(ctx context.Context, args struct{ Symbol string }) (*symbolResolver, error) {
+	ts, err := loadersFrom(ctx).transactions.Load(ctx, args.Symbol)
+	if err != nil || ts == nil || len(ts.TransactionRows) == 0 {
+		return nil, err
+	}
+	s := symbolResolver{symbol: args.Symbol}
+	for _, tr := range ts.TransactionRows {
+		if tr.Symbol == args.Symbol && tr.Security != "" {
+			s.security = tr.Security
+		}
+	}
+	return &s, nil
+}
+
+type accountResolver struct {
+	name string
+}
+
+func (a *accountResolver) Name() string {
+	return a.name
+}
+
+// Holdings returns the symbols with open lots in the account, from the transactions of every symbol read
+// in one batch.
+func (a *accountResolver) Holdings(ctx context.Context, args struct{ AsOf *string }) ([]*holdingResolver, error) {
+	asOf, err := parseDate("asOf", args.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	l := loadersFrom(ctx)
+	securities, err := l.symbolSecurities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := l.holdingsAsOf(asOf)
+	var holdings []*holdingResolver
+	for _, symbol := range sortedKeys(securities) {
+		lots, err := loader.Load(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		s := &symbolResolver{symbol: symbol, security: securities[symbol]}
+		holdings = append(holdings, newHoldings(s, lots, a.name)...)
+	}
+	return holdings, nil
+}
+
+// holdingResolver is the open lots of a symbol in an account.
+type holdingResolver struct {
+	account string
+	symbol  *symbolResolver
+	lots    []*model.Holding
+}
+
+// newHoldings returns the holdings of the lots of the symbol by account, of the account when it is not empty.
+func newHoldings(s *symbolResolver, lots []*model.Holding, account string) []*holdingResolver {
+	var holdings []*holdingResolver
+	for _, lot := range lots {
+		if account != "" && lot.Account != account {
+			continue
+		}
+		if n := len(holdings); n == 0 || holdings[n-1].account != lot.Account {
+			holdings = append(holdings, &holdingResolver{account: lot.Account, symbol: s})
+		}
+		h := holdings[len(holdings)-1]
+		h.lots = append(h.lots, lot)
+	}
+	return holdings
+}
+
+func (h *holdingResolver) Account() string {
+	return h.account
+}
+
+func (h *holdingResolver) Symbol() *symbolResolver {
+	return h.symbol
+}
+
+func (h *holdingResolver) Shares() float64 {
+	var shares model.Decimal
+	for _, lot := range h.lots {
+		shares = shares.Add(lot.RemainingShares)
+	}
+	return shares.Float64()
+}
+
+func (h *holdingResolver) Cost() float64 {
+	var cost model.Decimal
+	for _, lot := range h.lots {
+		cost = cost.Add(lot.Cost)
+	}
+	return cost.Float64()
+}
+
+func (h *holdingResolver) Lots() []*lotResolver {
+	lots := make([]*lotResolver, len(h.lots))
+	for i, lot := range h.lots {
+		lots[i] = &lotResolver{lot}
+	}
+	return lots
+}
+
+type lotResolver struct {
+	h *model.Holding
+}
+
+func (l *lotResolver) Account() string          { return l.h.Account }
+func (l *lotResolver) Symbol() string           { return l.h.Symbol }
+func (l *lotResolver) Type() string             { return string(l.h.Type) }
+func (l *lotResolver) Acquired() graphql.Time   { return graphql.Time{Time: l.h.Acquired} }
+func (l *lotResolver) OriginalShares() float64  { return l.h.OriginalShares.Float64() }
+func (l *lotResolver) RemainingShares() float64 { return l.h.RemainingShares.Float64() }
+func (l *lotResolver) CostPerShare() float64    { return l.h.CostPerShare.Float64() }
+func (l *lotResolver) Cost() float64            { return l.h.Cost.Float64() }
+func (l *lotResolver) HoldingPeriod() string    { return l.h.HoldingPeriod }
+func (l *lotResolver) DaysHeld() int32          { return int32(l.h.DaysHeld) }
+func (l *lotResolver) Currency() string         { return l.h.Currency }
+
+type symbolResolver struct {
+	symbol   string
+	security string
+}
+
+func (s *symbolResolver) Symbol() string {
+	return s.symbol
+}
+
+func (s *symbolResolver) Security() string {
+	return s.security
+}
+
+func (s *symbolResolver) Info(ctx context.Context, args struct {
+	Currency *string
+	AsOf     *string
+}) (*accountInfoResolver, error) {
+	asOf, err := parseDate("asOf", args.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	currency := ""
+	if args.Currency != nil {
+		currency = *args.Currency
+	}
+	l := loadersFrom(ctx)
+	ts, err := l.transactions.Load(ctx, s.symbol)
+	if err != nil {
+		return nil, err
+	}
+	info, err := model.AccountInfoFromTransactions(ctx, l.pg, s.symbol, currency, asOf, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &accountInfoResolver{info}, nil
+}
+
+func (s *symbolResolver) Transactions(ctx context.Context) ([]*transactionResolver, error) {
+	ts, err := loadersFrom(ctx).transactions.Load(ctx, s.symbol)
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]*transactionResolver, len(ts.TransactionRows))
+	for i, tr := range ts.TransactionRows {
+		transactions[i] = &transactionResolver{tr}
+	}
+	return transactions, nil
+}
+
+func (s *symbolResolver) Holdings(ctx context.Context, args struct{ AsOf *string }) ([]*holdingResolver, error) {
+	asOf, err := parseDate("asOf", args.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	lots, err := loadersFrom(ctx).holdingsAsOf(asOf).Load(ctx, s.symbol)
+	if err != nil {
+		return nil, err
+	}
+	return newHoldings(s, lots, ""), nil
+}
+
+func (s *symbolResolver) Dividends(ctx context.Context) ([]*dividendResolver, error) {
+	ds, err := loadersFrom(ctx).dividends.Load(ctx, s.symbol)
+	if err != nil {
+		return nil, err
+	}
+	dividends := make([]*dividendResolver, len(ds))
+	for i := range ds {
+		dividends[i] = &dividendResolver{&ds[i]}
+	}
+	return dividends, nil
+}
+
+func (s *symbolResolver) DividendHistory(ctx context.Context) ([]*dividendEntryResolver, error) {
+	dh, err := loadersFrom(ctx).dividendHistory.Load(ctx, s.symbol)
+	if err != nil || dh == nil {
+		return nil, err
+	}
+	entries := make([]*dividendEntryResolver, len(dh.DividendEntries))
+	for i, d := range dh.DividendEntries {
+		entries[i] = &dividendEntryResolver{d}
+	}
+	return entries, nil
+}
+
+func (s *symbolResolver) PriceHistory(ctx context.Context, args struct {
+	Start string
+	End   *string
+}) ([]*historicalResolver, error) {
+	start, err := parseDate("start", &args.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate("end", args.End)
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	history, err := loadersFrom(ctx).pricesBetween(start, end).Load(ctx, s.symbol)
+	if err != nil {
+		return nil, err
+	}
+	prices := make([]*historicalResolver, len(history))
+	for i, h := range history {
+		prices[i] = &historicalResolver{h}
+	}
+	return prices, nil
+}
+
+func (s *symbolResolver) PortfolioValue(ctx context.Context) (*portfolioValueResolver, error) {
+	pv, err := loadersFrom(ctx).portfolioValues.Load(ctx, s.symbol)
+	if err != nil || pv == nil {
+		return nil, err
+	}
+	return &portfolioValueResolver{pv}, nil
+}
+
+func (s *symbolResolver) Detail(ctx context.Context, args struct {
+	Date   *string
+	Months int32
+}) ([]*symbolDetailResolver, error) {
+	date, err := parseDate("date", args.Date)
+	if err != nil {
+		return nil, err
+	}
+	if date.IsZero() {
+		date = business_days.GetBusinessDay(time.Now())
+	}
+	set := model.NewSymbolDetailSet(loadersFrom(ctx).pg, s.symbol, fundHistoryTable)
+	if err := set.Create(ctx, date, int(args.Months)); err != nil {
+		return nil, err
+	}
+	details := make([]*symbolDetailResolver, len(set.Info))
+	for i, sd := range set.Info {
+		details[i] = &symbolDetailResolver{sd}
+	}
+	return details, nil
+}
+
+type transactionResolver struct {
+	t *model.Transaction
+}
+
+func (r *transactionResolver) ID() int32                 { return int32(r.t.Id) }
+func (r *transactionResolver) Date() graphql.Time        { return graphql.Time{Time: r.t.Date} }
+func (r *transactionResolver) Type() string              { return string(r.t.Type) }
+func (r *transactionResolver) Security() string          { return r.t.Security }
+func (r *transactionResolver) Symbol() string            { return r.t.Symbol }
+func (r *transactionResolver) Description() string       { return r.t.Description }
+func (r *transactionResolver) Shares() float64           { return r.t.Shares.Float64() }
+func (r *transactionResolver) InvestmentAmount() float64 { return r.t.InvestmentAmount.Float64() }
+func (r *transactionResolver) Amount() float64           { return r.t.Amount.Float64() }
+func (r *transactionResolver) Account() string           { return r.t.Account }
+func (r *transactionResolver) Currency() string          { return currencyOrDefault(r.t.Currency) }
+
+type accountInfoResolver struct {
+	a *model.AccountInfo
+}
+
+func (r *accountInfoResolver) Symbol() string             { return r.a.Symbol }
+func (r *accountInfoResolver) Security() string           { return r.a.Security }
+func (r *accountInfoResolver) SecurityType() string       { return r.a.SecurityType }
+func (r *accountInfoResolver) NumberOfShares() float64    { return r.a.NumberOfShares }
+func (r *accountInfoResolver) LatestPrice() float64       { return r.a.LatestPrice }
+func (r *accountInfoResolver) DividendsReceived() float64 { return r.a.DividendsReceived }
+func (r *accountInfoResolver) CapitalGainsPaid() float64  { return r.a.CapitalGainsPaid }
+func (r *accountInfoResolver) InterestIncome() float64    { return r.a.InterestIncome }
+func (r *accountInfoResolver) ReturnOfCapital() float64   { return r.a.ReturnOfCapital }
+func (r *accountInfoResolver) CapitalGain() float64       { return r.a.CapitalGain }
+func (r *accountInfoResolver) NetCost() float64           { return r.a.NetCost }
+func (r *accountInfoResolver) FirstBought() *graphql.Time { return optionalTime(r.a.FirstBought) }
+func (r *accountInfoResolver) AveragePrice() float64      { return r.a.AveragePrice }
+func (r *accountInfoResolver) Currency() string           { return r.a.Currency }
+func (r *accountInfoResolver) LocalCurrency() string      { return r.a.LocalCurrency }
+func (r *accountInfoResolver) LocalPrice() float64        { return r.a.LocalPrice }
+func (r *accountInfoResolver) FxRate() float64            { return r.a.FXRate }
+func (r *accountInfoResolver) PriceGain() float64         { return r.a.PriceGain }
+func (r *accountInfoResolver) CurrencyGain() float64      { return r.a.CurrencyGain }
+
+func (r *accountInfoResolver) Accounts() []*accountSharesResolver {
+	accounts := make([]*accountSharesResolver, 0, len(r.a.Accounts))
+	for _, name := range sortedKeys(r.a.Accounts) {
+		accounts = append(accounts, &accountSharesResolver{account: name, shares: r.a.Accounts[name]})
+	}
+	return accounts
+}
+
+type accountSharesResolver struct {
+	account string
+	shares  float64
+}
+
+func (r *accountSharesResolver) Account() string { return r.account }
+func (r *accountSharesResolver) Shares() float64 { return r.shares }
+
+type dividendResolver struct {
+	d *model.Dividends
+}
+
+func (r *dividendResolver) CashAmount() float64  { return r.d.CashAmount }
+func (r *dividendResolver) DividendType() string { return r.d.DividendType }
+func (r *dividendResolver) Frequency() int32     { return int32(r.d.Frequency) }
+func (r *dividendResolver) DeclarationDate() *graphql.Time {
+	return optionalTime(r.d.DeclarationDate.Time)
+}
+func (r *dividendResolver) ExDividendDate() *graphql.Time {
+	return optionalTime(r.d.ExDividendDate.Time)
+}
+func (r *dividendResolver) PayDate() *graphql.Time    { return optionalTime(r.d.PayDate.Time) }
+func (r *dividendResolver) RecordDate() *graphql.Time { return optionalTime(r.d.RecordDate.Time) }
+
+type dividendEntryResolver struct {
+	d *model.DividendEntry
+}
+
+func (r *dividendEntryResolver) Year() int32     { return int32(r.d.Year) }
+func (r *dividendEntryResolver) Month() int32    { return int32(r.d.Month) }
+func (r *dividendEntryResolver) Amount() float64 { return r.d.Amount }
+
+type historicalResolver struct {
+	h *model.Historical
+}
+
+func (r *historicalResolver) Date() graphql.Time { return graphql.Time{Time: r.h.Date} }
+func (r *historicalResolver) Open() float64      { return r.h.Open }
+func (r *historicalResolver) High() float64      { return r.h.High }
+func (r *historicalResolver) Low() float64       { return r.h.Low }
+func (r *historicalResolver) Close() float64     { return r.h.Close }
+func (r *historicalResolver) AdjClose() float64  { return r.h.AdjClose }
+func (r *historicalResolver) Volume() float64    { return r.h.Volume }
+func (r *historicalResolver) Source() string     { return r.h.Source }
+func (r *historicalResolver) Currency() string   { return currencyOrDefault(r.h.Currency) }
+
+type portfolioValueResolver struct {
+	p *model.PortfolioValueRecord
+}
+
+func (r *portfolioValueResolver) Name() string                 { return r.p.Name }
+func (r *portfolioValueResolver) Type() string                 { return r.p.Type }
+func (r *portfolioValueResolver) Quote() float64               { return r.p.Quote }
+func (r *portfolioValueResolver) PriceDayChange() float64      { return r.p.PriceDayChange }
+func (r *portfolioValueResolver) PriceDayChangePct() float64   { return r.p.PriceDayChangePct }
+func (r *portfolioValueResolver) Shares() float64              { return r.p.Shares }
+func (r *portfolioValueResolver) CostBasis() float64           { return r.p.CostBasis }
+func (r *portfolioValueResolver) MarketValue() float64         { return r.p.MarketValue }
+func (r *portfolioValueResolver) AverageCostPerShare() float64 { return r.p.AverageCostPerShare }
+func (r *portfolioValueResolver) GainLoss12Month() float64     { return r.p.GainLoss12Month }
+func (r *portfolioValueResolver) GainLoss() float64            { return r.p.GainLoss }
+func (r *portfolioValueResolver) GainLossPct() float64         { return r.p.GainLossPct }
+func (r *portfolioValueResolver) Currency() string             { return currencyOrDefault(r.p.Currency) }
+
+type symbolDetailResolver struct {
+	s *model.SymbolDetail
+}
+
+func (r *symbolDetailResolver) Year() int32           { return int32(r.s.Year) }
+func (r *symbolDetailResolver) Month() int32          { return int32(r.s.Month) }
+func (r *symbolDetailResolver) Quantity() float64     { return r.s.Quantity }
+func (r *symbolDetailResolver) Price() float64        { return r.s.Price }
+func (r *symbolDetailResolver) Value() float64        { return r.s.Value() }
+func (r *symbolDetailResolver) Dividends() float64    { return r.s.Dividends }
+func (r *symbolDetailResolver) CapitalGains() float64 { return r.s.CapitalGains }
+
+// optionalTime returns the time, or nil when it is not set.
+func optionalTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+// currencyOrDefault returns the currency, model.DefaultCurrency when it is empty.
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return model.DefaultCurrency
+	}
+	return currency
+}
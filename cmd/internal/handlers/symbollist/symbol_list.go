@@ -1,16 +1,20 @@
 package symbollist
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kpearce2430/stock-tools/model"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"time"
 )
 
 type SymbolList struct {
 	PGXConn *pgxpool.Pool
 	Lookups *model.LookUpSet
+	// PortfolioLookups returns the lookups of the portfolio of the request, Lookups is used when it is nil.
+	PortfolioLookups func(ctx context.Context) *model.LookUpSet
 }
 
 func NewSymbolList(pgxConn *pgxpool.Pool, lookups *model.LookUpSet) *SymbolList {
@@ -20,15 +24,24 @@ func NewSymbolList(pgxConn *pgxpool.Pool, lookups *model.LookUpSet) *SymbolList
 	}
 }
 
+// lookups returns the lookups of the portfolio of the request.
+func (s *SymbolList) lookups(ctx context.Context) *model.LookUpSet {
+	if s.PortfolioLookups != nil {
+		return s.PortfolioLookups(ctx)
+	}
+	return s.Lookups
+}
+
 func (s *SymbolList) SymbolListGet(c *gin.Context) {
-	if s.Lookups == nil {
+	lookups := s.lookups(c.Request.Context())
+	if lookups == nil {
 		panic("missing lookups")
 	}
 	if s.PGXConn == nil {
 		panic("missing pg connection")
 	}
 
-	symbolSet, err := model.SymbolList(c.Request.Context(), s.PGXConn, s.Lookups)
+	symbolSet, err := model.SymbolList(c.Request.Context(), s.PGXConn, lookups)
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, err.Error())
 		return
@@ -53,7 +66,18 @@ func (s *SymbolList) TickerInfoGet(c *gin.Context) {
 	logrus.Info("symbol:", acctSymbol)
 	// julDate := c.DefaultQuery("juldate", utils.JulDate())
 
-	acctInfo, err := model.AccountInfoGet(c.Request.Context(), s.PGXConn, acctSymbol)
+	// The holdings as of a date (YYYY-MM-DD), or the latest.
+	var asOf time.Time
+	if value := c.DefaultQuery("as_of", ""); value != "" {
+		var err error
+		if asOf, err = time.Parse("2006-01-02", value); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.StatusObject{Status: "invalid as_of: " + value})
+			return
+		}
+	}
+
+	currency := c.DefaultQuery("currency", model.DefaultCurrency)
+	acctInfo, err := model.AccountInfoGetAsOf(c.Request.Context(), s.PGXConn, acctSymbol, currency, asOf)
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, err.Error())
 		return
@@ -59,7 +59,7 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		logrus.Fatal(err.Error())
 	}
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, "transactions", testTransactions); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, "transactions", testTransactions); err != nil {
 		logrus.Fatal(err.Error())
 	}
 	os.Exit(m.Run())
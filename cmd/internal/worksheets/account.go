@@ -1,7 +1,6 @@
 package worksheets
 
 import (
-	"context"
 	"fmt"
 	"github.com/kpearce2430/stock-tools/model"
 	"github.com/sirupsen/logrus"
@@ -15,13 +14,13 @@ func (w *WorkSheet) AccountDividends(worksheetName string, start time.Time, mont
 		return err
 	}
 
-	accounts, err := model.AccountList(context.Background(), w.PGXConn)
+	accounts, err := model.AccountList(w.ctx(), w.PGXConn)
 	if err != nil {
 		logrus.Error("Error:", err.Error())
 		return err
 	}
 
-	symbols, err := model.SymbolList(context.Background(), w.PGXConn, w.Lookups)
+	symbols, err := model.SymbolList(w.ctx(), w.PGXConn, w.Lookups)
 	if err != nil {
 		logrus.Error("Error:", err.Error())
 		return err
@@ -37,7 +36,7 @@ func (w *WorkSheet) AccountDividends(worksheetName string, start time.Time, mont
 	col := 2
 
 	for _, account := range accounts {
-		if account[0] == 'z' {
+		if !model.AccountVisible(w.ctx(), account) {
 			continue
 		}
 		colInfoSymbol, err = NewColumnInfo(w.File, account, worksheetName, col)
@@ -75,7 +74,7 @@ func (w *WorkSheet) AccountDividends(worksheetName string, start time.Time, mont
 
 		tickerSet := model.NewTickerSet()
 		ts := model.NewTransactionSet()
-		if err = ts.GetTransactions(context.Background(), w.PGXConn, "", year, month); err != nil {
+		if err = ts.GetTransactions(w.ctx(), w.PGXConn, "", year, month); err != nil {
 			logrus.Error("Error:", err.Error())
 			return err
 		}
@@ -108,7 +107,7 @@ func (w *WorkSheet) AccountDividends(worksheetName string, start time.Time, mont
 					if ok {
 						a := ticker.GetAccount(colInfo.Name)
 						if a != nil {
-							paid = paid + a.Dividends()
+							paid = paid + a.Dividends().Float64()
 						}
 					}
 				}
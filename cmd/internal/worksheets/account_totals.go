@@ -0,0 +1,103 @@
+package worksheets
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	Account             = "Account"
+	Cash                = "Cash"
+	Contributions       = "Contributions"
+	Fees                = "Fees"
+	Income              = "Income"
+	MarketGain          = "Market Gain"
+	MarketValue         = "Market Value"
+	MoneyWeightedReturn = "Money Weighted Return"
+)
+
+// AccountTotals writes the cash, market value and contributions of each account with a total row.
+func (w *WorkSheet) AccountTotals(worksheetName string, totals []*model.AccountTotal) error {
+	if _, err := w.File.NewSheet(worksheetName); err != nil {
+		logrus.Error("Error:", err.Error())
+		return err
+	}
+
+	headers := []string{Account, Cash, MarketValue, TotalValue, Contributions, Income, Fees, MarketGain, MoneyWeightedReturn}
+	var allColumns []*ColumnInfo
+	for i, h := range headers {
+		colInfo, err := NewColumnInfo(w.File, h, worksheetName, i+1)
+		if err != nil {
+			logrus.Error("Error:", err.Error())
+			return err
+		}
+		colInfo.SetMaxSize(30)
+		allColumns = append(allColumns, colInfo)
+	}
+
+	row := 1
+	for _, colInfo := range allColumns {
+		if err := colInfo.WriteHeader(row, w.styles.Header); err != nil {
+			return err
+		}
+	}
+
+	sum := model.AccountTotal{Account: "Total"}
+	write := func(total *model.AccountTotal) error {
+		row++
+		for _, colInfo := range allColumns {
+			var err error
+			switch colInfo.Name {
+			case Account:
+				err = colInfo.WriteCell(row, total.Account, w.styles.TextStyle(row))
+			case Cash:
+				err = colInfo.WriteCell(row, total.Cash, w.styles.CurrencyStyle(row))
+			case MarketValue:
+				err = colInfo.WriteCell(row, total.MarketValue, w.styles.CurrencyStyle(row))
+			case TotalValue:
+				err = colInfo.WriteCell(row, total.TotalValue, w.styles.CurrencyStyle(row))
+			case Contributions:
+				err = colInfo.WriteCell(row, total.Contributions, w.styles.CurrencyStyle(row))
+			case Income:
+				err = colInfo.WriteCell(row, total.Income, w.styles.CurrencyStyle(row))
+			case Fees:
+				err = colInfo.WriteCell(row, total.Fees, w.styles.CurrencyStyle(row))
+			case MarketGain:
+				err = colInfo.WriteCell(row, total.MarketGain, w.styles.CurrencyStyle(row))
+			case MoneyWeightedReturn:
+				if total == &sum {
+					// The accounts can not be combined without the flows.
+					err = colInfo.WriteCell(row, "", w.styles.TextStyle(row))
+					break
+				}
+				err = colInfo.WriteCell(row, total.MoneyWeightedReturn, w.styles.PercentStyle(row))
+			}
+			if err != nil {
+				logrus.Error(err.Error())
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, total := range totals {
+		if err := write(total); err != nil {
+			return err
+		}
+		sum.Cash += total.Cash
+		sum.MarketValue += total.MarketValue
+		sum.TotalValue += total.TotalValue
+		sum.Contributions += total.Contributions
+		sum.Income += total.Income
+		sum.Fees += total.Fees
+		sum.MarketGain += total.MarketGain
+	}
+	if err := write(&sum); err != nil {
+		return err
+	}
+
+	for _, colInfo := range allColumns {
+		_ = colInfo.SetColumnSize()
+	}
+	return nil
+}
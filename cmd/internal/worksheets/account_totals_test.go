@@ -0,0 +1,32 @@
+package worksheets_test
+
+import (
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"testing"
+)
+
+func TestWorkSheet_AccountTotals(t *testing.T) {
+	totals := []*model.AccountTotal{
+		{Account: "Fidelity IRA", Cash: 2000.00, MarketValue: 3500.00, TotalValue: 5500.00, Contributions: 5000.00, MarketGain: 500.00, MoneyWeightedReturn: 0.10},
+		{Account: "HD ESPP", MarketValue: 100.00, TotalValue: 100.00, MarketGain: 100.00},
+	}
+
+	w := worksheets.NewWorkSheet(excelize.NewFile(), nil)
+	err := w.AccountTotals("Account Totals", totals)
+	assert.NoError(t, err, "Writing Account Totals")
+
+	value, err := w.File.GetCellValue("Account Totals", "B1")
+	assert.NoError(t, err, "Reading Header")
+	assert.Equal(t, worksheets.Cash, value)
+
+	value, err = w.File.GetCellValue("Account Totals", "A4")
+	assert.NoError(t, err, "Reading Total")
+	assert.Equal(t, "Total", value)
+
+	value, err = w.File.GetCellValue("Account Totals", "D4", excelize.Options{RawCellValue: true})
+	assert.NoError(t, err, "Reading Total Value")
+	assert.Equal(t, "5600", value)
+}
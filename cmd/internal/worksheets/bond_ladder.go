@@ -0,0 +1,117 @@
+package worksheets
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LadderAccount        = "Account"
+	LadderAccrued        = "Accrued Interest"
+	LadderAnnualIncome   = "Annual Income"
+	LadderCoupon         = "Coupon"
+	LadderCUSIP          = "CUSIP"
+	LadderDescription    = "Description"
+	LadderFace           = "Face"
+	LadderMarketValue    = "Market Value"
+	LadderMaturity       = "Maturity"
+	LadderNextCall       = "Next Call"
+	LadderPeriod         = "Period"
+	LadderWeightedCoupon = "Weighted Coupon"
+)
+
+// BondLadder writes a row for each bond held under the rung of the ladder it matures in.
+func (w *WorkSheet) BondLadder(worksheetName string, ladder []*model.LadderRung) error {
+	if _, err := w.File.NewSheet(worksheetName); err != nil {
+		logrus.Error("Error:", err.Error())
+		return err
+	}
+
+	headers := []string{LadderPeriod, LadderCUSIP, LadderDescription, LadderAccount, LadderMaturity, LadderNextCall,
+		LadderCoupon, LadderFace, LadderMarketValue, LadderAccrued, LadderAnnualIncome, LadderWeightedCoupon}
+	var allColumns []*ColumnInfo
+	for i, h := range headers {
+		colInfo, err := NewColumnInfo(w.File, h, worksheetName, i+1)
+		if err != nil {
+			logrus.Error("Error:", err.Error())
+			return err
+		}
+		colInfo.SetMaxSize(40)
+		allColumns = append(allColumns, colInfo)
+	}
+
+	row := 1
+	for _, colInfo := range allColumns {
+		if err := colInfo.WriteHeader(row, w.styles.Header); err != nil {
+			return err
+		}
+	}
+
+	for _, rung := range ladder {
+		// The rung row carries the totals, the bonds follow it.
+		row++
+		for _, colInfo := range allColumns {
+			var err error
+			switch colInfo.Name {
+			case LadderPeriod:
+				err = colInfo.WriteCell(row, rung.Period, w.styles.TextStyle(row))
+			case LadderFace:
+				err = colInfo.WriteCell(row, rung.Face, w.styles.CurrencyStyle(row))
+			case LadderMarketValue:
+				err = colInfo.WriteCell(row, rung.MarketValue, w.styles.CurrencyStyle(row))
+			case LadderWeightedCoupon:
+				err = colInfo.WriteCell(row, rung.WeightedCoupon, w.styles.PercentStyle(row))
+			default:
+				err = colInfo.WriteCell(row, "", w.styles.TextStyle(row))
+			}
+			if err != nil {
+				logrus.Error(err.Error())
+				return err
+			}
+		}
+
+		for _, h := range rung.Holdings {
+			row++
+			for _, colInfo := range allColumns {
+				var err error
+				switch colInfo.Name {
+				case LadderCUSIP:
+					err = colInfo.WriteCell(row, h.CUSIP, w.styles.TextStyle(row))
+				case LadderDescription:
+					err = colInfo.WriteCell(row, h.Description, w.styles.TextStyle(row))
+				case LadderAccount:
+					err = colInfo.WriteCell(row, h.Account, w.styles.TextStyle(row))
+				case LadderMaturity:
+					err = colInfo.WriteCell(row, h.MaturityDate, w.styles.DateStyle(row))
+				case LadderNextCall:
+					if h.NextCall == nil {
+						err = colInfo.WriteCell(row, "", w.styles.TextStyle(row))
+						break
+					}
+					err = colInfo.WriteCell(row, h.NextCall.Date, w.styles.DateStyle(row))
+				case LadderCoupon:
+					err = colInfo.WriteCell(row, h.CouponRate, w.styles.PercentStyle(row))
+				case LadderFace:
+					err = colInfo.WriteCell(row, h.Face, w.styles.CurrencyStyle(row))
+				case LadderMarketValue:
+					err = colInfo.WriteCell(row, h.MarketValue, w.styles.CurrencyStyle(row))
+				case LadderAccrued:
+					err = colInfo.WriteCell(row, h.AccruedInterest, w.styles.CurrencyStyle(row))
+				case LadderAnnualIncome:
+					err = colInfo.WriteCell(row, h.AnnualIncome, w.styles.CurrencyStyle(row))
+				default:
+					err = colInfo.WriteCell(row, "", w.styles.TextStyle(row))
+				}
+				if err != nil {
+					logrus.Error(err.Error())
+					return err
+				}
+			}
+		}
+	}
+
+	for _, colInfo := range allColumns {
+		_ = colInfo.SetColumnSize()
+	}
+	return nil
+}
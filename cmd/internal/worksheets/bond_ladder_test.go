@@ -0,0 +1,34 @@
+package worksheets_test
+
+import (
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"testing"
+	"time"
+)
+
+func TestWorkSheet_BondLadder(t *testing.T) {
+	asOf := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cd := &model.FixedIncome{CUSIP: "38149MXQ1", Description: "GOLDMAN SACHS BA 4.75%25CD", CouponRate: 0.0475, MaturityDate: time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC), Par: 100}
+	holdings := []*model.BondHolding{model.NewBondHolding(cd, "Fidelity IRA", 50, 5000.00, 0, asOf)}
+	ladder, err := model.MaturityLadder(holdings, model.LadderByYear)
+	assert.NoError(t, err, "Building Ladder")
+
+	w := worksheets.NewWorkSheet(excelize.NewFile(), nil)
+	err = w.BondLadder("Bond Ladder", ladder)
+	assert.NoError(t, err, "Writing Bond Ladder")
+
+	value, err := w.File.GetCellValue("Bond Ladder", "A2")
+	assert.NoError(t, err, "Reading Period")
+	assert.Equal(t, "2025", value)
+
+	value, err = w.File.GetCellValue("Bond Ladder", "B3")
+	assert.NoError(t, err, "Reading CUSIP")
+	assert.Equal(t, cd.CUSIP, value)
+
+	value, err = w.File.GetCellValue("Bond Ladder", "H3", excelize.Options{RawCellValue: true})
+	assert.NoError(t, err, "Reading Face")
+	assert.Equal(t, "5000", value)
+}
@@ -1,7 +1,6 @@
 package worksheets
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/kpearce2430/stock-tools/model"
@@ -15,7 +14,7 @@ var errNoSymbolsFound = fmt.Errorf("no symbols found")
 
 func (w *WorkSheet) getSortedSymbols() ([]string, map[string]string, error) {
 	var sortedSymbols []string
-	symbolList, err := model.SymbolList(context.Background(), w.PGXConn, w.Lookups)
+	symbolList, err := model.SymbolList(w.ctx(), w.PGXConn, w.Lookups)
 	if err != nil {
 		logrus.Error("Error:", err.Error())
 		return sortedSymbols, symbolList, err
@@ -33,7 +32,7 @@ func (w *WorkSheet) getSortedSymbols() ([]string, map[string]string, error) {
 
 func (w *WorkSheet) dividendAnalysisForMonth(symbol string, month, year int) (*model.DividendEntry, error) {
 
-	divEntry, err := model.GetDividendEntryForYearMonth(w.PGXConn, symbol, year, month)
+	divEntry, err := model.GetDividendEntryForYearMonth(w.ctx(), w.PGXConn, symbol, year, month)
 	//divEntry := model.DividendEntry{
 	//	Symbol: symbol,
 	//	Month:  month,
@@ -47,16 +46,15 @@ func (w *WorkSheet) dividendAnalysisForMonth(symbol string, month, year int) (*m
 	return divEntry, err
 }
 
-func (w *WorkSheet) dividendTicker(dchan chan []byte, symbol string, monthsAgo int) {
+func (w *WorkSheet) dividendTicker(dchan chan []byte, symbol string, start time.Time, monthsAgo int) {
 
-	start := time.Now()
 	year := start.Year()
 	month := int(start.Month())
 	tickerHistory := model.NewDividendHistory(symbol)
 
 	for i := 0; i < monthsAgo; i++ {
 		logrus.Debug("Doing:", symbol, ",", year, ",", month)
-		divEntry, err := model.GetDividendEntryForYearMonth(w.PGXConn, symbol, year, month)
+		divEntry, err := model.GetDividendEntryForYearMonth(w.ctx(), w.PGXConn, symbol, year, month)
 		if err != nil {
 			logrus.Error(err.Error())
 		}
@@ -81,7 +79,7 @@ func (w *WorkSheet) dividendTicker(dchan chan []byte, symbol string, monthsAgo i
 }
 
 func (w *WorkSheet) accountInfo(aChan chan []byte, symbol string) {
-	acctInfo, err := model.AccountInfoGet(context.Background(), w.PGXConn, symbol)
+	acctInfo, err := model.AccountInfoGetAsOf(w.ctx(), w.PGXConn, symbol, w.Currency, w.AsOf)
 	if err != nil {
 		logrus.Error("Error:", err.Error())
 		// panic(err.Error())
@@ -118,7 +116,7 @@ func (w *WorkSheet) DividendAnalysis(worksheetName string, start time.Time, mont
 	// Pull the history data
 	historyMatrix := make(map[string]*model.DividendHistory)
 	for _, symbol := range sortedSymbols {
-		go w.dividendTicker(divChannel, symbol, monthsAgo)
+		go w.dividendTicker(divChannel, symbol, start, monthsAgo)
 	}
 
 	for {
@@ -493,7 +491,7 @@ func (w *WorkSheet) YearOverYearDividendNew(worksheetName, divAnalysisWorksheet
 			if year == curYear && month > curMonth {
 				break
 			}
-			dh, err := model.DividendHistoryFromDB(context.Background(), w.PGXConn, "", year, month)
+			dh, err := model.DividendHistoryFromDB(w.ctx(), w.PGXConn, "", year, month)
 			// logrus.Info("dh>", dh.Sum())
 			if err != nil {
 				logrus.Error(err.Error())
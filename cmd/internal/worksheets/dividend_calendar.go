@@ -0,0 +1,123 @@
+package worksheets
+
+import (
+	"fmt"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	CalendarConfirmed = "Confirmed"
+	CalendarEstimated = "Estimated"
+	CalendarStatus    = "Status"
+	CalendarTotal     = "Total"
+	CalendarTotals    = "Totals"
+)
+
+// DividendCalendar writes the expected dividend income per symbol and month, with the confirmed
+// and estimated amounts for a symbol on separate rows.
+func (w *WorkSheet) DividendCalendar(worksheetName string, calendar *model.DividendCalendar) error {
+	if _, err := w.File.NewSheet(worksheetName); err != nil {
+		logrus.Error("Error:", err.Error())
+		return err
+	}
+
+	var allColumns []*ColumnInfo
+	colSymbol, err := NewColumnInfo(w.File, Symbol, worksheetName, 1)
+	if err != nil {
+		return err
+	}
+	allColumns = append(allColumns, colSymbol)
+
+	colStatus, err := NewColumnInfo(w.File, CalendarStatus, worksheetName, 2)
+	if err != nil {
+		return err
+	}
+	allColumns = append(allColumns, colStatus)
+
+	col := 3
+	for m := 0; m < calendar.Months; m++ {
+		date := calendar.Start.AddDate(0, m, 0)
+		colMonth, err := NewColumnInfo(w.File, fmt.Sprintf("%s/%02d", date.Month().String()[0:3], date.Year()%100), worksheetName, col)
+		if err != nil {
+			return err
+		}
+		colMonth.SetMaxSize(10)
+		allColumns = append(allColumns, colMonth)
+		col++
+	}
+
+	colTotal, err := NewColumnInfo(w.File, CalendarTotal, worksheetName, col)
+	if err != nil {
+		return err
+	}
+	colTotal.SetFormula(true)
+	allColumns = append(allColumns, colTotal)
+
+	row := 1
+	for _, colInfo := range allColumns {
+		if err := colInfo.WriteHeader(row, w.styles.Header); err != nil {
+			return err
+		}
+	}
+
+	firstMonth := allColumns[2].ColumnID
+	lastMonth := allColumns[len(allColumns)-2].ColumnID
+
+	row++
+	for _, symbol := range calendar.Symbols() {
+		for _, confirmed := range []bool{true, false} {
+			amounts := make([]float64, calendar.Months)
+			found := false
+			for _, e := range calendar.Entries {
+				if e.Symbol != symbol || e.Confirmed != confirmed {
+					continue
+				}
+				m := monthsBetween(calendar.Start, e.PayDate)
+				if m < 0 || m >= calendar.Months {
+					continue
+				}
+				amounts[m] += e.Amount
+				found = true
+			}
+			if !found {
+				continue
+			}
+
+			status := CalendarEstimated
+			if confirmed {
+				status = CalendarConfirmed
+			}
+			_ = colSymbol.WriteCell(row, symbol, w.styles.TextStyle(row))
+			_ = colStatus.WriteCell(row, status, w.styles.TextStyle(row))
+			for m, amount := range amounts {
+				_ = allColumns[m+2].WriteCell(row, amount, w.styles.CurrencyStyle(row))
+			}
+			_ = colTotal.WriteCell(row, fmt.Sprintf("=sum(%s%d:%s%d)", firstMonth, row, lastMonth, row), w.styles.CurrencyStyle(row))
+			row++
+		}
+	}
+
+	// Totals for each month and the year.
+	lastRow := row - 1
+	_ = colSymbol.WriteCell(row, CalendarTotals, w.styles.Header)
+	for _, colInfo := range allColumns[2:] {
+		colInfo.SetFormula(true)
+		formula := fmt.Sprintf("=sum(%s2:%s%d)", colInfo.ColumnID, colInfo.ColumnID, lastRow)
+		if err := colInfo.WriteCell(row, formula, w.styles.AccountingStyle(row)); err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+	}
+
+	for _, colInfo := range allColumns {
+		_ = colInfo.SetColumnSize()
+	}
+	return nil
+}
+
+// monthsBetween returns the number of months from the month of start to the month of date.
+func monthsBetween(start, date time.Time) int {
+	return (date.Year()-start.Year())*12 + int(date.Month()) - int(start.Month())
+}
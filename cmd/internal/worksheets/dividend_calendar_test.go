@@ -0,0 +1,41 @@
+package worksheets_test
+
+import (
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"testing"
+	"time"
+)
+
+func TestWorkSheet_DividendCalendar(t *testing.T) {
+	calendar := model.NewDividendCalendar(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), 12)
+	ds := model.NewDividendsSet([]model.Dividends{
+		{
+			Ticker:       "CSX",
+			CashAmount:   0.12,
+			DividendType: "CD",
+			Frequency:    4,
+			PayDate:      model.StockTime{Time: time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC)},
+		},
+	})
+	assert.True(t, calendar.AddDeclared("CSX", map[string]float64{"Fidelity IRA": 100}, &ds))
+	calendar.Sort()
+
+	w := worksheets.NewWorkSheet(excelize.NewFile(), nil)
+	err := w.DividendCalendar("Dividend Calendar", calendar)
+	assert.NoError(t, err, "Writing Dividend Calendar")
+
+	value, err := w.File.GetCellValue("Dividend Calendar", "C1")
+	assert.NoError(t, err, "Reading Header")
+	assert.Equal(t, "Jun/24", value)
+
+	value, err = w.File.GetCellValue("Dividend Calendar", "B2")
+	assert.NoError(t, err, "Reading Status")
+	assert.Equal(t, worksheets.CalendarConfirmed, value)
+
+	value, err = w.File.GetCellValue("Dividend Calendar", "B3")
+	assert.NoError(t, err, "Reading Status")
+	assert.Equal(t, worksheets.CalendarEstimated, value)
+}
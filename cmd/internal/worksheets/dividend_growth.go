@@ -0,0 +1,106 @@
+package worksheets
+
+import (
+	"fmt"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	GrowthAnnualDividend = "Annual Dividend"
+	GrowthFrequency      = "Frequency"
+	GrowthLastCut        = "Last Cut"
+	GrowthLastDividend   = "Last Dividend"
+	GrowthYearsIncreased = "Years Increased"
+	GrowthYieldOnCost    = "Yield on Cost"
+)
+
+// growthHeader returns the header for the growth rate over the years.
+func growthHeader(years int) string {
+	return fmt.Sprintf("%d Yr Growth", years)
+}
+
+// DividendGrowth writes the dividend growth rates, increases, cuts and yields for each symbol.
+func (w *WorkSheet) DividendGrowth(worksheetName string, growth []*model.DividendGrowth) error {
+	if _, err := w.File.NewSheet(worksheetName); err != nil {
+		logrus.Error("Error:", err.Error())
+		return err
+	}
+
+	headers := []string{Symbol, GrowthFrequency, GrowthLastDividend, GrowthAnnualDividend}
+	for _, years := range model.DividendGrowthPeriods {
+		headers = append(headers, growthHeader(years))
+	}
+	headers = append(headers, GrowthYearsIncreased, GrowthLastCut, TotalShares, TotalCost, LatestPrice, DividendYield, GrowthYieldOnCost)
+
+	var allColumns []*ColumnInfo
+	for i, h := range headers {
+		colInfo, err := NewColumnInfo(w.File, h, worksheetName, i+1)
+		if err != nil {
+			logrus.Error("Error:", err.Error())
+			return err
+		}
+		colInfo.SetMaxSize(20)
+		allColumns = append(allColumns, colInfo)
+	}
+
+	row := 1
+	for _, colInfo := range allColumns {
+		if err := colInfo.WriteHeader(row, w.styles.Header); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range growth {
+		row++
+		for i, colInfo := range allColumns {
+			var err error
+			switch colInfo.Name {
+			case Symbol:
+				err = colInfo.WriteCell(row, g.Symbol, w.styles.TextStyle(row))
+			case GrowthFrequency:
+				err = colInfo.WriteCell(row, g.Frequency, w.styles.NumberStyle(row))
+			case GrowthLastDividend:
+				err = colInfo.WriteCell(row, g.LastCashAmount, w.styles.CurrencyStyle(row))
+			case GrowthAnnualDividend:
+				err = colInfo.WriteCell(row, g.AnnualDividend, w.styles.CurrencyStyle(row))
+			case GrowthYearsIncreased:
+				err = colInfo.WriteCell(row, g.ConsecutiveIncrease, w.styles.NumberStyle(row))
+			case GrowthLastCut:
+				lastCut := ""
+				if len(g.Cuts) > 0 {
+					lastCut = g.Cuts[len(g.Cuts)-1].ExDividendDate.Format("2006-01-02")
+				}
+				err = colInfo.WriteCell(row, lastCut, w.styles.TextStyle(row))
+			case TotalShares:
+				err = colInfo.WriteCell(row, g.Shares, w.styles.NumberStyle(row))
+			case TotalCost:
+				err = colInfo.WriteCell(row, g.NetCost, w.styles.CurrencyStyle(row))
+			case LatestPrice:
+				err = colInfo.WriteCell(row, g.Price, w.styles.CurrencyStyle(row))
+			case DividendYield:
+				err = colInfo.WriteCell(row, g.CurrentYield, w.styles.PercentStyle(row))
+			case GrowthYieldOnCost:
+				err = colInfo.WriteCell(row, g.YieldOnCost, w.styles.PercentStyle(row))
+			default:
+				// The growth rate columns, left empty without enough history.
+				years := model.DividendGrowthPeriods[i-4]
+				rate, ok := g.Growth[years]
+				if !ok {
+					err = colInfo.WriteCell(row, "", w.styles.TextStyle(row))
+					break
+				}
+				err = colInfo.WriteCell(row, rate, w.styles.PercentStyle(row))
+			}
+			if err != nil {
+				logrus.Error(err.Error())
+				return err
+			}
+		}
+	}
+
+	for _, colInfo := range allColumns {
+		_ = colInfo.SetColumnSize()
+	}
+	return nil
+}
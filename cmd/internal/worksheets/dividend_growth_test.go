@@ -0,0 +1,36 @@
+package worksheets_test
+
+import (
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"testing"
+)
+
+func TestWorkSheet_DividendGrowth(t *testing.T) {
+	g := &model.DividendGrowth{
+		Symbol:         "CSX",
+		Growth:         map[int]float64{1: 0.09},
+		LastCashAmount: 0.12,
+		Frequency:      4,
+		AnnualDividend: 0.48,
+	}
+	g.SetPosition(100, 2500, 35)
+
+	w := worksheets.NewWorkSheet(excelize.NewFile(), nil)
+	err := w.DividendGrowth("Dividend Growth", []*model.DividendGrowth{g})
+	assert.NoError(t, err, "Writing Dividend Growth")
+
+	value, err := w.File.GetCellValue("Dividend Growth", "E1")
+	assert.NoError(t, err, "Reading Header")
+	assert.Equal(t, "1 Yr Growth", value)
+
+	value, err = w.File.GetCellValue("Dividend Growth", "A2")
+	assert.NoError(t, err, "Reading Symbol")
+	assert.Equal(t, "CSX", value)
+
+	value, err = w.File.GetCellValue("Dividend Growth", "F2")
+	assert.NoError(t, err, "Reading 3 Yr Growth")
+	assert.Equal(t, "", value)
+}
@@ -0,0 +1,144 @@
+package worksheets
+
+import (
+	"fmt"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RebalanceAccount       = "Account"
+	RebalanceAction        = "Action"
+	RebalanceAmount        = "Amount"
+	RebalanceCurrentValue  = "Current Value"
+	RebalanceCurrentWeight = "Current Weight"
+	RebalanceDifference    = "Difference"
+	RebalancePrice         = "Price"
+	RebalanceShares        = "Shares"
+	RebalanceTarget        = "Target"
+	RebalanceTargetType    = "Target Type"
+	RebalanceTargetValue   = "Target Value"
+	RebalanceTargetWeight  = "Target Weight"
+	RebalanceTaxAdvantaged = "Tax Advantaged"
+)
+
+// Rebalance writes the trades of the plan followed by the current and target allocation.
+func (w *WorkSheet) Rebalance(worksheetName string, plan *model.RebalancePlan) error {
+	if _, err := w.File.NewSheet(worksheetName); err != nil {
+		logrus.Error("Error:", err.Error())
+		return err
+	}
+
+	tradeHeaders := []string{
+		RebalanceAccount, Symbol, Type, RebalanceAction, RebalanceShares, RebalancePrice, RebalanceAmount, RebalanceTaxAdvantaged,
+	}
+
+	var tradeColumns []*ColumnInfo
+	for i, h := range tradeHeaders {
+		colInfo, err := NewColumnInfo(w.File, h, worksheetName, i+1)
+		if err != nil {
+			logrus.Error("Error:", err.Error())
+			return err
+		}
+		colInfo.SetMaxSize(25)
+		tradeColumns = append(tradeColumns, colInfo)
+	}
+
+	row := 1
+	for _, colInfo := range tradeColumns {
+		if err := colInfo.WriteHeader(row, w.styles.Header); err != nil {
+			return err
+		}
+	}
+
+	row++
+	for _, trade := range plan.Trades {
+		for _, colInfo := range tradeColumns {
+			var err error
+			switch colInfo.Name {
+			case RebalanceAccount:
+				err = colInfo.WriteCell(row, trade.Account, w.styles.TextStyle(row))
+			case Symbol:
+				err = colInfo.WriteCell(row, trade.Symbol, w.styles.TextStyle(row))
+			case Type:
+				err = colInfo.WriteCell(row, trade.SecurityType, w.styles.TextStyle(row))
+			case RebalanceAction:
+				err = colInfo.WriteCell(row, trade.Action, w.styles.TextStyle(row))
+			case RebalanceShares:
+				err = colInfo.WriteCell(row, trade.Shares, w.styles.NumberStyle(row))
+			case RebalancePrice:
+				err = colInfo.WriteCell(row, trade.Price, w.styles.CurrencyStyle(row))
+			case RebalanceAmount:
+				err = colInfo.WriteCell(row, trade.Amount, w.styles.CurrencyStyle(row))
+			case RebalanceTaxAdvantaged:
+				err = colInfo.WriteCell(row, trade.TaxAdvantaged, w.styles.TextStyle(row))
+			default:
+				err = fmt.Errorf("bad type[%s]", colInfo.Name)
+			}
+			if err != nil {
+				logrus.Error(err.Error())
+				return err
+			}
+		}
+		row++
+	}
+
+	// Leave a blank row between the trades and the positions.
+	row++
+	positionHeaders := []string{
+		RebalanceTarget, RebalanceTargetType, RebalanceTargetWeight, RebalanceCurrentWeight,
+		RebalanceTargetValue, RebalanceCurrentValue, RebalanceDifference,
+	}
+
+	var positionColumns []*ColumnInfo
+	for i, h := range positionHeaders {
+		colInfo, err := NewColumnInfo(w.File, h, worksheetName, i+1)
+		if err != nil {
+			logrus.Error("Error:", err.Error())
+			return err
+		}
+		positionColumns = append(positionColumns, colInfo)
+		if err := colInfo.WriteHeader(row, w.styles.Header); err != nil {
+			return err
+		}
+	}
+
+	row++
+	for _, pos := range plan.Positions {
+		for _, colInfo := range positionColumns {
+			var err error
+			switch colInfo.Name {
+			case RebalanceTarget:
+				err = colInfo.WriteCell(row, pos.Target, w.styles.TextStyle(row))
+			case RebalanceTargetType:
+				err = colInfo.WriteCell(row, pos.TargetType, w.styles.TextStyle(row))
+			case RebalanceTargetWeight:
+				err = colInfo.WriteCell(row, pos.TargetWeight, w.styles.PercentStyle(row))
+			case RebalanceCurrentWeight:
+				err = colInfo.WriteCell(row, pos.CurrentWeight, w.styles.PercentStyle(row))
+			case RebalanceTargetValue:
+				err = colInfo.WriteCell(row, pos.TargetValue, w.styles.CurrencyStyle(row))
+			case RebalanceCurrentValue:
+				err = colInfo.WriteCell(row, pos.CurrentValue, w.styles.CurrencyStyle(row))
+			case RebalanceDifference:
+				err = colInfo.WriteCell(row, pos.Difference, w.styles.CurrencyStyle(row))
+			default:
+				err = fmt.Errorf("bad type[%s]", colInfo.Name)
+			}
+			if err != nil {
+				logrus.Error(err.Error())
+				return err
+			}
+		}
+		row++
+	}
+
+	// Both tables share the columns, use the wider of the two.
+	for i, colInfo := range tradeColumns {
+		if i < len(positionColumns) && positionColumns[i].size > colInfo.size {
+			colInfo = positionColumns[i]
+		}
+		_ = colInfo.SetColumnSize()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package worksheets_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/cmd/internal/worksheets"
+	"github.com/kpearce2430/stock-tools/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"testing"
+)
+
+func TestWorkSheet_Rebalance(t *testing.T) {
+	g := model.AllocationGroup{
+		Name: "test",
+		Targets: []*model.AllocationTarget{
+			{Target: "HD", TargetType: model.TargetTypeSymbol, Weight: 0.6},
+			{Target: "CSX", TargetType: model.TargetTypeSymbol, Weight: 0.4},
+		},
+	}
+	holdings := []*model.RebalanceHolding{
+		{Account: "Fidelity IRA", Symbol: "HD", SecurityType: "Stock", Shares: 20, Price: 300.00},
+		{Account: "Fidelity IRA", Symbol: "CSX", SecurityType: "Stock", Shares: 100, Price: 30.00},
+	}
+
+	plan, err := model.Rebalance(context.Background(), &g, holdings, model.RebalanceOptions{Cash: 500.00})
+	assert.NoError(t, err, "Creating Rebalance Plan")
+
+	w := worksheets.NewWorkSheet(excelize.NewFile(), nil)
+	err = w.Rebalance("Rebalance", plan)
+	assert.NoError(t, err, "Writing Rebalance Sheet")
+
+	value, err := w.File.GetCellValue("Rebalance", "A1")
+	assert.NoError(t, err, "Reading Header")
+	assert.Equal(t, worksheets.RebalanceAccount, value)
+
+	value, err = w.File.GetCellValue("Rebalance", "D2")
+	assert.NoError(t, err, "Reading First Trade")
+	assert.Equal(t, model.RebalanceSell, value)
+}
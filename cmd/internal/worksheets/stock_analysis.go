@@ -1,7 +1,6 @@
 package worksheets
 
 import (
-	"context"
 	"fmt"
 	"github.com/kpearce2430/stock-tools/model"
 	"github.com/polygon-io/client-go/rest/models"
@@ -18,6 +17,8 @@ const (
 	AnnualReturn           = "Annual Return"
 	AveragePrice           = "Average Price"
 	CAGR                   = "CAGR"
+	CapitalGainsPaid       = "Capital Gains"
+	CurrencyGain           = "Currency Gain"
 	CurrentDividend        = "Current Dividend"
 	DaysAgo                = "Days Ago"
 	DividendsReceived      = "Dividends Received"
@@ -29,7 +30,9 @@ const (
 	Name                   = "Name"
 	Net                    = "Net"
 	PercentageOfPortfolio  = "Percentage Portfolio"
+	PriceGain              = "Price Gain"
 	ProjectedDividends     = "Projected Dividends"
+	ReturnOfCapital        = "Return of Capital"
 	ReturnOnInvestment     = "ROI"
 	Symbol                 = "Symbol"
 	TotalCost              = "Total Cost"
@@ -45,6 +48,7 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 	//}
 	var (
 		err                          error
+		currencyGainColRow           string
 		currentDividendRowCol        string
 		daysOwnedColRow              string
 		lastPriceColRow              string
@@ -64,7 +68,7 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 	)
 
 	if len(tickerInfo.Symbol) < 5 {
-		dividendsSet.FromDBbySymbol(context.Background(), w.PGXConn, "dividends", tickerInfo.Symbol)
+		dividendsSet.FromDBbySymbol(w.ctx(), w.PGXConn, "dividends", tickerInfo.Symbol)
 	}
 
 	if len(tickerInfo.Symbol) < 5 {
@@ -75,6 +79,13 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 		}
 	}
 
+	// The price gain is the gain less the currency gain, which comes after it.
+	for _, colInfo := range columnInfo {
+		if colInfo.Name == CurrencyGain {
+			currencyGainColRow = colInfo.GetColRow(row)
+		}
+	}
+
 	for _, colInfo := range columnInfo {
 		logrus.Debug("Working on :", colInfo.Name)
 		switch colInfo.Name {
@@ -101,10 +112,11 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 
 				logrus.Debug("Latest price for ", tickerInfo.Symbol, " is $", stockInfo.Close)
 				// TODO: Fix close being 0 for intraday
+				// The close is in the local currency of the stock.
 				if stockInfo.Close == 0 {
-					err = colInfo.WriteCell(row, stockInfo.Open, w.styles.CurrencyStyle(row))
+					err = colInfo.WriteCell(row, stockInfo.Open*tickerInfo.FXRate, w.styles.CurrencyStyle(row))
 				} else {
-					err = colInfo.WriteCell(row, stockInfo.Close, w.styles.CurrencyStyle(row))
+					err = colInfo.WriteCell(row, stockInfo.Close*tickerInfo.FXRate, w.styles.CurrencyStyle(row))
 				}
 
 			default: // Bond, Mutual Fund
@@ -124,10 +136,17 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 			err = colInfo.WriteCell(row, tickerInfo.DividendsReceived, w.styles.CurrencyStyle(row))
 			totalDividendsReceivedColRow = colInfo.GetColRow(row)
 
+		case CapitalGainsPaid:
+			// Most of the capital gains are in the dividends received as well, so they are not added to the net.
+			err = colInfo.WriteCell(row, tickerInfo.CapitalGainsPaid, w.styles.CurrencyStyle(row))
+
 		case InterestIncome:
 			err = colInfo.WriteCell(row, tickerInfo.InterestIncome, w.styles.CurrencyStyle(row))
 			totalInterestIncomeColRow = colInfo.GetColRow(row)
 
+		case ReturnOfCapital:
+			err = colInfo.WriteCell(row, tickerInfo.ReturnOfCapital, w.styles.CurrencyStyle(row))
+
 		case TotalCost:
 			err = colInfo.WriteCell(row, tickerInfo.NetCost, w.styles.CurrencyStyle(row))
 			totalCostColRow = colInfo.GetColRow(row)
@@ -161,6 +180,13 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 			formula := fmt.Sprintf("=(%s - %s) + (%s + %s)", totalValueColRow, totalCostColRow, totalDividendsReceivedColRow, totalInterestIncomeColRow)
 			err = colInfo.WriteCell(row, formula, w.styles.CurrencyStyle(row))
 
+		case PriceGain:
+			formula := fmt.Sprintf("=(%s - %s) - %s", totalValueColRow, totalCostColRow, currencyGainColRow)
+			err = colInfo.WriteCell(row, formula, w.styles.CurrencyStyle(row))
+
+		case CurrencyGain:
+			err = colInfo.WriteCell(row, tickerInfo.CurrencyGain, w.styles.CurrencyStyle(row))
+
 		case FirstBought:
 			firstBoughtStr := tickerInfo.FirstBought.Format("01-02-2006")
 			err = colInfo.WriteCell(row, firstBoughtStr, w.styles.TextStyle(row))
@@ -254,7 +280,7 @@ func (w *WorkSheet) writeStockAnalysisDetailRow(row int, columnInfo []*ColumnInf
 			if !ok {
 				shares = 0
 			}
-			if shares < 2 {
+			if shares < 2 && tickerInfo.Option == nil {
 				shares = 0
 			}
 			err = colInfo.WriteCell(row, shares, w.styles.GeneralStyle(row))
@@ -274,7 +300,7 @@ func (w *WorkSheet) StockAnalysis(worksheetName, julDate string) error {
 		return err
 	}
 
-	symbolList, err := model.SymbolList(context.Background(), w.PGXConn, w.Lookups)
+	symbolList, err := model.SymbolList(w.ctx(), w.PGXConn, w.Lookups)
 	if err != nil {
 		return err
 	}
@@ -289,12 +315,12 @@ func (w *WorkSheet) StockAnalysis(worksheetName, julDate string) error {
 	// Needed for the percentage of portfolio formula
 	sort.Strings(sortedSymbols)
 	var sortedAccounts []string
-	accountList, err := model.AccountList(context.Background(), w.PGXConn)
+	accountList, err := model.AccountList(w.ctx(), w.PGXConn)
 	if err != nil {
 		return err
 	}
 	for _, a := range accountList {
-		if a[0] == 'z' {
+		if !model.AccountVisible(w.ctx(), a) {
 			continue
 		}
 		sortedAccounts = append(sortedAccounts, a)
@@ -311,13 +337,17 @@ func (w *WorkSheet) StockAnalysis(worksheetName, julDate string) error {
 		LatestPrice,
 		TotalValue,
 		DividendsReceived,
+		CapitalGainsPaid,
 		InterestIncome,
+		ReturnOfCapital,
 		TotalCost,
 		AveragePrice,
 		CurrentDividend,
 		YearlyDividend,
 		LatestEarningsPerShare,
 		Net,
+		PriceGain,
+		CurrencyGain,
 		FirstBought,
 		DaysAgo,
 		ProjectedDividends,
@@ -351,6 +381,7 @@ func (w *WorkSheet) StockAnalysis(worksheetName, julDate string) error {
 
 		if columnNames[i] == TotalValue ||
 			columnNames[i] == Net ||
+			columnNames[i] == PriceGain ||
 			columnNames[i] == ProjectedDividends ||
 			columnNames[i] == DividendYield ||
 			columnNames[i] == PercentageOfPortfolio ||
@@ -379,7 +410,7 @@ func (w *WorkSheet) StockAnalysis(worksheetName, julDate string) error {
 	symbolData := make(map[string]*model.AccountInfo)
 
 	for _, symbol := range sortedSymbols {
-		tickerInfo, err := model.AccountInfoGet(context.Background(), w.PGXConn, symbol)
+		tickerInfo, err := model.AccountInfoGetAsOf(w.ctx(), w.PGXConn, symbol, w.Currency, w.AsOf)
 		logrus.Debug("symbol [", symbol, "] shares [", tickerInfo.NumberOfShares, "]")
 
 		if err != nil {
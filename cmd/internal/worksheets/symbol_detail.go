@@ -14,6 +14,7 @@ const (
 	detailsValue     = "Value"
 	detailsQuantity  = "Quantity"
 	detailsDividends = "Dividends"
+	detailsCapGains  = "Capital Gains"
 )
 
 func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date time.Time, monthsAgo int) error {
@@ -25,12 +26,12 @@ func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date tim
 	}
 
 	sd := model.NewSymbolDetailSet(w.PGXConn, symbol, table)
-	if err := sd.Create(date, monthsAgo); err != nil {
+	if err := sd.Create(w.ctx(), date, monthsAgo); err != nil {
 		logrus.Error("Error:", err.Error())
 		return err
 	}
 
-	headers := []string{detailsDate, detailsPrice, detailsQuantity, detailsValue, detailsDividends}
+	headers := []string{detailsDate, detailsPrice, detailsQuantity, detailsValue, detailsDividends, detailsCapGains}
 	var allColumns []*ColumnInfo
 
 	i := 1
@@ -97,6 +98,8 @@ func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date tim
 					logrus.Error(err.Error())
 					return err
 				}
+			case detailsCapGains:
+				_ = col.WriteCell(endRow, s.CapitalGains, w.styles.CurrencyStyle(endRow))
 			}
 		}
 	}
@@ -111,7 +114,7 @@ func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date tim
 	}
 	priceChart.AddValueSeries(priceColumn, startRow+1, priceColumn, endRow)
 	priceChart.AddCategorySeries(dateColumn, startRow+1, dateColumn, endRow)
-	if err := priceChart.BuildChart(w, "f3"); err != nil {
+	if err := priceChart.BuildChart(w, "g3"); err != nil {
 		logrus.Error(err.Error())
 		return err
 	}
@@ -125,7 +128,7 @@ func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date tim
 	}
 	valuesChart.AddValueSeries(valuesColumn, startRow+1, valuesColumn, endRow)
 	valuesChart.AddCategorySeries(dateColumn, startRow+1, dateColumn, endRow)
-	if err := valuesChart.BuildChart(w, "f20"); err != nil {
+	if err := valuesChart.BuildChart(w, "g20"); err != nil {
 		logrus.Error(err.Error())
 		return err
 	}
@@ -139,7 +142,7 @@ func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date tim
 	}
 	quantityChart.AddValueSeries(quantityColumn, startRow+1, quantityColumn, endRow)
 	quantityChart.AddCategorySeries(dateColumn, startRow+1, dateColumn, endRow)
-	if err := quantityChart.BuildChart(w, "n3"); err != nil {
+	if err := quantityChart.BuildChart(w, "o3"); err != nil {
 		logrus.Error(err.Error())
 		return err
 	}
@@ -153,7 +156,7 @@ func (w *WorkSheet) SymbolsDetails(worksheetName, symbol, table string, date tim
 	}
 	dividendChart.AddValueSeries(dividendColumn, startRow+1, dividendColumn, endRow)
 	dividendChart.AddCategorySeries(dateColumn, startRow+1, dateColumn, endRow)
-	if err := dividendChart.BuildChart(w, "n20"); err != nil {
+	if err := dividendChart.BuildChart(w, "o20"); err != nil {
 		logrus.Error(err.Error())
 		return err
 	}
@@ -1,7 +1,6 @@
 package worksheets
 
 import (
-	"context"
 	"fmt"
 	"github.com/kpearce2430/stock-tools/model"
 	"github.com/sirupsen/logrus"
@@ -31,7 +30,13 @@ func (w *WorkSheet) Transactions(worksheetName, julDate string) error {
 
 	//id, date, type, security, security_payee, symbol, account, description, shares, investment_amount,amount
 	tSet := model.NewTransactionSet()
-	if err := tSet.TransactionsGetAll(context.Background(), w.PGXConn); err != nil {
+	if w.AsOf.IsZero() {
+		err = tSet.TransactionsGetAll(w.ctx(), w.PGXConn)
+	} else {
+		end := w.AsOf.AddDate(0, 0, 1)
+		err = tSet.TransactionsAllGetBeforeDate(w.ctx(), w.PGXConn, end.Year(), int(end.Month()), end.Day())
+	}
+	if err != nil {
 		logrus.Error("Error:", err.Error())
 		return err
 	}
@@ -1,12 +1,14 @@
 package worksheets
 
 import (
+	"context"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kpearce2430/stock-tools/model"
 	"github.com/kpearce2430/stock-tools/stock_cache"
 	"github.com/polygon-io/client-go/rest/models"
 	"github.com/sirupsen/logrus"
 	"github.com/xuri/excelize/v2"
+	"time"
 )
 
 type WorkSheet struct {
@@ -15,6 +17,9 @@ type WorkSheet struct {
 	File       *excelize.File
 	styles     *Styles
 	StockCache *stock_cache.Cache[models.GetDailyOpenCloseAggResponse]
+	Currency   string          // the amounts are reported in, model.DefaultCurrency when empty
+	AsOf       time.Time       // the positions and prices are as of, the latest when zero
+	Context    context.Context // the queries run in, which holds the portfolio of the request
 	//DividendCache *stock_cache.Cache[models.Dividend]
 }
 
@@ -30,3 +35,11 @@ func NewWorkSheet(f *excelize.File, conn *pgxpool.Pool) *WorkSheet {
 		styles:  s,
 	}
 }
+
+// ctx returns the context of the queries, a background context in the default portfolio when none is set.
+func (w *WorkSheet) ctx() context.Context {
+	if w.Context == nil {
+		return context.Background()
+	}
+	return w.Context
+}
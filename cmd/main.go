@@ -6,6 +6,7 @@ import (
 	"github.com/kpearce2430/keputils/utils"
 	"github.com/kpearce2430/stock-tools/cmd/internal/app"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,6 +17,7 @@ import (
 func main() {
 
 	myPort := fmt.Sprintf(":%s", utils.GetEnv("PORT", "8080"))
+	grpcPort := fmt.Sprintf(":%s", utils.GetEnv("GRPC_PORT", "9090"))
 	a := app.NewApp(myPort)
 
 	go func() {
@@ -25,6 +27,17 @@ func main() {
 		}
 	}()
 
+	grpcListener, err := net.Listen("tcp", grpcPort)
+	if err != nil {
+		log.Fatalf("grpc listen: %s\n", err)
+	}
+	go func() {
+		// Serve returns nil once the server is stopped.
+		if err := a.GRPCSrv.Serve(grpcListener); err != nil {
+			log.Fatalf("grpc serve: %s\n", err)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shut down the server with
 	// a timeout of 5 seconds.
 	quit := make(chan os.Signal)
@@ -37,8 +50,20 @@ func main() {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
+	// The gRPC calls finish while the HTTP server shuts down, and are cancelled at the timeout.
+	grpcStopped := make(chan struct{})
+	go func() {
+		a.GRPCSrv.GracefulStop()
+		close(grpcStopped)
+	}()
+	// An HTTP server that does not shut down in time is logged, and the gRPC server is still stopped.
 	if err := a.Srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server Shutdown:", err)
+		log.Println("Server Shutdown:", err)
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		a.GRPCSrv.Stop()
 	}
 	// catching ctx.Done(). timeout of 5 seconds.
 	select {
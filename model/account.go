@@ -5,13 +5,12 @@ import (
 	"fmt"
 	"github.com/kpearce2430/keputils/utils"
 	"github.com/sirupsen/logrus"
-	"math"
-	"strings"
 	"time"
 )
 
 var BuyTransactions = []string{
-	"Buy", "Buy Bonds", "Add Shares", "Reinvest Dividend", "Reinvest Long-term Capital Gain", "Reinvest Short-term Capital Gain", "xxx"}
+	"Buy", "Buy Bonds", "Add Shares", "Reinvest Dividend", "Reinvest Long-term Capital Gain", "Reinvest Short-term Capital Gain",
+	"Reinvest Return of Capital", string(CorporateActionType), string(OptionBuyToOpen), "xxx"}
 
 // Account is the intermediary structure that holds a set of Entity values in the Entities list for an account.
 type Account struct {
@@ -30,6 +29,9 @@ func NewAccount(name string) *Account {
 // AddEntity add an Entity to the account as a transaction.
 func (a *Account) AddEntity(e *Entity) {
 	switch {
+	case isOptionTransaction(e.Type):
+		a.AddOptionEntity(e)
+		return
 	case e.Type == "Sell" || e.Type == "Short Sell":
 		a.SellShares(e)
 		return
@@ -44,11 +46,14 @@ func (a *Account) AddEntity(e *Entity) {
 	case e.Type == "Sell Bonds":
 		a.SellBonds(e)
 		return
+	case e.Type == "Return of Capital" || e.Type == "Reinvest Return of Capital":
+		a.ApplyReturnOfCapital(e)
+		a.Entities = append(a.Entities, e)
 	default:
 		a.Entities = append(a.Entities, e)
 	}
 
-	if len(a.Pending) > 0 && a.NumberOfShares() > a.NumberOfPending() {
+	if len(a.Pending) > 0 && a.NumberOfShares().Cmp(a.NumberOfPending()) > 0 {
 		for len(a.Pending) > 0 {
 			en := a.Pending[0]
 			a.Pending = a.Pending[1:]
@@ -57,69 +62,105 @@ func (a *Account) AddEntity(e *Entity) {
 	}
 }
 
-// SellBonds will remove all shares from an account.
+// SellBonds sells the units of the bonds in the transaction at its price, or redeems every unit when the
+// transaction has no units.
 func (a *Account) SellBonds(e *Entity) {
 	logrus.Debug("Selling Bonds:", e.Symbol)
+	if e.PricePerShare.IsZero() && !e.Shares.IsZero() {
+		e.PricePerShare = e.Amount.Div(e.Shares).Abs()
+	}
+	if !e.Shares.IsZero() {
+		a.SellShares(e)
+		return
+	}
 	for _, entry := range a.Entities {
-		if entry.Type == "Buy Bonds" {
-			numShares := entry.Shares
-			if entry.SellShares(numShares, 0.00) != 0.00 {
-				panic("Number of shares remaining!!!")
-			}
+		if entry.Type == "Buy Bonds" && entry.RemainingShares.Sign() > 0 {
+			entry.SellShares(entry.RemainingShares, e.PricePerShare)
 		}
 	}
 }
 
 // RemoveShares will remove the Entity shares from the account.
 func (a *Account) RemoveShares(e *Entity) {
-	sharesToSell := math.Abs(e.Shares)
+	sharesToSell := e.Shares.Abs()
 	for _, entry := range a.Entities {
 		if utils.Contains(BuyTransactions, string(entry.Type)) {
-			sharesToSell = entry.SellShares(sharesToSell, 0.00)
+			sharesToSell = entry.SellShares(sharesToSell, Decimal{})
 		}
-		if sharesToSell <= 0 {
+		if sharesToSell.Sign() <= 0 {
 			break
 		}
 	}
-	if sharesToSell > 0.02 {
-		logrus.Debugf("Remove Shares: %.02f Shares of %s Remaining to Sell", sharesToSell, e.Symbol)
+	if sharesToSell.Sign() > 0 {
+		logrus.Debugf("Remove Shares: %s Shares of %s Remaining to Sell", sharesToSell, e.Symbol)
 		a.Pending = append(a.Pending, e)
 	}
 }
 
+// ApplyReturnOfCapital reduces the cost of the open lots by the return of capital in proportion to their
+// shares. What is more than the cost left in a lot is recorded as a capital gain on the Entity.
+func (a *Account) ApplyReturnOfCapital(e *Entity) {
+	amount := e.ReturnOfCapital()
+
+	var openLots []*Entity
+	totalShares := Decimal{}
+	for _, entry := range a.Entities {
+		if utils.Contains(BuyTransactions, string(entry.Type)) && entry.RemainingShares.Sign() > 0 {
+			openLots = append(openLots, entry)
+			totalShares = totalShares.Add(entry.RemainingShares)
+		}
+	}
+
+	if totalShares.Sign() <= 0 {
+		logrus.Debugf("Return of Capital: %s for %s with no open lots", amount, e.Symbol)
+		e.CapitalGain = amount
+		return
+	}
+
+	// The last lot is allocated what is left so the allocations add up to the amount.
+	left := amount
+	for i, entry := range openLots {
+		allocated := left
+		if i < len(openLots)-1 {
+			allocated = amount.Mul(entry.RemainingShares).Div(totalShares).RoundCents()
+		}
+		left = left.Sub(allocated)
+		reduction := MinDecimal(allocated, MaxDecimal(Decimal{}, entry.NetCost()))
+		entry.BasisAdjustment = entry.BasisAdjustment.Add(reduction)
+		e.CapitalGain = e.CapitalGain.Add(allocated.Sub(reduction))
+	}
+}
+
 // SellShares will remove the Entity shares from the account from a sell.
 func (a *Account) SellShares(e *Entity) {
-	sharesToSell := math.Abs(e.Shares)
+	sharesToSell := e.Shares.Abs()
 	numberOfShares := a.NumberOfShares()
 
-	if numberOfShares >= sharesToSell {
-		logrus.Debugf("%s Selling %0.2f Shares, %.2f PPS: %.2f", e.Security, sharesToSell, numberOfShares, e.PricePerShare)
+	if numberOfShares.Cmp(sharesToSell) >= 0 {
+		logrus.Debugf("%s Selling %s Shares, %s PPS: %s", e.Security, sharesToSell, numberOfShares, e.PricePerShare)
 	}
 
 	for _, entry := range a.Entities {
 		if utils.Contains(BuyTransactions, string(entry.Type)) {
 			sharesToSell = entry.SellShares(sharesToSell, e.PricePerShare)
 		}
-		if sharesToSell <= 0 {
+		if sharesToSell.Sign() <= 0 {
 			break
 		}
 	}
-	if sharesToSell > 0.02 {
-		logrus.Errorf("%.02f Shares of %s Remaining to Sell", sharesToSell, e.Symbol)
+	if sharesToSell.Sign() > 0 {
+		logrus.Errorf("%s Shares of %s Remaining to Sell", sharesToSell, e.Symbol)
 		a.Pending = append(a.Pending, e)
 	}
 }
 
-// SplitShares will execute a split on the shares for the Entities in the account.
+// SplitShares will execute a split on the shares for the Entities in the account. A split that cannot be
+// read from the description is logged and skipped, the corporate_actions table can supply it instead.
 func (a *Account) SplitShares(e *Entity) {
-	parts := strings.Split(e.Description, " ")
-	newShares, err := utils.FloatParse(parts[0])
-	if err != nil {
-		panic(err.Error())
-	}
-	oldShares, err := utils.FloatParse(parts[2])
+	newShares, oldShares, err := parseSplitRatio(e.Description)
 	if err != nil {
-		panic(err.Error())
+		logrus.Error(e.Symbol, " ", e.Date.Format(dateToPgLayout), ": ", err.Error())
+		return
 	}
 
 	logrus.Debug("New Shares:", newShares, " Old Shares:", oldShares)
@@ -129,55 +170,92 @@ func (a *Account) SplitShares(e *Entity) {
 }
 
 // NumberOfShares returns the total shares of the Entities in the account.
-func (a *Account) NumberOfShares() float64 {
-	total := 0.00
+func (a *Account) NumberOfShares() Decimal {
+	total := Decimal{}
 	for _, e := range a.Entities {
-		total += e.RemainingShares
+		total = total.Add(e.RemainingShares)
 	}
 	return total
 }
 
 // NumberOfPending returns the sum of the RemainingShares of the Pending entities in the account.
-func (a *Account) NumberOfPending() float64 {
-	total := 0.00
+func (a *Account) NumberOfPending() Decimal {
+	total := Decimal{}
 	for _, e := range a.Pending {
-		total += math.Abs(e.RemainingShares)
+		total = total.Add(e.RemainingShares.Abs())
 	}
 	return total
 }
 
 // Dividends returns the sum of the dividends of the Entities in the account.
-func (a *Account) Dividends() float64 {
-	amt := 0.00
+func (a *Account) Dividends() Decimal {
+	amt := Decimal{}
 	for _, e := range a.Entities {
-		amt += e.Dividends()
+		amt = amt.Add(e.Dividends())
 	}
 	return amt
 }
 
 // DividendsPaid returns the sum of the dividends paid of the Entities in the account.
-func (a *Account) DividendsPaid() float64 {
-	amt := 0.00
+func (a *Account) DividendsPaid() Decimal {
+	amt := Decimal{}
+	for _, e := range a.Entities {
+		amt = amt.Add(e.DividendsPaid())
+	}
+	return amt
+}
+
+// DividendIncome returns the sum of the dividends of the Entities in the account, without the capital gain
+// distributions in DividendsPaid.
+func (a *Account) DividendIncome() Decimal {
+	amt := Decimal{}
+	for _, e := range a.Entities {
+		amt = amt.Add(e.DividendIncome())
+	}
+	return amt
+}
+
+// CapitalGainDistributions returns the sum of the capital gains distributed to the Entities in the account.
+func (a *Account) CapitalGainDistributions() Decimal {
+	amt := Decimal{}
+	for _, e := range a.Entities {
+		amt = amt.Add(e.CapitalGainDistributions())
+	}
+	return amt
+}
+
+// ReturnOfCapital returns the sum of the return of capital of the Entities in the account.
+func (a *Account) ReturnOfCapital() Decimal {
+	amt := Decimal{}
+	for _, e := range a.Entities {
+		amt = amt.Add(e.ReturnOfCapital())
+	}
+	return amt
+}
+
+// ReturnOfCapitalGain returns the sum of the return of capital more than the cost of the lots in the account.
+func (a *Account) ReturnOfCapitalGain() Decimal {
+	amt := Decimal{}
 	for _, e := range a.Entities {
-		amt += e.DividendsPaid()
+		amt = amt.Add(e.CapitalGain)
 	}
 	return amt
 }
 
 // InterestIncome returns the sum of the interest paid of the Entities in the account.
-func (a *Account) InterestIncome() float64 {
-	amt := 0.00
+func (a *Account) InterestIncome() Decimal {
+	amt := Decimal{}
 	for _, e := range a.Entities {
-		amt += e.InterestIncome()
+		amt = amt.Add(e.InterestIncome())
 	}
 	return amt
 }
 
 // NetCost returns the sum of the costs of the Entities in the account
-func (a *Account) NetCost() float64 {
-	amt := 0.00
+func (a *Account) NetCost() Decimal {
+	amt := Decimal{}
 	for _, e := range a.Entities {
-		amt += e.NetCost()
+		amt = amt.Add(e.NetCost())
 	}
 	return amt
 }
@@ -187,7 +265,7 @@ func (a *Account) FirstBought() time.Time {
 	theDate := time.Now()
 	for _, e := range a.Entities {
 		if utils.Contains(BuyTransactions, string(e.Type)) {
-			if e.RemainingShares > 0.1 {
+			if e.RemainingShares.Cmp(NewDecimal(0.1)) > 0 {
 				if theDate.Unix() > e.Date.Unix() {
 					theDate = e.Date
 				}
@@ -198,8 +276,8 @@ func (a *Account) FirstBought() time.Time {
 }
 
 // AverageCost returns the average (NetCost / NumberOfShares) cost of the entities in the account.
-func (a *Account) AverageCost() float64 {
-	return a.NetCost() / a.NumberOfShares()
+func (a *Account) AverageCost() Decimal {
+	return a.NetCost().Div(a.NumberOfShares())
 }
 
 // String returns the string representation of the Account and it's Entity's
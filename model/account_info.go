@@ -11,7 +11,7 @@ import (
 const (
 	transactionTable       = "transactions"
 	selectAccountStatement = "SELECT DISTINCT account FROM transactions ORDER BY account"
-	selectSymbolStatement  = "SELECT DISTINCT symbol, security FROM transactions ORDER BY symbol;"
+	selectSymbolStatement  = "SELECT DISTINCT symbol, security FROM transactions WHERE symbol <> '' ORDER BY symbol;"
 )
 
 type AccountInfo struct {
@@ -22,10 +22,21 @@ type AccountInfo struct {
 	Accounts          map[string]float64 `json:"accounts,omitempty"`
 	LatestPrice       float64            `json:"latestPrice,omitempty"` // iex or pv
 	DividendsReceived float64            `json:"dividendsReceived,omitempty"`
+	CapitalGainsPaid  float64            `json:"capitalGainsPaid,omitempty"` // capital gain distributions
 	InterestIncome    float64            `json:"interestIncome,omitempty"`
+	ReturnOfCapital   float64            `json:"returnOfCapital,omitempty"`
+	CapitalGain       float64            `json:"capitalGain,omitempty"` // return of capital more than the cost
 	NetCost           float64            `json:"netCost,omitempty"`
 	FirstBought       time.Time          `json:"firstBought,omitempty"`
 	AveragePrice      float64            `json:"averagePrice,omitempty"`
+	Option            *OptionContract    `json:"option,omitempty"`
+	OptionPositions   []*OptionPosition  `json:"optionPositions,omitempty"`
+	Currency          string             `json:"currency,omitempty"`      // the amounts are reported in
+	LocalCurrency     string             `json:"localCurrency,omitempty"` // the security is traded in
+	LocalPrice        float64            `json:"localPrice,omitempty"`
+	FXRate            float64            `json:"fxRate,omitempty"` // latest rate from the local to the reporting currency
+	PriceGain         float64            `json:"priceGain,omitempty"`
+	CurrencyGain      float64            `json:"currencyGain,omitempty"`
 }
 
 func AccountList(ctx context.Context, pgxConn *pgxpool.Pool) ([]string, error) {
@@ -85,50 +96,103 @@ func getLatestPrice(pv *PortfolioValueRecord) float64 {
 		return pv.Quote
 	case "Mutual Fund":
 		return pv.Quote
+	case pvTypeOption:
+		// Options are quoted per share of the underlying.
+		return pv.Quote
 	case "Bond":
+		// Bonds are quoted as a percent of par, use par without a quote.
+		if pv.Quote > 0 {
+			return pv.Quote
+		}
 		return 100.00
 	}
 	return 0.00
 }
 
+// AccountInfoGet returns the holdings of the symbol in DefaultCurrency.
 func AccountInfoGet(ctx context.Context, pgxConn *pgxpool.Pool, acctSymbol string) (*AccountInfo, error) {
+	return AccountInfoGetInCurrency(ctx, pgxConn, acctSymbol, DefaultCurrency)
+}
+
+// AccountInfoGetInCurrency returns the holdings of the symbol with the amounts in the reporting currency.
+// The costs and income are converted at the rate on the date of each transaction and the price at the
+// latest rate, so the gain is split into the change in the price and the change in the rate.
+func AccountInfoGetInCurrency(ctx context.Context, pgxConn *pgxpool.Pool, acctSymbol, currency string) (*AccountInfo, error) {
+	return AccountInfoGetAsOf(ctx, pgxConn, acctSymbol, currency, time.Time{})
+}
+
+// AccountInfoGetAsOf returns the holdings of the symbol as they were on asOf, from the transactions and
+// corporate actions on or before it. The price is the close in fund_history on or before asOf, or the
+// portfolio value quote on or before it, and the rates are the rates of asOf. A zero asOf returns the
+// holdings of AccountInfoGetInCurrency at the latest price.
+func AccountInfoGetAsOf(ctx context.Context, pgxConn *pgxpool.Pool, acctSymbol, currency string, asOf time.Time) (*AccountInfo, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
 
 	tSet := NewTransactionSet()
-	if err := tSet.TransactionSetFromDBbySymbol(ctx, pgxConn, transactionTable, acctSymbol); err != nil {
+	if err := tSet.TransactionSetWithOptionsBySymbol(ctx, pgxConn, transactionTable, acctSymbol); err != nil {
 		return nil, err
 	}
+	return AccountInfoFromTransactions(ctx, pgxConn, acctSymbol, currency, asOf, tSet)
+}
+
+// AccountInfoFromTransactions returns the account info of the symbol from the transactions of it and of
+// the options on it, as read by TransactionSetWithOptionsBySymbol or TransactionSetsWithOptionsBySymbols.
+func AccountInfoFromTransactions(ctx context.Context, pgxConn *pgxpool.Pool, acctSymbol, currency string, asOf time.Time, transactions *TransactionSet) (*AccountInfo, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	tSet := NewTransactionSet()
+	tSet.portfolio = transactions.portfolio
+	tSet.TransactionRows = transactionsAsOf(transactions.TransactionRows, asOf)
 
 	var securityNames []string
-	ticker := NewTicker(acctSymbol)
+	localCurrency := DefaultCurrency
 	for _, tr := range tSet.TransactionRows {
-		ent, err := NewEntityFromTransaction(tr)
-		if err != nil {
-			return nil, err
-		}
-
-		if tr.Security != "" && !utils.Contains(securityNames, tr.Security) {
+		if tr.Symbol == acctSymbol && tr.Security != "" && !utils.Contains(securityNames, tr.Security) {
 			logrus.Debug("Adding SecurityPayee:", tr.Security)
 			securityNames = append(securityNames, tr.Security)
 		}
-		ticker.AddEntity(ent)
+		if tr.Symbol == acctSymbol {
+			localCurrency = currencyOrDefault(tr.Currency)
+		}
+	}
+
+	ticker, err := TickerGetAsOf(ctx, pgxConn, acctSymbol, tSet, asOf)
+	if err != nil {
+		return nil, err
 	}
 
 	acctInfo := AccountInfo{
 		Symbol:         acctSymbol,
-		Security:       securityNames[len(securityNames)-1], // last one found
-		NumberOfShares: ticker.NumberOfShares(),
+		NumberOfShares: ticker.NumberOfShares().Float64(),
+		Currency:       currency,
+		LocalCurrency:  localCurrency,
+		FXRate:         1.00,
+	}
+	if len(securityNames) > 0 {
+		acctInfo.Security = securityNames[len(securityNames)-1] // last one found
 	}
 
-	if ticker.NumberOfShares() <= 0 {
+	// A short option position has negative contracts.
+	contract, _ := ParseOCCSymbol(acctSymbol)
+	if shares := ticker.NumberOfShares(); shares.Sign() <= 0 && (contract == nil || shares.IsZero()) {
 		return &acctInfo, nil
 	}
 
 	acctInfo.Accounts = make(map[string]float64)
 	for _, acct := range ticker.Accounts {
-		acctInfo.Accounts[acct.Name] = acct.NumberOfShares()
+		acctInfo.Accounts[acct.Name] = acct.NumberOfShares().Float64()
 	}
 	acctInfo.DividendsReceived = ticker.DividendsPaid()
+	acctInfo.CapitalGainsPaid = ticker.CapitalGainDistributions()
 	acctInfo.InterestIncome = ticker.InterestIncome()
+	acctInfo.ReturnOfCapital = ticker.ReturnOfCapital()
+	acctInfo.CapitalGain = ticker.ReturnOfCapitalGain()
 	acctInfo.NetCost = ticker.NetCost()
 	acctInfo.FirstBought = ticker.FirstBought()
 
@@ -137,13 +201,93 @@ func AccountInfoGet(ctx context.Context, pgxConn *pgxpool.Pool, acctSymbol strin
 	}
 
 	var pvValue PortfolioValueRecord
-	err := pvValue.GetLastDB(pgxConn, ticker.Symbol, "portfolio_value")
+	if asOf.IsZero() {
+		err = pvValue.GetLastDB(ctx, pgxConn, ticker.Symbol, "portfolio_value")
+	} else {
+		err = pvValue.GetOnOrBeforeDB(ctx, pgxConn, ticker.Symbol, "portfolio_value", asOf)
+	}
 
 	if err != nil {
 		logrus.Error("Error Getting PV for ", ticker.Symbol, " Shares:", acctInfo.NumberOfShares, ":", err.Error())
 	}
 
 	acctInfo.SecurityType = pvValue.Type
-	acctInfo.LatestPrice = getLatestPrice(&pvValue)
+	acctInfo.LocalPrice = getLatestPrice(&pvValue)
+	if !asOf.IsZero() {
+		if hist, err := NewHistoricalDataSet(pgxConn, fundHistoryTable).OnOrBefore(ticker.Symbol, asOf); err == nil {
+			acctInfo.LocalPrice = hist.Close
+			pvValue.Currency = hist.Currency
+		}
+	}
+	if contract != nil {
+		// The contracts are valued at the price of a contract.
+		acctInfo.SecurityType = pvTypeOption
+		acctInfo.LocalPrice *= contract.Multiplier
+		acctInfo.Option = contract
+		acctInfo.OptionPositions = ticker.OptionPositions()
+	}
+
+	// A quote without a currency is in the currency of the transactions.
+	pvCurrency := localCurrency
+	if pvValue.Currency != "" {
+		pvCurrency = pvValue.Currency
+	}
+	var rates *FXRateSet
+	if pvCurrency != localCurrency || localCurrency != currency {
+		if rates, err = FXRateSetGet(ctx, pgxConn, pvCurrency, localCurrency, currency, DefaultCurrency); err != nil {
+			return nil, err
+		}
+	}
+	rateDate := asOf
+	if rateDate.IsZero() {
+		rateDate = time.Now()
+	}
+	if err := acctInfo.convert(ticker, rates, pvCurrency, rateDate); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
 	return &acctInfo, nil
 }
+
+// convert sets the latest price from the quote in pvCurrency and the amounts in the reporting currency,
+// and splits the gain into the price and currency gains. The price gain is the gain in the local currency
+// at the latest rate, and the currency gain is the change in the value of the cost from the rates it was
+// paid at to the latest rate.
+func (i *AccountInfo) convert(ticker *Ticker, rates *FXRateSet, pvCurrency string, date time.Time) error {
+	localPrice, err := rates.Convert(i.LocalPrice, pvCurrency, i.LocalCurrency, date)
+	if err != nil {
+		return err
+	}
+	if i.FXRate, err = rates.Rate(i.LocalCurrency, i.Currency, date); err != nil {
+		return err
+	}
+	i.LocalPrice = localPrice
+	i.LatestPrice = localPrice * i.FXRate
+
+	localCost := i.NetCost
+	if i.LocalCurrency != i.Currency {
+		amounts := []struct {
+			value  *float64
+			amount func(e *Entity) Decimal
+		}{
+			{&i.NetCost, (*Entity).NetCost},
+			{&i.DividendsReceived, (*Entity).DividendsPaid},
+			{&i.CapitalGainsPaid, (*Entity).CapitalGainDistributions},
+			{&i.InterestIncome, (*Entity).InterestIncome},
+			{&i.ReturnOfCapital, (*Entity).ReturnOfCapital},
+			{&i.CapitalGain, func(e *Entity) Decimal { return e.CapitalGain }},
+		}
+		for _, a := range amounts {
+			if *a.value, err = ticker.Converted(a.amount, rates, i.Currency); err != nil {
+				return err
+			}
+		}
+		if i.NumberOfShares > 2.00 {
+			i.AveragePrice = i.NetCost / i.NumberOfShares
+		}
+	}
+
+	i.PriceGain = (i.NumberOfShares*localPrice - localCost) * i.FXRate
+	i.CurrencyGain = localCost*i.FXRate - i.NetCost
+	return nil
+}
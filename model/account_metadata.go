@@ -0,0 +1,349 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AccountsTable = "accounts"
+
+	AccountTypeTaxable        = "taxable"
+	AccountTypeTraditionalIRA = "traditional IRA"
+	AccountTypeRoth           = "roth"
+	AccountTypeHSA            = "HSA"
+	AccountType401k           = "401k"
+
+	TaxTreatmentTaxable  = "taxable"
+	TaxTreatmentDeferred = "tax deferred"
+	TaxTreatmentFree     = "tax free"
+
+	accountMetadataFields = "name, account_type, owner, tax_treatment, owner_birth_year, display_name, institution, open_date, closed_date, hidden, currency"
+)
+
+var (
+	errAccountName         = errors.New("account name missing")
+	errAccountType         = errors.New("account type unknown")
+	errAccountTaxTreatment = errors.New("account tax treatment unknown")
+	errAccountDates        = errors.New("account closed before it was opened")
+	errAccountAbsent       = errors.New("account not found")
+)
+
+// AccountMetadata describes an account from the transactions. OwnerBirthYear is used for the catch-up
+// contributions allowed once the owner reaches the age in the ContributionLimit. An account with a
+// ClosedDate is left out of the share totals after that date, and a Hidden account is left out of the
+// worksheets. The cash of the account is held in its Currency.
+type AccountMetadata struct {
+	Name           string     `json:"name"`
+	DisplayName    string     `json:"displayName,omitempty"`
+	Institution    string     `json:"institution,omitempty"`
+	AccountType    string     `json:"type"`
+	Owner          string     `json:"owner,omitempty"`
+	TaxTreatment   string     `json:"taxTreatment,omitempty"`
+	OwnerBirthYear int        `json:"ownerBirthYear,omitempty"`
+	OpenDate       *time.Time `json:"openDate,omitempty"`
+	ClosedDate     *time.Time `json:"closedDate,omitempty"`
+	Hidden         bool       `json:"hidden,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+}
+
+func (m *AccountMetadata) String() string {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// defaultTaxTreatment returns the usual tax treatment for the account type.
+func defaultTaxTreatment(accountType string) string {
+	switch accountType {
+	case AccountTypeTraditionalIRA, AccountType401k:
+		return TaxTreatmentDeferred
+	case AccountTypeRoth, AccountTypeHSA:
+		return TaxTreatmentFree
+	}
+	return TaxTreatmentTaxable
+}
+
+// Validate checks the account has a name and known type, tax treatment and currency, setting the tax
+// treatment from the type and the currency to DefaultCurrency when they are missing.
+func (m *AccountMetadata) Validate() error {
+	if m.Name == "" {
+		return errAccountName
+	}
+	switch m.AccountType {
+	case AccountTypeTaxable, AccountTypeTraditionalIRA, AccountTypeRoth, AccountTypeHSA, AccountType401k:
+	default:
+		return fmt.Errorf("%w: %s", errAccountType, m.AccountType)
+	}
+	switch m.TaxTreatment {
+	case "":
+		m.TaxTreatment = defaultTaxTreatment(m.AccountType)
+	case TaxTreatmentTaxable, TaxTreatmentDeferred, TaxTreatmentFree:
+	default:
+		return fmt.Errorf("%w: %s", errAccountTaxTreatment, m.TaxTreatment)
+	}
+	if m.OpenDate != nil && m.ClosedDate != nil && m.ClosedDate.Before(*m.OpenDate) {
+		return errAccountDates
+	}
+	currency, err := NormalizeCurrency(m.Currency)
+	if err != nil {
+		return err
+	}
+	m.Currency = currency
+	return nil
+}
+
+// Label returns the display name of the account, or the name when there is none.
+func (m *AccountMetadata) Label() string {
+	if m.DisplayName != "" {
+		return m.DisplayName
+	}
+	return m.Name
+}
+
+// ClosedOn returns true when the account was closed on or before the date.
+func (m *AccountMetadata) ClosedOn(date time.Time) bool {
+	return m.ClosedDate != nil && !m.ClosedDate.After(date)
+}
+
+// Retirement returns true when the account is one of the tax advantaged types with contribution limits.
+func (m *AccountMetadata) Retirement() bool {
+	return m.AccountType != AccountTypeTaxable
+}
+
+// NewAccountMetadataFromJSON reads a single account or a list of them.
+func NewAccountMetadataFromJSON(data []byte) ([]*AccountMetadata, error) {
+	var accounts []*AccountMetadata
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var m AccountMetadata
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, &m)
+	} else if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, err
+	}
+
+	for _, m := range accounts {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+// dateArg returns the date as a query argument, nil for NULL when there is none.
+func dateArg(date *time.Time) any {
+	if date == nil || date.IsZero() {
+		return nil
+	}
+	return *date
+}
+
+// ToDB stores the account, replacing the account with the same name.
+func (m *AccountMetadata) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"+
+			" ON CONFLICT (portfolio_id, name) DO UPDATE SET account_type = EXCLUDED.account_type, owner = EXCLUDED.owner,"+
+			" tax_treatment = EXCLUDED.tax_treatment, owner_birth_year = EXCLUDED.owner_birth_year,"+
+			" display_name = EXCLUDED.display_name, institution = EXCLUDED.institution, open_date = EXCLUDED.open_date,"+
+			" closed_date = EXCLUDED.closed_date, hidden = EXCLUDED.hidden, currency = EXCLUDED.currency;",
+		AccountsTable, accountMetadataFields)
+	if _, err := pg.Exec(ctx, insertStatement, m.Name, m.AccountType, m.Owner, m.TaxTreatment, m.OwnerBirthYear,
+		m.DisplayName, m.Institution, dateArg(m.OpenDate), dateArg(m.ClosedDate), m.Hidden, m.Currency); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// accountMetadataGet returns the accounts selected by the where clause and its arguments keyed by name.
+func accountMetadataGet(ctx context.Context, pg *pgxpool.Pool, where string, args ...any) (map[string]*AccountMetadata, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s %s ORDER BY name;", accountMetadataFields, AccountsTable, where), args...)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]*AccountMetadata)
+	for rows.Next() {
+		m := AccountMetadata{}
+		if err := rows.Scan(&m.Name, &m.AccountType, &m.Owner, &m.TaxTreatment, &m.OwnerBirthYear,
+			&m.DisplayName, &m.Institution, &m.OpenDate, &m.ClosedDate, &m.Hidden, &m.Currency); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		accounts[m.Name] = &m
+	}
+	return accounts, nil
+}
+
+// AccountMetadataGetAll returns the accounts keyed by name.
+func AccountMetadataGetAll(ctx context.Context, pg *pgxpool.Pool) (map[string]*AccountMetadata, error) {
+	return accountMetadataGet(ctx, pg, "")
+}
+
+// AccountMetadataGet returns the named account.
+func AccountMetadataGet(ctx context.Context, pg *pgxpool.Pool, name string) (*AccountMetadata, error) {
+	accounts, err := accountMetadataGet(ctx, pg, "WHERE name = $1", name)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := accounts[name]
+	if !ok {
+		return nil, errAccountAbsent
+	}
+	return m, nil
+}
+
+// AccountMetadataDelete removes the named account.
+func AccountMetadataDelete(ctx context.Context, pg *pgxpool.Pool, name string) error {
+	tag, err := pg.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE name = $1;", AccountsTable), name)
+	if err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errAccountAbsent
+	}
+	return nil
+}
+
+// IsAccountNotFound returns true when the error is from a missing account.
+func IsAccountNotFound(err error) bool {
+	return errors.Is(err, errAccountAbsent)
+}
+
+// guessAccountType returns the account type suggested by the name of the account.
+func guessAccountType(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "roth"):
+		return AccountTypeRoth
+	case strings.Contains(lower, "ira"):
+		return AccountTypeTraditionalIRA
+	case strings.Contains(lower, "401"):
+		return AccountType401k
+	case strings.Contains(lower, "hsa"):
+		return AccountTypeHSA
+	}
+	return AccountTypeTaxable
+}
+
+// AccountsSeed adds the accounts in the transactions that are not in the accounts table yet, using the
+// first and last transaction dates for the open and closed dates. Accounts were marked closed by starting
+// their name with a 'z' before the accounts table, so only those get a closed date.
+func AccountsSeed(ctx context.Context, pg *pgxpool.Pool) error {
+	rows, err := pg.Query(ctx, fmt.Sprintf(
+		"SELECT account, MIN(date), MAX(date) FROM %s WHERE account NOT IN (SELECT name FROM %s) GROUP BY account;",
+		transactionTable, AccountsTable))
+	if err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+
+	var seeds []*AccountMetadata
+	for rows.Next() {
+		var first, last time.Time
+		m := AccountMetadata{}
+		if err := rows.Scan(&m.Name, &first, &last); err != nil {
+			rows.Close()
+			logrus.Error(err.Error())
+			return err
+		}
+		m.AccountType = guessAccountType(m.Name)
+		m.OpenDate = &first
+		if strings.HasPrefix(m.Name, "z") {
+			m.ClosedDate = &last
+		}
+		seeds = append(seeds, &m)
+	}
+	rows.Close()
+
+	for _, m := range seeds {
+		logrus.Info("Adding account ", m.Name)
+		if err := m.ToDB(ctx, pg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	accountRegistryLock sync.RWMutex
+	accountRegistry     = make(map[string]map[string]*AccountMetadata)
+)
+
+// SetAccountRegistry sets the accounts of the portfolio used to decide which accounts are closed or hidden.
+func SetAccountRegistry(portfolio string, accounts map[string]*AccountMetadata) {
+	accountRegistryLock.Lock()
+	defer accountRegistryLock.Unlock()
+	accountRegistry[portfolio] = accounts
+}
+
+// AccountRegistryLoad reads the accounts table into the account registry of the portfolio of the context.
+func AccountRegistryLoad(ctx context.Context, pg *pgxpool.Pool) error {
+	accounts, err := AccountMetadataGetAll(ctx, pg)
+	if err != nil {
+		return err
+	}
+	SetAccountRegistry(PortfolioFromContext(ctx), accounts)
+	return nil
+}
+
+// AccountRegistryLoaded returns true when the accounts of the portfolio have been read.
+func AccountRegistryLoaded(portfolio string) bool {
+	accountRegistryLock.RLock()
+	defer accountRegistryLock.RUnlock()
+	_, ok := accountRegistry[portfolio]
+	return ok
+}
+
+// lookupAccount returns the registered account of the portfolio with the name.
+func lookupAccount(portfolio, name string) (*AccountMetadata, bool) {
+	accountRegistryLock.RLock()
+	defer accountRegistryLock.RUnlock()
+	m, ok := accountRegistry[portfolio][name]
+	return m, ok
+}
+
+// accountClosed returns true when the account of the portfolio was closed on or before the date.
+func accountClosed(portfolio, name string, date time.Time) bool {
+	m, ok := lookupAccount(portfolio, name)
+	return ok && m.ClosedOn(date)
+}
+
+// AccountClosed returns true when the account was closed on or before the date. Accounts that are not
+// registered are open.
+func AccountClosed(ctx context.Context, name string, date time.Time) bool {
+	return accountClosed(PortfolioFromContext(ctx), name, date)
+}
+
+// AccountVisible returns true when the account is open and not hidden, so it is shown in the worksheets.
+func AccountVisible(ctx context.Context, name string) bool {
+	m, ok := lookupAccount(PortfolioFromContext(ctx), name)
+	return !ok || (!m.Hidden && !m.ClosedOn(time.Now()))
+}
+
+// AccountCurrency returns the currency of the cash in the account. Accounts that are not registered are
+// in DefaultCurrency.
+func AccountCurrency(ctx context.Context, name string) string {
+	m, ok := lookupAccount(PortfolioFromContext(ctx), name)
+	if !ok {
+		return DefaultCurrency
+	}
+	return currencyOrDefault(m.Currency)
+}
@@ -0,0 +1,70 @@
+package model_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/model"
+	"testing"
+	"time"
+)
+
+func TestNewAccountMetadataFromJSON(t *testing.T) {
+	accounts, err := model.NewAccountMetadataFromJSON([]byte(`{"name":"Jane IRA","type":"roth","owner":"Jane"}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if accounts[0].TaxTreatment != model.TaxTreatmentFree {
+		t.Error("got tax treatment", accounts[0].TaxTreatment, "want", model.TaxTreatmentFree)
+	}
+
+	if _, err := model.NewAccountMetadataFromJSON([]byte(`[{"name":"Jane IRA","type":"pension"}]`)); err == nil {
+		t.Error("expected an error for an unknown account type")
+	}
+}
+
+func TestAccountRegistry(t *testing.T) {
+	closed := time.Date(2023, time.September, 5, 0, 0, 0, 0, time.UTC)
+	model.SetAccountRegistry(model.DefaultPortfolio, map[string]*model.AccountMetadata{
+		"Ameritrade IRA":  {Name: "Ameritrade IRA", AccountType: model.AccountTypeTraditionalIRA, ClosedDate: &closed},
+		"HD ESPP":         {Name: "HD ESPP", AccountType: model.AccountTypeTaxable, Hidden: true},
+		"Schwab Rollover": {Name: "Schwab Rollover", AccountType: model.AccountTypeTraditionalIRA},
+	})
+	defer model.SetAccountRegistry(model.DefaultPortfolio, map[string]*model.AccountMetadata{})
+
+	ticker := model.NewTicker("CSX")
+	for _, account := range []string{"Ameritrade IRA", "HD ESPP", "Schwab Rollover", "Fidelity IRA"} {
+		en, err := model.NewEntityFromTransaction(&model.Transaction{
+			Date: time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX",
+			Description: "10 shares @ 30.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-300.00), Account: account,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ticker.AddEntity(en)
+	}
+
+	if shares := ticker.NumberOfShares(); shares != model.NewDecimal(30) {
+		t.Errorf("got %s shares, want 30 without the closed account", shares)
+	}
+	if shares := ticker.TotalShares(true); shares != model.NewDecimal(40) {
+		t.Errorf("got %s shares, want 40 in all accounts", shares)
+	}
+	if _, ok := ticker.AccountShares()["Ameritrade IRA"]; ok {
+		t.Error("closed account in the account shares")
+	}
+
+	ctx := context.Background()
+	if model.AccountClosed(ctx, "Ameritrade IRA", closed.AddDate(0, 0, -1)) {
+		t.Error("account closed before the closed date")
+	}
+	if model.AccountVisible(ctx, "HD ESPP") || model.AccountVisible(ctx, "Ameritrade IRA") {
+		t.Error("hidden or closed account visible")
+	}
+	if !model.AccountVisible(ctx, "Fidelity IRA") {
+		t.Error("unregistered account not visible")
+	}
+
+	// The accounts of another portfolio are registered apart.
+	if !model.AccountVisible(model.WithPortfolio(ctx, "household"), "HD ESPP") {
+		t.Error("account hidden in the default portfolio hidden in another")
+	}
+}
@@ -69,9 +69,43 @@ func TestAccount_SellBonds(t *testing.T) {
 	t.Log("Length>", len(Accounts))
 	for _, acct := range Accounts {
 		t.Log(acct.Name, ",", acct.NumberOfShares(), ", $", acct.DividendsPaid(), ", $", acct.InterestIncome())
-		if acct.NumberOfShares() != 0.00 {
+		if !acct.NumberOfShares().IsZero() {
 			t.Error("Expecting 0.00 Shares, Found:", acct.NumberOfShares())
 		}
 	}
 
 }
+
+func TestAccount_ReturnOfCapital(t *testing.T) {
+	acct := model.NewAccount("Fidelity IRA")
+	acct.AddEntity(&model.Entity{Type: "Buy", Symbol: "MO", Shares: model.NewDecimal(100), RemainingShares: model.NewDecimal(100), Amount: model.NewDecimal(-1000.00)})
+	acct.AddEntity(&model.Entity{Type: "Buy", Symbol: "MO", Shares: model.NewDecimal(100), RemainingShares: model.NewDecimal(100), Amount: model.NewDecimal(-200.00)})
+
+	// $3 a share, the second lot only has $2 a share of cost left.
+	acct.AddEntity(&model.Entity{Type: "Return of Capital", Symbol: "MO", Amount: model.NewDecimal(600.00), InvestmentAmount: model.NewDecimal(-600.00)})
+
+	if got := acct.NetCost(); got != model.NewDecimal(700.00) {
+		t.Errorf("got net cost %s, want 700.00", got)
+	}
+	if got := acct.AverageCost(); got != model.NewDecimal(3.50) {
+		t.Errorf("got average cost %s, want 3.50", got)
+	}
+	if got := acct.ReturnOfCapital(); got != model.NewDecimal(600.00) {
+		t.Errorf("got return of capital %s, want 600.00", got)
+	}
+	if got := acct.ReturnOfCapitalGain(); got != model.NewDecimal(100.00) {
+		t.Errorf("got capital gain %s, want 100.00", got)
+	}
+	if got := acct.DividendsPaid(); !got.IsZero() {
+		t.Errorf("got dividends paid %s, want 0.00", got)
+	}
+
+	// The reinvested form also buys shares at the amount reinvested.
+	acct.AddEntity(&model.Entity{Type: "Reinvest Return of Capital", Symbol: "MO", Shares: model.NewDecimal(10), RemainingShares: model.NewDecimal(10), Amount: model.NewDecimal(-100.00), InvestmentAmount: model.NewDecimal(100.00)})
+	if got := acct.NumberOfShares(); got != model.NewDecimal(210) {
+		t.Errorf("got %s shares, want 210", got)
+	}
+	if got := acct.NetCost(); got != model.NewDecimal(750.00) {
+		t.Errorf("got net cost %s, want 750.00", got)
+	}
+}
@@ -0,0 +1,208 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"math"
+	"strings"
+)
+
+const (
+	AllocationGroupsTable  = "allocation_groups"
+	AllocationTargetsTable = "allocation_targets"
+
+	// TargetTypeSymbol is a target weight for a single symbol.
+	TargetTypeSymbol = "symbol"
+	// TargetTypeClass is a target weight for an asset class (Stock, Mutual Fund, Bond).
+	TargetTypeClass = "class"
+
+	allocationTargetFields = "account_group, target, target_type, weight"
+)
+
+var (
+	errMissingGroupName      = errors.New("missing allocation group name")
+	errInvalidTargetType     = errors.New("invalid allocation target type")
+	errInvalidTargetWeights  = errors.New("allocation target weights must add up to 1.0")
+	errAllocationGroupAbsent = errors.New("allocation group not found")
+)
+
+// AllocationTarget is the weight wanted for a symbol or asset class within an AllocationGroup.
+type AllocationTarget struct {
+	Target     string  `json:"target"`
+	TargetType string  `json:"type"`
+	Weight     float64 `json:"weight"`
+}
+
+// AllocationGroup is a named set of accounts that are rebalanced together to the Targets.
+type AllocationGroup struct {
+	Name     string              `json:"name"`
+	Accounts []string            `json:"accounts"`
+	Targets  []*AllocationTarget `json:"targets"`
+}
+
+// NewAllocationGroup creates an empty AllocationGroup.
+func NewAllocationGroup(name string) *AllocationGroup {
+	return &AllocationGroup{
+		Name: name,
+	}
+}
+
+// NewAllocationGroupFromJSON creates an AllocationGroup from the JSON provided and validates it.
+func NewAllocationGroupFromJSON(jsonBytes []byte) (*AllocationGroup, error) {
+	g := AllocationGroup{}
+	if err := json.Unmarshal(jsonBytes, &g); err != nil {
+		return nil, err
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (g *AllocationGroup) String() string {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// Validate checks the group has a name, known target types and weights that add up to 1.0.
+func (g *AllocationGroup) Validate() error {
+	if g.Name == "" {
+		return errMissingGroupName
+	}
+
+	total := 0.00
+	for _, t := range g.Targets {
+		switch t.TargetType {
+		case TargetTypeSymbol, TargetTypeClass:
+		default:
+			return fmt.Errorf("%w: %s", errInvalidTargetType, t.TargetType)
+		}
+		if t.Weight < 0 {
+			return errInvalidTargetWeights
+		}
+		total += t.Weight
+	}
+
+	if math.Abs(total-1.00) > 0.001 {
+		return errInvalidTargetWeights
+	}
+	return nil
+}
+
+// HasAccount returns true when the account is part of the group.
+func (g *AllocationGroup) HasAccount(account string) bool {
+	for _, a := range g.Accounts {
+		if strings.Compare(a, account) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ToDB replaces the accounts and targets stored for the group in one transaction.
+func (g *AllocationGroup) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, table := range []string{AllocationGroupsTable, AllocationTargetsTable} {
+		column := "name"
+		if table == AllocationTargetsTable {
+			column = "account_group"
+		}
+		deleteStatement := fmt.Sprintf("DELETE FROM %s WHERE %s = $1;", table, column)
+		if _, err := tx.Exec(ctx, deleteStatement, g.Name); err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+	}
+
+	for _, account := range g.Accounts {
+		insertStatement := fmt.Sprintf(
+			"INSERT INTO %s(name, account) VALUES($1, $2);", AllocationGroupsTable)
+		if _, err := tx.Exec(ctx, insertStatement, g.Name, account); err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+	}
+
+	for _, t := range g.Targets {
+		insertStatement := fmt.Sprintf(
+			"INSERT INTO %s(%s) VALUES($1, $2, $3, $4);", AllocationTargetsTable, allocationTargetFields)
+		if _, err := tx.Exec(ctx, insertStatement, g.Name, t.Target, t.TargetType, t.Weight); err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// AllocationGroupFromDB reads the accounts and targets for the named group.
+func AllocationGroupFromDB(ctx context.Context, pg *pgxpool.Pool, name string) (*AllocationGroup, error) {
+	g := NewAllocationGroup(name)
+
+	rows, err := pg.Query(ctx, fmt.Sprintf(
+		"SELECT account FROM %s WHERE name = $1 ORDER BY account;", AllocationGroupsTable), name)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	for rows.Next() {
+		var account string
+		if err := rows.Scan(&account); err != nil {
+			rows.Close()
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		g.Accounts = append(g.Accounts, account)
+	}
+	rows.Close()
+
+	rows, err = pg.Query(ctx, fmt.Sprintf(
+		"SELECT %s FROM %s WHERE account_group = $1 ORDER BY weight DESC;",
+		allocationTargetFields, AllocationTargetsTable), name)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var group string
+		t := AllocationTarget{}
+		if err := rows.Scan(&group, &t.Target, &t.TargetType, &t.Weight); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		g.Targets = append(g.Targets, &t)
+	}
+
+	if len(g.Accounts) == 0 && len(g.Targets) == 0 {
+		return nil, errAllocationGroupAbsent
+	}
+	return g, nil
+}
+
+// IsAllocationGroupNotFound returns true when the error is from a missing allocation group.
+func IsAllocationGroupNotFound(err error) bool {
+	return errors.Is(err, errAllocationGroupAbsent)
+}
@@ -0,0 +1,400 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	CashDeposit         = "deposit"
+	CashWithdrawal      = "withdrawal"
+	CashBuy             = "buy"
+	CashSell            = "sell"
+	CashDividend        = "dividend"
+	CashInterest        = "interest"
+	CashCapitalGain     = "capital gain"
+	CashReturnOfCapital = "return of capital"
+	CashFee             = "fee"
+	CashTax             = "tax"
+	CashOther           = "other"
+)
+
+var (
+	errCashNoFlows  = errors.New("no deposits or withdrawals")
+	errCashNoReturn = errors.New("money weighted return not found")
+)
+
+// cashContributions are the categories of the Payment/Deposit transactions that are payroll contributions.
+var cashContributions = []string{"401k", "ESPP"}
+
+// CashEntry is a transaction that moved cash in or out of an account, with the balance after it.
+type CashEntry struct {
+	Id          int             `json:"id,omitempty"`
+	Date        time.Time       `json:"date"`
+	Account     string          `json:"account"`
+	Type        TransactionType `json:"type"`
+	Category    string          `json:"category"`
+	Symbol      string          `json:"symbol,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Amount      float64         `json:"amount"`
+	Balance     float64         `json:"balance"`
+}
+
+// External returns true when the entry is money put into or taken out of the account.
+func (e *CashEntry) External() bool {
+	return e.Category == CashDeposit || e.Category == CashWithdrawal
+}
+
+// CashLedger is the cash held in an account built from the amounts of its transactions.
+// Withdrawals and Fees are positive amounts taken out of the account.
+type CashLedger struct {
+	Account     string       `json:"account"`
+	Entries     []*CashEntry `json:"entries,omitempty"`
+	Balance     float64      `json:"balance"`
+	Deposits    float64      `json:"deposits"`
+	Withdrawals float64      `json:"withdrawals"`
+	Purchases   float64      `json:"purchases"`
+	Sales       float64      `json:"sales"`
+	Income      float64      `json:"income"`
+	Fees        float64      `json:"fees"`
+	Other       float64      `json:"other,omitempty"`
+}
+
+func (l *CashLedger) String() string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// cashCategory returns how the transaction changed the cash in the account.
+func cashCategory(tr *Transaction) string {
+	if optionType, ok := optionTransactionType(tr.Type); ok && IsOptionSymbol(tr.Symbol) {
+		switch optionType {
+		case OptionBuyToOpen, OptionBuyToClose:
+			return CashBuy
+		case OptionSellToOpen, OptionSellToClose:
+			return CashSell
+		}
+	}
+
+	switch tr.Type {
+	case "Buy", "Buy Bonds":
+		return CashBuy
+	case "Sell", "Short Sell", "Sell Bonds":
+		return CashSell
+	case "Dividend Income":
+		return CashDividend
+	case "Interest Income":
+		return CashInterest
+	case "Long-term Capital Gain", "Short-term Capital Gain":
+		return CashCapitalGain
+	case "Return of Capital":
+		return CashReturnOfCapital
+	case "Payment/Deposit":
+		// The category is in the description, such as "Transfer:[Ameritrade IRA]" or "Bank Chrg".
+		switch {
+		case strings.HasPrefix(tr.Description, "Transfer:"), containsFold(cashContributions, tr.Description):
+			if tr.Amount.Sign() < 0 {
+				return CashWithdrawal
+			}
+			return CashDeposit
+		case tr.Description == "Int Inc":
+			return CashInterest
+		case tr.Description == "Div Income":
+			return CashDividend
+		case tr.Description == "Bank Chrg":
+			return CashFee
+		case strings.Contains(strings.ToLower(tr.Description), "tax"):
+			return CashTax
+		}
+	}
+	return CashOther
+}
+
+// add records the cash moved by the transaction. Transactions without an amount, such as reinvestments,
+// do not change the cash.
+func (l *CashLedger) add(tr *Transaction) {
+	if tr.Amount.IsZero() {
+		return
+	}
+	amount := tr.Amount.Float64()
+
+	l.Balance += amount
+	entry := CashEntry{
+		Id:          tr.Id,
+		Date:        tr.Date,
+		Account:     tr.Account,
+		Type:        tr.Type,
+		Category:    cashCategory(tr),
+		Symbol:      tr.Symbol,
+		Description: tr.Description,
+		Amount:      amount,
+		Balance:     l.Balance,
+	}
+
+	switch entry.Category {
+	case CashDeposit:
+		l.Deposits += amount
+	case CashWithdrawal:
+		l.Withdrawals -= amount
+	case CashBuy:
+		l.Purchases -= amount
+	case CashSell:
+		l.Sales += amount
+	case CashDividend, CashInterest, CashCapitalGain, CashReturnOfCapital:
+		l.Income += amount
+	case CashFee, CashTax:
+		l.Fees -= amount
+	default:
+		l.Other += amount
+	}
+	l.Entries = append(l.Entries, &entry)
+}
+
+// Contributions returns the deposits less the withdrawals.
+func (l *CashLedger) Contributions() float64 {
+	return l.Deposits - l.Withdrawals
+}
+
+// BalanceOn returns the cash in the account at the end of the date.
+func (l *CashLedger) BalanceOn(date time.Time) float64 {
+	i := sort.Search(len(l.Entries), func(i int) bool {
+		return l.Entries[i].Date.After(date)
+	})
+	if i == 0 {
+		return 0.00
+	}
+	return l.Entries[i-1].Balance
+}
+
+// ExternalFlows returns the deposits and withdrawals in date order.
+func (l *CashLedger) ExternalFlows() []*CashEntry {
+	var flows []*CashEntry
+	for _, e := range l.Entries {
+		if e.External() {
+			flows = append(flows, e)
+		}
+	}
+	return flows
+}
+
+// NewCashLedgers builds the cash ledger of every account in the transactions, keyed by account name.
+func NewCashLedgers(ts *TransactionSet) map[string]*CashLedger {
+	rows := append([]*Transaction{}, ts.TransactionRows...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Date.Before(rows[j].Date)
+	})
+
+	ledgers := make(map[string]*CashLedger)
+	for _, tr := range rows {
+		if tr.Account == "" {
+			continue
+		}
+		l, ok := ledgers[tr.Account]
+		if !ok {
+			l = &CashLedger{Account: tr.Account}
+			ledgers[tr.Account] = l
+		}
+		l.add(tr)
+	}
+	return ledgers
+}
+
+// MoneyWeightedReturn returns the annual rate that makes the deposits and withdrawals grow to the value
+// at the end date, the internal rate of return of the flows into and out of the account.
+func MoneyWeightedReturn(flows []*CashEntry, end time.Time, endValue float64) (float64, error) {
+	if len(flows) == 0 {
+		return 0.00, errCashNoFlows
+	}
+
+	first := flows[0].Date
+	npv := func(rate float64) float64 {
+		// Money put in is paid out by the investor so the signs are reversed.
+		total := endValue / math.Pow(1+rate, end.Sub(first).Hours()/(24*365.25))
+		for _, f := range flows {
+			total -= f.Amount / math.Pow(1+rate, f.Date.Sub(first).Hours()/(24*365.25))
+		}
+		return total
+	}
+
+	// Bisection since the value is not always well behaved enough for Newton's method.
+	low, high := -0.99, 10.00
+	if npv(low)*npv(high) > 0 {
+		return 0.00, errCashNoReturn
+	}
+	for i := 0; i < 200 && high-low > 1e-9; i++ {
+		mid := (low + high) / 2
+		if npv(low)*npv(mid) <= 0 {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return (low + high) / 2, nil
+}
+
+// AccountTotal is the value of an account including its cash, and how much of it was contributed.
+type AccountTotal struct {
+	Account             string  `json:"account"`
+	Cash                float64 `json:"cash"`
+	MarketValue         float64 `json:"marketValue"`
+	TotalValue          float64 `json:"totalValue"`
+	Contributions       float64 `json:"contributions"`
+	Income              float64 `json:"income"`
+	Fees                float64 `json:"fees"`
+	MarketGain          float64 `json:"marketGain"`                    // total value less the contributions
+	MoneyWeightedReturn float64 `json:"moneyWeightedReturn,omitempty"` // in the currency of the account
+	Currency            string  `json:"currency,omitempty"`
+}
+
+// NewAccountTotals combines the cash ledgers with the market value of the holdings in each account.
+func NewAccountTotals(ledgers map[string]*CashLedger, marketValues map[string]float64, asOf time.Time) []*AccountTotal {
+	names := make(map[string]bool)
+	for name := range ledgers {
+		names[name] = true
+	}
+	for name := range marketValues {
+		names[name] = true
+	}
+	var accounts []string
+	for name := range names {
+		accounts = append(accounts, name)
+	}
+	sort.Strings(accounts)
+
+	var totals []*AccountTotal
+	for _, account := range accounts {
+		total := AccountTotal{
+			Account:     account,
+			MarketValue: marketValues[account],
+		}
+		if l, ok := ledgers[account]; ok {
+			total.Cash = l.BalanceOn(asOf)
+			total.Contributions = l.Contributions()
+			total.Income = l.Income
+			total.Fees = l.Fees
+		}
+		total.TotalValue = total.Cash + total.MarketValue
+		total.MarketGain = total.TotalValue - total.Contributions
+
+		if l, ok := ledgers[account]; ok {
+			if rate, err := MoneyWeightedReturn(l.ExternalFlows(), asOf, total.TotalValue); err == nil {
+				total.MoneyWeightedReturn = rate
+			}
+		}
+		totals = append(totals, &total)
+	}
+	return totals
+}
+
+// allTransactions returns every transaction in date order.
+func allTransactions(ctx context.Context, pgxConn *pgxpool.Pool) (*TransactionSet, error) {
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	ts := NewTransactionSet()
+	if err := ts.TransactionsAllGetBeforeDate(ctx, pgxConn, tomorrow.Year(), int(tomorrow.Month()), tomorrow.Day()); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	return ts, nil
+}
+
+// CashLedgersGet returns the cash ledger for the account, or every account when account is empty.
+func CashLedgersGet(ctx context.Context, pgxConn *pgxpool.Pool, account string) ([]*CashLedger, error) {
+	ts, err := allTransactions(ctx, pgxConn)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgers := NewCashLedgers(ts)
+	var results []*CashLedger
+	for name, l := range ledgers {
+		if account == "" || name == account {
+			results = append(results, l)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Account < results[j].Account
+	})
+	return results, nil
+}
+
+// convert converts the amounts of the total from the currency of the account at the rate on the date.
+func (t *AccountTotal) convert(rates *FXRateSet, from, to string, date time.Time) error {
+	rate, err := rates.Rate(from, to, date)
+	if err != nil {
+		return err
+	}
+	t.Currency = to
+	t.Cash *= rate
+	t.MarketValue *= rate
+	t.TotalValue *= rate
+	t.Contributions *= rate
+	t.Income *= rate
+	t.Fees *= rate
+	t.MarketGain *= rate
+	return nil
+}
+
+// AccountTotalsGet returns the cash, market value and contributions of every account in the reporting
+// currency. The totals are kept in the currency of each account and converted at the latest rate.
+func AccountTotalsGet(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet, currency string) ([]*AccountTotal, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := allTransactions(ctx, pgxConn)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := FXRateSetGet(ctx, pgxConn)
+	if err != nil {
+		return nil, err
+	}
+	asOf := time.Now()
+
+	symbols, err := SymbolList(ctx, pgxConn, lookups)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	marketValues := make(map[string]float64)
+	for symbol := range symbols {
+		if symbol == "" {
+			continue
+		}
+		acctInfo, err := AccountInfoGetInCurrency(ctx, pgxConn, symbol, currency)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		for account, shares := range acctInfo.Accounts {
+			value, err := rates.Convert(shares*acctInfo.LocalPrice, acctInfo.LocalCurrency, AccountCurrency(ctx, account), asOf)
+			if err != nil {
+				logrus.Error(err.Error())
+				return nil, err
+			}
+			marketValues[account] += value
+		}
+	}
+
+	totals := NewAccountTotals(NewCashLedgers(ts), marketValues, asOf)
+	for _, total := range totals {
+		if err := total.convert(rates, AccountCurrency(ctx, total.Account), currency, asOf); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+	}
+	return totals, nil
+}
@@ -0,0 +1,107 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewCashLedgers(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.January, 3, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(5000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX", Description: "100 shares @ 30.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-3000.00), Account: "Fidelity IRA"},
+		{Id: 3, Date: time.Date(2023, time.March, 15, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "CSX", Amount: model.NewDecimal(11.00), Account: "Fidelity IRA"},
+		{Id: 4, Date: time.Date(2023, time.March, 31, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Bank Chrg", Amount: model.NewDecimal(-25.00), Account: "Fidelity IRA"},
+		{Id: 5, Date: time.Date(2023, time.June, 15, 0, 0, 0, 0, time.UTC), Type: "Reinvest Dividend", Symbol: "CSX", Description: "0.3 shares @ 33.00", Shares: model.NewDecimal(0.3), InvestmentAmount: model.NewDecimal(9.90), Account: "Fidelity IRA"},
+		{Id: 6, Date: time.Date(2023, time.September, 1, 0, 0, 0, 0, time.UTC), Type: "Sell", Symbol: "CSX", Description: "50 shares @ 34.00", Shares: model.NewDecimal(50), Amount: model.NewDecimal(1700.00), Account: "Fidelity IRA"},
+		{Id: 7, Date: time.Date(2023, time.October, 2, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(-1000.00), Account: "Fidelity IRA"},
+		{Id: 8, Date: time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "401k", Amount: model.NewDecimal(84.62), Account: "Home Depot 401(k)"},
+	}
+
+	ledgers := model.NewCashLedgers(ts)
+	if len(ledgers) != 2 {
+		t.Fatalf("got %d ledgers, want 2", len(ledgers))
+	}
+
+	l := ledgers["Fidelity IRA"]
+	t.Log(l.String())
+	if len(l.Entries) != 6 {
+		t.Errorf("got %d entries, want 6 without the reinvestment", len(l.Entries))
+	}
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"balance", l.Balance, 2686.00},
+		{"deposits", l.Deposits, 5000.00},
+		{"withdrawals", l.Withdrawals, 1000.00},
+		{"contributions", l.Contributions(), 4000.00},
+		{"purchases", l.Purchases, 3000.00},
+		{"sales", l.Sales, 1700.00},
+		{"income", l.Income, 11.00},
+		{"fees", l.Fees, 25.00},
+		{"balance on", l.BalanceOn(time.Date(2023, time.March, 31, 0, 0, 0, 0, time.UTC)), 1986.00},
+		{"balance before", l.BalanceOn(time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC)), 0.00},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 0.001 {
+			t.Errorf("%s got %.2f, want %.2f", c.name, c.got, c.want)
+		}
+	}
+
+	if flows := l.ExternalFlows(); len(flows) != 2 || flows[1].Category != model.CashWithdrawal {
+		t.Error("unexpected external flows")
+	}
+	if ledgers["Home Depot 401(k)"].Deposits != 84.62 {
+		t.Error("401k contribution not a deposit")
+	}
+}
+
+func TestMoneyWeightedReturn(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(2, 0, 0)
+	flows := []*model.CashEntry{
+		{Date: start, Category: model.CashDeposit, Amount: 1000.00},
+	}
+
+	years := end.Sub(start).Hours() / (24 * 365.25)
+	rate, err := model.MoneyWeightedReturn(flows, end, 1000.00*math.Pow(1.10, years))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if math.Abs(rate-0.10) > 0.0001 {
+		t.Errorf("got rate %.4f, want 0.1000", rate)
+	}
+
+	if _, err := model.MoneyWeightedReturn(nil, end, 1000.00); err == nil {
+		t.Error("expected an error without flows")
+	}
+}
+
+func TestNewAccountTotals(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.January, 3, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(5000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX", Description: "100 shares @ 30.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-3000.00), Account: "Fidelity IRA"},
+	}
+
+	asOf := time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC)
+	totals := model.NewAccountTotals(model.NewCashLedgers(ts), map[string]float64{"Fidelity IRA": 3500.00, "HD ESPP": 100.00}, asOf)
+	if len(totals) != 2 {
+		t.Fatalf("got %d totals, want 2", len(totals))
+	}
+
+	total := totals[0]
+	if total.Account != "Fidelity IRA" || total.Cash != 2000.00 || total.TotalValue != 5500.00 || total.MarketGain != 500.00 {
+		t.Errorf("unexpected total %+v", total)
+	}
+	if math.Abs(total.MoneyWeightedReturn-0.10) > 0.001 {
+		t.Errorf("got money weighted return %.4f, want 0.10", total.MoneyWeightedReturn)
+	}
+	if totals[1].Account != "HD ESPP" || totals[1].TotalValue != 100.00 {
+		t.Errorf("unexpected total %+v", totals[1])
+	}
+}
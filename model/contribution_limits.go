@@ -0,0 +1,357 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ContributionLimitsTable = "contribution_limits"
+
+	LimitIRA  = "IRA"
+	Limit401k = "401k"
+	LimitHSA  = "HSA"
+
+	contributionLimitFields = "year, limit_type, amount, catch_up, catch_up_age"
+)
+
+var (
+	errLimitYear = errors.New("contribution limit year missing")
+	errLimitType = errors.New("contribution limit type unknown")
+
+	transferPattern = regexp.MustCompile(`^Transfer:\[(.*)\]`)
+)
+
+// ContributionLimit is the most that can be contributed in a tax year to the accounts of the limit type,
+// plus the catch-up once the owner reaches the catch-up age.
+type ContributionLimit struct {
+	Year       int     `json:"year"`
+	LimitType  string  `json:"type"`
+	Amount     float64 `json:"amount"`
+	CatchUp    float64 `json:"catchUp,omitempty"`
+	CatchUpAge int     `json:"catchUpAge,omitempty"`
+}
+
+// Validate checks the limit has a year and known type.
+func (l *ContributionLimit) Validate() error {
+	if l.Year <= 0 {
+		return errLimitYear
+	}
+	switch l.LimitType {
+	case LimitIRA, Limit401k, LimitHSA:
+	default:
+		return fmt.Errorf("%w: %s", errLimitType, l.LimitType)
+	}
+	return nil
+}
+
+// DefaultContributionLimits are the IRS limits for the recent years. Later years, or corrections, are
+// loaded into the contribution_limits table and replace these.
+func DefaultContributionLimits() []*ContributionLimit {
+	return []*ContributionLimit{
+		{Year: 2019, LimitType: LimitIRA, Amount: 6000, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2020, LimitType: LimitIRA, Amount: 6000, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2021, LimitType: LimitIRA, Amount: 6000, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2022, LimitType: LimitIRA, Amount: 6000, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2023, LimitType: LimitIRA, Amount: 6500, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2024, LimitType: LimitIRA, Amount: 7000, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2025, LimitType: LimitIRA, Amount: 7000, CatchUp: 1000, CatchUpAge: 50},
+		{Year: 2019, LimitType: Limit401k, Amount: 19000, CatchUp: 6000, CatchUpAge: 50},
+		{Year: 2020, LimitType: Limit401k, Amount: 19500, CatchUp: 6500, CatchUpAge: 50},
+		{Year: 2021, LimitType: Limit401k, Amount: 19500, CatchUp: 6500, CatchUpAge: 50},
+		{Year: 2022, LimitType: Limit401k, Amount: 20500, CatchUp: 6500, CatchUpAge: 50},
+		{Year: 2023, LimitType: Limit401k, Amount: 22500, CatchUp: 7500, CatchUpAge: 50},
+		{Year: 2024, LimitType: Limit401k, Amount: 23000, CatchUp: 7500, CatchUpAge: 50},
+		{Year: 2025, LimitType: Limit401k, Amount: 23500, CatchUp: 7500, CatchUpAge: 50},
+		{Year: 2019, LimitType: LimitHSA, Amount: 3500, CatchUp: 1000, CatchUpAge: 55},
+		{Year: 2020, LimitType: LimitHSA, Amount: 3550, CatchUp: 1000, CatchUpAge: 55},
+		{Year: 2021, LimitType: LimitHSA, Amount: 3600, CatchUp: 1000, CatchUpAge: 55},
+		{Year: 2022, LimitType: LimitHSA, Amount: 3650, CatchUp: 1000, CatchUpAge: 55},
+		{Year: 2023, LimitType: LimitHSA, Amount: 3850, CatchUp: 1000, CatchUpAge: 55},
+		{Year: 2024, LimitType: LimitHSA, Amount: 4150, CatchUp: 1000, CatchUpAge: 55},
+		{Year: 2025, LimitType: LimitHSA, Amount: 4300, CatchUp: 1000, CatchUpAge: 55},
+	}
+}
+
+// limitType returns the limit the account type counts against. Traditional and Roth IRAs share a limit.
+func limitType(accountType string) string {
+	switch accountType {
+	case AccountTypeTraditionalIRA, AccountTypeRoth:
+		return LimitIRA
+	case AccountType401k:
+		return Limit401k
+	case AccountTypeHSA:
+		return LimitHSA
+	}
+	return ""
+}
+
+// findLimit returns the limit of the type for the year, or nil when there is none.
+func findLimit(limits []*ContributionLimit, year int, limitType string) *ContributionLimit {
+	for _, l := range limits {
+		if l.Year == year && l.LimitType == limitType {
+			return l
+		}
+	}
+	return nil
+}
+
+// NewContributionLimitsFromJSON reads a single contribution limit or a list of them.
+func NewContributionLimitsFromJSON(data []byte) ([]*ContributionLimit, error) {
+	var limits []*ContributionLimit
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var l ContributionLimit
+		if err := json.Unmarshal(data, &l); err != nil {
+			return nil, err
+		}
+		limits = append(limits, &l)
+	} else if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, err
+	}
+
+	for _, l := range limits {
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return limits, nil
+}
+
+// ToDB stores the limit, replacing the limit of the same type for the year.
+func (l *ContributionLimit) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5)"+
+			" ON CONFLICT (year, limit_type) DO UPDATE SET amount = EXCLUDED.amount, catch_up = EXCLUDED.catch_up,"+
+			" catch_up_age = EXCLUDED.catch_up_age;",
+		ContributionLimitsTable, contributionLimitFields)
+	if _, err := pg.Exec(ctx, insertStatement, l.Year, l.LimitType, l.Amount, l.CatchUp, l.CatchUpAge); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// ContributionLimitsGet returns the default limits with the ones stored in the database replacing them.
+func ContributionLimitsGet(ctx context.Context, pg *pgxpool.Pool) ([]*ContributionLimit, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s;", contributionLimitFields, ContributionLimitsTable))
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	limits := DefaultContributionLimits()
+	for rows.Next() {
+		l := ContributionLimit{}
+		if err := rows.Scan(&l.Year, &l.LimitType, &l.Amount, &l.CatchUp, &l.CatchUpAge); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if existing := findLimit(limits, l.Year, l.LimitType); existing != nil {
+			*existing = l
+			continue
+		}
+		limits = append(limits, &l)
+	}
+
+	sort.Slice(limits, func(i, j int) bool {
+		if limits[i].Year != limits[j].Year {
+			return limits[i].Year < limits[j].Year
+		}
+		return limits[i].LimitType < limits[j].LimitType
+	})
+	return limits, nil
+}
+
+// Contribution is the money put into the accounts of an owner with the same limit in a tax year.
+type Contribution struct {
+	TaxYear   int      `json:"taxYear"`
+	Owner     string   `json:"owner"`
+	LimitType string   `json:"type"`
+	Accounts  []string `json:"accounts"`
+	Amount    float64  `json:"amount"`
+	Limit     float64  `json:"limit,omitempty"`
+	Remaining float64  `json:"remaining,omitempty"`
+	Over      bool     `json:"over,omitempty"`
+}
+
+// Distribution is the money taken out of a retirement account in a tax year.
+type Distribution struct {
+	TaxYear     int     `json:"taxYear"`
+	Owner       string  `json:"owner"`
+	Account     string  `json:"account"`
+	AccountType string  `json:"accountType"`
+	Amount      float64 `json:"amount"`
+}
+
+// ContributionReport is the contributions compared to their limits and the distributions. Accounts with
+// deposits or withdrawals but no metadata are listed in Unknown.
+type ContributionReport struct {
+	Contributions []*Contribution `json:"contributions"`
+	Distributions []*Distribution `json:"distributions,omitempty"`
+	Unknown       []string        `json:"unknown,omitempty"`
+}
+
+func (r *ContributionReport) String() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// transferAccount returns the other account of a transfer, or an empty string when it is not a transfer.
+func transferAccount(description string) string {
+	if m := transferPattern.FindStringSubmatch(description); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// NewContributionReport adds up the deposits and withdrawals of the retirement accounts for each calendar
+// year. Transfers to or from an account in the ledgers or the metadata are rollovers and are not counted.
+// Payroll deposits include any employer match since the transactions do not separate it.
+func NewContributionReport(ledgers map[string]*CashLedger, accounts map[string]*AccountMetadata, limits []*ContributionLimit) *ContributionReport {
+	report := ContributionReport{}
+	rollover := func(description string) bool {
+		other := transferAccount(description)
+		if other == "" {
+			return false
+		}
+		_, inLedgers := ledgers[other]
+		_, inAccounts := accounts[other]
+		return inLedgers || inAccounts
+	}
+
+	contributions := make(map[string]*Contribution)
+	distributions := make(map[string]*Distribution)
+	var names []string
+	for name := range ledgers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		meta, ok := accounts[name]
+		if !ok {
+			if len(ledgers[name].ExternalFlows()) > 0 {
+				report.Unknown = append(report.Unknown, name)
+			}
+			continue
+		}
+		if !meta.Retirement() {
+			continue
+		}
+
+		for _, e := range ledgers[name].ExternalFlows() {
+			if rollover(e.Description) {
+				continue
+			}
+			year := e.Date.Year()
+			switch e.Category {
+			case CashDeposit:
+				key := fmt.Sprintf("%d|%s|%s", year, meta.Owner, limitType(meta.AccountType))
+				c, ok := contributions[key]
+				if !ok {
+					c = &Contribution{TaxYear: year, Owner: meta.Owner, LimitType: limitType(meta.AccountType)}
+					contributions[key] = c
+				}
+				if len(c.Accounts) == 0 || c.Accounts[len(c.Accounts)-1] != name {
+					c.Accounts = append(c.Accounts, name)
+				}
+				c.Amount += e.Amount
+			case CashWithdrawal:
+				key := fmt.Sprintf("%d|%s", year, name)
+				d, ok := distributions[key]
+				if !ok {
+					d = &Distribution{TaxYear: year, Owner: meta.Owner, Account: name, AccountType: meta.AccountType}
+					distributions[key] = d
+				}
+				d.Amount -= e.Amount
+			}
+		}
+	}
+
+	for _, c := range contributions {
+		if l := findLimit(limits, c.TaxYear, c.LimitType); l != nil {
+			c.Limit = l.Amount
+			for _, name := range c.Accounts {
+				birthYear := accounts[name].OwnerBirthYear
+				if birthYear > 0 && c.TaxYear-birthYear >= l.CatchUpAge {
+					c.Limit += l.CatchUp
+					break
+				}
+			}
+			c.Remaining = c.Limit - c.Amount
+			c.Over = c.Remaining < -0.005
+		}
+		report.Contributions = append(report.Contributions, c)
+	}
+	sort.Slice(report.Contributions, func(i, j int) bool {
+		a, b := report.Contributions[i], report.Contributions[j]
+		if a.TaxYear != b.TaxYear {
+			return a.TaxYear < b.TaxYear
+		}
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		return a.LimitType < b.LimitType
+	})
+
+	for _, d := range distributions {
+		report.Distributions = append(report.Distributions, d)
+	}
+	sort.Slice(report.Distributions, func(i, j int) bool {
+		a, b := report.Distributions[i], report.Distributions[j]
+		if a.TaxYear != b.TaxYear {
+			return a.TaxYear < b.TaxYear
+		}
+		return a.Account < b.Account
+	})
+	return &report
+}
+
+// ContributionReportGet returns the contribution report for the tax year and owner, or all of them when
+// year is zero or owner is empty.
+func ContributionReportGet(ctx context.Context, pg *pgxpool.Pool, year int, owner string) (*ContributionReport, error) {
+	ts, err := allTransactions(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := AccountMetadataGetAll(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := ContributionLimitsGet(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+
+	report := NewContributionReport(NewCashLedgers(ts), accounts, limits)
+	if year == 0 && owner == "" {
+		return report, nil
+	}
+
+	filtered := ContributionReport{Unknown: report.Unknown}
+	for _, c := range report.Contributions {
+		if (year == 0 || c.TaxYear == year) && (owner == "" || c.Owner == owner) {
+			filtered.Contributions = append(filtered.Contributions, c)
+		}
+	}
+	for _, d := range report.Distributions {
+		if (year == 0 || d.TaxYear == year) && (owner == "" || d.Owner == owner) {
+			filtered.Distributions = append(filtered.Distributions, d)
+		}
+	}
+	return &filtered, nil
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"testing"
+	"time"
+)
+
+func TestNewContributionReport(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(4000.00), Account: "Jane IRA"},
+		{Id: 2, Date: time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(3000.00), Account: "Schwab Contributory IRA Jane"},
+		{Id: 3, Date: time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[FI 401k]", Amount: model.NewDecimal(50000.00), Account: "Fidelity IRA"},
+		{Id: 4, Date: time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "401k", Amount: model.NewDecimal(1000.00), Account: "Home Depot 401(k)"},
+		{Id: 5, Date: time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(-2500.00), Account: "Fidelity IRA"},
+		{Id: 6, Date: time.Date(2023, time.July, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[Jane IRA]", Amount: model.NewDecimal(-1000.00), Account: "Fidelity IRA"},
+		{Id: 7, Date: time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(500.00), Account: "HD ESPP"},
+		{Id: 8, Date: time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", Description: "Transfer:[USAA CLASSIC CHECKING]", Amount: model.NewDecimal(500.00), Account: "Wachovia Brokerage"},
+	}
+
+	accounts := map[string]*model.AccountMetadata{
+		"Jane IRA":                     {Name: "Jane IRA", AccountType: model.AccountTypeTraditionalIRA, Owner: "Jane", OwnerBirthYear: 1980},
+		"Schwab Contributory IRA Jane": {Name: "Schwab Contributory IRA Jane", AccountType: model.AccountTypeRoth, Owner: "Jane", OwnerBirthYear: 1980},
+		"Fidelity IRA":                 {Name: "Fidelity IRA", AccountType: model.AccountTypeTraditionalIRA, Owner: "Keith", OwnerBirthYear: 1965},
+		"FI 401k":                      {Name: "FI 401k", AccountType: model.AccountType401k, Owner: "Keith"},
+		"Home Depot 401(k)":            {Name: "Home Depot 401(k)", AccountType: model.AccountType401k, Owner: "Keith", OwnerBirthYear: 1965},
+		"HD ESPP":                      {Name: "HD ESPP", AccountType: model.AccountTypeTaxable, Owner: "Keith"},
+	}
+
+	report := model.NewContributionReport(model.NewCashLedgers(ts), accounts, model.DefaultContributionLimits())
+	t.Log(report.String())
+
+	if len(report.Contributions) != 2 {
+		t.Fatalf("got %d contributions, want 2", len(report.Contributions))
+	}
+
+	jane := report.Contributions[0]
+	if jane.Owner != "Jane" || jane.LimitType != model.LimitIRA || jane.Amount != 7000.00 || len(jane.Accounts) != 2 {
+		t.Errorf("unexpected contribution %+v", jane)
+	}
+	if jane.Limit != 6500.00 || !jane.Over || jane.Remaining != -500.00 {
+		t.Errorf("got limit %.2f over %v, want 6500.00 and over", jane.Limit, jane.Over)
+	}
+
+	keith := report.Contributions[1]
+	if keith.Owner != "Keith" || keith.LimitType != model.Limit401k || keith.Limit != 30000.00 || keith.Over {
+		t.Errorf("unexpected contribution %+v, want the catch-up included", keith)
+	}
+
+	if len(report.Distributions) != 1 || report.Distributions[0].Amount != 2500.00 {
+		t.Errorf("unexpected distributions %+v", report.Distributions)
+	}
+	if len(report.Unknown) != 1 || report.Unknown[0] != "Wachovia Brokerage" {
+		t.Errorf("unexpected unknown accounts %v", report.Unknown)
+	}
+}
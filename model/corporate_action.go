@@ -0,0 +1,497 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	CorporateActionsTable = "corporate_actions"
+
+	ActionSplit        = "split"
+	ActionReverseSplit = "reverse split"
+	ActionSpinoff      = "spinoff"
+	ActionCashMerger   = "cash merger"
+	ActionStockMerger  = "stock merger"
+	ActionSymbolChange = "symbol change"
+
+	ActionSourceManual  = "manual"
+	ActionSourcePolygon = "polygon"
+
+	// CorporateActionType is the type of the Entity for shares received from a corporate action.
+	CorporateActionType TransactionType = "Corporate Action"
+
+	corporateActionFields = "symbol, action_date, action_type, split_from, split_to, new_symbol, cash_per_share, basis_percent, source"
+)
+
+var (
+	errActionSymbol     = errors.New("corporate action symbol missing")
+	errActionDate       = errors.New("corporate action date missing")
+	errActionType       = errors.New("corporate action type unknown")
+	errActionRatio      = errors.New("corporate action ratio invalid")
+	errActionNewSymbol  = errors.New("corporate action new symbol missing")
+	errActionBasis      = errors.New("corporate action basis percent must be between 0 and 1")
+	errActionCash       = errors.New("corporate action cash per share missing")
+	errSplitDescription = errors.New("stock split description invalid")
+
+	splitRatioPattern = regexp.MustCompile(`^\s*([0-9.]+)\s*(?:for|-for-|:|/)\s*([0-9.]+)`)
+)
+
+// CorporateAction is an event that changes the shares or symbol of a holding. Splits and mergers
+// convert SplitFrom shares to SplitTo shares, a spinoff gives SplitTo shares of NewSymbol for every
+// SplitFrom shares held and moves BasisPercent of the cost to them.
+type CorporateAction struct {
+	Symbol       string    `json:"symbol"`
+	Date         time.Time `json:"date"`
+	ActionType   string    `json:"type"`
+	SplitFrom    float64   `json:"splitFrom,omitempty"`
+	SplitTo      float64   `json:"splitTo,omitempty"`
+	NewSymbol    string    `json:"newSymbol,omitempty"`
+	CashPerShare float64   `json:"cashPerShare,omitempty"`
+	BasisPercent float64   `json:"basisPercent,omitempty"`
+	Source       string    `json:"source,omitempty"`
+}
+
+func (ca *CorporateAction) String() string {
+	b, err := json.Marshal(ca)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// Ratio returns the number of new shares for each share held.
+func (ca *CorporateAction) Ratio() float64 {
+	if ca.SplitFrom <= 0 || ca.SplitTo <= 0 {
+		return 1.00
+	}
+	return ca.SplitTo / ca.SplitFrom
+}
+
+// shares returns the shares received in the new symbol for the shares held, rounded to six places.
+func (ca *CorporateAction) shares(held Decimal) Decimal {
+	if ca.SplitFrom <= 0 || ca.SplitTo <= 0 {
+		return held
+	}
+	return held.Mul(NewDecimal(ca.SplitTo)).Div(NewDecimal(ca.SplitFrom))
+}
+
+// Validate checks the action has what is needed for its type.
+func (ca *CorporateAction) Validate() error {
+	switch {
+	case ca.Symbol == "":
+		return errActionSymbol
+	case ca.Date.IsZero():
+		return errActionDate
+	}
+
+	switch ca.ActionType {
+	case ActionSplit, ActionReverseSplit:
+		if ca.SplitFrom <= 0 || ca.SplitTo <= 0 {
+			return errActionRatio
+		}
+	case ActionSpinoff:
+		if ca.SplitFrom <= 0 || ca.SplitTo <= 0 {
+			return errActionRatio
+		}
+		if ca.NewSymbol == "" {
+			return errActionNewSymbol
+		}
+		if ca.BasisPercent < 0 || ca.BasisPercent > 1 {
+			return errActionBasis
+		}
+	case ActionCashMerger:
+		if ca.CashPerShare <= 0 {
+			return errActionCash
+		}
+	case ActionStockMerger:
+		if ca.SplitFrom <= 0 || ca.SplitTo <= 0 {
+			return errActionRatio
+		}
+		if ca.NewSymbol == "" {
+			return errActionNewSymbol
+		}
+	case ActionSymbolChange:
+		if ca.NewSymbol == "" {
+			return errActionNewSymbol
+		}
+	default:
+		return fmt.Errorf("%w: %s", errActionType, ca.ActionType)
+	}
+	return nil
+}
+
+// NewCorporateActionsFromJSON reads a single corporate action or a list of them.
+func NewCorporateActionsFromJSON(data []byte) ([]*CorporateAction, error) {
+	var actions []*CorporateAction
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var ca CorporateAction
+		if err := json.Unmarshal(data, &ca); err != nil {
+			return nil, err
+		}
+		actions = append(actions, &ca)
+	} else if err := json.Unmarshal(data, &actions); err != nil {
+		return nil, err
+	}
+
+	for _, ca := range actions {
+		if ca.Source == "" {
+			ca.Source = ActionSourceManual
+		}
+		if err := ca.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+// polygonSplit is a split returned by Polygon.
+type polygonSplit struct {
+	ExecutionDate StockTime `json:"execution_date"`
+	SplitFrom     float64   `json:"split_from"`
+	SplitTo       float64   `json:"split_to"`
+	Ticker        string    `json:"ticker"`
+}
+
+// NewCorporateActionsFromSplits converts the splits from Polygon to split and reverse split actions.
+func NewCorporateActionsFromSplits(data []byte) ([]*CorporateAction, error) {
+	var splits []polygonSplit
+	if err := json.Unmarshal(data, &splits); err != nil {
+		return nil, err
+	}
+
+	var actions []*CorporateAction
+	for _, s := range splits {
+		ca := CorporateAction{
+			Symbol:     s.Ticker,
+			Date:       s.ExecutionDate.Time,
+			ActionType: ActionSplit,
+			SplitFrom:  s.SplitFrom,
+			SplitTo:    s.SplitTo,
+			Source:     ActionSourcePolygon,
+		}
+		if s.SplitTo < s.SplitFrom {
+			ca.ActionType = ActionReverseSplit
+		}
+		if err := ca.Validate(); err != nil {
+			return nil, err
+		}
+		actions = append(actions, &ca)
+	}
+	return actions, nil
+}
+
+// parseSplitRatio reads the new and old shares from a description like "2 for 1 split".
+func parseSplitRatio(description string) (float64, float64, error) {
+	parts := splitRatioPattern.FindStringSubmatch(description)
+	if len(parts) != 3 {
+		return 0.00, 0.00, fmt.Errorf("%w: %s", errSplitDescription, description)
+	}
+	newShares, err := utils.FloatParse(parts[1])
+	if err != nil {
+		return 0.00, 0.00, err
+	}
+	oldShares, err := utils.FloatParse(parts[2])
+	if err != nil {
+		return 0.00, 0.00, err
+	}
+	if newShares <= 0 || oldShares <= 0 {
+		return 0.00, 0.00, fmt.Errorf("%w: %s", errSplitDescription, description)
+	}
+	return newShares, oldShares, nil
+}
+
+// ApplyCorporateAction applies the action to the lots in the account and returns the lots received in
+// the new symbol. Received lots keep the date of the lot they came from for the holding period.
+func (a *Account) ApplyCorporateAction(ca *CorporateAction) []*Entity {
+	var received []*Entity
+	for _, e := range a.Entities {
+		if !utils.Contains(BuyTransactions, string(e.Type)) || e.RemainingShares.Sign() <= 0 {
+			continue
+		}
+
+		switch ca.ActionType {
+		case ActionSplit, ActionReverseSplit:
+			e.SplitShares(ca.SplitTo, ca.SplitFrom)
+		case ActionSpinoff:
+			allocated := e.NetCost().Mul(NewDecimal(ca.BasisPercent)).RoundCents()
+			e.BasisAdjustment = e.BasisAdjustment.Add(allocated)
+			received = append(received, newCorporateActionEntity(ca, e, a.Name, ca.shares(e.RemainingShares), allocated))
+		case ActionCashMerger:
+			e.SellShares(e.RemainingShares, NewDecimal(ca.CashPerShare))
+		case ActionStockMerger, ActionSymbolChange:
+			// The lot is closed without a sale and its basis moves to the new shares, only the cash of a
+			// merger is sold from it and reduces the basis that moves.
+			basis := e.NetCost()
+			if ca.CashPerShare > 0 {
+				lot := Lot{NumberShares: e.RemainingShares, PricePerShare: NewDecimal(ca.CashPerShare), SoldDate: ca.Date}
+				e.SoldLots = append(e.SoldLots, &lot)
+				if basis = basis.Sub(lot.Proceeds()); basis.Sign() < 0 {
+					basis = Decimal{}
+				}
+			}
+			received = append(received, newCorporateActionEntity(ca, e, a.Name, ca.shares(e.RemainingShares), basis))
+			e.BasisAdjustment = e.BasisAdjustment.Add(basis)
+			e.RemainingShares = Decimal{}
+		}
+	}
+	return received
+}
+
+// newCorporateActionEntity creates the lot received in the new symbol from the lot from.
+func newCorporateActionEntity(ca *CorporateAction, from *Entity, account string, shares, cost Decimal) *Entity {
+	en := Entity{
+		Date:            from.Date,
+		Type:            CorporateActionType,
+		Symbol:          ca.NewSymbol,
+		Description:     fmt.Sprintf("%s from %s", ca.ActionType, ca.Symbol),
+		Shares:          shares,
+		Amount:          cost.Neg(),
+		Account:         account,
+		RemainingShares: shares,
+		Currency:        from.Currency,
+	}
+	if shares.Sign() > 0 {
+		en.PricePerShare = cost.Div(shares)
+	}
+	return &en
+}
+
+// ApplyCorporateAction applies the action to every account of the ticker and returns the lots received
+// in the new symbol.
+func (t *Ticker) ApplyCorporateAction(ca *CorporateAction) []*Entity {
+	var names []string
+	for name := range t.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var received []*Entity
+	for _, name := range names {
+		received = append(received, t.Accounts[name].ApplyCorporateAction(ca)...)
+	}
+	return received
+}
+
+// SetCorporateActions sets the actions applied by LoadTickerSet in date order.
+func (s *TickerSet) SetCorporateActions(actions []*CorporateAction) {
+	s.actions = make([]*CorporateAction, len(actions))
+	copy(s.actions, actions)
+	sort.SliceStable(s.actions, func(i, j int) bool {
+		return s.actions[i].Date.Before(s.actions[j].Date)
+	})
+	s.nextAction = 0
+}
+
+// applyCorporateActions applies the actions up to and including date, adding the lots received to
+// the ticker for the new symbol.
+func (s *TickerSet) applyCorporateActions(date time.Time) {
+	for ; s.nextAction < len(s.actions) && !s.actions[s.nextAction].Date.After(date); s.nextAction++ {
+		ca := s.actions[s.nextAction]
+		ticker, ok := s.Set[ca.Symbol]
+		if !ok {
+			continue
+		}
+		logrus.Debug("Applying ", ca.ActionType, " to ", ca.Symbol)
+
+		received := ticker.ApplyCorporateAction(ca)
+		if len(received) == 0 {
+			continue
+		}
+		newTicker, ok := s.Set[ca.NewSymbol]
+		if !ok {
+			newTicker = s.newTicker(ca.NewSymbol)
+			s.Set[ca.NewSymbol] = newTicker
+		}
+		for _, en := range received {
+			newTicker.AddEntity(en)
+		}
+	}
+}
+
+// hasSplitAction returns true when there is a split action for the symbol within a week of date, so a
+// Stock Split transaction for it is not applied twice.
+func (s *TickerSet) hasSplitAction(symbol string, date time.Time) bool {
+	for _, ca := range s.actions {
+		if ca.Symbol != symbol || (ca.ActionType != ActionSplit && ca.ActionType != ActionReverseSplit) {
+			continue
+		}
+		if math.Abs(ca.Date.Sub(date).Hours()) <= 7*24 {
+			return true
+		}
+	}
+	return false
+}
+
+// ToDB stores the action, replacing an action of the same type for the symbol on the date.
+func (ca *CorporateAction) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := ca.Validate(); err != nil {
+		return err
+	}
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)"+
+			" ON CONFLICT (symbol, action_date, action_type) DO UPDATE SET split_from = EXCLUDED.split_from,"+
+			" split_to = EXCLUDED.split_to, new_symbol = EXCLUDED.new_symbol, cash_per_share = EXCLUDED.cash_per_share,"+
+			" basis_percent = EXCLUDED.basis_percent, source = EXCLUDED.source;",
+		CorporateActionsTable, corporateActionFields)
+	if _, err := pg.Exec(ctx, insertStatement, ca.Symbol, ca.Date.Format(dateToPgLayout), ca.ActionType,
+		ca.SplitFrom, ca.SplitTo, ca.NewSymbol, ca.CashPerShare, ca.BasisPercent, ca.Source); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// CorporateActionsGetBySymbol returns the actions for the symbol or that give shares in the symbol.
+func CorporateActionsGetBySymbol(ctx context.Context, pg *pgxpool.Pool, symbol string) ([]*CorporateAction, error) {
+	return corporateActionsGet(ctx, pg, "WHERE symbol = $1 OR new_symbol = $1", symbol)
+}
+
+// CorporateActionsGetAll returns the actions for every symbol in date order.
+func CorporateActionsGetAll(ctx context.Context, pg *pgxpool.Pool) ([]*CorporateAction, error) {
+	return corporateActionsGet(ctx, pg, "")
+}
+
+// corporateActionsGet returns the actions selected by the where clause in date order.
+func corporateActionsGet(ctx context.Context, pg *pgxpool.Pool, where string, args ...any) ([]*CorporateAction, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf(
+		"SELECT %s FROM %s %s ORDER BY action_date;",
+		corporateActionFields, CorporateActionsTable, where), args...)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*CorporateAction
+	for rows.Next() {
+		ca := CorporateAction{}
+		if err := rows.Scan(&ca.Symbol, &ca.Date, &ca.ActionType, &ca.SplitFrom, &ca.SplitTo,
+			&ca.NewSymbol, &ca.CashPerShare, &ca.BasisPercent, &ca.Source); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		actions = append(actions, &ca)
+	}
+	return actions, nil
+}
+
+// TickerGet builds the ticker for the symbol from its transactions and the corporate actions for it.
+// When an action moves shares between symbols the transactions and actions for the other symbols are
+// loaded as well, so the lots received carry their dates and cost.
+func TickerGet(ctx context.Context, pg *pgxpool.Pool, symbol string, ts *TransactionSet) (*Ticker, error) {
+	return TickerGetAsOf(ctx, pg, symbol, ts, time.Time{})
+}
+
+// TickerGetAsOf builds the ticker as TickerGet does with only the corporate actions and transactions of
+// the other symbols on or before asOf, so the transactions of the symbol should end at asOf as well. A zero
+// asOf includes all of them.
+func TickerGetAsOf(ctx context.Context, pg *pgxpool.Pool, symbol string, ts *TransactionSet, asOf time.Time) (*Ticker, error) {
+	actions, err := CorporateActionsGetBySymbol(ctx, pg, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bonds and CDs are redeemed at maturity whether or not there is a transaction for it.
+	terms, err := FixedIncomeGet(ctx, pg, symbol)
+	switch {
+	case err == nil:
+		actions = append(actions, terms.MaturityAction())
+	case !IsFixedIncomeNotFound(err):
+		return nil, err
+	}
+
+	actions = actionsAsOf(actions, asOf)
+
+	tickerSet := NewTickerSet()
+	if len(actions) > 0 {
+		seen := make(map[string]bool)
+		for _, ca := range actions {
+			seen[ca.String()] = true
+		}
+
+		loaded := map[string]bool{symbol: true}
+		rows := append([]*Transaction{}, ts.TransactionRows...)
+		for _, ca := range append([]*CorporateAction{}, actions...) {
+			for _, other := range []string{ca.Symbol, ca.NewSymbol} {
+				if other == "" || loaded[other] {
+					continue
+				}
+				loaded[other] = true
+
+				otherSet := NewTransactionSet()
+				if err := otherSet.TransactionSetFromDBbySymbol(ctx, pg, transactionTable, other); err != nil {
+					logrus.Error(err.Error())
+					return nil, err
+				}
+				rows = append(rows, transactionsAsOf(otherSet.TransactionRows, asOf)...)
+
+				otherActions, err := CorporateActionsGetBySymbol(ctx, pg, other)
+				if err != nil {
+					return nil, err
+				}
+				for _, oa := range actionsAsOf(otherActions, asOf) {
+					if !seen[oa.String()] {
+						seen[oa.String()] = true
+						actions = append(actions, oa)
+					}
+				}
+			}
+		}
+
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].Date.Before(rows[j].Date)
+		})
+		ts = &TransactionSet{TransactionRows: rows, Date: ts.Date}
+		tickerSet.SetCorporateActions(actions)
+	}
+
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	ticker, ok := tickerSet.GetTicker(symbol)
+	if !ok {
+		return ts.newTicker(symbol), nil
+	}
+	return ticker, nil
+}
+
+// actionsAsOf returns the actions on or before asOf, or all of them for a zero asOf.
+func actionsAsOf(actions []*CorporateAction, asOf time.Time) []*CorporateAction {
+	if asOf.IsZero() {
+		return actions
+	}
+	var before []*CorporateAction
+	for _, ca := range actions {
+		if !ca.Date.After(asOf) {
+			before = append(before, ca)
+		}
+	}
+	return before
+}
+
+// transactionsAsOf returns the transactions on or before asOf, or all of them for a zero asOf.
+func transactionsAsOf(rows []*Transaction, asOf time.Time) []*Transaction {
+	if asOf.IsZero() {
+		return rows
+	}
+	var before []*Transaction
+	for _, tr := range rows {
+		if !tr.Date.After(asOf) {
+			before = append(before, tr)
+		}
+	}
+	return before
+}
@@ -0,0 +1,145 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCorporateAction_Validate(t *testing.T) {
+	date := time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		ca    model.CorporateAction
+		valid bool
+	}{
+		{"split", model.CorporateAction{Symbol: "NVDA", Date: date, ActionType: model.ActionSplit, SplitFrom: 1, SplitTo: 10}, true},
+		{"split without ratio", model.CorporateAction{Symbol: "NVDA", Date: date, ActionType: model.ActionSplit}, false},
+		{"spinoff", model.CorporateAction{Symbol: "GE", Date: date, ActionType: model.ActionSpinoff, SplitFrom: 4, SplitTo: 1, NewSymbol: "GEV", BasisPercent: 0.2}, true},
+		{"spinoff bad basis", model.CorporateAction{Symbol: "GE", Date: date, ActionType: model.ActionSpinoff, SplitFrom: 4, SplitTo: 1, NewSymbol: "GEV", BasisPercent: 20}, false},
+		{"cash merger", model.CorporateAction{Symbol: "ATVI", Date: date, ActionType: model.ActionCashMerger}, false},
+		{"symbol change", model.CorporateAction{Symbol: "FB", Date: date, ActionType: model.ActionSymbolChange, NewSymbol: "META"}, true},
+		{"unknown", model.CorporateAction{Symbol: "FB", Date: date, ActionType: "rename"}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.ca.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestTickerSet_CorporateActionSplit(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "NVDA", Description: "10 shares @ 500.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-5000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC), Type: "Stock Split", Symbol: "NVDA", Description: "10 for 1 split", Account: "Fidelity IRA"},
+		{Id: 3, Date: time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "NVDA", Description: "10 shares @ 120.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-1200.00), Account: "Fidelity IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	tickerSet.SetCorporateActions([]*model.CorporateAction{
+		{Symbol: "NVDA", Date: time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC), ActionType: model.ActionSplit, SplitFrom: 1, SplitTo: 10},
+	})
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ticker, ok := tickerSet.GetTicker("NVDA")
+	if !ok {
+		t.Fatal("missing ticker")
+	}
+	// The Stock Split transaction is the same split and is not applied twice.
+	if shares := ticker.NumberOfShares(); shares != model.NewDecimal(110) {
+		t.Errorf("got %s shares, want 110", shares)
+	}
+}
+
+func TestTickerSet_CorporateActionSpinoff(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2020, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "GE", Description: "100 shares @ 100.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-10000.00), Account: "Fidelity IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	tickerSet.SetCorporateActions([]*model.CorporateAction{
+		{Symbol: "GE", Date: time.Date(2024, time.April, 2, 0, 0, 0, 0, time.UTC), ActionType: model.ActionSpinoff, SplitFrom: 4, SplitTo: 1, NewSymbol: "GEV", BasisPercent: 0.20},
+		{Symbol: "GEV", Date: time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC), ActionType: model.ActionSymbolChange, NewSymbol: "GEVX"},
+	})
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ge, _ := tickerSet.GetTicker("GE")
+	if ge.NumberOfShares() != model.NewDecimal(100) || math.Abs(ge.NetCost()-8000.00) > 0.001 {
+		t.Errorf("got GE %s shares cost %.2f, want 100 and 8000.00", ge.NumberOfShares(), ge.NetCost())
+	}
+
+	gev, _ := tickerSet.GetTicker("GEV")
+	if !gev.NumberOfShares().IsZero() {
+		t.Errorf("got GEV %s shares, want 0 after the symbol change", gev.NumberOfShares())
+	}
+
+	gevx, ok := tickerSet.GetTicker("GEVX")
+	if !ok {
+		t.Fatal("missing GEVX")
+	}
+	if gevx.NumberOfShares() != model.NewDecimal(25) || math.Abs(gevx.NetCost()-2000.00) > 0.001 {
+		t.Errorf("got GEVX %s shares cost %.2f, want 25 and 2000.00", gevx.NumberOfShares(), gevx.NetCost())
+	}
+	if !gevx.FirstBought().Equal(time.Date(2020, time.January, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected the holding period to carry over:", gevx.FirstBought())
+	}
+}
+
+func TestAccount_SplitSharesInvalid(t *testing.T) {
+	acct := model.NewAccount("Fidelity IRA")
+	acct.AddEntity(&model.Entity{Type: "Buy", Symbol: "T", Shares: model.NewDecimal(10), RemainingShares: model.NewDecimal(10), Amount: model.NewDecimal(-100.00)})
+	acct.AddEntity(&model.Entity{Type: "Stock Split", Symbol: "T", Description: "Spinoff of WBD"})
+	if acct.NumberOfShares() != model.NewDecimal(10) {
+		t.Errorf("got %s shares, want 10", acct.NumberOfShares())
+	}
+
+	acct.AddEntity(&model.Entity{Type: "Stock Split", Symbol: "T", Description: "3-for-2 split"})
+	if acct.NumberOfShares() != model.NewDecimal(15) {
+		t.Errorf("got %s shares, want 15", acct.NumberOfShares())
+	}
+}
+
+func TestAccount_ApplyCorporateActionSymbolChange(t *testing.T) {
+	acct := model.NewAccount("Fidelity IRA")
+	lot := &model.Entity{Type: "Buy", Symbol: "FB", Shares: model.NewDecimal(10), RemainingShares: model.NewDecimal(10), Amount: model.NewDecimal(-1500.00)}
+	acct.AddEntity(lot)
+
+	received := acct.ApplyCorporateAction(&model.CorporateAction{Symbol: "FB", Date: time.Date(2022, time.June, 9, 0, 0, 0, 0, time.UTC), ActionType: model.ActionSymbolChange, NewSymbol: "META"})
+
+	// A symbol change is not a sale, so no gain or loss is realized and the basis moves to the new symbol.
+	if len(lot.SoldLots) != 0 {
+		t.Errorf("got %d lots sold, want none", len(lot.SoldLots))
+	}
+	if lot.RemainingShares.Sign() != 0 || lot.NetCost().Sign() != 0 {
+		t.Errorf("got FB %s shares cost %s, want the lot closed", lot.RemainingShares, lot.NetCost())
+	}
+	if len(received) != 1 || received[0].Symbol != "META" || received[0].NetCost() != model.NewDecimal(1500.00) {
+		t.Fatalf("got %v, want 10 META shares cost 1500.00", received)
+	}
+}
+
+func TestAccount_ApplyCorporateActionStockMerger(t *testing.T) {
+	acct := model.NewAccount("Fidelity IRA")
+	lot := &model.Entity{Type: "Buy", Symbol: "PXD", Shares: model.NewDecimal(10), RemainingShares: model.NewDecimal(10), Amount: model.NewDecimal(-2000.00)}
+	acct.AddEntity(lot)
+
+	received := acct.ApplyCorporateAction(&model.CorporateAction{Symbol: "PXD", Date: time.Date(2024, time.May, 3, 0, 0, 0, 0, time.UTC),
+		ActionType: model.ActionStockMerger, SplitFrom: 1, SplitTo: 2.3234, NewSymbol: "XOM", CashPerShare: 5.00})
+
+	// Only the cash is sold, the rest of the basis moves to the new shares.
+	if len(lot.SoldLots) != 1 || lot.SoldLots[0].Proceeds() != model.NewDecimal(50.00) {
+		t.Errorf("got %v lots sold, want 50.00 of cash", lot.SoldLots)
+	}
+	if len(received) != 1 || received[0].NetCost() != model.NewDecimal(1950.00) || received[0].RemainingShares != model.NewDecimal(23.234) {
+		t.Fatalf("got %v, want 23.234 XOM shares cost 1950.00", received)
+	}
+}
@@ -0,0 +1,295 @@
+package model
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCurrency is the currency of the amounts and prices loaded without one.
+	DefaultCurrency = "USD"
+
+	FXRatesTable = "fx_rates"
+	fxRateFields = "base, quote, date, rate, source"
+
+	// fxRateColumn is the column of the rate in the files, the Close column of the files used by /historical
+	// is also read.
+	fxRateColumn = "Rate"
+)
+
+var (
+	errCurrency       = errors.New("currency must be a three letter ISO 4217 code")
+	errFXRateNotFound = errors.New("fx rate not found")
+	errFXRate         = errors.New("fx rate must be more than zero")
+)
+
+// NormalizeCurrency returns the upper case ISO 4217 code of the currency, or DefaultCurrency when it is empty.
+func NormalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+	if len(currency) != 3 {
+		return "", fmt.Errorf("%w: %s", errCurrency, currency)
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%w: %s", errCurrency, currency)
+		}
+	}
+	return currency, nil
+}
+
+// currencyOrDefault returns the currency, or DefaultCurrency when it is empty.
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+// FXRate is the price of one unit of the Base currency in the Quote currency on the date, so 1 EUR
+// is 1.08 USD for a Base of EUR and a Quote of USD.
+type FXRate struct {
+	Base   string    `json:"base"`
+	Quote  string    `json:"quote"`
+	Date   time.Time `json:"date"`
+	Rate   float64   `json:"rate"`
+	Source string    `json:"source,omitempty"`
+}
+
+// Validate checks the currencies and the rate.
+func (r *FXRate) Validate() error {
+	var err error
+	if r.Base, err = NormalizeCurrency(r.Base); err != nil {
+		return err
+	}
+	if r.Quote, err = NormalizeCurrency(r.Quote); err != nil {
+		return err
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("%w: %s%s %s", errFXRate, r.Base, r.Quote, r.Date.Format(dateToPgLayout))
+	}
+	return nil
+}
+
+// NewFXRates reads the rates of the base in the quote currency from csv with the Date and either the
+// Rate or the Close column, so the daily history of EURUSD=X can be loaded as it is downloaded.
+func NewFXRates(base, quote, source, rawData string) ([]*FXRate, error) {
+	r := csv.NewReader(strings.NewReader(rawData))
+	// This sets the reader to not base the number of fields off the first record.
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	var rates []*FXRate
+	var headers []string
+	for _, record := range records {
+		if headers == nil {
+			headers = record
+			continue
+		}
+		rate := FXRate{Base: base, Quote: quote, Source: source}
+		for i, h := range headers {
+			if i >= len(record) {
+				break
+			}
+			switch strings.TrimSpace(h) {
+			case HistoricalDate:
+				if rate.Date, err = time.Parse(dateToPgLayout, record[i]); err != nil {
+					return nil, err
+				}
+			case fxRateColumn, HistoricalClose:
+				if record[i] == "null" {
+					// Days without a rate are skipped.
+					continue
+				}
+				if rate.Rate, err = utils.FloatParse(record[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if rate.Rate == 0 {
+			continue
+		}
+		if err := rate.Validate(); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+	return rates, nil
+}
+
+// ToDB stores the rate, replacing the rate for the pair on the same date.
+func (r *FXRate) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5)"+
+			" ON CONFLICT (base, quote, date) DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source;",
+		FXRatesTable, fxRateFields)
+	if _, err := pg.Exec(ctx, insertStatement, r.Base, r.Quote, r.Date.Format(dateToPgLayout), r.Rate, r.Source); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// FXRatesLoadDB loads the rates of the base in the quote currency in rawData into fx_rates and returns
+// the number loaded.
+func FXRatesLoadDB(ctx context.Context, pg *pgxpool.Pool, base, quote, source, rawData string) (int, error) {
+	rates, err := NewFXRates(base, quote, source, rawData)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range rates {
+		if err := r.ToDB(ctx, pg); err != nil {
+			return 0, err
+		}
+	}
+	logrus.Info("Loaded ", len(rates), " ", base, quote, " rates")
+	return len(rates), nil
+}
+
+// FXRateSet is the history of the rates of each pair used to convert amounts between currencies.
+type FXRateSet struct {
+	rates map[string][]*FXRate
+}
+
+func fxPair(base, quote string) string {
+	return base + quote
+}
+
+// NewFXRateSet returns the set of the rates.
+func NewFXRateSet(rates []*FXRate) *FXRateSet {
+	s := FXRateSet{rates: make(map[string][]*FXRate)}
+	for _, r := range rates {
+		pair := fxPair(r.Base, r.Quote)
+		s.rates[pair] = append(s.rates[pair], r)
+	}
+	for _, history := range s.rates {
+		sort.Slice(history, func(i, j int) bool {
+			return history[i].Date.Before(history[j].Date)
+		})
+	}
+	return &s
+}
+
+// FXRateSetGet returns the rates with any of the currencies as the base or the quote, or every rate
+// without currencies.
+func FXRateSetGet(ctx context.Context, pg *pgxpool.Pool, currencies ...string) (*FXRateSet, error) {
+	where := ""
+	var args []any
+	if len(currencies) > 0 {
+		where = "WHERE base = ANY($1) OR quote = ANY($1)"
+		args = append(args, currencies)
+	}
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s %s ORDER BY base, quote, date;", fxRateFields, FXRatesTable, where), args...)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*FXRate
+	for rows.Next() {
+		r := FXRate{}
+		if err := rows.Scan(&r.Base, &r.Quote, &r.Date, &r.Rate, &r.Source); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		rates = append(rates, &r)
+	}
+	return NewFXRateSet(rates), nil
+}
+
+// last returns the last rate of the pair on or before the date.
+func (s *FXRateSet) last(base, quote string, date time.Time) (float64, bool) {
+	history := s.rates[fxPair(base, quote)]
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].Date.After(date)
+	})
+	if i == 0 {
+		return 0.00, false
+	}
+	return history[i-1].Rate, true
+}
+
+// direct returns the rate from the pair or the inverse of the reversed pair.
+func (s *FXRateSet) direct(from, to string, date time.Time) (float64, bool) {
+	if rate, ok := s.last(from, to, date); ok {
+		return rate, true
+	}
+	if rate, ok := s.last(to, from, date); ok {
+		return 1 / rate, true
+	}
+	return 0.00, false
+}
+
+// Rate returns the units of to for one unit of from using the last rate on or before the date. A pair
+// without rates is crossed through DefaultCurrency.
+func (s *FXRateSet) Rate(from, to string, date time.Time) (float64, error) {
+	from, to = currencyOrDefault(from), currencyOrDefault(to)
+	if from == to {
+		return 1.00, nil
+	}
+	if s != nil {
+		if rate, ok := s.direct(from, to, date); ok {
+			return rate, nil
+		}
+		if from != DefaultCurrency && to != DefaultCurrency {
+			fromRate, fromOK := s.direct(from, DefaultCurrency, date)
+			toRate, toOK := s.direct(DefaultCurrency, to, date)
+			if fromOK && toOK {
+				return fromRate * toRate, nil
+			}
+		}
+	}
+	return 0.00, fmt.Errorf("%w: %s%s on %s", errFXRateNotFound, from, to, date.Format(dateToPgLayout))
+}
+
+// Convert returns the amount in from as an amount in to on the date.
+func (s *FXRateSet) Convert(amount float64, from, to string, date time.Time) (float64, error) {
+	rate, err := s.Rate(from, to, date)
+	if err != nil {
+		return 0.00, err
+	}
+	return amount * rate, nil
+}
+
+// IsFXRateNotFound returns true when the error is from a missing rate.
+func IsFXRateNotFound(err error) bool {
+	return errors.Is(err, errFXRateNotFound)
+}
+
+// Converted returns the sum of the amount of each Entity of the ticker converted from the currency of the
+// Entity to the currency at the rate on the date of the Entity.
+func (t *Ticker) Converted(amount func(e *Entity) Decimal, rates *FXRateSet, currency string) (float64, error) {
+	total := 0.00
+	for _, acct := range t.Accounts {
+		for _, e := range acct.Entities {
+			value := amount(e).Float64()
+			if value == 0 {
+				continue
+			}
+			converted, err := rates.Convert(value, e.Currency, currency, e.Date)
+			if err != nil {
+				return 0.00, err
+			}
+			total += converted
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,136 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     string
+		valid    bool
+	}{
+		{"", model.DefaultCurrency, true},
+		{"eur", "EUR", true},
+		{" GBP ", "GBP", true},
+		{"EURO", "", false},
+		{"U$D", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := model.NormalizeCurrency(tt.currency)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("%q: got %q %v, want %q valid %v", tt.currency, got, err, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestNewFXRates(t *testing.T) {
+	const rawData = "Date,Open,High,Low,Close,Adj Close,Volume\n" +
+		"2024-01-02,1.1037,1.1046,1.0937,1.1037,1.1037,0\n" +
+		"2024-01-03,null,null,null,null,null,null\n" +
+		"2024-01-04,1.0924,1.0965,1.0914,1.0924,1.0924,0\n"
+
+	rates, err := model.NewFXRates("eur", "usd", "yahoo", rawData)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(rates) != 2 {
+		t.Fatalf("got %d rates, want 2", len(rates))
+	}
+	if rates[0].Base != "EUR" || rates[0].Quote != "USD" || rates[0].Rate != 1.1037 {
+		t.Errorf("unexpected rate %+v", rates[0])
+	}
+
+	if _, err := model.NewFXRates("EUR", "USD", "csv", "Date,Rate\n2024-01-02,-1\n"); err == nil {
+		t.Error("expected an error for a negative rate")
+	}
+}
+
+func TestFXRateSet_Rate(t *testing.T) {
+	jan := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	rates := model.NewFXRateSet([]*model.FXRate{
+		{Base: "EUR", Quote: "USD", Date: feb, Rate: 1.08},
+		{Base: "EUR", Quote: "USD", Date: jan, Rate: 1.10},
+		{Base: "GBP", Quote: "USD", Date: jan, Rate: 1.25},
+	})
+
+	tests := []struct {
+		from, to string
+		date     time.Time
+		want     float64
+		found    bool
+	}{
+		{"USD", "USD", jan, 1.00, true},
+		{"EUR", "USD", jan, 1.10, true},
+		{"EUR", "USD", jan.AddDate(0, 0, 10), 1.10, true}, // last rate on or before
+		{"EUR", "USD", feb.AddDate(0, 0, 1), 1.08, true},
+		{"USD", "EUR", jan, 1 / 1.10, true},    // inverse
+		{"GBP", "EUR", jan, 1.25 / 1.10, true}, // crossed through USD
+		{"EUR", "USD", jan.AddDate(0, 0, -1), 0, false},
+		{"JPY", "USD", jan, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := rates.Rate(tt.from, tt.to, tt.date)
+		if (err == nil) != tt.found {
+			t.Errorf("%s%s %s: got %v, want found %v", tt.from, tt.to, tt.date.Format("2006-01-02"), err, tt.found)
+			continue
+		}
+		if !tt.found {
+			if !model.IsFXRateNotFound(err) {
+				t.Errorf("%s%s: got %v, want not found", tt.from, tt.to, err)
+			}
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.000001 {
+			t.Errorf("%s%s %s: got %.6f, want %.6f", tt.from, tt.to, tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestTicker_Converted(t *testing.T) {
+	jan := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: jan, Type: "Buy", Symbol: "SAP", Description: "10 shares @ 100.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-1000.00), Account: "Fidelity IRA", Currency: "EUR"},
+		{Id: 2, Date: feb, Type: "Buy", Symbol: "SAP", Description: "10 shares @ 110.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-1100.00), Account: "Fidelity IRA", Currency: "EUR"},
+		{Id: 3, Date: feb, Type: "Dividend Income", Symbol: "SAP", Amount: model.NewDecimal(50.00), Account: "Fidelity IRA", Currency: "EUR"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	ticker, _ := tickerSet.GetTicker("SAP")
+
+	rates := model.NewFXRateSet([]*model.FXRate{
+		{Base: "EUR", Quote: "USD", Date: jan, Rate: 1.10},
+		{Base: "EUR", Quote: "USD", Date: feb, Rate: 1.08},
+	})
+
+	cost, err := ticker.Converted((*model.Entity).NetCost, rates, "USD")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if want := 1000.00*1.10 + 1100.00*1.08; math.Abs(cost-want) > 0.001 {
+		t.Errorf("got cost %.2f, want %.2f", cost, want)
+	}
+
+	dividends, err := ticker.Converted((*model.Entity).DividendsPaid, rates, "USD")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if want := 50.00 * 1.08; math.Abs(dividends-want) > 0.001 {
+		t.Errorf("got dividends %.2f, want %.2f", dividends, want)
+	}
+
+	if _, err := ticker.Converted((*model.Entity).NetCost, rates, "JPY"); !model.IsFXRateNotFound(err) {
+		t.Errorf("got %v, want the rate not found", err)
+	}
+}
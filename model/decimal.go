@@ -0,0 +1,223 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is a fixed-point number with six decimal places used for the shares and amounts of the lots, so
+// the sums of many reinvestments do not drift as float64 sums do. The rounding rules are:
+//   - values read from text, NUMERIC or float64 are rounded half away from zero to six places,
+//   - products and quotients are rounded half away from zero to six places,
+//   - an amount of money computed from shares and a price is rounded to the cent with RoundCents.
+type Decimal struct {
+	units int64 // millionths
+}
+
+const (
+	decimalPlaces = 6
+	decimalScale  = 1000000
+)
+
+var (
+	errDecimal = errors.New("invalid decimal")
+)
+
+// NewDecimal returns the float rounded half away from zero to six places.
+func NewDecimal(f float64) Decimal {
+	return Decimal{units: int64(math.Round(f * decimalScale))}
+}
+
+// ParseDecimal reads the number in the text exactly, ignoring the commas and the dollar, pound and percent
+// signs as utils.FloatParse does, and returns zero for an empty or N/A value.
+func ParseDecimal(s string) (Decimal, error) {
+	t := strings.NewReplacer(",", "", "$", "", "#", "", "%", "").Replace(strings.TrimSpace(s))
+	switch t {
+	case "", "N/A", "Add":
+		return Decimal{}, nil
+	}
+
+	r, ok := new(big.Rat).SetString(t)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %s", errDecimal, s)
+	}
+	n := new(big.Int).Mul(r.Num(), big.NewInt(decimalScale))
+	return Decimal{units: divRound(n, r.Denom())}, nil
+}
+
+// divRound returns n / d rounded half away from zero.
+func divRound(n, d *big.Int) int64 {
+	q, m := new(big.Int).QuoRem(n, d, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(new(big.Int).Abs(d)) >= 0 {
+		if n.Sign()*d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// Float64 returns the nearest float.
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / decimalScale
+}
+
+// Add returns d + o.
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{units: d.units + o.units}
+}
+
+// Sub returns d - o.
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{units: d.units - o.units}
+}
+
+// Mul returns d * o rounded to six places.
+func (d Decimal) Mul(o Decimal) Decimal {
+	n := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(o.units))
+	return Decimal{units: divRound(n, big.NewInt(decimalScale))}
+}
+
+// Div returns d / o rounded to six places, or zero when o is zero.
+func (d Decimal) Div(o Decimal) Decimal {
+	if o.units == 0 {
+		return Decimal{}
+	}
+	n := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(decimalScale))
+	return Decimal{units: divRound(n, big.NewInt(o.units))}
+}
+
+// Round returns d rounded half away from zero to the places.
+func (d Decimal) Round(places int) Decimal {
+	if places >= decimalPlaces {
+		return d
+	}
+	unit := int64(math.Pow10(decimalPlaces - places))
+	return Decimal{units: divRound(big.NewInt(d.units), big.NewInt(unit)) * unit}
+}
+
+// RoundCents returns d rounded half away from zero to the cent.
+func (d Decimal) RoundCents() Decimal {
+	return d.Round(2)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units}
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	if d.units < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Sign returns -1, 0 or +1 for a negative, zero or positive d.
+func (d Decimal) Sign() int {
+	switch {
+	case d.units < 0:
+		return -1
+	case d.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero returns true when d is zero.
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// Cmp returns -1, 0 or +1 when d is less than, equal to or more than o.
+func (d Decimal) Cmp(o Decimal) int {
+	return d.Sub(o).Sign()
+}
+
+// MinDecimal returns the lesser of a and b.
+func MinDecimal(a, b Decimal) Decimal {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
+
+// MaxDecimal returns the greater of a and b.
+func MaxDecimal(a, b Decimal) Decimal {
+	if a.Cmp(b) > 0 {
+		return a
+	}
+	return b
+}
+
+// String returns d without trailing zeros, 12.5 or -3.
+func (d Decimal) String() string {
+	sign := ""
+	units := d.units
+	if units < 0 {
+		sign, units = "-", -units
+	}
+	s := fmt.Sprintf("%s%d.%06d", sign, units/decimalScale, units%decimalScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON writes d as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads d from a JSON number or string.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	value, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
+
+// Scan reads d from a NUMERIC column, which pgx passes as text, or from a float or integer column.
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+	case string:
+		value, err := ParseDecimal(v)
+		if err != nil {
+			return err
+		}
+		*d = value
+	case []byte:
+		return d.Scan(string(v))
+	case float64:
+		*d = NewDecimal(v)
+	case float32:
+		*d = NewDecimal(float64(v))
+	case int64:
+		*d = Decimal{units: v * decimalScale}
+	default:
+		return fmt.Errorf("%w: cannot scan %T", errDecimal, src)
+	}
+	return nil
+}
+
+// Value writes d to a NUMERIC column as text so it is stored exactly.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,208 @@
+package model_test
+
+import (
+	"encoding/json"
+	"github.com/kpearce2430/stock-tools/model"
+	"testing"
+	"time"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		valid bool
+	}{
+		{"12.5", "12.5", true},
+		{"-1,234.56", "-1234.56", true},
+		{"$0.10", "0.1", true},
+		{"0.0000005", "0.000001", true}, // half away from zero
+		{"-0.0000005", "-0.000001", true},
+		{"0.00000049", "0", true},
+		{"", "0", true},
+		{"N/A", "0", true},
+		{"abc", "0", false},
+	}
+
+	for _, tt := range tests {
+		got, err := model.ParseDecimal(tt.value)
+		if (err == nil) != tt.valid || got.String() != tt.want {
+			t.Errorf("%q: got %s %v, want %s valid %v", tt.value, got, err, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	// Ten reinvestments of 0.1 shares are one share, which a float64 sum is not.
+	total := model.Decimal{}
+	for i := 0; i < 10; i++ {
+		total = total.Add(model.NewDecimal(0.1))
+	}
+	if total != model.NewDecimal(1) {
+		t.Errorf("got %s, want 1", total)
+	}
+
+	tests := []struct {
+		name string
+		got  model.Decimal
+		want string
+	}{
+		{"mul", model.NewDecimal(3.333333).Mul(model.NewDecimal(3)), "9.999999"},
+		{"mul rounds", model.NewDecimal(0.000001).Mul(model.NewDecimal(0.5)), "0.000001"},
+		{"div", model.NewDecimal(100).Div(model.NewDecimal(3)), "33.333333"},
+		{"div by zero", model.NewDecimal(100).Div(model.Decimal{}), "0"},
+		{"cents", model.NewDecimal(10.005).RoundCents(), "10.01"},
+		{"negative cents", model.NewDecimal(-10.005).RoundCents(), "-10.01"},
+		{"cents down", model.NewDecimal(10.004999).RoundCents(), "10"},
+		{"round", model.NewDecimal(1.23456).Round(3), "1.235"},
+		{"abs", model.NewDecimal(-2.5).Abs(), "2.5"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecimal_Encoding(t *testing.T) {
+	d, _ := model.ParseDecimal("1234.567891")
+
+	b, err := json.Marshal(struct {
+		Shares model.Decimal `json:"shares"`
+	}{d})
+	if err != nil || string(b) != `{"shares":1234.567891}` {
+		t.Errorf("got %s %v", b, err)
+	}
+
+	for _, data := range []string{`1234.567891`, `"1234.567891"`} {
+		var got model.Decimal
+		if err := json.Unmarshal([]byte(data), &got); err != nil || got != d {
+			t.Errorf("%s: got %s %v, want %s", data, got, err, d)
+		}
+	}
+
+	// NUMERIC is written as text and read back as text without a loss.
+	value, err := d.Value()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var got model.Decimal
+	if err := got.Scan(value); err != nil || got != d {
+		t.Errorf("got %s %v, want %s", got, err, d)
+	}
+	if err := got.Scan([]byte("-0.5")); err != nil || got != model.NewDecimal(-0.5) {
+		t.Errorf("got %s %v, want -0.5", got, err)
+	}
+	if err := got.Scan(true); err == nil {
+		t.Error("expected an error scanning a bool")
+	}
+}
+
+// TestDecimal_Transactions replays the transaction exports through the lot engine and checks the shares
+// of each account exactly. The funds sold after years of fractional reinvestments must have no shares left,
+// and the cost of each account must be in whole cents.
+func TestDecimal_Transactions(t *testing.T) {
+	type position struct {
+		symbol  string
+		account string
+	}
+	tests := []struct {
+		name   string
+		data   []byte
+		shares map[position]string
+	}{
+		{"aapl", applTransactions, map[position]string{
+			{"AAPL", "Ameritrade IRA"}: "400",
+		}},
+		{"msft", msftTransactions, map[position]string{
+			{"MSFT", "Schwab Rollover IRA Keith"}:    "100",
+			{"MSFT", "Schwab Contributory IRA Jane"}: "100",
+		}},
+		{"usaix", usaixTransactions, map[position]string{
+			{"USAIX", "Schwab Rollover IRA Keith"}:    "6187.133",
+			{"USAIX", "Schwab Contributory IRA Jane"}: "1978.673",
+		}},
+		{"all", testTransactionsAll, map[position]string{
+			{"FCNTX", "Fidelity IRA"}:   "9799.701",
+			{"FAGIX", "Fidelity IRA"}:   "11629.318",
+			{"FMSDX", "Fidelity IRA"}:   "786.287",
+			{"NBGEX", "Fidelity IRA"}:   "1775.842",
+			{"USAIX", "Ameritrade IRA"}: "6169.648",
+			{"USAIX", "Jane IRA"}:       "1973.081",
+			{"USNQX", "Jane IRA"}:       "1055.978",
+			{"USNQX", "Ameritrade IRA"}: "825.975",
+			{"JENSX", "Ameritrade IRA"}: "242.661",
+			{"SVSPX", "Jane IRA"}:       "108.7115",
+			{"HD", "HD ESPP"}:           "224.507164",
+			{"FBNDX", "Fidelity IRA"}:   "0",
+			{"FTBFX", "Fidelity IRA"}:   "0",
+			{"FCBFX", "Fidelity IRA"}:   "0",
+			{"ORNAX", "Ameritrade IRA"}: "0",
+		}},
+	}
+
+	for _, tt := range tests {
+		ts := model.NewTransactionSet()
+		if err := ts.Load(tt.data); err != nil {
+			t.Fatal(tt.name, err.Error())
+		}
+		tickerSet := model.NewTickerSet()
+		if err := tickerSet.LoadTickerSet(ts); err != nil {
+			t.Fatal(tt.name, err.Error())
+		}
+
+		for p, want := range tt.shares {
+			ticker, ok := tickerSet.GetTicker(p.symbol)
+			if !ok {
+				t.Errorf("%s: missing ticker %s", tt.name, p.symbol)
+				continue
+			}
+			acct := ticker.GetAccount(p.account)
+			if acct == nil {
+				t.Errorf("%s: missing account %s of %s", tt.name, p.account, p.symbol)
+				continue
+			}
+			if got := acct.NumberOfShares().String(); got != want {
+				t.Errorf("%s %s %s: got %s shares, want %s", tt.name, p.symbol, p.account, got, want)
+			}
+		}
+
+		for symbol, ticker := range tickerSet.Set {
+			for _, acct := range ticker.Accounts {
+				if cost := acct.NetCost(); cost != cost.RoundCents() {
+					t.Errorf("%s %s %s: net cost %s is not in cents", tt.name, symbol, acct.Name, cost)
+				}
+			}
+		}
+	}
+}
+
+// TestDecimal_Reinvestments checks that a sale of the shares of a buy and its reinvestments leaves no
+// fraction of a share behind, and that a partial sale leaves the exact difference.
+func TestDecimal_Reinvestments(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2023, month, d, 0, 0, 0, 0, time.UTC) }
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: day(time.January, 5), Type: "Buy", Symbol: "CSX", Description: "10 shares @ 30.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-300.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: day(time.March, 15), Type: "Reinvest Dividend", Symbol: "CSX", Description: "0.1234 shares @ 30.01", Shares: model.NewDecimal(0.1234), Amount: model.NewDecimal(-3.70), Account: "Fidelity IRA"},
+		{Id: 3, Date: day(time.June, 15), Type: "Reinvest Dividend", Symbol: "CSX", Description: "0.3333 shares @ 30.00", Shares: model.NewDecimal(0.3333), Amount: model.NewDecimal(-10.00), Account: "Fidelity IRA"},
+		{Id: 4, Date: day(time.September, 15), Type: "Reinvest Dividend", Symbol: "CSX", Description: "0.3333 shares @ 30.03", Shares: model.NewDecimal(0.3333), Amount: model.NewDecimal(-10.01), Account: "Fidelity IRA"},
+		{Id: 5, Date: day(time.October, 2), Type: "Sell", Symbol: "CSX", Description: "10.79 shares @ 32.00", Shares: model.NewDecimal(-10.79), Amount: model.NewDecimal(345.28), Account: "Fidelity IRA"},
+		{Id: 6, Date: day(time.January, 5), Type: "Buy", Symbol: "FCNTX", Description: "3.333 shares @ 30.003", Shares: model.NewDecimal(3.333), Amount: model.NewDecimal(-100.00), Account: "Jane IRA"},
+		{Id: 7, Date: day(time.May, 1), Type: "Sell", Symbol: "FCNTX", Description: "1.111 shares @ 31.50", Shares: model.NewDecimal(-1.111), Amount: model.NewDecimal(35.00), Account: "Jane IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	csx, _ := tickerSet.GetTicker("CSX")
+	if shares := csx.NumberOfShares(); !shares.IsZero() {
+		t.Errorf("got %s CSX shares, want 0", shares)
+	}
+	fcntx, _ := tickerSet.GetTicker("FCNTX")
+	if shares := fcntx.NumberOfShares(); shares != model.NewDecimal(2.222) {
+		t.Errorf("got %s FCNTX shares, want 2.222", shares)
+	}
+}
@@ -0,0 +1,276 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+const (
+	dividendsTable      = "dividends"
+	dividendTypeSpecial = "SC"
+)
+
+// DividendCalendarEntry is the dividend expected for a symbol in an account on a pay date.
+// Confirmed entries come from declared dividends, the others are estimates.
+type DividendCalendarEntry struct {
+	Symbol         string    `json:"symbol"`
+	Account        string    `json:"account"`
+	Year           int       `json:"year"`
+	Month          int       `json:"month"`
+	ExDividendDate time.Time `json:"exDividendDate,omitempty"`
+	PayDate        time.Time `json:"payDate"`
+	CashAmount     float64   `json:"cashAmount"`
+	Shares         float64   `json:"shares"`
+	Amount         float64   `json:"amount"`
+	Confirmed      bool      `json:"confirmed"`
+}
+
+// DividendCalendarMonth is the total expected income for a month.
+type DividendCalendarMonth struct {
+	Year      int     `json:"year"`
+	Month     int     `json:"month"`
+	Confirmed float64 `json:"confirmed"`
+	Estimated float64 `json:"estimated"`
+	Total     float64 `json:"total"`
+}
+
+// DividendCalendar is the expected dividend income for the Months starting with the month of Start.
+type DividendCalendar struct {
+	Start   time.Time                `json:"start"`
+	Months  int                      `json:"months"`
+	Totals  []*DividendCalendarMonth `json:"totals,omitempty"`
+	Entries []*DividendCalendarEntry `json:"entries"`
+}
+
+// NewDividendCalendar creates a calendar starting on the first of the month of start.
+func NewDividendCalendar(start time.Time, months int) *DividendCalendar {
+	return &DividendCalendar{
+		Start:  time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC),
+		Months: months,
+	}
+}
+
+func (c *DividendCalendar) String() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// End returns the first day after the calendar.
+func (c *DividendCalendar) End() time.Time {
+	return c.Start.AddDate(0, c.Months, 0)
+}
+
+func (c *DividendCalendar) inWindow(t time.Time) bool {
+	return !t.Before(c.Start) && t.Before(c.End())
+}
+
+func (c *DividendCalendar) addEntry(symbol, account string, payDate, exDate time.Time, cashAmount, shares, amount float64, confirmed bool) {
+	c.Entries = append(c.Entries, &DividendCalendarEntry{
+		Symbol:         symbol,
+		Account:        account,
+		Year:           payDate.Year(),
+		Month:          int(payDate.Month()),
+		ExDividendDate: exDate,
+		PayDate:        payDate,
+		CashAmount:     cashAmount,
+		Shares:         shares,
+		Amount:         amount,
+		Confirmed:      confirmed,
+	})
+}
+
+// AddDeclared adds the declared dividends paid in the calendar as confirmed and projects the latest
+// regular dividend forward by its frequency as estimates. It returns false when there is no recent
+// regular dividend to project from.
+func (c *DividendCalendar) AddDeclared(symbol string, shares map[string]float64, ds *DividendsSet) bool {
+	var latest *Dividends
+	for i := range ds.Dividends {
+		d := &ds.Dividends[i]
+		if c.inWindow(d.PayDate.Time) {
+			for account, numShares := range shares {
+				c.addEntry(symbol, account, d.PayDate.Time, d.ExDividendDate.Time, d.CashAmount, numShares, d.CashAmount*numShares, true)
+			}
+		}
+
+		if d.DividendType == dividendTypeSpecial || d.Frequency <= 0 {
+			continue
+		}
+		if latest == nil || d.PayDate.After(latest.PayDate.Time) {
+			latest = d
+		}
+	}
+
+	// Nothing declared in the last year, the dividend may have been suspended or the table is stale.
+	if latest == nil || latest.PayDate.Before(c.Start.AddDate(-1, 0, 0)) {
+		return false
+	}
+
+	interval := 12 / latest.Frequency
+	if interval < 1 {
+		interval = 1
+	}
+
+	for k := 1; ; k++ {
+		payDate := addMonths(latest.PayDate.Time, interval*k)
+		if !payDate.Before(c.End()) {
+			break
+		}
+		if !c.inWindow(payDate) {
+			continue
+		}
+		exDate := addMonths(latest.ExDividendDate.Time, interval*k)
+		for account, numShares := range shares {
+			c.addEntry(symbol, account, payDate, exDate, latest.CashAmount, numShares, latest.CashAmount*numShares, false)
+		}
+	}
+	return true
+}
+
+// AddHistory estimates the dividends for the symbol by repeating the dividends received in the year
+// before the calendar, scaled from the shares held then to the shares held now. The transactions must
+// be all the transactions for the symbol in date order.
+func (c *DividendCalendar) AddHistory(symbol string, shares map[string]float64, ts *TransactionSet) error {
+	lookBack := c.Start.AddDate(-1, 0, 0)
+	ticker := ts.newTicker(symbol)
+	for _, tr := range ts.TransactionRows {
+		en, err := NewEntityFromTransaction(tr)
+		if err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+
+		paid := en.DividendIncome().Float64()
+		current := shares[en.Account]
+		if paid > 0 && current > 0 && !en.Date.Before(lookBack) && en.Date.Before(c.Start) {
+			held := 0.00
+			if acct, ok := ticker.Accounts[en.Account]; ok {
+				held = acct.NumberOfShares().Float64()
+			}
+
+			amount := paid
+			cashAmount := 0.00
+			if held > 0 {
+				cashAmount = paid / held
+				amount = cashAmount * current
+			}
+			c.addEntry(symbol, en.Account, addMonths(en.Date, 12), time.Time{}, cashAmount, current, amount, false)
+		}
+		ticker.AddEntity(en)
+	}
+	return nil
+}
+
+// Sort orders the entries by pay date, symbol and account and computes the monthly totals.
+func (c *DividendCalendar) Sort() {
+	sort.SliceStable(c.Entries, func(i, j int) bool {
+		a, b := c.Entries[i], c.Entries[j]
+		switch {
+		case !a.PayDate.Equal(b.PayDate):
+			return a.PayDate.Before(b.PayDate)
+		case a.Symbol != b.Symbol:
+			return a.Symbol < b.Symbol
+		}
+		return a.Account < b.Account
+	})
+	c.Totals = c.MonthTotals()
+}
+
+// MonthTotals returns the confirmed and estimated totals for each month of the calendar.
+func (c *DividendCalendar) MonthTotals() []*DividendCalendarMonth {
+	var totals []*DividendCalendarMonth
+	for m := 0; m < c.Months; m++ {
+		date := c.Start.AddDate(0, m, 0)
+		totals = append(totals, &DividendCalendarMonth{Year: date.Year(), Month: int(date.Month())})
+	}
+
+	for _, e := range c.Entries {
+		m := (e.Year-c.Start.Year())*12 + e.Month - int(c.Start.Month())
+		if m < 0 || m >= len(totals) {
+			continue
+		}
+		if e.Confirmed {
+			totals[m].Confirmed += e.Amount
+		} else {
+			totals[m].Estimated += e.Amount
+		}
+		totals[m].Total += e.Amount
+	}
+	return totals
+}
+
+// Symbols returns the sorted symbols in the calendar.
+func (c *DividendCalendar) Symbols() []string {
+	found := make(map[string]bool)
+	var symbols []string
+	for _, e := range c.Entries {
+		if !found[e.Symbol] {
+			found[e.Symbol] = true
+			symbols = append(symbols, e.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// DividendCalendarGet projects the dividend income for the symbol, or every symbol held when symbol is empty,
+// from the shares held on asOf, or the shares held now for a zero asOf.
+func DividendCalendarGet(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet, symbol string, start time.Time, months int, asOf time.Time) (*DividendCalendar, error) {
+	c := NewDividendCalendar(start, months)
+
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbolList, err := SymbolList(ctx, pgxConn, lookups)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		symbols = nil
+		for s := range symbolList {
+			if s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+	}
+
+	for _, s := range symbols {
+		ts := NewTransactionSet()
+		if err := ts.TransactionSetFromDBbySymbol(ctx, pgxConn, transactionTable, s); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		ts.TransactionRows = transactionsAsOf(ts.TransactionRows, asOf)
+		ticker, err := TickerGetAsOf(ctx, pgxConn, s, ts, asOf)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+
+		shares := ticker.AccountShares()
+		if len(shares) == 0 {
+			continue
+		}
+
+		var ds DividendsSet
+		if err := ds.FromDBbySymbol(ctx, pgxConn, dividendsTable, s); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+
+		if c.AddDeclared(s, shares, &ds) {
+			continue
+		}
+		if err := c.AddHistory(s, shares, ts); err != nil {
+			return nil, err
+		}
+	}
+
+	c.Sort()
+	return c, nil
+}
@@ -0,0 +1,136 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func stockTime(year int, month time.Month, day int) model.StockTime {
+	return model.StockTime{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestDividendCalendar_AddDeclared(t *testing.T) {
+	start := time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC)
+	c := model.NewDividendCalendar(start, 12)
+
+	ds := model.NewDividendsSet([]model.Dividends{
+		{Ticker: "CSX", CashAmount: 0.12, DividendType: "CD", Frequency: 4, ExDividendDate: stockTime(2024, time.May, 31), PayDate: stockTime(2024, time.June, 14)},
+		{Ticker: "CSX", CashAmount: 0.11, DividendType: "CD", Frequency: 4, ExDividendDate: stockTime(2024, time.February, 28), PayDate: stockTime(2024, time.March, 15)},
+	})
+	shares := map[string]float64{"Fidelity IRA": 100, "HD ML Individual Account": 50}
+
+	if !c.AddDeclared("CSX", shares, &ds) {
+		t.Fatal("expected the declared dividends to be used")
+	}
+	c.Sort()
+	t.Log(c.String())
+
+	confirmed := 0
+	estimated := 0
+	for _, e := range c.Entries {
+		if e.Confirmed {
+			confirmed++
+			if e.Month != 6 {
+				t.Error("unexpected confirmed month:", e.Month)
+			}
+			continue
+		}
+		estimated++
+		if e.CashAmount != 0.12 {
+			t.Error("expected the estimate to use the latest dividend:", e.CashAmount)
+		}
+	}
+
+	// June is confirmed, September, December and March are estimated for both accounts.
+	if confirmed != 2 || estimated != 6 {
+		t.Errorf("got %d confirmed, %d estimated, want 2 and 6", confirmed, estimated)
+	}
+
+	totals := c.MonthTotals()
+	if len(totals) != 12 {
+		t.Fatalf("got %d months, want 12", len(totals))
+	}
+	if math.Abs(totals[0].Confirmed-18.00) > 0.001 {
+		t.Errorf("got June confirmed %.2f, want 18.00", totals[0].Confirmed)
+	}
+	if math.Abs(totals[3].Estimated-18.00) > 0.001 {
+		t.Errorf("got September estimated %.2f, want 18.00", totals[3].Estimated)
+	}
+}
+
+func TestDividendCalendar_AddDeclaredMonthEnd(t *testing.T) {
+	c := model.NewDividendCalendar(time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC), 12)
+	ds := model.NewDividendsSet([]model.Dividends{
+		{Ticker: "O", CashAmount: 0.75, DividendType: "CD", Frequency: 4, ExDividendDate: stockTime(2024, time.July, 31), PayDate: stockTime(2024, time.August, 31)},
+	})
+	if !c.AddDeclared("O", map[string]float64{"Fidelity IRA": 10}, &ds) {
+		t.Fatal("expected the declared dividends to be used")
+	}
+	c.Sort()
+
+	// The dividends paid on the 31st are estimated on the last day of the shorter months.
+	want := []struct{ pay, ex time.Time }{
+		{time.Date(2024, time.November, 30, 0, 0, 0, 0, time.UTC), time.Date(2024, time.October, 31, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC), time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, time.May, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, time.July, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(c.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %s", len(c.Entries), len(want), c.String())
+	}
+	for i, e := range c.Entries {
+		if !e.PayDate.Equal(want[i].pay) || !e.ExDividendDate.Equal(want[i].ex) {
+			t.Errorf("got pay %s ex %s, want pay %s ex %s", e.PayDate.Format(time.DateOnly), e.ExDividendDate.Format(time.DateOnly),
+				want[i].pay.Format(time.DateOnly), want[i].ex.Format(time.DateOnly))
+		}
+	}
+
+	for i, month := range c.MonthTotals() {
+		estimated := 0.00
+		switch month.Month {
+		case 11, 2, 5, 8:
+			estimated = 7.50
+		}
+		if math.Abs(month.Estimated-estimated) > 0.001 {
+			t.Errorf("month %d: got estimated %.2f, want %.2f", i, month.Estimated, estimated)
+		}
+	}
+}
+
+func TestDividendCalendar_AddDeclaredStale(t *testing.T) {
+	c := model.NewDividendCalendar(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), 12)
+	ds := model.NewDividendsSet([]model.Dividends{
+		{Ticker: "T", CashAmount: 0.52, DividendType: "CD", Frequency: 4, PayDate: stockTime(2022, time.February, 1)},
+	})
+	if c.AddDeclared("T", map[string]float64{"Fidelity IRA": 10}, &ds) {
+		t.Error("expected a stale declaration to be ignored")
+	}
+}
+
+func TestDividendCalendar_AddHistory(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "FCNTX", Description: "100 shares @ 10.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-1000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2023, time.December, 15, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "FCNTX", Amount: model.NewDecimal(50.00), Account: "Fidelity IRA"},
+		{Id: 3, Date: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "FCNTX", Description: "100 shares @ 10.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-1000.00), Account: "Fidelity IRA"},
+	}
+
+	c := model.NewDividendCalendar(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), 12)
+	if err := c.AddHistory("FCNTX", map[string]float64{"Fidelity IRA": 200}, ts); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(c.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(c.Entries))
+	}
+	e := c.Entries[0]
+	if e.Confirmed || e.Year != 2024 || e.Month != 12 {
+		t.Error("unexpected entry:", e)
+	}
+	// 0.50 a share on 100 shares then, 200 shares now.
+	if math.Abs(e.Amount-100.00) > 0.001 {
+		t.Errorf("got %.2f, want 100.00", e.Amount)
+	}
+}
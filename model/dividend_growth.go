@@ -0,0 +1,209 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"time"
+)
+
+// DividendGrowthPeriods are the number of years the dividend growth rates are computed for.
+var DividendGrowthPeriods = []int{1, 3, 5, 10}
+
+// DividendYear is the total regular dividend per share with an ex-dividend date in the year.
+type DividendYear struct {
+	Year   int     `json:"year"`
+	Amount float64 `json:"amount"`
+}
+
+// DividendCut is a regular dividend that was lower than the one before it.
+type DividendCut struct {
+	ExDividendDate time.Time `json:"exDividendDate"`
+	Previous       float64   `json:"previous"`
+	Current        float64   `json:"current"`
+}
+
+// DividendGrowth is the dividend history of a symbol with the growth rates, cuts and yields.
+// Growth maps the number of years to the compound annual growth rate ending with the last full year,
+// and only has the periods there is enough history for.
+type DividendGrowth struct {
+	Symbol              string          `json:"symbol"`
+	Years               []*DividendYear `json:"years"`
+	Growth              map[int]float64 `json:"growth"`
+	ConsecutiveIncrease int             `json:"consecutiveIncrease"`
+	Cuts                []*DividendCut  `json:"cuts,omitempty"`
+	LastCashAmount      float64         `json:"lastCashAmount"`
+	Frequency           int             `json:"frequency"`
+	AnnualDividend      float64         `json:"annualDividend"`
+	Shares              float64         `json:"shares,omitempty"`
+	NetCost             float64         `json:"netCost,omitempty"`
+	Price               float64         `json:"price,omitempty"`
+	CurrentYield        float64         `json:"currentYield,omitempty"`
+	YieldOnCost         float64         `json:"yieldOnCost,omitempty"`
+}
+
+func (g *DividendGrowth) String() string {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// NewDividendGrowth computes the dividend growth from the regular dividends in the set. Only the
+// years before asOf are used for the growth rates since the current year is not complete.
+func NewDividendGrowth(symbol string, ds *DividendsSet, asOf time.Time) *DividendGrowth {
+	g := DividendGrowth{
+		Symbol: symbol,
+		Growth: make(map[int]float64),
+	}
+
+	var regular []Dividends
+	for _, d := range ds.Dividends {
+		if d.DividendType == dividendTypeSpecial || d.CashAmount <= 0 {
+			continue
+		}
+		regular = append(regular, d)
+	}
+	if len(regular) == 0 {
+		return &g
+	}
+	sort.Slice(regular, func(i, j int) bool {
+		return exDividendDate(&regular[i]).Before(exDividendDate(&regular[j]))
+	})
+
+	annual := make(map[int]float64)
+	for i, d := range regular {
+		annual[exDividendDate(&d).Year()] += d.CashAmount
+
+		// Compare the annual rates so a change in frequency is not a cut.
+		if i > 0 {
+			prev := regular[i-1]
+			if annualRate(&d) < annualRate(&prev)*0.99 {
+				g.Cuts = append(g.Cuts, &DividendCut{
+					ExDividendDate: exDividendDate(&d),
+					Previous:       prev.CashAmount,
+					Current:        d.CashAmount,
+				})
+			}
+		}
+	}
+
+	for year, amount := range annual {
+		g.Years = append(g.Years, &DividendYear{Year: year, Amount: amount})
+	}
+	sort.Slice(g.Years, func(i, j int) bool {
+		return g.Years[i].Year < g.Years[j].Year
+	})
+
+	lastYear := asOf.Year() - 1
+	if last, ok := annual[lastYear]; ok && last > 0 {
+		for _, period := range DividendGrowthPeriods {
+			if first, ok := annual[lastYear-period]; ok && first > 0 {
+				g.Growth[period] = math.Pow(last/first, 1/float64(period)) - 1
+			}
+		}
+
+		for year := lastYear; annual[year-1] > 0 && annual[year] > annual[year-1]*1.0001; year-- {
+			g.ConsecutiveIncrease++
+		}
+	}
+
+	latest := regular[len(regular)-1]
+	g.LastCashAmount = latest.CashAmount
+	g.Frequency = latest.Frequency
+	if g.Frequency > 0 {
+		g.AnnualDividend = latest.CashAmount * float64(g.Frequency)
+	} else {
+		// Without a frequency use the dividends for the last year.
+		for _, d := range regular {
+			if exDividendDate(&d).After(asOf.AddDate(-1, 0, 0)) {
+				g.AnnualDividend += d.CashAmount
+			}
+		}
+	}
+	return &g
+}
+
+// SetPosition sets the current yield from the price and the yield on cost from the shares held and
+// what was paid for them.
+func (g *DividendGrowth) SetPosition(shares, netCost, price float64) {
+	g.Shares = shares
+	g.NetCost = netCost
+	g.Price = price
+	g.CurrentYield = 0.00
+	g.YieldOnCost = 0.00
+	if price > 0 {
+		g.CurrentYield = g.AnnualDividend / price
+	}
+	if shares > 0 && netCost > 0 {
+		g.YieldOnCost = g.AnnualDividend * shares / netCost
+	}
+}
+
+// exDividendDate returns the ex-dividend date of the dividend, or the pay date when there is none.
+func exDividendDate(d *Dividends) time.Time {
+	if d.ExDividendDate.IsZero() {
+		return d.PayDate.Time
+	}
+	return d.ExDividendDate.Time
+}
+
+// annualRate returns the dividend for a year at the rate of the dividend.
+func annualRate(d *Dividends) float64 {
+	if d.Frequency > 0 {
+		return d.CashAmount * float64(d.Frequency)
+	}
+	return d.CashAmount
+}
+
+// DividendGrowthGet computes the dividend growth for the symbol, or every symbol with dividends when
+// symbol is empty, using the shares, net cost and price on asOf for the yields. A zero asOf is today with
+// the latest price.
+func DividendGrowthGet(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet, symbol string, asOf time.Time) ([]*DividendGrowth, error) {
+	positionsAsOf := asOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbolList, err := SymbolList(ctx, pgxConn, lookups)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		symbols = nil
+		for s := range symbolList {
+			if s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+		sort.Strings(symbols)
+	}
+
+	var results []*DividendGrowth
+	for _, s := range symbols {
+		var ds DividendsSet
+		if err := ds.FromDBbySymbol(ctx, pgxConn, dividendsTable, s); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if len(ds.Dividends) == 0 {
+			continue
+		}
+
+		g := NewDividendGrowth(s, &ds, asOf)
+		acctInfo, err := AccountInfoGetAsOf(ctx, pgxConn, s, DefaultCurrency, positionsAsOf)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		g.SetPosition(acctInfo.NumberOfShares, acctInfo.NetCost, acctInfo.LatestPrice)
+		results = append(results, g)
+	}
+	return results, nil
+}
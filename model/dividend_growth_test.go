@@ -0,0 +1,82 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func quarterly(ticker string, year int, amount float64) []model.Dividends {
+	var divs []model.Dividends
+	for _, month := range []time.Month{time.February, time.May, time.August, time.November} {
+		divs = append(divs, model.Dividends{Ticker: ticker, CashAmount: amount, DividendType: "CD", Frequency: 4, ExDividendDate: stockTime(year, month, 28), PayDate: stockTime(year, month+1, 15)})
+	}
+	return divs
+}
+
+func TestNewDividendGrowth(t *testing.T) {
+	var divs []model.Dividends
+	amount := 0.25
+	for year := 2013; year <= 2023; year++ {
+		divs = append(divs, quarterly("HD", year, amount)...)
+		amount *= 1.10
+	}
+	divs = append(divs, model.Dividends{Ticker: "HD", CashAmount: 5.00, DividendType: "SC", ExDividendDate: stockTime(2023, time.December, 1)})
+	ds := model.NewDividendsSet(divs)
+
+	g := model.NewDividendGrowth("HD", &ds, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC))
+	t.Log(g.String())
+
+	for _, years := range model.DividendGrowthPeriods {
+		rate, ok := g.Growth[years]
+		if !ok {
+			t.Errorf("missing %d year growth", years)
+			continue
+		}
+		if math.Abs(rate-0.10) > 0.0001 {
+			t.Errorf("got %d year growth %.4f, want 0.10", years, rate)
+		}
+	}
+	if g.ConsecutiveIncrease != 10 {
+		t.Errorf("got %d consecutive increases, want 10", g.ConsecutiveIncrease)
+	}
+	if len(g.Cuts) != 0 {
+		t.Error("unexpected cuts:", len(g.Cuts))
+	}
+
+	g.SetPosition(100, 10000, 200)
+	if math.Abs(g.AnnualDividend-g.LastCashAmount*4) > 0.0001 {
+		t.Errorf("got annual dividend %.4f, want %.4f", g.AnnualDividend, g.LastCashAmount*4)
+	}
+	if math.Abs(g.CurrentYield-g.AnnualDividend/200) > 0.0001 {
+		t.Errorf("got current yield %.4f", g.CurrentYield)
+	}
+	if math.Abs(g.YieldOnCost-g.AnnualDividend/100) > 0.0001 {
+		t.Errorf("got yield on cost %.4f", g.YieldOnCost)
+	}
+}
+
+func TestNewDividendGrowth_Cut(t *testing.T) {
+	divs := quarterly("T", 2021, 0.52)
+	divs = append(divs, quarterly("T", 2022, 0.2775)...)
+	divs = append(divs, quarterly("T", 2023, 0.2775)...)
+	ds := model.NewDividendsSet(divs)
+
+	g := model.NewDividendGrowth("T", &ds, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if len(g.Cuts) != 1 {
+		t.Fatalf("got %d cuts, want 1", len(g.Cuts))
+	}
+	if g.Cuts[0].Previous != 0.52 || g.Cuts[0].Current != 0.2775 {
+		t.Error("unexpected cut:", g.Cuts[0])
+	}
+	if g.ConsecutiveIncrease != 0 {
+		t.Errorf("got %d consecutive increases, want 0", g.ConsecutiveIncrease)
+	}
+	if _, ok := g.Growth[3]; ok {
+		t.Error("expected no 3 year growth without the history")
+	}
+	if g.Growth[1] != 0 {
+		t.Errorf("got 1 year growth %.4f, want 0", g.Growth[1])
+	}
+}
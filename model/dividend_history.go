@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"math"
 	"strings"
 	"time"
 )
@@ -44,6 +45,12 @@ var (
 	errDividendEntryNotFound = errors.New("dividend history entry not found")
 )
 
+// IsDividendHistoryArgumentError returns true when the error is from a symbol, year or month of
+// DividendHistoryFromDB that is not valid.
+func IsDividendHistoryArgumentError(err error) bool {
+	return errors.Is(err, errInvalidArguments) || errors.Is(err, errInvalidYear) || errors.Is(err, errInvalidMonth)
+}
+
 // check to see if an int is between two numbers.
 func intInRange(num, min, max int) bool {
 	if min >= max {
@@ -107,19 +114,19 @@ func DividendHistoryFromDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol st
 	sb.WriteString(" FROM ")
 	sb.WriteString(dividendHistoryTable)
 	sb.WriteString(" WHERE ")
+	var args []any
 	if symbol != "" {
-		sb.WriteString(" symbol = '")
-		sb.WriteString(symbol)
+		sb.WriteString(" symbol = $1")
+		args = append(args, symbol)
 		needAnd = true
-		sb.WriteString("'")
 	}
 
 	if intInRange(year, 1980, now.Year()) {
 		if needAnd {
 			sb.WriteString(" AND ")
 		}
-		sb.WriteString(" year = ")
-		sb.WriteString(fmt.Sprintf("'%d'", year))
+		args = append(args, year)
+		sb.WriteString(fmt.Sprintf(" year = $%d", len(args)))
 		needAnd = true
 	}
 
@@ -127,11 +134,11 @@ func DividendHistoryFromDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol st
 		if needAnd {
 			sb.WriteString(" AND ")
 		}
-		sb.WriteString(" month = ")
-		sb.WriteString(fmt.Sprintf("'%d'", month))
+		args = append(args, month)
+		sb.WriteString(fmt.Sprintf(" month = $%d", len(args)))
 	}
 
-	rows, err := pgxConn.Query(ctx, sb.String())
+	rows, err := pgxConn.Query(ctx, sb.String(), args...)
 	defer rows.Close()
 	if err != nil {
 		logrus.Error(err.Error())
@@ -157,6 +164,33 @@ func DividendHistoryFromDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol st
 	return dh, nil
 }
 
+// DividendHistoriesFromDB returns the dividend history of each symbol by year and month from a single query.
+func DividendHistoriesFromDB(ctx context.Context, pgxConn *pgxpool.Pool, symbols []string) (map[string]*DividendHistory, error) {
+	rows, err := pgxConn.Query(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE symbol = ANY($1) ORDER BY symbol, year, month;",
+		dividendHistoryFields, dividendHistoryTable), symbols)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := make(map[string]*DividendHistory, len(symbols))
+	for _, symbol := range symbols {
+		histories[symbol] = NewDividendHistory(symbol)
+	}
+	for rows.Next() {
+		var d DividendEntry
+		if err := rows.Scan(&d.Symbol, &d.Year, &d.Month, &d.Amount); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if dh, ok := histories[d.Symbol]; ok {
+			dh.DividendEntries = append(dh.DividendEntries, &d)
+		}
+	}
+	return histories, rows.Err()
+}
+
 func (d *DividendHistory) AddEntry(symbol string, year, month int, amt float64) int {
 	dh := NewDividendEntry(symbol, year, month)
 	dh.Amount = amt
@@ -170,14 +204,14 @@ func NewDividendHistory(symbol string) *DividendHistory {
 	}
 }
 
-func GetDividendEntryForYearMonth(pg *pgxpool.Pool, symbol string, year, month int) (*DividendEntry, error) {
+func GetDividendEntryForYearMonth(ctx context.Context, pg *pgxpool.Pool, symbol string, year, month int) (*DividendEntry, error) {
 	today := time.Now()
 	requested := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	cutOver := time.Date(today.Year()-1, today.Month(), 1, 0, 0, 0, 0, time.UTC)
 
 	if requested.Before(cutOver) {
 		// Check the DB
-		d, err := DividendEntryFromDB(context.Background(), pg, symbol, year, month)
+		d, err := DividendEntryFromDB(ctx, pg, symbol, year, month)
 		if err == nil {
 			return d, nil
 		}
@@ -190,7 +224,7 @@ func GetDividendEntryForYearMonth(pg *pgxpool.Pool, symbol string, year, month i
 
 	d := NewDividendEntry(symbol, year, month)
 	tSet := NewTransactionSet()
-	if err := tSet.TransactionsForMonth(context.Background(), pg, d.Symbol, d.Year, d.Month); err != nil {
+	if err := tSet.TransactionsForMonth(ctx, pg, d.Symbol, d.Year, d.Month); err != nil {
 		logrus.Error(err.Error())
 		return nil, err
 	}
@@ -198,7 +232,7 @@ func GetDividendEntryForYearMonth(pg *pgxpool.Pool, symbol string, year, month i
 	logrus.Debugf("%s Found %d transactions", d.Symbol, len(tSet.TransactionRows))
 	if len(tSet.TransactionRows) <= 0 {
 		d.Amount = 0.00
-		err := d.ToDB(context.Background(), pg)
+		err := d.ToDB(ctx, pg)
 		if err != nil {
 			logrus.Error(err.Error())
 		}
@@ -221,7 +255,7 @@ func GetDividendEntryForYearMonth(pg *pgxpool.Pool, symbol string, year, month i
 
 	d.Amount = ticker.Dividends()
 
-	err := d.ToDB(context.Background(), pg)
+	err := d.ToDB(ctx, pg)
 	if err != nil {
 		logrus.Error(err.Error())
 	}
@@ -230,10 +264,10 @@ func GetDividendEntryForYearMonth(pg *pgxpool.Pool, symbol string, year, month i
 
 func DividendEntryFromDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol string, year, month int) (*DividendEntry, error) {
 	selectStatement := fmt.Sprintf(
-		"SELECT %s From %s WHERE symbol = '%s' and year = '%d' and month = '%d' ",
-		dividendHistoryFields, dividendHistoryTable, symbol, year, month)
+		"SELECT %s From %s WHERE symbol = $1 and year = $2 and month = $3 ",
+		dividendHistoryFields, dividendHistoryTable)
 
-	rows, err := pgxConn.Query(ctx, selectStatement)
+	rows, err := pgxConn.Query(ctx, selectStatement, symbol, year, month)
 	defer rows.Close()
 	if err != nil {
 		logrus.Error(err.Error())
@@ -265,9 +299,10 @@ func DividendEntryFromDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol stri
 
 func (d *DividendEntry) ToDB(ctx context.Context, pgxConn *pgxpool.Pool) error {
 	insertStatement := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES ('%s','%d','%d','%.2f') ON CONFLICT(symbol, year, month) DO UPDATE SET amount = EXCLUDED.amount;",
-		dividendHistoryTable, dividendHistoryFields, d.Symbol, d.Year, d.Month, d.Amount)
-	rows, err := pgxConn.Query(ctx, insertStatement)
+		"INSERT INTO %s (%s) VALUES ($1,$2,$3,$4) ON CONFLICT(portfolio_id, symbol, year, month) DO UPDATE SET amount = EXCLUDED.amount;",
+		dividendHistoryTable, dividendHistoryFields)
+	// The amount is stored in cents.
+	rows, err := pgxConn.Query(ctx, insertStatement, d.Symbol, d.Year, d.Month, math.Round(d.Amount*100)/100)
 	defer rows.Close()
 	if err != nil {
 		return err
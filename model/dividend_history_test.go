@@ -161,14 +161,14 @@ func TestDividendHistory_Sum(t *testing.T) {
 
 	ls := model.LoadLookupSet("1", string(csvLookupData))
 
-	if err = model.TransactionSetLoadToDB(pgxConn, ls, transactionTable, testTrans20231); err != nil {
+	if err = model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, transactionTable, testTrans20231); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
 
 	dh := model.NewDividendHistory("USAIX")
 	for i := 1; i <= 12; i++ {
-		d, err := model.GetDividendEntryForYearMonth(pgxConn, "USAIX", 2023, i)
+		d, err := model.GetDividendEntryForYearMonth(context.Background(), pgxConn, "USAIX", 2023, i)
 		if err != nil {
 			t.Error(err.Error())
 			return
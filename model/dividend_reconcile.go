@@ -0,0 +1,275 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ReconcileMatched    = "matched"
+	ReconcileMissing    = "missing"
+	ReconcileShort      = "short"
+	ReconcileOver       = "over"
+	ReconcilePending    = "pending"
+	ReconcileUnexpected = "unexpected"
+)
+
+// ReconcileOptions controls how received dividends are matched to declared dividends.
+type ReconcileOptions struct {
+	AsOf         time.Time // declared dividends paid after this are pending
+	WindowDays   int       // days either side of the pay date to look for the payment
+	TolerancePct float64   // difference allowed between expected and received before flagging
+}
+
+// DefaultReconcileOptions matches payments within a week of the pay date and within 2% of the expected amount.
+func DefaultReconcileOptions() ReconcileOptions {
+	return ReconcileOptions{
+		AsOf:         time.Now(),
+		WindowDays:   7,
+		TolerancePct: 0.02,
+	}
+}
+
+// DividendReconciliation compares a declared dividend with the payment received in an account.
+// Unexpected payments have no declared dividend and only the received fields are set.
+type DividendReconciliation struct {
+	Symbol         string    `json:"symbol"`
+	Account        string    `json:"account"`
+	RecordDate     time.Time `json:"recordDate"`
+	PayDate        time.Time `json:"payDate"`
+	CashAmount     float64   `json:"cashAmount,omitempty"`
+	Shares         float64   `json:"shares,omitempty"`
+	Expected       float64   `json:"expected"`
+	Received       float64   `json:"received"`
+	ReceivedDate   time.Time `json:"receivedDate"`
+	TransactionIds []int     `json:"transactionIds,omitempty"`
+	Status         string    `json:"status"`
+}
+
+func (r *DividendReconciliation) String() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// receivedDividend is a dividend payment found in the transactions.
+type receivedDividend struct {
+	id      int
+	account string
+	date    time.Time
+	amount  float64
+	matched bool
+}
+
+// ReconcileDividends matches the declared dividends for the symbol to the dividends received in the
+// transactions. The expected payment uses the shares held in each account at the end of the record
+// date, and a payment is matched when it is in the same account within the window of the pay date.
+// The transactions must be all the transactions for the symbol in date order.
+func ReconcileDividends(symbol string, ds *DividendsSet, ts *TransactionSet, opts ReconcileOptions) ([]*DividendReconciliation, error) {
+	declared := make([]Dividends, len(ds.Dividends))
+	copy(declared, ds.Dividends)
+	sort.Slice(declared, func(i, j int) bool {
+		return recordDate(&declared[i]).Before(recordDate(&declared[j]))
+	})
+
+	// Shares held per account as of each record date, and the dividends received.
+	holdings := make([]map[string]float64, len(declared))
+	var received []*receivedDividend
+
+	ticker := ts.newTicker(symbol)
+	next := 0
+	for _, tr := range ts.TransactionRows {
+		for next < len(declared) && tr.Date.After(recordDate(&declared[next])) {
+			holdings[next] = accountHoldings(ticker)
+			next++
+		}
+
+		en, err := NewEntityFromTransaction(tr)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if amount := en.DividendIncome().Float64(); amount > 0 {
+			received = append(received, &receivedDividend{id: tr.Id, account: en.Account, date: en.Date, amount: amount})
+		}
+		ticker.AddEntity(en)
+	}
+	for ; next < len(declared); next++ {
+		holdings[next] = accountHoldings(ticker)
+	}
+
+	window := time.Duration(opts.WindowDays) * 24 * time.Hour
+	var results []*DividendReconciliation
+	for i := range declared {
+		d := &declared[i]
+		var accounts []string
+		for account := range holdings[i] {
+			accounts = append(accounts, account)
+		}
+		sort.Strings(accounts)
+
+		for _, account := range accounts {
+			r := DividendReconciliation{
+				Symbol:     symbol,
+				Account:    account,
+				RecordDate: recordDate(d),
+				PayDate:    d.PayDate.Time,
+				CashAmount: d.CashAmount,
+				Shares:     holdings[i][account],
+			}
+			r.Expected = math.Round(r.CashAmount*r.Shares*100) / 100
+
+			// The closest payment to the pay date in the account.
+			var match *receivedDividend
+			for _, rd := range received {
+				if rd.matched || rd.account != account {
+					continue
+				}
+				diff := rd.date.Sub(r.PayDate)
+				if diff < -window || diff > window {
+					continue
+				}
+				if match == nil || math.Abs(float64(diff)) < math.Abs(float64(match.date.Sub(r.PayDate))) {
+					match = rd
+				}
+			}
+
+			switch {
+			case match != nil:
+				match.matched = true
+				r.Received = match.amount
+				r.ReceivedDate = match.date
+				r.TransactionIds = append(r.TransactionIds, match.id)
+				tolerance := math.Max(0.01, r.Expected*opts.TolerancePct)
+				switch {
+				case r.Received < r.Expected-tolerance:
+					r.Status = ReconcileShort
+				case r.Received > r.Expected+tolerance:
+					r.Status = ReconcileOver
+				default:
+					r.Status = ReconcileMatched
+				}
+			case r.PayDate.After(opts.AsOf):
+				r.Status = ReconcilePending
+			default:
+				r.Status = ReconcileMissing
+			}
+			results = append(results, &r)
+		}
+	}
+
+	// Payments that were not matched, within the dates covered by the declared dividends.
+	if len(declared) > 0 {
+		first, last := declared[0].PayDate.Time, declared[0].PayDate.Time
+		for i := range declared {
+			if declared[i].PayDate.Before(first) {
+				first = declared[i].PayDate.Time
+			}
+			if declared[i].PayDate.After(last) {
+				last = declared[i].PayDate.Time
+			}
+		}
+		for _, rd := range received {
+			if rd.matched || rd.date.Before(first.Add(-window)) || rd.date.After(last.Add(window)) {
+				continue
+			}
+			results = append(results, &DividendReconciliation{
+				Symbol:         symbol,
+				Account:        rd.account,
+				Received:       rd.amount,
+				ReceivedDate:   rd.date,
+				TransactionIds: []int{rd.id},
+				Status:         ReconcileUnexpected,
+			})
+		}
+	}
+	return results, nil
+}
+
+// recordDate returns the record date of the dividend, or the ex-dividend date when there is none.
+func recordDate(d *Dividends) time.Time {
+	if d.RecordDate.IsZero() {
+		return d.ExDividendDate.Time
+	}
+	return d.RecordDate.Time
+}
+
+// accountHoldings returns the shares held in every account of the ticker, closed accounts included.
+func accountHoldings(t *Ticker) map[string]float64 {
+	shares := make(map[string]float64)
+	for name, acct := range t.Accounts {
+		if numShares := acct.NumberOfShares().Float64(); numShares > 0.01 {
+			shares[name] = numShares
+		}
+	}
+	return shares
+}
+
+// DividendReconciliationGet reconciles the declared dividends for the symbol, or every symbol when
+// symbol is empty, keeping only the results with one of the statuses when statuses are given.
+func DividendReconciliationGet(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet, symbol string, statuses []string, opts ReconcileOptions) ([]*DividendReconciliation, error) {
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbolList, err := SymbolList(ctx, pgxConn, lookups)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		symbols = nil
+		for s := range symbolList {
+			if s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+		sort.Strings(symbols)
+	}
+
+	var results []*DividendReconciliation
+	for _, s := range symbols {
+		var ds DividendsSet
+		if err := ds.FromDBbySymbol(ctx, pgxConn, dividendsTable, s); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if len(ds.Dividends) == 0 {
+			continue
+		}
+
+		ts := NewTransactionSet()
+		if err := ts.TransactionSetFromDBbySymbol(ctx, pgxConn, transactionTable, s); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+
+		symbolResults, err := ReconcileDividends(s, &ds, ts, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range symbolResults {
+			if len(statuses) > 0 && !containsFold(statuses, r.Status) {
+				continue
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// containsFold returns true when value is in list ignoring case.
+func containsFold(list []string, value string) bool {
+	for _, l := range list {
+		if strings.EqualFold(strings.TrimSpace(l), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReconcileDividends(t *testing.T) {
+	ds := model.NewDividendsSet([]model.Dividends{
+		{Ticker: "CSX", CashAmount: 0.11, DividendType: "CD", Frequency: 4, RecordDate: stockTime(2024, time.February, 29), PayDate: stockTime(2024, time.March, 15)},
+		{Ticker: "CSX", CashAmount: 0.12, DividendType: "CD", Frequency: 4, RecordDate: stockTime(2024, time.May, 31), PayDate: stockTime(2024, time.June, 14)},
+		{Ticker: "CSX", CashAmount: 0.12, DividendType: "CD", Frequency: 4, RecordDate: stockTime(2024, time.August, 30), PayDate: stockTime(2024, time.September, 13)},
+		{Ticker: "CSX", CashAmount: 0.12, DividendType: "CD", Frequency: 4, RecordDate: stockTime(2024, time.November, 29), PayDate: stockTime(2024, time.December, 13)},
+	})
+
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX", Description: "100 shares @ 30.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-3000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "CSX", Amount: model.NewDecimal(11.00), Account: "Fidelity IRA"},
+		{Id: 3, Date: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX", Description: "100 shares @ 35.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-3500.00), Account: "Fidelity IRA"},
+		{Id: 4, Date: time.Date(2024, time.June, 17, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "CSX", Amount: model.NewDecimal(12.00), Account: "Fidelity IRA"},
+		{Id: 5, Date: time.Date(2024, time.July, 20, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "CSX", Amount: model.NewDecimal(5.00), Account: "Fidelity IRA"},
+	}
+
+	opts := model.ReconcileOptions{
+		AsOf:         time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC),
+		WindowDays:   7,
+		TolerancePct: 0.02,
+	}
+	results, err := model.ReconcileDividends("CSX", &ds, ts, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, r := range results {
+		t.Log(r.String())
+	}
+
+	want := []struct {
+		status   string
+		expected float64
+	}{
+		{model.ReconcileMatched, 11.00},
+		{model.ReconcileShort, 24.00},
+		{model.ReconcileMissing, 24.00},
+		{model.ReconcilePending, 24.00},
+		{model.ReconcileUnexpected, 0.00},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		if results[i].Status != w.status {
+			t.Errorf("result %d: got status %s, want %s", i, results[i].Status, w.status)
+		}
+		if math.Abs(results[i].Expected-w.expected) > 0.001 {
+			t.Errorf("result %d: got expected %.2f, want %.2f", i, results[i].Expected, w.expected)
+		}
+	}
+	if results[4].TransactionIds[0] != 5 {
+		t.Error("expected transaction 5 to be unexpected:", results[4].TransactionIds)
+	}
+}
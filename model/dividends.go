@@ -83,8 +83,12 @@ func (ds *DividendsSet) ToDB(ctx context.Context, pg *pgxpool.Pool, tableName st
 func (d *Dividends) ToDB(ctx context.Context, pg *pgxpool.Pool, tableName string) error {
 	insertStatement := fmt.Sprintf(
 		"INSERT INTO %s(ticker, cash_amount, declaration_date, dividend_type, ex_dividend_date, frequency, pay_date, record_date)"+
-			" VALUES('%s','%f','%s','%s','%s','%d','%s','%s');",
-		tableName,
+			" VALUES($1,$2,$3,$4,$5,$6,$7,$8)"+
+			" ON CONFLICT (ticker, declaration_date) DO UPDATE SET cash_amount = EXCLUDED.cash_amount,"+
+			" dividend_type = EXCLUDED.dividend_type, ex_dividend_date = EXCLUDED.ex_dividend_date,"+
+			" frequency = EXCLUDED.frequency, pay_date = EXCLUDED.pay_date, record_date = EXCLUDED.record_date;",
+		tableName)
+	rows, err := pg.Query(ctx, insertStatement,
 		d.Ticker,
 		d.CashAmount,
 		d.DeclarationDate.Format(dateToPgLayout),
@@ -93,7 +97,6 @@ func (d *Dividends) ToDB(ctx context.Context, pg *pgxpool.Pool, tableName string
 		d.Frequency,
 		d.PayDate.Format(dateToPgLayout),
 		d.RecordDate.Format(dateToPgLayout))
-	rows, err := pg.Query(ctx, insertStatement)
 	defer rows.Close()
 	if err != nil {
 		return err
@@ -103,17 +106,32 @@ func (d *Dividends) ToDB(ctx context.Context, pg *pgxpool.Pool, tableName string
 
 func (ds *DividendsSet) FromDBbySymbol(ctx context.Context, pg *pgxpool.Pool, tableName, symbol string) error {
 	return ds.getDividends(ctx, pg, fmt.Sprintf(
-		"SELECT %s FROM %s WHERE ticker = '%s' ORDER BY declaration_date DESC;",
-		dividendsTableFields, tableName, symbol))
+		"SELECT %s FROM %s WHERE ticker = $1 ORDER BY declaration_date DESC;",
+		dividendsTableFields, tableName), symbol)
 }
 
-func (ds *DividendsSet) getDividends(ctx context.Context, pg *pgxpool.Pool, selectStatement string) error {
+// DividendsBySymbols returns the dividends of each symbol, latest declared first, from a single query.
+func DividendsBySymbols(ctx context.Context, pg *pgxpool.Pool, tableName string, symbols []string) (map[string][]Dividends, error) {
+	var ds DividendsSet
+	if err := ds.getDividends(ctx, pg, fmt.Sprintf(
+		"SELECT %s FROM %s WHERE ticker = ANY($1) ORDER BY declaration_date DESC;",
+		dividendsTableFields, tableName), symbols); err != nil {
+		return nil, err
+	}
+	dividends := make(map[string][]Dividends, len(symbols))
+	for _, d := range ds.Dividends {
+		dividends[d.Ticker] = append(dividends[d.Ticker], d)
+	}
+	return dividends, nil
+}
+
+func (ds *DividendsSet) getDividends(ctx context.Context, pg *pgxpool.Pool, selectStatement string, args ...any) error {
 
 	if len(ds.Dividends) > 0 {
 		clear(ds.Dividends)
 	}
 
-	rows, err := pg.Query(ctx, selectStatement)
+	rows, err := pg.Query(ctx, selectStatement, args...)
 	defer rows.Close()
 	if err != nil {
 		logrus.Error(err.Error())
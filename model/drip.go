@@ -0,0 +1,341 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"time"
+)
+
+const fundHistoryTable = "fund_history"
+
+var (
+	errDripNoDividend  = errors.New("no regular dividend found")
+	errDripNoFrequency = errors.New("dividend frequency unknown")
+	errDripNoShares    = errors.New("no shares held")
+)
+
+// DripAssumptions are the annual growth rates used to project a position forward.
+type DripAssumptions struct {
+	Years          int     `json:"years"`
+	DividendGrowth float64 `json:"dividendGrowth"`
+	PriceGrowth    float64 `json:"priceGrowth"`
+	Reinvest       bool    `json:"reinvest"`
+}
+
+// DripPayment is a dividend paid on the pay date, and the shares bought with it when reinvesting.
+type DripPayment struct {
+	PayDate          time.Time `json:"payDate"`
+	DividendPerShare float64   `json:"dividendPerShare"`
+	Price            float64   `json:"price"`
+	Shares           float64   `json:"shares"`
+	Income           float64   `json:"income"`
+	SharesBought     float64   `json:"sharesBought,omitempty"`
+}
+
+// DripYear is the position at the end of a year of the projection.
+type DripYear struct {
+	Year   int     `json:"year"`
+	Shares float64 `json:"shares"`
+	Price  float64 `json:"price"`
+	Value  float64 `json:"value"`
+	Income float64 `json:"income"`
+	Cash   float64 `json:"cash"`
+}
+
+// DripProjection projects the shares and dividend income of a position forward.
+type DripProjection struct {
+	Symbol      string          `json:"symbol"`
+	Start       time.Time       `json:"start"`
+	Shares      float64         `json:"shares"`
+	Price       float64         `json:"price"`
+	CashAmount  float64         `json:"cashAmount"`
+	Frequency   int             `json:"frequency"`
+	Assumptions DripAssumptions `json:"assumptions"`
+	Payments    []*DripPayment  `json:"payments,omitempty"`
+	Years       []*DripYear     `json:"years"`
+	EndShares   float64         `json:"endShares"`
+	EndValue    float64         `json:"endValue"`
+	TotalIncome float64         `json:"totalIncome"`
+	Cash        float64         `json:"cash"`
+}
+
+func (p *DripProjection) String() string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// nthPayDate returns the pay date n payments after date for the number of payments a year. It counts
+// from date rather than from the pay date before, so a dividend paid on the 31st is paid on the last day
+// of a shorter month and on the 31st again after it.
+func nthPayDate(date time.Time, frequency, n int) time.Time {
+	if 12%frequency == 0 {
+		return addMonths(date, 12/frequency*n)
+	}
+	return date.AddDate(0, 0, 365/frequency*n)
+}
+
+// ProjectDrip projects the position forward from start using the latest regular dividend to set the pay
+// dates and starting dividend. The dividend grows once a year and the price grows continuously, and
+// dividends buy shares at the price on the pay date when reinvesting.
+func ProjectDrip(symbol string, start time.Time, shares, price float64, latest *Dividends, a DripAssumptions) (*DripProjection, error) {
+	switch {
+	case shares <= 0:
+		return nil, errDripNoShares
+	case latest == nil || latest.CashAmount <= 0:
+		return nil, errDripNoDividend
+	case latest.Frequency <= 0:
+		return nil, errDripNoFrequency
+	}
+
+	p := DripProjection{
+		Symbol:      symbol,
+		Start:       start,
+		Shares:      shares,
+		Price:       price,
+		CashAmount:  latest.CashAmount,
+		Frequency:   latest.Frequency,
+		Assumptions: a,
+	}
+
+	end := start.AddDate(a.Years, 0, 0)
+	n := 0
+	payDate := latest.PayDate.Time
+	for !payDate.After(start) {
+		n++
+		payDate = nthPayDate(latest.PayDate.Time, latest.Frequency, n)
+	}
+
+	priceAt := func(date time.Time) float64 {
+		years := date.Sub(start).Hours() / (24 * 365.25)
+		return price * math.Pow(1+a.PriceGrowth, years)
+	}
+
+	year := &DripYear{Year: 1}
+	yearEnd := start.AddDate(1, 0, 0)
+	closeYear := func() {
+		year.Shares = shares
+		year.Price = priceAt(yearEnd)
+		year.Value = shares * year.Price
+		year.Cash = p.Cash
+		p.Years = append(p.Years, year)
+	}
+
+	for !payDate.After(end) {
+		for payDate.After(yearEnd) {
+			closeYear()
+			year = &DripYear{Year: year.Year + 1}
+			yearEnd = start.AddDate(year.Year, 0, 0)
+		}
+
+		growthYears := year.Year - 1
+		payment := DripPayment{
+			PayDate:          payDate,
+			DividendPerShare: latest.CashAmount * math.Pow(1+a.DividendGrowth, float64(growthYears)),
+			Price:            priceAt(payDate),
+			Shares:           shares,
+		}
+		payment.Income = payment.DividendPerShare * shares
+		if a.Reinvest && payment.Price > 0 {
+			payment.SharesBought = payment.Income / payment.Price
+			shares += payment.SharesBought
+		} else {
+			p.Cash += payment.Income
+		}
+
+		year.Income += payment.Income
+		p.TotalIncome += payment.Income
+		p.Payments = append(p.Payments, &payment)
+		n++
+		payDate = nthPayDate(latest.PayDate.Time, latest.Frequency, n)
+	}
+	for year.Year <= a.Years {
+		closeYear()
+		year = &DripYear{Year: year.Year + 1}
+		yearEnd = start.AddDate(year.Year, 0, 0)
+	}
+
+	p.EndShares = shares
+	p.EndValue = shares * priceAt(end)
+	return &p, nil
+}
+
+// DripBacktest compares reinvesting the dividends against taking them as cash over the holding period.
+type DripBacktest struct {
+	Symbol         string    `json:"symbol"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	EndPrice       float64   `json:"endPrice"`
+	Dividends      int       `json:"dividends"`
+	ReinvestShares float64   `json:"reinvestShares"`
+	ReinvestValue  float64   `json:"reinvestValue"`
+	ReinvestIncome float64   `json:"reinvestIncome"`
+	CashShares     float64   `json:"cashShares"`
+	CashValue      float64   `json:"cashValue"`
+	CashReceived   float64   `json:"cashReceived"`
+	CashTotal      float64   `json:"cashTotal"` // value of the shares plus the dividends received
+	Difference     float64   `json:"difference"`
+	Warnings       []string  `json:"warnings,omitempty"`
+}
+
+func (b *DripBacktest) String() string {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// closeOnOrBefore returns the closing price on the last date in the history on or before date.
+func closeOnOrBefore(history []*Historical, date time.Time) (float64, bool) {
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].Date.After(date)
+	})
+	if i == 0 {
+		return 0.00, false
+	}
+	return history[i-1].Close, true
+}
+
+// BacktestDrip replays the transactions for the symbol twice, once reinvesting every dividend and once
+// taking them as cash. Both use the actual purchases, sales and splits, and the dividend per share
+// received, but ignore the shares bought by the actual reinvestments. Reinvestments use the closing
+// price from history, falling back to the reinvestment price in the transaction.
+func BacktestDrip(symbol string, ts *TransactionSet, history []*Historical, end time.Time, endPrice float64) (*DripBacktest, error) {
+	b := DripBacktest{
+		Symbol:   symbol,
+		End:      end,
+		EndPrice: endPrice,
+	}
+
+	uninvested := 0.00
+	ticker := ts.newTicker(symbol)
+	for _, tr := range ts.TransactionRows {
+		if tr.Date.After(end) {
+			break
+		}
+		en, err := NewEntityFromTransaction(tr)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+
+		before := ticker.TotalShares(true).Float64()
+		ticker.AddEntity(en)
+		after := ticker.TotalShares(true).Float64()
+
+		if amount := en.DividendIncome().Float64(); amount > 0 {
+			if before <= 0 {
+				b.Warnings = append(b.Warnings, fmt.Sprintf("%s dividend with no shares held", en.Date.Format("2006-01-02")))
+				continue
+			}
+			perShare := amount / before
+			b.Dividends++
+
+			price, ok := closeOnOrBefore(history, en.Date)
+			if !ok && en.PricePerShare.Sign() > 0 {
+				price, ok = en.PricePerShare.Float64(), true
+			}
+			income := perShare * b.ReinvestShares
+			b.ReinvestIncome += income
+			if ok && price > 0 {
+				b.ReinvestShares += income / price
+			} else {
+				b.Warnings = append(b.Warnings, fmt.Sprintf("%s no price to reinvest %.2f", en.Date.Format("2006-01-02"), income))
+				uninvested += income
+			}
+			b.CashReceived += perShare * b.CashShares
+			continue
+		}
+
+		switch {
+		case en.Type == "Stock Split" && before > 0:
+			ratio := after / before
+			b.ReinvestShares *= ratio
+			b.CashShares *= ratio
+		case after != before:
+			if b.Start.IsZero() {
+				b.Start = en.Date
+			}
+			b.ReinvestShares = math.Max(0.00, b.ReinvestShares+after-before)
+			b.CashShares = math.Max(0.00, b.CashShares+after-before)
+		}
+	}
+
+	b.ReinvestValue = b.ReinvestShares*endPrice + uninvested
+	b.CashValue = b.CashShares * endPrice
+	b.CashTotal = b.CashValue + b.CashReceived
+	b.Difference = b.ReinvestValue - b.CashTotal
+	return &b, nil
+}
+
+// latestRegularDividend returns the most recent dividend in the set that is not a special dividend.
+func latestRegularDividend(ds *DividendsSet) *Dividends {
+	var latest *Dividends
+	for i := range ds.Dividends {
+		d := &ds.Dividends[i]
+		if d.DividendType == dividendTypeSpecial || d.CashAmount <= 0 {
+			continue
+		}
+		if latest == nil || exDividendDate(d).After(exDividendDate(latest)) {
+			latest = d
+		}
+	}
+	return latest
+}
+
+// DripProjectionGet projects the current position in the symbol forward using the latest declared dividend.
+func DripProjectionGet(ctx context.Context, pgxConn *pgxpool.Pool, symbol string, a DripAssumptions) (*DripProjection, error) {
+	acctInfo, err := AccountInfoGet(ctx, pgxConn, symbol)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	var ds DividendsSet
+	if err := ds.FromDBbySymbol(ctx, pgxConn, dividendsTable, symbol); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	return ProjectDrip(symbol, time.Now(), acctInfo.NumberOfShares, acctInfo.LatestPrice, latestRegularDividend(&ds), a)
+}
+
+// DripBacktestGet compares reinvesting against taking cash for the symbol using the prices in fund_history.
+func DripBacktestGet(ctx context.Context, pgxConn *pgxpool.Pool, symbol string) (*DripBacktest, error) {
+	ts := NewTransactionSet()
+	if err := ts.TransactionSetFromDBbySymbol(ctx, pgxConn, transactionTable, symbol); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	if len(ts.TransactionRows) == 0 {
+		return nil, errDripNoShares
+	}
+
+	end := time.Now()
+	history, err := NewHistoricalDataSet(pgxConn, fundHistoryTable).Between(symbol, ts.TransactionRows[0].Date.AddDate(0, 0, -7), end)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	acctInfo, err := AccountInfoGet(ctx, pgxConn, symbol)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	endPrice := acctInfo.LatestPrice
+	if endPrice <= 0 && len(history) > 0 {
+		endPrice = history[len(history)-1].Close
+	}
+	return BacktestDrip(symbol, ts, history, end, endPrice)
+}
@@ -0,0 +1,102 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProjectDrip(t *testing.T) {
+	start := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	latest := model.Dividends{Ticker: "CSX", CashAmount: 0.25, DividendType: "CD", Frequency: 4, PayDate: stockTime(2024, time.March, 15)}
+
+	cash, err := model.ProjectDrip("CSX", start, 100, 25.00, &latest, model.DripAssumptions{Years: 2})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(cash.Payments) != 8 || len(cash.Years) != 2 {
+		t.Fatalf("got %d payments and %d years, want 8 and 2", len(cash.Payments), len(cash.Years))
+	}
+	if !cash.Payments[0].PayDate.Equal(time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected first pay date:", cash.Payments[0].PayDate)
+	}
+	if cash.EndShares != 100 || math.Abs(cash.Cash-200.00) > 0.001 {
+		t.Errorf("got %.4f shares and %.2f cash, want 100 and 200.00", cash.EndShares, cash.Cash)
+	}
+
+	drip, err := model.ProjectDrip("CSX", start, 100, 25.00, &latest, model.DripAssumptions{Years: 2, DividendGrowth: 0.10, Reinvest: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Log(drip.String())
+	// 1% a quarter reinvested for a year, then 1.1% a quarter.
+	want := 100 * math.Pow(1.01, 4) * math.Pow(1.011, 4)
+	if math.Abs(drip.EndShares-want) > 0.0001 {
+		t.Errorf("got %.4f shares, want %.4f", drip.EndShares, want)
+	}
+	if drip.Cash != 0 {
+		t.Errorf("got %.2f cash, want 0", drip.Cash)
+	}
+
+	if _, err := model.ProjectDrip("CSX", start, 100, 25.00, &model.Dividends{CashAmount: 1.00}, model.DripAssumptions{Years: 1}); err == nil {
+		t.Error("expected an error without a frequency")
+	}
+}
+
+func TestProjectDripMonthEnd(t *testing.T) {
+	latest := model.Dividends{Ticker: "O", CashAmount: 0.75, DividendType: "CD", Frequency: 4, PayDate: stockTime(2024, time.August, 31)}
+	p, err := model.ProjectDrip("O", time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC), 10, 60.00, &latest, model.DripAssumptions{Years: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	want := []time.Time{
+		time.Date(2024, time.November, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.May, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if len(p.Payments) != len(want) {
+		t.Fatalf("got %d payments, want %d", len(p.Payments), len(want))
+	}
+	for i, payment := range p.Payments {
+		if !payment.PayDate.Equal(want[i]) {
+			t.Errorf("got pay date %s, want %s", payment.PayDate.Format(time.DateOnly), want[i].Format(time.DateOnly))
+		}
+	}
+}
+
+func TestBacktestDrip(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "FCNTX", Description: "100 shares @ 10.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-1000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2023, time.June, 15, 0, 0, 0, 0, time.UTC), Type: "Reinvest Dividend", Symbol: "FCNTX", Description: "5 shares @ 20.00", Shares: model.NewDecimal(5), Amount: model.NewDecimal(-100.00), InvestmentAmount: model.NewDecimal(100.00), Account: "Fidelity IRA"},
+		{Id: 3, Date: time.Date(2023, time.December, 15, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "FCNTX", Amount: model.NewDecimal(105.00), Account: "Fidelity IRA"},
+	}
+	history := []*model.Historical{
+		{Symbol: "FCNTX", Date: time.Date(2023, time.June, 14, 0, 0, 0, 0, time.UTC), Close: 20.00},
+		{Symbol: "FCNTX", Date: time.Date(2023, time.December, 14, 0, 0, 0, 0, time.UTC), Close: 25.00},
+	}
+
+	b, err := model.BacktestDrip("FCNTX", ts, history, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), 30.00)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Log(b.String())
+
+	if b.Dividends != 2 {
+		t.Errorf("got %d dividends, want 2", b.Dividends)
+	}
+	// $1 a share both times, reinvested at 20 then 25.
+	wantShares := 105 * 1.04
+	if math.Abs(b.ReinvestShares-wantShares) > 0.0001 {
+		t.Errorf("got %.4f reinvested shares, want %.4f", b.ReinvestShares, wantShares)
+	}
+	if b.CashShares != 100 || math.Abs(b.CashReceived-200.00) > 0.001 {
+		t.Errorf("got %.4f shares and %.2f cash, want 100 and 200.00", b.CashShares, b.CashReceived)
+	}
+	if math.Abs(b.Difference-(wantShares*30-3200)) > 0.001 {
+		t.Errorf("got difference %.2f", b.Difference)
+	}
+}
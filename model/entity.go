@@ -5,7 +5,6 @@ import (
 	"github.com/kpearce2430/keputils/utils"
 	"github.com/segmentio/encoding/json"
 	"github.com/sirupsen/logrus"
-	"math"
 	"strings"
 	"time"
 )
@@ -19,12 +18,16 @@ type Entity struct {
 	Symbol           string          `json:"symbol,omitempty"`
 	SecurityPayee    string          `json:"security_payee,omitempty"`
 	Description      string          `json:"description,omitempty"`
-	Shares           float64         `json:"shares,omitempty"`
-	InvestmentAmount float64         `json:"investment_amount,omitempty"`
-	Amount           float64         `json:"amount,omitempty"`
+	Shares           Decimal         `json:"shares,omitempty"`
+	InvestmentAmount Decimal         `json:"investment_amount,omitempty"`
+	Amount           Decimal         `json:"amount,omitempty"`
 	Account          string          `json:"account,omitempty"`
-	PricePerShare    float64         `json:"pps,omitempty"`
-	RemainingShares  float64         `json:"remaining_shares,omitempty"`
+	PricePerShare    Decimal         `json:"pps,omitempty"`
+	RemainingShares  Decimal         `json:"remaining_shares,omitempty"`
+	BasisAdjustment  Decimal         `json:"basis_adjustment,omitempty"` // cost moved out of the lot by a corporate action or return of capital
+	CapitalGain      Decimal         `json:"capital_gain,omitempty"`     // return of capital more than the cost of the lots
+	Premium          Decimal         `json:"premium,omitempty"`          // option premium of the contracts assigned or exercised
+	Currency         string          `json:"currency,omitempty"`         // of the amounts, DefaultCurrency when empty
 	SoldLots         []*Lot          `json:"sold_lots,omitempty"`
 }
 
@@ -41,6 +44,10 @@ func (e *Entity) Copy() *Entity {
 		Amount:           e.Amount,
 		PricePerShare:    e.PricePerShare,
 		RemainingShares:  e.RemainingShares,
+		BasisAdjustment:  e.BasisAdjustment,
+		CapitalGain:      e.CapitalGain,
+		Premium:          e.Premium,
+		Currency:         e.Currency,
 	}
 
 	for _, l := range e.SoldLots {
@@ -68,65 +75,80 @@ func NewEntityFromTransaction(tr *Transaction) (*Entity, error) {
 		Amount:           tr.Amount,
 		Account:          tr.Account,
 		RemainingShares:  tr.Shares,
+		Currency:         tr.Currency,
 	}
 
-	if e.Type == "Buy" || e.Type == "Reinvest Dividend" || e.Type == "Sell" {
-		parts := strings.Split(e.Description, " ")
-		switch len(parts) {
-		case 4:
-			pps, err := utils.FloatParse(parts[3])
-			// e.PricePerShare, err := utils.FloatParse(parts[3])
-			if err != nil {
-				e.PricePerShare = 0.00
-				return &e, err
-			}
-			e.PricePerShare = pps
+	if optionType, ok := optionTransactionType(e.Type); ok && IsOptionSymbol(e.Symbol) {
+		// The price of a contract includes the multiplier, so it is taken from the amount.
+		e.Type = optionType
+		e.PricePerShare = e.Amount.Div(e.Shares).Abs()
+		return &e, nil
+	}
 
-		default:
+	if e.Type == "Buy" || e.Type == "Reinvest Dividend" || e.Type == "Sell" {
+		pps, found, err := pricePerShare(e.Description)
+		switch {
+		case err != nil:
+			e.PricePerShare = Decimal{}
+			return &e, err
+		case !found:
 			logrus.Error("Invalid Description for Price Per Share:", e)
-			e.PricePerShare = 0.00
+			e.PricePerShare = Decimal{}
 			// return &e, errPricePerShare
 		}
+		e.PricePerShare = pps
 	}
 	return &e, nil
 }
 
-func (e *Entity) SellShares(numSharesToSell float64, pps float64) float64 {
+// pricePerShare returns the price following the @ in a description such as "10 shares @ 500.00".
+func pricePerShare(description string) (Decimal, bool, error) {
+	parts := strings.Fields(description)
+	for i, p := range parts {
+		if p == "@" && i+1 < len(parts) {
+			pps, err := ParseDecimal(parts[i+1])
+			return pps, true, err
+		}
+	}
+	return Decimal{}, false, nil
+}
+
+func (e *Entity) SellShares(numSharesToSell Decimal, pps Decimal) Decimal {
 
-	if e.RemainingShares <= 0 {
+	if e.RemainingShares.Sign() <= 0 {
 		return numSharesToSell
 	}
 	// if there are 50 shares to sell with 100 remaining shares, remove 50
 	// return 0 for the number of shares remaining to sell.
 	// partial or full sale
-	if e.RemainingShares >= numSharesToSell {
-		e.RemainingShares = e.RemainingShares - numSharesToSell
+	if e.RemainingShares.Cmp(numSharesToSell) >= 0 {
+		e.RemainingShares = e.RemainingShares.Sub(numSharesToSell)
 		lot := Lot{
 			NumberShares:  numSharesToSell,
 			PricePerShare: pps,
 			SoldDate:      time.Now(), // TODO - Fix this
 		}
 		e.SoldLots = append(e.SoldLots, &lot)
-		return 0.00
+		return Decimal{}
 	}
 	// there are 50 shares remaining and 100 to sell,
 	// remove the 50 and return there are 50 more to sell.
-	remainingShares := numSharesToSell - e.RemainingShares
+	remainingShares := numSharesToSell.Sub(e.RemainingShares)
 	lot := Lot{
 		NumberShares:  e.RemainingShares,
 		PricePerShare: pps,
 		SoldDate:      time.Now(), // TODO - Fix this
 	}
 	e.SoldLots = append(e.SoldLots, &lot)
-	e.RemainingShares = 0.00
+	e.RemainingShares = Decimal{}
 	return remainingShares
 }
 
 func (e *Entity) SplitShares(newShares, oldShares float64) {
-	if e.RemainingShares <= 0 {
+	if e.RemainingShares.Sign() <= 0 {
 		return
 	}
-	e.RemainingShares = (e.RemainingShares / oldShares) * newShares
+	e.RemainingShares = e.RemainingShares.Mul(NewDecimal(newShares)).Div(NewDecimal(oldShares))
 
 }
 
@@ -138,37 +160,44 @@ func (e *Entity) String() string {
 	return fmt.Sprintf(string(bytes))
 }
 
-func (e *Entity) amountType(incomeType TransactionType) float64 {
+func (e *Entity) amountType(incomeType TransactionType) Decimal {
 	if e.Type == incomeType {
-		if e.Amount > 0 {
+		if e.Amount.Sign() > 0 {
 			return e.Amount
 		}
 		return e.InvestmentAmount
 	}
-	return 0.00
+	return Decimal{}
 }
 
-func (e *Entity) DividendIncome() float64 {
-	return e.amountType("Dividend Income") + e.amountType("Reinvest Dividend")
+func (e *Entity) DividendIncome() Decimal {
+	return e.amountType("Dividend Income").Add(e.amountType("Reinvest Dividend"))
 }
 
-func (e *Entity) LongTermCapitalGain() float64 {
+func (e *Entity) LongTermCapitalGain() Decimal {
 	amt := e.amountType("Long-term Capital Gain")
-	amt += e.amountType("Reinvest Long-term Capital Gain")
+	amt = amt.Add(e.amountType("Reinvest Long-term Capital Gain"))
 	return amt
 }
 
-func (e *Entity) ShortTermCapitalGain() float64 {
+func (e *Entity) ShortTermCapitalGain() Decimal {
 	amt := e.amountType("Short-term Capital Gain")
-	amt += e.amountType("Reinvest Short-term Capital Gain")
+	amt = amt.Add(e.amountType("Reinvest Short-term Capital Gain"))
 	return amt
 }
 
-func (e *Entity) Dividends() float64 {
-	return e.DividendIncome() + e.InterestIncome() + e.LongTermCapitalGain() + e.ShortTermCapitalGain()
+// ReturnOfCapital returns the return of capital, which reduces the cost of the lots and is not income.
+func (e *Entity) ReturnOfCapital() Decimal {
+	return e.amountType("Return of Capital").Add(e.amountType("Reinvest Return of Capital"))
+}
+
+func (e *Entity) Dividends() Decimal {
+	return e.DividendIncome().Add(e.InterestIncome()).Add(e.LongTermCapitalGain()).Add(e.ShortTermCapitalGain())
 }
 
-func (e *Entity) DividendsPaid() float64 {
+// DividendsPaid returns the dividend paid, with the capital gain distributions of the types below, as the
+// dividends received have always been counted. DividendIncome is the dividend alone.
+func (e *Entity) DividendsPaid() Decimal {
 	/*
 	   "Dividend Income", *
 	   "Reinvest Dividend", *
@@ -181,8 +210,6 @@ func (e *Entity) DividendsPaid() float64 {
 	switch e.Type {
 	case "Dividend Income":
 		return e.Amount
-	case "Return of Capital":
-		return e.Amount
 	case "Reinvest Dividend":
 		return e.InvestmentAmount
 	case "Reinvest Long-term Capital Gain":
@@ -192,18 +219,23 @@ func (e *Entity) DividendsPaid() float64 {
 	case "Reinvest Short-term Capital Gain":
 		return e.Amount
 	}
-	return 0.00
+	return Decimal{}
 }
 
-func (e *Entity) InterestIncome() float64 {
+// CapitalGainDistributions returns the short and long term capital gains distributed in cash or reinvested.
+func (e *Entity) CapitalGainDistributions() Decimal {
+	return e.LongTermCapitalGain().Add(e.ShortTermCapitalGain())
+}
+
+func (e *Entity) InterestIncome() Decimal {
 	switch e.Type {
 	case "Interest Income", "Int Inc", "int inc":
 		return e.Amount
 	}
-	return 0.00
+	return Decimal{}
 }
 
-func (e *Entity) NetCost() float64 {
+func (e *Entity) NetCost() Decimal {
 	/*
 	   amt = 0.00
 	   type = self.entry.get("entryType")
@@ -216,12 +248,16 @@ func (e *Entity) NetCost() float64 {
 	   return amt
 
 	*/
-	amt := 0.00
+	if e.Type == OptionSellToOpen {
+		// The premium received for the open contracts.
+		return e.RemainingShares.Mul(e.premiumPerContract()).RoundCents()
+	}
+	amt := Decimal{}
 	if utils.Contains(BuyTransactions, string(e.Type)) {
-		if e.RemainingShares > 0.00 {
-			amt = math.Abs(e.Amount)
+		if e.RemainingShares.Sign() > 0 {
+			amt = e.Amount.Abs().Sub(e.BasisAdjustment)
 			for _, lot := range e.SoldLots {
-				amt -= lot.Proceeds()
+				amt = amt.Sub(lot.Proceeds())
 			}
 		}
 	}
@@ -25,7 +25,7 @@ func TestNewEntityFromTransaction(t *testing.T) {
 				t.Log(err.Error())
 				t.FailNow()
 			}
-			if e.RemainingShares != 100 || e.Shares != 100 {
+			if e.RemainingShares != model.NewDecimal(100) || e.Shares != model.NewDecimal(100) {
 				t.Log("Invalid Shares")
 				t.Fail()
 			}
@@ -46,15 +46,15 @@ func TestNewEntityFromTransaction(t *testing.T) {
 				t.FailNow()
 			}
 			e.SplitShares(newShares, oldShares)
-			if e.RemainingShares != 400 {
+			if e.RemainingShares != model.NewDecimal(400) {
 				t.Log("Number of shares don'hist_usaix.csv match 400:", e.RemainingShares)
 				t.Fail()
 			}
 		}
 	}
 
-	e.SellShares(200.0, 100.00)
-	if e.RemainingShares != 200 {
+	e.SellShares(model.NewDecimal(200.0), model.NewDecimal(100.00))
+	if e.RemainingShares != model.NewDecimal(200) {
 		t.Log("Number of shares don'hist_usaix.csv match 200:", e.RemainingShares)
 		t.Fail()
 	}
@@ -73,7 +73,7 @@ func TestEntity_BadData(t *testing.T) {
 		t.Fail()
 	}
 
-	if e.PricePerShare != 0.00 {
+	if !e.PricePerShare.IsZero() {
 		t.Log("Invalid price per share")
 		t.Fail()
 	}
@@ -1,15 +1,32 @@
 package model
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
 	"strings"
+	"sync"
 	"time"
 )
 
+const AccountEventsTable = "account_events"
+
+var errAccountEvent = errors.New("invalid account event")
+
+// IsAccountEventError returns true when the error is from an account event that is not valid.
+func IsAccountEventError(err error) bool {
+	return errors.Is(err, errAccountEvent)
+}
+
+// Events are the days shares moved from one account to another without a transfer in the transactions.
 type Events struct {
-	Date        time.Time
-	EventType   string
-	FromAccount string
-	ToAccount   string
+	Date        time.Time `json:"date"`
+	EventType   string    `json:"type,omitempty"`
+	FromAccount string    `json:"fromAccount"`
+	ToAccount   string    `json:"toAccount"`
 }
 
 func (e *Events) IsFromAccount(tm time.Time, account string) (bool, string) {
@@ -29,3 +46,191 @@ func (e *Events) IsToAccount(tm time.Time, account string) (bool, string) {
 	}
 	return false, ""
 }
+
+// defaultEvents are the account events of the default portfolio from before they were in the
+// account_events table, kept under the events stored for it.
+var defaultEvents = []Events{
+	{
+		Date:        time.Date(2020, time.December, 28, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z HD Restricted Stock",
+		ToAccount:   "HD ML Individual Account",
+	},
+	{
+		Date:        time.Date(2021, time.March, 28, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z HD Restricted Stock",
+		ToAccount:   "HD ML Individual Account",
+	},
+	{
+		Date:        time.Date(2022, time.March, 23, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z HD Restricted Stock",
+		ToAccount:   "HD ML Individual Account",
+	},
+	{
+		Date:        time.Date(2023, time.March, 24, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z HD Restricted Stock",
+		ToAccount:   "HD ML Individual Account",
+	},
+	{
+		Date:        time.Date(2023, time.September, 05, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z Ameritrade IRA",
+		ToAccount:   "Schwab Rollover IRA Keith",
+	},
+	{
+		Date:        time.Date(2023, time.September, 05, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z Jane IRA",
+		ToAccount:   "Schwab Contributory IRA Jane",
+	},
+	{
+		Date:        time.Date(2024, time.March, 25, 00, 00, 00, 00, time.UTC),
+		FromAccount: "z HD Restricted Stock",
+		ToAccount:   "HD ML Individual Account",
+	},
+}
+
+var (
+	eventRegistryLock sync.RWMutex
+	eventRegistry     = make(map[string][]Events)
+)
+
+// SetAccountEvents sets the account events of the portfolio given to the tickers loaded after. The
+// defaultEvents that are not among them are added for the default portfolio.
+func SetAccountEvents(portfolio string, events []Events) {
+	if portfolio == DefaultPortfolio {
+		events = mergeEvents(defaultEvents, events)
+	}
+	eventRegistryLock.Lock()
+	defer eventRegistryLock.Unlock()
+	eventRegistry[portfolio] = events
+}
+
+// mergeEvents returns the events with the ones of base that are not the same move on the same day.
+func mergeEvents(base, events []Events) []Events {
+	key := func(e *Events) string {
+		return e.Date.Format(dateToPgLayout) + "\x00" + e.FromAccount + "\x00" + e.ToAccount
+	}
+	seen := make(map[string]bool, len(events))
+	for i := range events {
+		seen[key(&events[i])] = true
+	}
+	merged := make([]Events, 0, len(base)+len(events))
+	for i := range base {
+		if !seen[key(&base[i])] {
+			merged = append(merged, base[i])
+		}
+	}
+	return append(merged, events...)
+}
+
+// accountEvents returns the account events of the portfolio.
+func accountEvents(portfolio string) []Events {
+	eventRegistryLock.RLock()
+	defer eventRegistryLock.RUnlock()
+	if events, ok := eventRegistry[portfolio]; ok {
+		return events
+	}
+	if portfolio == DefaultPortfolio {
+		return defaultEvents
+	}
+	return nil
+}
+
+// AccountEvents returns the account events the tickers of the portfolio of the context are loaded with.
+func AccountEvents(ctx context.Context) []Events {
+	return accountEvents(PortfolioFromContext(ctx))
+}
+
+// NewAccountEventsFromJSON reads a list of account events with dates such as 2024-03-25.
+func NewAccountEventsFromJSON(data []byte) ([]Events, error) {
+	var input []struct {
+		Date        StockTime `json:"date"`
+		EventType   string    `json:"type"`
+		FromAccount string    `json:"fromAccount"`
+		ToAccount   string    `json:"toAccount"`
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("%w: %s", errAccountEvent, err.Error())
+	}
+
+	events := make([]Events, 0, len(input))
+	for _, in := range input {
+		switch {
+		case in.Date.IsZero():
+			return nil, fmt.Errorf("%w: missing date", errAccountEvent)
+		case in.FromAccount == "" || in.ToAccount == "":
+			return nil, fmt.Errorf("%w: missing account on %s", errAccountEvent, in.Date.Format(dateToPgLayout))
+		case in.FromAccount == in.ToAccount:
+			return nil, fmt.Errorf("%w: %s to itself", errAccountEvent, in.FromAccount)
+		}
+		events = append(events, Events{Date: in.Date.Time, EventType: in.EventType, FromAccount: in.FromAccount, ToAccount: in.ToAccount})
+	}
+	return events, nil
+}
+
+// AccountEventsGet returns the account events of the portfolio of the context by date.
+func AccountEventsGet(ctx context.Context, pg *pgxpool.Pool) ([]Events, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT event_date, event_type, from_account, to_account FROM %s ORDER BY event_date, from_account;", AccountEventsTable))
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Events{}
+	for rows.Next() {
+		e := Events{}
+		var eventType *string
+		if err := rows.Scan(&e.Date, &eventType, &e.FromAccount, &e.ToAccount); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if eventType != nil {
+			e.EventType = *eventType
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// AccountEventsLoad reads the account events of the portfolio of the context for its tickers. The default
+// portfolio keeps the events it had before the table under its own.
+func AccountEventsLoad(ctx context.Context, pg *pgxpool.Pool) error {
+	events, err := AccountEventsGet(ctx, pg)
+	if err != nil {
+		return err
+	}
+	SetAccountEvents(PortfolioFromContext(ctx), events)
+	return nil
+}
+
+// StoreAccountEvents adds the account events to the portfolio of the context, replacing the same move on
+// the same day, and registers the events of the portfolio.
+func StoreAccountEvents(ctx context.Context, pg *pgxpool.Pool, events []Events) error {
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, e := range events {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s(event_date, event_type, from_account, to_account) VALUES($1,$2,$3,$4)"+
+			" ON CONFLICT (portfolio_id, event_date, from_account, to_account) DO UPDATE SET event_type = EXCLUDED.event_type;", AccountEventsTable),
+			e.Date, e.EventType, e.FromAccount, e.ToAccount); err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+
+	stored, err := AccountEventsGet(ctx, pg)
+	if err != nil {
+		return err
+	}
+	SetAccountEvents(PortfolioFromContext(ctx), stored)
+	return nil
+}
@@ -1,8 +1,10 @@
 package model_test
 
 import (
+	"context"
 	"github.com/kpearce2430/stock-tools/model"
 	"testing"
+	"time"
 )
 
 func eventDriver(t *testing.T, testSet *model.TransactionSet) bool {
@@ -62,3 +64,67 @@ func TestEvents_USAIX(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNewAccountEventsFromJSON(t *testing.T) {
+	events, err := model.NewAccountEventsFromJSON([]byte(`[{"date":"2024-03-25","fromAccount":"z HD Restricted Stock","toAccount":"HD ML Individual Account"}]`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 1 || events[0].Date.Format("2006-01-02") != "2024-03-25" || events[0].ToAccount != "HD ML Individual Account" {
+		t.Errorf("got %+v", events)
+	}
+
+	for _, data := range []string{`{"date":"2024-03-25"}`, `[{"fromAccount":"a","toAccount":"b"}]`,
+		`[{"date":"2024-03-25","fromAccount":"a"}]`, `[{"date":"2024-03-25","fromAccount":"a","toAccount":"a"}]`} {
+		if _, err := model.NewAccountEventsFromJSON([]byte(data)); !model.IsAccountEventError(err) {
+			t.Errorf("%s: got %v, want an invalid account event", data, err)
+		}
+	}
+}
+
+func TestAccountEvents_Portfolio(t *testing.T) {
+	household := model.WithPortfolio(context.Background(), "household")
+	if got := model.AccountEvents(context.Background()); len(got) == 0 {
+		t.Error("the default portfolio has no account events")
+	}
+	if got := model.AccountEvents(household); len(got) != 0 {
+		t.Errorf("got %d account events before the portfolio has any", len(got))
+	}
+
+	events := []model.Events{{Date: time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC), FromAccount: "Brokerage", ToAccount: "Trust"}}
+	model.SetAccountEvents("household", events)
+	defer model.SetAccountEvents("household", nil)
+	if got := model.AccountEvents(household); len(got) != 1 || got[0].ToAccount != "Trust" {
+		t.Errorf("got %+v", got)
+	}
+	if got := model.AccountEvents(context.Background()); len(got) == 0 || got[0].ToAccount == "Trust" {
+		t.Error("the events of the household portfolio are in the default portfolio")
+	}
+}
+
+func TestAccountEvents_DefaultMerged(t *testing.T) {
+	defaults := len(model.AccountEvents(context.Background()))
+	defer model.SetAccountEvents(model.DefaultPortfolio, nil)
+
+	// A stored event is added to the default events, and one for the same move on the same day replaces it.
+	model.SetAccountEvents(model.DefaultPortfolio, []model.Events{
+		{Date: time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC), FromAccount: "Brokerage", ToAccount: "Trust"},
+		{Date: time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC), EventType: "vest", FromAccount: "z HD Restricted Stock", ToAccount: "HD ML Individual Account"},
+	})
+	got := model.AccountEvents(context.Background())
+	if len(got) != defaults+1 {
+		t.Fatalf("got %d account events, want %d", len(got), defaults+1)
+	}
+	vests := 0
+	for _, e := range got {
+		if e.Date.Equal(time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC)) {
+			vests++
+			if e.EventType != "vest" {
+				t.Errorf("got %+v, want the stored event", e)
+			}
+		}
+	}
+	if vests != 1 {
+		t.Errorf("got %d events on 2024-03-25, want 1", vests)
+	}
+}
@@ -0,0 +1,515 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FixedIncomeTable = "fixed_income"
+
+	DayCountActual365 = "actual/365"
+	DayCount30360     = "30/360"
+
+	LadderByMonth = "month"
+	LadderByYear  = "year"
+
+	// ActionSourceMaturity is the source of the cash merger that redeems a bond at maturity.
+	ActionSourceMaturity = "maturity"
+
+	fixedIncomeFields = "cusip, description, coupon_rate, frequency, issue_date, maturity_date, par, day_count, call_schedule"
+)
+
+var (
+	errFixedIncomeCUSIP     = errors.New("fixed income cusip missing")
+	errFixedIncomeMaturity  = errors.New("fixed income maturity date missing or before the issue date")
+	errFixedIncomeFrequency = errors.New("fixed income payment frequency invalid")
+	errFixedIncomeDayCount  = errors.New("fixed income day count unknown")
+	errFixedIncomeAbsent    = errors.New("fixed income not found")
+	errLadderBucket         = errors.New("ladder bucket must be month or year")
+
+	// "GOLDMAN SACHS BA 4.75%25CD FDIC INS DUE 01/16/25US"
+	cdDuePattern = regexp.MustCompile(`([0-9.]+)%\d{2}CD .*DUE (\d{2}/\d{2}/\d{2})`)
+	// "06418CMA1 BANK OZK LITTLE ROCK ARK CD 5.40000% 10/04/2024"
+	cdRatePattern = regexp.MustCompile(`CD ([0-9.]+)% (\d{2}/\d{2}/\d{4})`)
+)
+
+// CallDate is a date the issuer can redeem the bond early and the price per unit it pays.
+type CallDate struct {
+	Date  time.Time `json:"date"`
+	Price float64   `json:"price"`
+}
+
+// FixedIncome is the terms of a bond or brokered CD. Units are held in the transactions as shares and
+// are redeemed for Par at maturity, so a $5,000 CD with a par of 100 is 50 units. A Frequency of zero
+// pays all the interest at maturity.
+type FixedIncome struct {
+	CUSIP        string      `json:"cusip"`
+	Description  string      `json:"description,omitempty"`
+	CouponRate   float64     `json:"couponRate"` // annual rate, 0.047 for 4.7%
+	Frequency    int         `json:"frequency"`  // coupon payments a year
+	IssueDate    time.Time   `json:"issueDate"`
+	MaturityDate time.Time   `json:"maturityDate"`
+	Par          float64     `json:"par,omitempty"`
+	DayCount     string      `json:"dayCount,omitempty"`
+	CallSchedule []*CallDate `json:"callSchedule,omitempty"`
+}
+
+func (f *FixedIncome) String() string {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// Validate checks the terms, setting the par to 100 and the day count to actual/365 when they are missing.
+func (f *FixedIncome) Validate() error {
+	if f.CUSIP == "" {
+		return errFixedIncomeCUSIP
+	}
+	if f.MaturityDate.IsZero() || (!f.IssueDate.IsZero() && !f.MaturityDate.After(f.IssueDate)) {
+		return errFixedIncomeMaturity
+	}
+	switch f.Frequency {
+	case 0, 1, 2, 4, 12:
+	default:
+		return fmt.Errorf("%w: %d", errFixedIncomeFrequency, f.Frequency)
+	}
+	switch f.DayCount {
+	case "":
+		f.DayCount = DayCountActual365
+	case DayCountActual365, DayCount30360:
+	default:
+		return fmt.Errorf("%w: %s", errFixedIncomeDayCount, f.DayCount)
+	}
+	if f.Par <= 0 {
+		f.Par = 100.00
+	}
+	sort.Slice(f.CallSchedule, func(i, j int) bool {
+		return f.CallSchedule[i].Date.Before(f.CallSchedule[j].Date)
+	})
+	return nil
+}
+
+// NewFixedIncomeFromDescription reads the coupon rate and maturity date from the description of a
+// brokered CD. The interest is paid at maturity unless the description says it is monthly.
+func NewFixedIncomeFromDescription(cusip, description string) (*FixedIncome, error) {
+	f := FixedIncome{CUSIP: cusip, Description: description}
+
+	var rate, due, layout string
+	if m := cdDuePattern.FindStringSubmatch(description); m != nil {
+		rate, due, layout = m[1], m[2], "01/02/06"
+	} else if m := cdRatePattern.FindStringSubmatch(description); m != nil {
+		rate, due, layout = m[1], m[2], "01/02/2006"
+	} else {
+		return nil, fmt.Errorf("%w: %s", errFixedIncomeMaturity, description)
+	}
+
+	coupon, err := utils.FloatParse(rate)
+	if err != nil {
+		return nil, err
+	}
+	f.CouponRate = coupon / 100
+	if f.MaturityDate, err = time.Parse(layout, due); err != nil {
+		return nil, err
+	}
+	if strings.Contains(description, "MTHLY") {
+		f.Frequency = 12
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// yearFraction returns the part of a year between the dates using the day count.
+func (f *FixedIncome) yearFraction(start, end time.Time) float64 {
+	if f.DayCount == DayCount30360 {
+		d1, d2 := start.Day(), end.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		days := 360*(end.Year()-start.Year()) + 30*(int(end.Month())-int(start.Month())) + d2 - d1
+		return float64(days) / 360
+	}
+	return end.Sub(start).Hours() / 24 / 365
+}
+
+// CouponDates returns the coupon payment dates in order, counting back from the maturity date.
+func (f *FixedIncome) CouponDates() []time.Time {
+	if f.Frequency == 0 {
+		return []time.Time{f.MaturityDate}
+	}
+
+	first := f.IssueDate
+	if first.IsZero() {
+		// Without an issue date count back far enough to cover any holding.
+		first = f.MaturityDate.AddDate(-30, 0, 0)
+	}
+
+	months := 12 / f.Frequency
+	var dates []time.Time
+	for i := 0; ; i++ {
+		date := addMonths(f.MaturityDate, -months*i)
+		if !date.After(first) {
+			break
+		}
+		dates = append([]time.Time{date}, dates...)
+	}
+	return dates
+}
+
+// accrualStart returns the coupon date, or issue date, interest has been accruing from on the date.
+// Without an issue date interest paid at maturity is not accrued.
+func (f *FixedIncome) accrualStart(date time.Time, coupons []time.Time) time.Time {
+	start := f.IssueDate
+	for _, c := range coupons {
+		if c.After(date) {
+			break
+		}
+		start = c
+	}
+	if start.IsZero() {
+		return date
+	}
+	return start
+}
+
+// AccruedInterest returns the interest earned on the units since the last coupon and not yet paid.
+func (f *FixedIncome) AccruedInterest(date time.Time, units float64) float64 {
+	if !f.MaturityDate.After(date) || (!f.IssueDate.IsZero() && date.Before(f.IssueDate)) {
+		return 0.00
+	}
+	start := f.accrualStart(date, f.CouponDates())
+	return units * f.Par * f.CouponRate * f.yearFraction(start, date)
+}
+
+// BondCashFlow is a coupon or principal payment expected on the date.
+type BondCashFlow struct {
+	Date      time.Time `json:"date"`
+	CUSIP     string    `json:"cusip"`
+	Account   string    `json:"account,omitempty"`
+	Interest  float64   `json:"interest"`
+	Principal float64   `json:"principal,omitempty"`
+}
+
+// CashFlows returns the coupons paid on the units after the date and the principal at maturity.
+func (f *FixedIncome) CashFlows(units float64, after time.Time) []*BondCashFlow {
+	var flows []*BondCashFlow
+	coupons := f.CouponDates()
+	for i, c := range coupons {
+		if !c.After(after) {
+			continue
+		}
+		start := f.IssueDate
+		if i > 0 {
+			start = coupons[i-1]
+		} else if start.IsZero() {
+			start = c
+		}
+		flow := BondCashFlow{
+			Date:     c,
+			CUSIP:    f.CUSIP,
+			Interest: units * f.Par * f.CouponRate * f.yearFraction(start, c),
+		}
+		if c.Equal(f.MaturityDate) {
+			flow.Principal = units * f.Par
+		}
+		flows = append(flows, &flow)
+	}
+	return flows
+}
+
+// NextCall returns the first call date after the date, or nil when there is none.
+func (f *FixedIncome) NextCall(date time.Time) *CallDate {
+	for _, c := range f.CallSchedule {
+		if c.Date.After(date) {
+			return c
+		}
+	}
+	return nil
+}
+
+// MaturityAction returns the cash merger that redeems the units for par on the maturity date, so
+// holdings without a redemption transaction are closed when they mature.
+func (f *FixedIncome) MaturityAction() *CorporateAction {
+	return &CorporateAction{
+		Symbol:       f.CUSIP,
+		Date:         f.MaturityDate,
+		ActionType:   ActionCashMerger,
+		CashPerShare: f.Par,
+		Source:       ActionSourceMaturity,
+	}
+}
+
+// BondHolding is the units of a bond or CD held in an account. Price is the quote as a percent of par.
+type BondHolding struct {
+	CUSIP           string    `json:"cusip"`
+	Description     string    `json:"description,omitempty"`
+	Account         string    `json:"account"`
+	Units           float64   `json:"units"`
+	Face            float64   `json:"face"`
+	Cost            float64   `json:"cost"`
+	Price           float64   `json:"price"`
+	MarketValue     float64   `json:"marketValue"`
+	AccruedInterest float64   `json:"accruedInterest"`
+	CouponRate      float64   `json:"couponRate"`
+	AnnualIncome    float64   `json:"annualIncome"`
+	MaturityDate    time.Time `json:"maturityDate"`
+	NextCall        *CallDate `json:"nextCall,omitempty"`
+	terms           *FixedIncome
+}
+
+// NewBondHolding values the units held in the account on the date at the price, or at par without one.
+func NewBondHolding(f *FixedIncome, account string, units, cost, price float64, asOf time.Time) *BondHolding {
+	if price <= 0 {
+		price = 100.00
+	}
+	h := BondHolding{
+		CUSIP:           f.CUSIP,
+		Description:     f.Description,
+		Account:         account,
+		Units:           units,
+		Face:            units * f.Par,
+		Cost:            cost,
+		Price:           price,
+		MarketValue:     units * f.Par * price / 100,
+		AccruedInterest: f.AccruedInterest(asOf, units),
+		CouponRate:      f.CouponRate,
+		MaturityDate:    f.MaturityDate,
+		NextCall:        f.NextCall(asOf),
+		terms:           f,
+	}
+	h.AnnualIncome = h.Face * h.CouponRate
+	return &h
+}
+
+// BondCashFlows returns the coupons and principal expected from the holdings after the date in date order.
+func BondCashFlows(holdings []*BondHolding, after time.Time) []*BondCashFlow {
+	var flows []*BondCashFlow
+	for _, h := range holdings {
+		for _, flow := range h.terms.CashFlows(h.Units, after) {
+			flow.Account = h.Account
+			flows = append(flows, flow)
+		}
+	}
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].Date.Before(flows[j].Date)
+	})
+	return flows
+}
+
+// LadderRung is the holdings maturing in a month or year.
+type LadderRung struct {
+	Period         string         `json:"period"`
+	Face           float64        `json:"face"`
+	MarketValue    float64        `json:"marketValue"`
+	WeightedCoupon float64        `json:"weightedCoupon"`
+	Holdings       []*BondHolding `json:"holdings"`
+}
+
+// MaturityLadder groups the holdings by the month or year they mature in, in maturity order.
+func MaturityLadder(holdings []*BondHolding, bucket string) ([]*LadderRung, error) {
+	layout := "2006-01"
+	switch bucket {
+	case LadderByMonth:
+	case LadderByYear:
+		layout = "2006"
+	default:
+		return nil, fmt.Errorf("%w: %s", errLadderBucket, bucket)
+	}
+
+	sorted := append([]*BondHolding{}, holdings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MaturityDate.Before(sorted[j].MaturityDate)
+	})
+
+	var rungs []*LadderRung
+	for _, h := range sorted {
+		period := h.MaturityDate.Format(layout)
+		if len(rungs) == 0 || rungs[len(rungs)-1].Period != period {
+			rungs = append(rungs, &LadderRung{Period: period})
+		}
+		rung := rungs[len(rungs)-1]
+		rung.Holdings = append(rung.Holdings, h)
+		rung.Face += h.Face
+		rung.MarketValue += h.MarketValue
+	}
+
+	for _, rung := range rungs {
+		if rung.Face <= 0 {
+			continue
+		}
+		for _, h := range rung.Holdings {
+			rung.WeightedCoupon += h.CouponRate * h.Face / rung.Face
+		}
+	}
+	return rungs, nil
+}
+
+// NewFixedIncomeFromJSON reads the terms of a single bond or a list of them. A bond without a maturity
+// date reads its terms from the description.
+func NewFixedIncomeFromJSON(data []byte) ([]*FixedIncome, error) {
+	var bonds []*FixedIncome
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var f FixedIncome
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+		bonds = append(bonds, &f)
+	} else if err := json.Unmarshal(data, &bonds); err != nil {
+		return nil, err
+	}
+
+	for i, f := range bonds {
+		if f.MaturityDate.IsZero() && f.Description != "" {
+			described, err := NewFixedIncomeFromDescription(f.CUSIP, f.Description)
+			if err != nil {
+				return nil, err
+			}
+			bonds[i] = described
+			continue
+		}
+		if err := f.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return bonds, nil
+}
+
+// ToDB stores the terms, replacing the terms stored for the CUSIP.
+func (f *FixedIncome) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	calls, err := json.Marshal(f.CallSchedule)
+	if err != nil {
+		return err
+	}
+	issueDate := &f.IssueDate
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)"+
+			" ON CONFLICT (cusip) DO UPDATE SET description = EXCLUDED.description, coupon_rate = EXCLUDED.coupon_rate,"+
+			" frequency = EXCLUDED.frequency, issue_date = EXCLUDED.issue_date, maturity_date = EXCLUDED.maturity_date,"+
+			" par = EXCLUDED.par, day_count = EXCLUDED.day_count, call_schedule = EXCLUDED.call_schedule;",
+		FixedIncomeTable, fixedIncomeFields)
+	if _, err := pg.Exec(ctx, insertStatement, f.CUSIP, f.Description, f.CouponRate, f.Frequency, dateArg(issueDate),
+		f.MaturityDate.Format(dateToPgLayout), f.Par, f.DayCount, string(calls)); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// fixedIncomeGet returns the terms selected by the where clause with the arguments of its placeholders.
+func fixedIncomeGet(ctx context.Context, pg *pgxpool.Pool, where string, args ...any) ([]*FixedIncome, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s %s ORDER BY maturity_date;", fixedIncomeFields, FixedIncomeTable, where), args...)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bonds []*FixedIncome
+	for rows.Next() {
+		var issueDate *time.Time
+		var calls string
+		f := FixedIncome{}
+		if err := rows.Scan(&f.CUSIP, &f.Description, &f.CouponRate, &f.Frequency, &issueDate,
+			&f.MaturityDate, &f.Par, &f.DayCount, &calls); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if issueDate != nil {
+			f.IssueDate = *issueDate
+		}
+		if calls != "" {
+			if err := json.Unmarshal([]byte(calls), &f.CallSchedule); err != nil {
+				logrus.Error(err.Error())
+				return nil, err
+			}
+		}
+		bonds = append(bonds, &f)
+	}
+	return bonds, nil
+}
+
+// FixedIncomeGetAll returns the terms of every bond in maturity order.
+func FixedIncomeGetAll(ctx context.Context, pg *pgxpool.Pool) ([]*FixedIncome, error) {
+	return fixedIncomeGet(ctx, pg, "")
+}
+
+// FixedIncomeGet returns the terms of the bond with the CUSIP.
+func FixedIncomeGet(ctx context.Context, pg *pgxpool.Pool, cusip string) (*FixedIncome, error) {
+	bonds, err := fixedIncomeGet(ctx, pg, "WHERE cusip = $1", cusip)
+	if err != nil {
+		return nil, err
+	}
+	if len(bonds) == 0 {
+		return nil, errFixedIncomeAbsent
+	}
+	return bonds[0], nil
+}
+
+// IsFixedIncomeNotFound returns true when the error is from missing bond terms.
+func IsFixedIncomeNotFound(err error) bool {
+	return errors.Is(err, errFixedIncomeAbsent)
+}
+
+// BondHoldingsGet returns the units of every bond held in each account on the date, valued at the latest
+// portfolio value quote.
+func BondHoldingsGet(ctx context.Context, pg *pgxpool.Pool, asOf time.Time) ([]*BondHolding, error) {
+	bonds, err := FixedIncomeGetAll(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+
+	var holdings []*BondHolding
+	for _, f := range bonds {
+		ts := NewTransactionSet()
+		if err := ts.TransactionSetFromDBbySymbol(ctx, pg, transactionTable, f.CUSIP); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		ticker, err := TickerGet(ctx, pg, f.CUSIP, ts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Brokered CDs are usually bought when they are issued.
+		if f.IssueDate.IsZero() {
+			f.IssueDate = ticker.FirstBought()
+		}
+
+		var pv PortfolioValueRecord
+		if err := pv.GetLastDB(ctx, pg, f.CUSIP, "portfolio_value"); err != nil {
+			logrus.Debug("No portfolio value for ", f.CUSIP, ": ", err.Error())
+		}
+
+		var names []string
+		for name := range ticker.Accounts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			acct := ticker.Accounts[name]
+			if units := acct.NumberOfShares().Float64(); units > 0.001 {
+				holdings = append(holdings, NewBondHolding(f, name, units, acct.NetCost().Float64(), pv.Quote, asOf))
+			}
+		}
+	}
+	return holdings, nil
+}
@@ -0,0 +1,165 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewFixedIncomeFromDescription(t *testing.T) {
+	tests := []struct {
+		description string
+		coupon      float64
+		frequency   int
+		maturity    time.Time
+	}{
+		{"GOLDMAN SACHS BA 4.75%25CD FDIC INS DUE 01/16/25US", 0.0475, 0, time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"06418CMA1 BANK OZK LITTLE ROCK ARK CD 5.40000% 10/04/2024 MTHLY", 0.054, 12, time.Date(2024, time.October, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		f, err := model.NewFixedIncomeFromDescription("CUSIP", tt.description)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.description, err.Error())
+		}
+		if math.Abs(f.CouponRate-tt.coupon) > 0.000001 || f.Frequency != tt.frequency || !f.MaturityDate.Equal(tt.maturity) {
+			t.Errorf("%s: got %+v", tt.description, f)
+		}
+		if f.Par != 100.00 || f.DayCount != model.DayCountActual365 {
+			t.Errorf("%s: got par %.2f day count %s, want the defaults", tt.description, f.Par, f.DayCount)
+		}
+	}
+
+	if _, err := model.NewFixedIncomeFromDescription("CUSIP", "FIDELITY TOTAL BOND"); err == nil {
+		t.Error("expected an error without a maturity date")
+	}
+}
+
+func TestFixedIncome_CashFlows(t *testing.T) {
+	f := model.FixedIncome{
+		CUSIP:        "912828XX",
+		CouponRate:   0.04,
+		Frequency:    2,
+		IssueDate:    time.Date(2023, time.March, 15, 0, 0, 0, 0, time.UTC),
+		MaturityDate: time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC),
+		DayCount:     model.DayCount30360,
+		CallSchedule: []*model.CallDate{{Date: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Price: 100.00}},
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// 50 units is $5,000 face paying $100 every six months.
+	flows := f.CashFlows(50, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC))
+	if len(flows) != 3 {
+		t.Fatalf("got %d cash flows, want 3", len(flows))
+	}
+	for _, flow := range flows {
+		if math.Abs(flow.Interest-100.00) > 0.001 {
+			t.Errorf("got interest %.2f on %s, want 100.00", flow.Interest, flow.Date)
+		}
+	}
+	if last := flows[2]; !last.Date.Equal(f.MaturityDate) || last.Principal != 5000.00 {
+		t.Errorf("got principal %.2f on %s, want 5000.00 at maturity", last.Principal, last.Date)
+	}
+
+	// Three months into a six month coupon is half of it.
+	if accrued := f.AccruedInterest(time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC), 50); math.Abs(accrued-50.00) > 0.001 {
+		t.Errorf("got accrued interest %.2f, want 50.00", accrued)
+	}
+	if accrued := f.AccruedInterest(f.MaturityDate, 50); accrued != 0.00 {
+		t.Errorf("got accrued interest %.2f at maturity, want 0.00", accrued)
+	}
+	if call := f.NextCall(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)); call == nil || call.Price != 100.00 {
+		t.Errorf("got next call %v", call)
+	}
+}
+
+func TestFixedIncome_CouponDatesMonthEnd(t *testing.T) {
+	f := model.FixedIncome{
+		CUSIP:        "912828YY",
+		CouponRate:   0.05,
+		Frequency:    2,
+		IssueDate:    time.Date(2023, time.August, 31, 0, 0, 0, 0, time.UTC),
+		MaturityDate: time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC),
+		DayCount:     model.DayCount30360,
+	}
+
+	// A bond maturing on the 31st pays on the last day of February.
+	want := []time.Time{
+		time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.August, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC),
+	}
+	dates := f.CouponDates()
+	if len(dates) != len(want) {
+		t.Fatalf("got %d coupon dates, want %d: %v", len(dates), len(want), dates)
+	}
+	for i, date := range dates {
+		if !date.Equal(want[i]) {
+			t.Errorf("got coupon date %s, want %s", date.Format(time.DateOnly), want[i].Format(time.DateOnly))
+		}
+	}
+}
+
+func TestMaturityLadder(t *testing.T) {
+	asOf := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	short := &model.FixedIncome{CUSIP: "A", CouponRate: 0.05, MaturityDate: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), Par: 100}
+	middle := &model.FixedIncome{CUSIP: "B", CouponRate: 0.03, MaturityDate: time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC), Par: 100}
+	long := &model.FixedIncome{CUSIP: "C", CouponRate: 0.04, MaturityDate: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), Par: 100}
+
+	holdings := []*model.BondHolding{
+		model.NewBondHolding(long, "Fidelity IRA", 20, 2000.00, 98.50, asOf),
+		model.NewBondHolding(short, "Fidelity IRA", 10, 1000.00, 0, asOf),
+		model.NewBondHolding(middle, "Wachovia Brokerage", 30, 3000.00, 0, asOf),
+	}
+	if holdings[0].MarketValue != 1970.00 {
+		t.Errorf("got market value %.2f, want 1970.00", holdings[0].MarketValue)
+	}
+
+	ladder, err := model.MaturityLadder(holdings, model.LadderByYear)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(ladder) != 2 || ladder[0].Period != "2024" || ladder[1].Period != "2026" {
+		t.Fatalf("unexpected ladder %+v", ladder)
+	}
+	if ladder[0].Face != 4000.00 || math.Abs(ladder[0].WeightedCoupon-0.035) > 0.000001 {
+		t.Errorf("got face %.2f coupon %.4f, want 4000.00 and 0.035", ladder[0].Face, ladder[0].WeightedCoupon)
+	}
+
+	if ladder, _ = model.MaturityLadder(holdings, model.LadderByMonth); len(ladder) != 3 {
+		t.Errorf("got %d rungs by month, want 3", len(ladder))
+	}
+	if _, err = model.MaturityLadder(holdings, "week"); err == nil {
+		t.Error("expected an error for the bucket")
+	}
+}
+
+func TestTickerSet_BondMaturity(t *testing.T) {
+	f := model.FixedIncome{CUSIP: "38149MXQ1", CouponRate: 0.0475, MaturityDate: time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC), Type: "Buy Bonds", Symbol: f.CUSIP, Description: "50 shares @ 100.00", Shares: model.NewDecimal(50), Amount: model.NewDecimal(-5000.00), Account: "Fidelity IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	tickerSet.SetCorporateActions([]*model.CorporateAction{f.MaturityAction()})
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ticker, ok := tickerSet.GetTicker(f.CUSIP)
+	if !ok {
+		t.Fatal("missing ticker")
+	}
+	if shares := ticker.NumberOfShares(); !shares.IsZero() {
+		t.Errorf("got %s units after maturity, want 0", shares)
+	}
+}
@@ -14,7 +14,7 @@ import (
 	"time"
 )
 
-const historicDBFields = "source,symbol,date, open,high, low, close, adj_close,volume"
+const historicDBFields = "source,symbol,date, open,high, low, close, adj_close,volume, currency"
 
 var (
 	errPGXConnectionNil = fmt.Errorf("pgx connection pool is null")
@@ -42,6 +42,7 @@ type Historical struct {
 	AdjClose float64   `json:"adjClose"`
 	Volume   float64   `json:"volume"`
 	Source   string    `json:"source"`
+	Currency string    `json:"currency,omitempty"` // of the prices, DefaultCurrency when empty
 }
 
 func (h *Historical) String() string {
@@ -77,6 +78,7 @@ const (
 	HistoricalClose    = "Close"
 	HistoricalAdjClose = "Adj Close"
 	HistoricalVolume   = "Volume"
+	HistoricalCurrency = "Currency"
 )
 
 func NewHistorical(symbol, source string, headers, record []string) (*Historical, error) {
@@ -126,7 +128,13 @@ func NewHistorical(symbol, source string, headers, record []string) (*Historical
 				return nil, err
 			}
 		case HistoricalVolume:
-			hist.AdjClose, err = strconv.ParseFloat(record[i], 64)
+			hist.Volume, err = strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				logrus.Error(err)
+				return nil, err
+			}
+		case HistoricalCurrency:
+			hist.Currency, err = NormalizeCurrency(record[i])
 			if err != nil {
 				logrus.Error(err)
 				return nil, err
@@ -300,17 +308,18 @@ func (h *HistoricalDataSet) LoadDB(hist *Historical) error {
 	   close NUMERIC,
 	   adj_close NUMERIC,
 	   volume NUMERIC,
+	   currency VARCHAR(3),
 	*/
 	if h.pgxConn == nil {
 		return errPGXConnectionNil
 	}
 	insertStatement := fmt.Sprintf(
 		"INSERT INTO %s(%s)"+
-			" VALUES('%s','%s','%s','%.04f','%.04f','%.04f','%.04f','%.04f','%.04f') "+
+			" VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) "+
 			" ON CONFLICT DO NOTHING;",
-		h.historyTable, historicDBFields, hist.Source, hist.Symbol, hist.Date.Format("2006-01-02"),
-		hist.Open, hist.High, hist.Low, hist.Close, hist.AdjClose, hist.Volume)
-	rows, err := h.pgxConn.Query(context.Background(), insertStatement)
+		h.historyTable, historicDBFields)
+	rows, err := h.pgxConn.Query(context.Background(), insertStatement, hist.Source, hist.Symbol, hist.Date.Format("2006-01-02"),
+		hist.Open, hist.High, hist.Low, hist.Close, hist.AdjClose, hist.Volume, currencyOrDefault(hist.Currency))
 	defer rows.Close()
 	if err != nil {
 		return err
@@ -323,10 +332,10 @@ func (h *HistoricalDataSet) Last(symbol string, date time.Time) (*Historical, er
 		return nil, errPGXConnectionNil
 	}
 	queryStatement := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE symbol='%s' AND date < '%s' ORDER BY DATE DESC LIMIT 1;",
-		historicDBFields, h.historyTable, symbol, fmt.Sprintf("%4d-%02d-01", date.Year(), date.Month()))
+		"SELECT %s FROM %s WHERE symbol = $1 AND date < $2 ORDER BY DATE DESC LIMIT 1;",
+		historicDBFields, h.historyTable)
 
-	rows, err := h.pgxConn.Query(context.Background(), queryStatement)
+	rows, err := h.pgxConn.Query(context.Background(), queryStatement, symbol, fmt.Sprintf("%4d-%02d-01", date.Year(), date.Month()))
 	defer rows.Close()
 	if err != nil {
 		logrus.Error(err.Error())
@@ -337,7 +346,7 @@ func (h *HistoricalDataSet) Last(symbol string, date time.Time) (*Historical, er
 	// Iterate through the result set
 	i := 0
 	for rows.Next() {
-		err = rows.Scan(&hist.Source, &hist.Symbol, &hist.Date, &hist.Open, &hist.High, &hist.Low, &hist.Close, &hist.AdjClose, &hist.Volume)
+		err = rows.Scan(&hist.Source, &hist.Symbol, &hist.Date, &hist.Open, &hist.High, &hist.Low, &hist.Close, &hist.AdjClose, &hist.Volume, &hist.Currency)
 		if err != nil {
 			logrus.Error(err.Error())
 			return nil, err
@@ -353,3 +362,75 @@ func (h *HistoricalDataSet) Last(symbol string, date time.Time) (*Historical, er
 	}
 	return &hist, fmt.Errorf("retrived %d records", i)
 }
+
+// OnOrBefore returns the latest historical record for the symbol on or before the date.
+func (h *HistoricalDataSet) OnOrBefore(symbol string, date time.Time) (*Historical, error) {
+	if h.pgxConn == nil {
+		return nil, errPGXConnectionNil
+	}
+	hist := Historical{}
+	err := h.pgxConn.QueryRow(context.Background(), fmt.Sprintf(
+		"SELECT %s FROM %s WHERE symbol = $1 AND date <= $2::timestamp ORDER BY date DESC LIMIT 1;", historicDBFields, h.historyTable),
+		symbol, date.Format(dateToPgLayout)).
+		Scan(&hist.Source, &hist.Symbol, &hist.Date, &hist.Open, &hist.High, &hist.Low, &hist.Close, &hist.AdjClose, &hist.Volume, &hist.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &hist, nil
+}
+
+// Between returns the historical records for the symbol from start up to and including end, ordered by date.
+func (h *HistoricalDataSet) Between(symbol string, start, end time.Time) ([]*Historical, error) {
+	if h.pgxConn == nil {
+		return nil, errPGXConnectionNil
+	}
+	queryStatement := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE symbol = $1 AND date >= $2 AND date <= $3 ORDER BY date;",
+		historicDBFields, h.historyTable)
+
+	rows, err := h.pgxConn.Query(context.Background(), queryStatement, symbol, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	defer rows.Close()
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	var history []*Historical
+	for rows.Next() {
+		hist := Historical{}
+		err = rows.Scan(&hist.Source, &hist.Symbol, &hist.Date, &hist.Open, &hist.High, &hist.Low, &hist.Close, &hist.AdjClose, &hist.Volume, &hist.Currency)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		history = append(history, &hist)
+	}
+	return history, nil
+}
+
+// BetweenBySymbols returns the historical records of each symbol from start up to and including end,
+// ordered by date, from a single query.
+func (h *HistoricalDataSet) BetweenBySymbols(ctx context.Context, symbols []string, start, end time.Time) (map[string][]*Historical, error) {
+	if h.pgxConn == nil {
+		return nil, errPGXConnectionNil
+	}
+	rows, err := h.pgxConn.Query(ctx, fmt.Sprintf(
+		"SELECT %s FROM %s WHERE symbol = ANY($1) AND date >= $2::timestamp AND date <= $3::timestamp ORDER BY symbol, date;",
+		historicDBFields, h.historyTable), symbols, start.Format(dateToPgLayout), end.Format(dateToPgLayout))
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string][]*Historical, len(symbols))
+	for rows.Next() {
+		hist := Historical{}
+		if err := rows.Scan(&hist.Source, &hist.Symbol, &hist.Date, &hist.Open, &hist.High, &hist.Low, &hist.Close, &hist.AdjClose, &hist.Volume, &hist.Currency); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		history[hist.Symbol] = append(history[hist.Symbol], &hist)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+const (
+	ShortTermHolding = "short"
+	LongTermHolding  = "long"
+)
+
+// Holding is an open lot, the shares left of a buy in an account.
+type Holding struct {
+	Symbol          string          `json:"symbol"`
+	Security        string          `json:"security,omitempty"`
+	Account         string          `json:"account"`
+	Type            TransactionType `json:"type"`
+	Acquired        time.Time       `json:"acquired"`
+	OriginalShares  Decimal         `json:"originalShares"`
+	RemainingShares Decimal         `json:"remainingShares"`
+	CostPerShare    Decimal         `json:"costPerShare"`
+	Cost            Decimal         `json:"cost"`
+	HoldingPeriod   string          `json:"holdingPeriod"`
+	DaysHeld        int             `json:"daysHeld"`
+	LatestPrice     Decimal         `json:"latestPrice,omitempty"`
+	MarketValue     Decimal         `json:"marketValue,omitempty"`
+	UnrealizedGain  Decimal         `json:"unrealizedGain,omitempty"`
+	Currency        string          `json:"currency,omitempty"`
+}
+
+// holdingPeriod returns the days the lot acquired was held on asOf and whether it is long term, held more
+// than a year.
+func holdingPeriod(acquired, asOf time.Time) (int, string) {
+	days := int(asOf.Sub(acquired).Hours() / 24)
+	if asOf.After(acquired.AddDate(1, 0, 0)) {
+		return days, LongTermHolding
+	}
+	return days, ShortTermHolding
+}
+
+// newHolding returns the open lot of the entity. The cost is the cost of the buy less its basis
+// adjustments, shared between the remaining and sold shares, so a sale does not change the cost per share
+// of what is left as it changes NetCost.
+func newHolding(account string, e *Entity, asOf time.Time) *Holding {
+	shares := e.RemainingShares
+	for _, lot := range e.SoldLots {
+		shares = shares.Add(lot.NumberShares)
+	}
+	costPerShare := e.Amount.Abs().Sub(e.BasisAdjustment).Div(shares)
+
+	h := Holding{
+		Symbol:          e.Symbol,
+		Account:         account,
+		Type:            e.Type,
+		Acquired:        e.Date,
+		OriginalShares:  e.Shares.Abs(),
+		RemainingShares: e.RemainingShares,
+		CostPerShare:    costPerShare.Round(4),
+		Cost:            costPerShare.Mul(e.RemainingShares).RoundCents(),
+		Currency:        currencyOrDefault(e.Currency),
+	}
+	h.DaysHeld, h.HoldingPeriod = holdingPeriod(e.Date, asOf)
+	return &h
+}
+
+// Holdings returns the open lots of the ticker by account and date acquired, with the holding period on
+// asOf.
+func (t *Ticker) Holdings(asOf time.Time) []*Holding {
+	holdings := []*Holding{}
+	for _, acct := range t.Accounts {
+		for _, e := range acct.Entities {
+			if e.RemainingShares.Sign() > 0 && utils.Contains(BuyTransactions, string(e.Type)) {
+				holdings = append(holdings, newHolding(acct.Name, e, asOf))
+			}
+		}
+	}
+	sort.SliceStable(holdings, func(i, j int) bool {
+		if holdings[i].Account != holdings[j].Account {
+			return holdings[i].Account < holdings[j].Account
+		}
+		return holdings[i].Acquired.Before(holdings[j].Acquired)
+	})
+	return holdings
+}
+
+// SetPrice values the lot at the price.
+func (h *Holding) SetPrice(price Decimal) {
+	h.LatestPrice = price
+	h.MarketValue = h.RemainingShares.Mul(price).RoundCents()
+	h.UnrealizedGain = h.MarketValue.Sub(h.Cost)
+}
+
+// HoldingsGet returns the open lots of the symbol from its transactions on or before asOf, or from all of
+// them for a zero asOf.
+func HoldingsGet(ctx context.Context, pg *pgxpool.Pool, symbol string, asOf time.Time) ([]*Holding, error) {
+	ts := NewTransactionSet()
+	if err := ts.TransactionSetWithOptionsBySymbol(ctx, pg, transactionTable, symbol); err != nil {
+		return nil, err
+	}
+	return HoldingsFromTransactions(ctx, pg, symbol, asOf, ts)
+}
+
+// HoldingsFromTransactions returns the open lots of the symbol on asOf from the transactions of it and of
+// the options on it.
+func HoldingsFromTransactions(ctx context.Context, pg *pgxpool.Pool, symbol string, asOf time.Time, transactions *TransactionSet) ([]*Holding, error) {
+	ts := NewTransactionSet()
+	ts.portfolio = transactions.portfolio
+	ts.TransactionRows = transactionsAsOf(transactions.TransactionRows, asOf)
+
+	security := ""
+	for _, tr := range ts.TransactionRows {
+		if tr.Symbol == symbol && tr.Security != "" {
+			security = tr.Security
+		}
+	}
+
+	ticker, err := TickerGetAsOf(ctx, pg, symbol, ts, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	holdings := ticker.Holdings(asOf)
+	for _, h := range holdings {
+		h.Security = security
+	}
+	return holdings, nil
+}
+
+// HoldingsGetAll returns the open lots of every symbol with a transaction on or before asOf, or of every
+// symbol for a zero asOf. The transactions and corporate actions are read once and loaded into one
+// TickerSet, so the shares moved between symbols by an action are in the lots of the symbol received.
+func HoldingsGetAll(ctx context.Context, pg *pgxpool.Pool, asOf time.Time) ([]*Holding, error) {
+	ts := NewTransactionSet()
+	if err := ts.TransactionsAllGetAsOf(ctx, pg, asOf); err != nil {
+		return nil, err
+	}
+
+	actions, err := CorporateActionsGetAll(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+	// Bonds and CDs are redeemed at maturity whether or not there is a transaction for it.
+	bonds, err := FixedIncomeGetAll(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range bonds {
+		actions = append(actions, f.MaturityAction())
+	}
+
+	tickerSet := NewTickerSet()
+	tickerSet.SetCorporateActions(actionsAsOf(actions, asOf))
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	securities := make(map[string]string)
+	for _, tr := range ts.TransactionRows {
+		if tr.Security != "" {
+			securities[tr.Symbol] = tr.Security
+		}
+	}
+
+	symbols := make([]string, 0, len(tickerSet.Set))
+	for symbol := range tickerSet.Set {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	holdings := []*Holding{}
+	for _, symbol := range symbols {
+		for _, h := range tickerSet.Set[symbol].Holdings(asOf) {
+			h.Security = securities[symbol]
+			holdings = append(holdings, h)
+		}
+	}
+	return holdings, nil
+}
@@ -0,0 +1,48 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"testing"
+	"time"
+)
+
+func TestTicker_Holdings(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	ts := model.TransactionSet{TransactionRows: []*model.Transaction{
+		{Id: 1, Date: day(2022, time.January, 5), Type: "Buy", Symbol: "CSX", Description: "100 shares @ 30.00", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-3000), Account: "Fidelity IRA"},
+		{Id: 2, Date: day(2024, time.January, 5), Type: "Buy", Symbol: "CSX", Description: "50 shares @ 40.00", Shares: model.NewDecimal(50), Amount: model.NewDecimal(-2000), Account: "Fidelity IRA"},
+		{Id: 3, Date: day(2024, time.January, 8), Type: "Buy", Symbol: "CSX", Description: "10 shares @ 35.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-350), Account: "Brokerage"},
+		{Id: 4, Date: day(2024, time.February, 1), Type: "Sell", Symbol: "CSX", Description: "120 shares @ 45.00", Shares: model.NewDecimal(-120), Amount: model.NewDecimal(5400), Account: "Fidelity IRA"},
+	}}
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(&ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	ticker, _ := tickerSet.GetTicker("CSX")
+
+	holdings := ticker.Holdings(day(2025, time.January, 6))
+	if len(holdings) != 2 {
+		t.Fatalf("got %d lots, want 2", len(holdings))
+	}
+	if h := holdings[0]; h.Account != "Brokerage" || h.RemainingShares != model.NewDecimal(10) || h.Cost != model.NewDecimal(350) ||
+		h.HoldingPeriod != model.ShortTermHolding {
+		t.Errorf("unexpected lot %+v", h)
+	}
+	// The sale of 20 of its shares leaves the cost per share of the lot as it was bought.
+	h := holdings[1]
+	if h.Account != "Fidelity IRA" || h.OriginalShares != model.NewDecimal(50) || h.RemainingShares != model.NewDecimal(30) ||
+		h.CostPerShare != model.NewDecimal(40) || h.Cost != model.NewDecimal(1200) || h.HoldingPeriod != model.LongTermHolding || h.DaysHeld != 367 {
+		t.Errorf("unexpected lot %+v", h)
+	}
+
+	h.SetPrice(model.NewDecimal(36.50))
+	if h.MarketValue != model.NewDecimal(1095) || h.UnrealizedGain != model.NewDecimal(-105) {
+		t.Errorf("unexpected value %s and gain %s", h.MarketValue, h.UnrealizedGain)
+	}
+
+	if holdings := ticker.Holdings(day(2024, time.December, 31)); holdings[1].HoldingPeriod != model.ShortTermHolding {
+		t.Errorf("got %s, want a short term lot", holdings[1].HoldingPeriod)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"time"
 )
 
+const lookupHistoryTable = "lookup_history"
+
 type LookUpSet struct {
 	Id        string            `json:"_id"`
 	Rev       string            `json:"_rev,omitempty"`
@@ -109,14 +111,23 @@ func LoadLookupFromCSV(ctx context.Context, pgxConn *pgxpool.Pool, table string,
 
 func LoadLookupToDB(ctx context.Context, pgxConn *pgxpool.Pool, table, security, symbol string) error {
 	insertStatement := fmt.Sprintf(
-		"INSERT INTO %s ( security, symbol)"+" VALUES('%s','%s');",
-		table, security, symbol)
-	rows, err := pgxConn.Query(ctx, insertStatement)
+		"INSERT INTO %s ( security, symbol)"+" VALUES($1,$2);",
+		table)
+	rows, err := pgxConn.Query(ctx, insertStatement, security, symbol)
 	defer rows.Close()
 	if err != nil {
 		return err
 	}
-	return nil
+	// A security already in the table is left as it was, and so is its history.
+	if rows.Close(); rows.Err() != nil {
+		logrus.Debug(security, ": ", rows.Err().Error())
+		return nil
+	}
+
+	// The history keeps the lookups as they were on a date for GetLookUpsAsOf.
+	_, err = pgxConn.Exec(ctx, fmt.Sprintf("INSERT INTO %s(table_name, security, symbol, loaded) VALUES($1,$2,$3,$4);", lookupHistoryTable),
+		table, security, symbol, time.Now().UTC())
+	return err
 }
 
 func countLookups(ctx context.Context, pgxConn *pgxpool.Pool, table string) (int, error) {
@@ -156,3 +167,38 @@ func GetLookUpsFromDB(ctx context.Context, pgxConn *pgxpool.Pool, table string)
 	logrus.Infof("Loaded %v lookups from DB", len(l.LookUps))
 	return l, nil
 }
+
+// GetLookUpsAsOf returns the lookups of the table as they were at the end of asOf, the latest symbol of each
+// security loaded on or before it. A lookup loaded before the history was kept is in every set.
+func GetLookUpsAsOf(ctx context.Context, pgxConn *pgxpool.Pool, table string, asOf time.Time) (*LookUpSet, error) {
+	l := NewLookupSet(table)
+	l.Timestamp = asOf.Format(dateToPgLayout)
+
+	rows, err := pgxConn.Query(ctx, fmt.Sprintf(
+		"SELECT security, symbol FROM (SELECT DISTINCT ON (security) security, symbol FROM %s"+
+			" WHERE table_name = $1 AND loaded < $2::timestamp ORDER BY security, loaded DESC) h"+
+			" UNION ALL SELECT security, symbol FROM %s l WHERE NOT EXISTS"+
+			" (SELECT 1 FROM %s WHERE table_name = $1 AND security = l.security);",
+		lookupHistoryTable, table, lookupHistoryTable),
+		table, asOf.AddDate(0, 0, 1).Format(dateToPgLayout))
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var security, symbol string
+		if err := rows.Scan(&security, &symbol); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		l.LookUps[security] = symbol
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	logrus.Infof("Loaded %v lookups as of %s from DB", len(l.LookUps), l.Timestamp)
+	return l, nil
+}
@@ -3,11 +3,12 @@ package model
 import "time"
 
 type Lot struct {
-	NumberShares  float64
-	PricePerShare float64
+	NumberShares  Decimal
+	PricePerShare Decimal
 	SoldDate      time.Time
 }
 
-func (l *Lot) Proceeds() float64 {
-	return l.NumberShares * l.PricePerShare
+// Proceeds returns the shares sold at the price per share rounded to the cent.
+func (l *Lot) Proceeds() Decimal {
+	return l.NumberShares.Mul(l.PricePerShare).RoundCents()
 }
@@ -0,0 +1,606 @@
+package model
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FundDistributionsTable = "fund_distributions"
+	FundProfilesTable      = "fund_profiles"
+
+	DistributionDividend         = "dividend"
+	DistributionShortTermCapGain = "short-term capital gain"
+	DistributionLongTermCapGain  = "long-term capital gain"
+
+	NAVProviderDirectory = "directory"
+
+	// navDistributionsSuffix names the distributions file dropped next to the NAV file, AAAAX-distributions.csv.
+	navDistributionsSuffix = "-distributions"
+	navLoadedDirectory     = "loaded"
+
+	fundDistributionFields = "symbol, date, distribution_type, per_share, source"
+	fundProfileFields      = "symbol, name, family, expense_ratio"
+)
+
+var (
+	errNAVProviderUnknown   = errors.New("nav provider unknown")
+	errNAVNotFound          = errors.New("nav history not found")
+	errDistributionType     = errors.New("distribution type unknown")
+	errDistributionPerShare = errors.New("distribution per share must be more than zero")
+	errFundSymbol           = errors.New("fund symbol missing")
+	errFundExpenseRatio     = errors.New("fund expense ratio must be a fraction between 0 and 1")
+)
+
+// NAVProvider supplies the daily net asset values and the distributions of a mutual fund. The NAV is
+// the Close of the Historical records.
+type NAVProvider interface {
+	Source() string
+	NAVs(ctx context.Context, symbol string, start, end time.Time) ([]*Historical, error)
+	Distributions(ctx context.Context, symbol string, start, end time.Time) ([]*FundDistribution, error)
+}
+
+var (
+	navProvidersLock sync.RWMutex
+	navProviders     = make(map[string]NAVProvider)
+)
+
+// RegisterNAVProvider makes the provider available by its source name, replacing one with the same name.
+func RegisterNAVProvider(p NAVProvider) {
+	navProvidersLock.Lock()
+	defer navProvidersLock.Unlock()
+	navProviders[p.Source()] = p
+}
+
+// NAVProviderGet returns the registered provider with the source name.
+func NAVProviderGet(source string) (NAVProvider, error) {
+	navProvidersLock.RLock()
+	defer navProvidersLock.RUnlock()
+	p, ok := navProviders[source]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errNAVProviderUnknown, source)
+	}
+	return p, nil
+}
+
+// FundDistribution is a distribution per share paid by a fund on the date. Capital gain distributions
+// are kept apart from the dividends so the yield is not overstated.
+type FundDistribution struct {
+	Symbol           string    `json:"symbol"`
+	Date             time.Time `json:"date"`
+	DistributionType string    `json:"type"`
+	PerShare         float64   `json:"perShare"`
+	Source           string    `json:"source,omitempty"`
+}
+
+func (d *FundDistribution) String() string {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// Validate checks the distribution has a symbol, a known type and an amount.
+func (d *FundDistribution) Validate() error {
+	if d.Symbol == "" {
+		return errFundSymbol
+	}
+	switch d.DistributionType {
+	case DistributionDividend, DistributionShortTermCapGain, DistributionLongTermCapGain:
+	default:
+		return fmt.Errorf("%w: %s", errDistributionType, d.DistributionType)
+	}
+	if d.PerShare <= 0 {
+		return fmt.Errorf("%w: %s %.4f", errDistributionPerShare, d.Symbol, d.PerShare)
+	}
+	return nil
+}
+
+// CapitalGain returns true when the distribution is a short or long term capital gain.
+func (d *FundDistribution) CapitalGain() bool {
+	return d.DistributionType != DistributionDividend
+}
+
+// distributionType returns the distribution type for the names used by the fund companies.
+func distributionType(name string) string {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case strings.Contains(lower, "short"):
+		return DistributionShortTermCapGain
+	case strings.Contains(lower, "long"), strings.Contains(lower, "capital"):
+		return DistributionLongTermCapGain
+	case strings.Contains(lower, "div"), strings.Contains(lower, "income"):
+		return DistributionDividend
+	}
+	return lower
+}
+
+// NewFundDistributions reads the distributions of the symbol from a csv with Date, Type and Amount columns.
+func NewFundDistributions(symbol, source string, r io.Reader) ([]*FundDistribution, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var distributions []*FundDistribution
+	var headers []string
+	for _, record := range records {
+		if headers == nil {
+			headers = record
+			continue
+		}
+		d := FundDistribution{Symbol: symbol, Source: source}
+		for i, h := range headers {
+			if i >= len(record) {
+				break
+			}
+			switch strings.TrimSpace(h) {
+			case HistoricalDate:
+				if d.Date, err = time.Parse(dateToPgLayout, record[i]); err != nil {
+					return nil, err
+				}
+			case "Type":
+				d.DistributionType = distributionType(record[i])
+			case "Amount":
+				if d.PerShare, err = utils.FloatParse(record[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := d.Validate(); err != nil {
+			return nil, err
+		}
+		distributions = append(distributions, &d)
+	}
+	return distributions, nil
+}
+
+// ToDB stores the distribution, replacing the same type on the same date.
+func (d *FundDistribution) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5)"+
+			" ON CONFLICT (symbol, date, distribution_type) DO UPDATE SET per_share = EXCLUDED.per_share, source = EXCLUDED.source;",
+		FundDistributionsTable, fundDistributionFields)
+	if _, err := pg.Exec(ctx, insertStatement, d.Symbol, d.Date.Format(dateToPgLayout), d.DistributionType, d.PerShare, d.Source); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// FundDistributionsGet returns the distributions of the symbol in date order.
+func FundDistributionsGet(ctx context.Context, pg *pgxpool.Pool, symbol string) ([]*FundDistribution, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE symbol = $1 ORDER BY date, distribution_type;",
+		fundDistributionFields, FundDistributionsTable), symbol)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var distributions []*FundDistribution
+	for rows.Next() {
+		d := FundDistribution{}
+		if err := rows.Scan(&d.Symbol, &d.Date, &d.DistributionType, &d.PerShare, &d.Source); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		distributions = append(distributions, &d)
+	}
+	return distributions, nil
+}
+
+// NAVDirectoryProvider reads the NAV history from files dropped in a directory. The NAVs of AAAAX are
+// in AAAAX.csv with the Date and Close columns used by /historical, and its distributions are in
+// AAAAX-distributions.csv.
+type NAVDirectoryProvider struct {
+	Dir string
+}
+
+func NewNAVDirectoryProvider(dir string) *NAVDirectoryProvider {
+	return &NAVDirectoryProvider{Dir: dir}
+}
+
+func (p *NAVDirectoryProvider) Source() string {
+	return NAVProviderDirectory
+}
+
+func (p *NAVDirectoryProvider) navFile(symbol string) string {
+	return filepath.Join(p.Dir, symbol+".csv")
+}
+
+func (p *NAVDirectoryProvider) distributionsFile(symbol string) string {
+	return filepath.Join(p.Dir, symbol+navDistributionsSuffix+".csv")
+}
+
+// Symbols returns the symbols with a NAV file in the directory.
+func (p *NAVDirectoryProvider) Symbols() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(p.Dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".csv")
+		if strings.HasSuffix(name, navDistributionsSuffix) {
+			continue
+		}
+		symbols = append(symbols, name)
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// NAVs returns the NAVs of the symbol between the dates, including both.
+func (p *NAVDirectoryProvider) NAVs(_ context.Context, symbol string, start, end time.Time) ([]*Historical, error) {
+	f, err := os.Open(p.navFile(symbol))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", errNAVNotFound, symbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var history []*Historical
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		hist, err := NewHistorical(symbol, p.Source(), records[0], record)
+		if err != nil {
+			return nil, err
+		}
+		if hist.Date.Before(start) || hist.Date.After(end) {
+			continue
+		}
+		history = append(history, hist)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+	return history, nil
+}
+
+// Distributions returns the distributions of the symbol between the dates, or none when there is no file.
+func (p *NAVDirectoryProvider) Distributions(_ context.Context, symbol string, start, end time.Time) ([]*FundDistribution, error) {
+	f, err := os.Open(p.distributionsFile(symbol))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	all, err := NewFundDistributions(symbol, p.Source(), f)
+	if err != nil {
+		return nil, err
+	}
+	var distributions []*FundDistribution
+	for _, d := range all {
+		if !d.Date.Before(start) && !d.Date.After(end) {
+			distributions = append(distributions, d)
+		}
+	}
+	return distributions, nil
+}
+
+// MarkLoaded moves the files of the symbol into the loaded directory so they are not ingested again.
+func (p *NAVDirectoryProvider) MarkLoaded(symbol string) error {
+	loaded := filepath.Join(p.Dir, navLoadedDirectory)
+	if err := os.MkdirAll(loaded, 0o755); err != nil {
+		return err
+	}
+	for _, f := range []string{p.navFile(symbol), p.distributionsFile(symbol)} {
+		if _, err := os.Stat(f); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := os.Rename(f, filepath.Join(loaded, filepath.Base(f))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FundIngest is the number of NAVs and distributions loaded for a fund and the gaps left in its history.
+type FundIngest struct {
+	Symbol        string    `json:"symbol"`
+	Source        string    `json:"source"`
+	NAVs          int       `json:"navs"`
+	Distributions int       `json:"distributions"`
+	Gaps          []*NAVGap `json:"gaps,omitempty"`
+}
+
+// FundIngestNAVs loads the NAVs and distributions of the symbol between the dates from the provider
+// into fund_history and fund_distributions.
+func FundIngestNAVs(ctx context.Context, pg *pgxpool.Pool, p NAVProvider, symbol string, start, end time.Time) (*FundIngest, error) {
+	history, err := p.NAVs(ctx, symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+	distributions, err := p.Distributions(ctx, symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := NewHistoricalDataSet(pg, fundHistoryTable)
+	for _, hist := range history {
+		if err := ds.LoadDB(hist); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+	}
+	for _, d := range distributions {
+		if err := d.ToDB(ctx, pg); err != nil {
+			return nil, err
+		}
+	}
+
+	ingest := FundIngest{Symbol: symbol, Source: p.Source(), NAVs: len(history), Distributions: len(distributions)}
+	if len(history) > 0 {
+		ingest.Gaps = NAVGaps(history, history[0].Date, end)
+	}
+	logrus.Info("Loaded ", ingest.NAVs, " NAVs and ", ingest.Distributions, " distributions for ", symbol, " from ", p.Source())
+	return &ingest, nil
+}
+
+// FundIngestDirectory loads every fund dropped in the directory and moves the files it loaded aside.
+func FundIngestDirectory(ctx context.Context, pg *pgxpool.Pool, p *NAVDirectoryProvider) ([]*FundIngest, error) {
+	symbols, err := p.Symbols()
+	if err != nil {
+		return nil, err
+	}
+
+	var ingests []*FundIngest
+	for _, symbol := range symbols {
+		ingest, err := FundIngestNAVs(ctx, pg, p, symbol, time.Time{}, time.Now())
+		if err != nil {
+			return ingests, fmt.Errorf("%s: %w", symbol, err)
+		}
+		if err := p.MarkLoaded(symbol); err != nil {
+			return ingests, err
+		}
+		ingests = append(ingests, ingest)
+	}
+	return ingests, nil
+}
+
+// NAVGap is a run of business days without a NAV.
+type NAVGap struct {
+	Symbol  string    `json:"symbol"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Missing int       `json:"missing"`
+}
+
+// businessDaysBetween returns the weekdays after start and before end.
+func businessDaysBetween(start, end time.Time) int {
+	days := 0
+	for d := start.AddDate(0, 0, 1); d.Before(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	return days
+}
+
+// NAVGaps returns the gaps in the history, ordered by date, from start through end. A single missing
+// weekday is taken to be a market holiday and is not a gap.
+func NAVGaps(history []*Historical, start, end time.Time) []*NAVGap {
+	if len(history) == 0 {
+		return nil
+	}
+	symbol := history[0].Symbol
+
+	var gaps []*NAVGap
+	check := func(from, to time.Time) {
+		if missing := businessDaysBetween(from, to); missing > 1 {
+			gaps = append(gaps, &NAVGap{Symbol: symbol, From: from, To: to, Missing: missing})
+		}
+	}
+
+	check(start.AddDate(0, 0, -1), history[0].Date)
+	for i := 1; i < len(history); i++ {
+		check(history[i-1].Date, history[i].Date)
+	}
+	check(history[len(history)-1].Date, end.AddDate(0, 0, 1))
+	return gaps
+}
+
+// FundHistoryGaps returns the gaps in the NAVs of the symbol in fund_history between the dates.
+func FundHistoryGaps(pg *pgxpool.Pool, symbol string, start, end time.Time) ([]*NAVGap, error) {
+	history, err := NewHistoricalDataSet(pg, fundHistoryTable).Between(symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("%w: %s", errNAVNotFound, symbol)
+	}
+	return NAVGaps(history, start, end), nil
+}
+
+// FundProfile is the description of a fund and its annual expense ratio, 0.0004 for 0.04%.
+type FundProfile struct {
+	Symbol       string  `json:"symbol"`
+	Name         string  `json:"name,omitempty"`
+	Family       string  `json:"family,omitempty"`
+	ExpenseRatio float64 `json:"expenseRatio"`
+}
+
+// Validate checks the profile has a symbol and an expense ratio that is a fraction.
+func (f *FundProfile) Validate() error {
+	if f.Symbol == "" {
+		return errFundSymbol
+	}
+	if f.ExpenseRatio < 0 || f.ExpenseRatio >= 1 {
+		return fmt.Errorf("%w: %s %.4f", errFundExpenseRatio, f.Symbol, f.ExpenseRatio)
+	}
+	return nil
+}
+
+// NewFundProfilesFromJSON reads a single fund profile or a list of them.
+func NewFundProfilesFromJSON(data []byte) ([]*FundProfile, error) {
+	var profiles []*FundProfile
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var f FundProfile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, &f)
+	} else if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+
+	for _, f := range profiles {
+		if err := f.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// ToDB stores the profile, replacing the profile of the same fund.
+func (f *FundProfile) ToDB(ctx context.Context, pg *pgxpool.Pool) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	insertStatement := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4)"+
+			" ON CONFLICT (symbol) DO UPDATE SET name = EXCLUDED.name, family = EXCLUDED.family, expense_ratio = EXCLUDED.expense_ratio;",
+		FundProfilesTable, fundProfileFields)
+	if _, err := pg.Exec(ctx, insertStatement, f.Symbol, f.Name, f.Family, f.ExpenseRatio); err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// FundProfilesGet returns the fund profiles keyed by symbol.
+func FundProfilesGet(ctx context.Context, pg *pgxpool.Pool) (map[string]*FundProfile, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s ORDER BY symbol;", fundProfileFields, FundProfilesTable))
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := make(map[string]*FundProfile)
+	for rows.Next() {
+		f := FundProfile{}
+		if err := rows.Scan(&f.Symbol, &f.Name, &f.Family, &f.ExpenseRatio); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		profiles[f.Symbol] = &f
+	}
+	return profiles, nil
+}
+
+// FundExpense is the yearly cost of holding a fund at its expense ratio.
+type FundExpense struct {
+	Symbol       string  `json:"symbol"`
+	Name         string  `json:"name,omitempty"`
+	MarketValue  float64 `json:"marketValue"`
+	ExpenseRatio float64 `json:"expenseRatio"`
+	AnnualCost   float64 `json:"annualCost"`
+}
+
+// FundExpenseReport is the cost of the funds held, the expense ratio weighted by market value and the
+// funds held without a profile.
+type FundExpenseReport struct {
+	Funds                []*FundExpense `json:"funds"`
+	MarketValue          float64        `json:"marketValue"`
+	AnnualCost           float64        `json:"annualCost"`
+	WeightedExpenseRatio float64        `json:"weightedExpenseRatio"`
+	Unknown              []string       `json:"unknown,omitempty"`
+}
+
+// NewFundExpenseReport builds the expense report from the market value of each fund held.
+func NewFundExpenseReport(profiles map[string]*FundProfile, marketValues map[string]float64) *FundExpenseReport {
+	var symbols []string
+	for symbol := range marketValues {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	report := FundExpenseReport{}
+	for _, symbol := range symbols {
+		value := marketValues[symbol]
+		if value <= 0 {
+			continue
+		}
+		profile, ok := profiles[symbol]
+		if !ok {
+			report.Unknown = append(report.Unknown, symbol)
+			continue
+		}
+		expense := FundExpense{
+			Symbol:       symbol,
+			Name:         profile.Name,
+			MarketValue:  value,
+			ExpenseRatio: profile.ExpenseRatio,
+			AnnualCost:   value * profile.ExpenseRatio,
+		}
+		report.Funds = append(report.Funds, &expense)
+		report.MarketValue += expense.MarketValue
+		report.AnnualCost += expense.AnnualCost
+	}
+	if report.MarketValue > 0 {
+		report.WeightedExpenseRatio = report.AnnualCost / report.MarketValue
+	}
+	return &report
+}
+
+// FundExpenseReportGet builds the expense report for the mutual funds held, and any other fund with a
+// profile, using their latest prices.
+func FundExpenseReportGet(ctx context.Context, pg *pgxpool.Pool, lookups *LookUpSet) (*FundExpenseReport, error) {
+	profiles, err := FundProfilesGet(ctx, pg)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := SymbolList(ctx, pg, lookups)
+	if err != nil {
+		return nil, err
+	}
+
+	marketValues := make(map[string]float64)
+	for symbol := range symbols {
+		if _, ok := profiles[symbol]; !ok && SymbolTypeMap[symbol] != "Mutual Fund" {
+			continue
+		}
+		info, err := AccountInfoGet(ctx, pg, symbol)
+		if err != nil {
+			return nil, err
+		}
+		marketValues[symbol] = info.NumberOfShares * info.LatestPrice
+	}
+	return NewFundExpenseReport(profiles, marketValues), nil
+}
@@ -0,0 +1,137 @@
+package model_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNAVDirectoryProvider(t *testing.T) {
+	dir := t.TempDir()
+	navs := "Date,Open,High,Low,Close,Adj Close,Volume\n" +
+		"2024-01-03,10.10,10.10,10.10,10.10,10.10,0\n" +
+		"2024-01-02,10.00,10.00,10.00,10.00,10.00,0\n" +
+		"2024-01-04,10.20,10.20,10.20,10.20,10.20,0\n"
+	distributions := "Date,Type,Amount\n" +
+		"2024-01-03,Dividend,0.05\n" +
+		"2024-01-03,Long Term Cap Gain,0.40\n" +
+		"2024-01-03,Short Term Cap Gain,0.10\n"
+	if err := os.WriteFile(filepath.Join(dir, "USAIX.csv"), []byte(navs), 0o644); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "USAIX-distributions.csv"), []byte(distributions), 0o644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	p := model.NewNAVDirectoryProvider(dir)
+	symbols, err := p.Symbols()
+	if err != nil || len(symbols) != 1 || symbols[0] != "USAIX" {
+		t.Fatalf("got symbols %v %v, want USAIX", symbols, err)
+	}
+
+	history, err := p.NAVs(context.Background(), "USAIX", time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), time.Now())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(history) != 2 || history[0].Close != 10.10 || history[1].AdjClose != 10.20 {
+		t.Errorf("unexpected history %v", history)
+	}
+
+	all, err := p.Distributions(context.Background(), "USAIX", time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	capitalGains := 0.00
+	for _, d := range all {
+		if d.CapitalGain() {
+			capitalGains += d.PerShare
+		}
+	}
+	if len(all) != 3 || math.Abs(capitalGains-0.50) > 0.000001 {
+		t.Errorf("got %d distributions with %.2f capital gains, want 3 and 0.50", len(all), capitalGains)
+	}
+
+	if _, err := p.NAVs(context.Background(), "VTSAX", time.Time{}, time.Now()); err == nil {
+		t.Error("expected an error for a fund without a file")
+	}
+
+	if err := p.MarkLoaded("USAIX"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if symbols, _ := p.Symbols(); len(symbols) != 0 {
+		t.Errorf("got symbols %v after loading, want none", symbols)
+	}
+}
+
+func TestNewFundDistributions_Invalid(t *testing.T) {
+	if _, err := model.NewFundDistributions("USAIX", "test", strings.NewReader("Date,Type,Amount\n2024-01-03,Return,0.05\n")); err == nil {
+		t.Error("expected an error for the distribution type")
+	}
+	if _, err := model.NewFundDistributions("USAIX", "test", strings.NewReader("Date,Type,Amount\n2024-01-03,Dividend,0\n")); err == nil {
+		t.Error("expected an error for the amount")
+	}
+}
+
+func TestNAVGaps(t *testing.T) {
+	day := func(d int) *model.Historical {
+		return &model.Historical{Symbol: "USAIX", Date: time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC), Close: 10.00}
+	}
+	// Jan 1 is a holiday, the 10th through 12th are missing and the history stops on the 25th.
+	history := []*model.Historical{day(2), day(3), day(4), day(5), day(8), day(9), day(15), day(16), day(25)}
+
+	gaps := model.NAVGaps(history, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC))
+	if len(gaps) != 3 {
+		t.Fatalf("got %d gaps, want 3: %v", len(gaps), gaps)
+	}
+	if gaps[0].Missing != 3 || !gaps[0].From.Equal(day(9).Date) {
+		t.Errorf("got gap %+v, want the 10th through 12th", gaps[0])
+	}
+	if gaps[1].Missing != 6 {
+		t.Errorf("got gap %+v, want 6 days missing after the 16th", gaps[1])
+	}
+	if gaps[2].Missing != 4 {
+		t.Errorf("got gap %+v, want 4 days missing at the end", gaps[2])
+	}
+}
+
+func TestNewFundExpenseReport(t *testing.T) {
+	profiles := map[string]*model.FundProfile{
+		"VTSAX": {Symbol: "VTSAX", ExpenseRatio: 0.0004},
+		"USAIX": {Symbol: "USAIX", ExpenseRatio: 0.0100},
+	}
+	report := model.NewFundExpenseReport(profiles, map[string]float64{"VTSAX": 90000.00, "USAIX": 10000.00, "FTBFX": 5000.00, "JENSX": 0})
+
+	if len(report.Funds) != 2 || len(report.Unknown) != 1 || report.Unknown[0] != "FTBFX" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if math.Abs(report.AnnualCost-136.00) > 0.001 || math.Abs(report.WeightedExpenseRatio-0.00136) > 0.000001 {
+		t.Errorf("got cost %.2f ratio %.5f, want 136.00 and 0.00136", report.AnnualCost, report.WeightedExpenseRatio)
+	}
+
+	if _, err := model.NewFundProfilesFromJSON([]byte(`{"symbol":"VTSAX","expenseRatio":4}`)); err == nil {
+		t.Error("expected an error for an expense ratio given as a percent")
+	}
+}
+
+func TestTicker_CapitalGainDistributions(t *testing.T) {
+	acct := model.NewAccount("Fidelity IRA")
+	acct.AddEntity(&model.Entity{Type: "Dividend Income", Symbol: "USAIX", Amount: model.NewDecimal(50.00)})
+	acct.AddEntity(&model.Entity{Type: "Reinvest Long-term Capital Gain", Symbol: "USAIX", Shares: model.NewDecimal(4), RemainingShares: model.NewDecimal(4), Amount: model.NewDecimal(0), InvestmentAmount: model.NewDecimal(40.00)})
+	acct.AddEntity(&model.Entity{Type: "Short-term Capital Gain", Symbol: "USAIX", Amount: model.NewDecimal(10.00)})
+
+	if got := acct.DividendIncome(); got != model.NewDecimal(50.00) {
+		t.Errorf("got dividend income %s, want 50.00", got)
+	}
+	// The dividends paid keep the reinvested and short term capital gains they have always counted.
+	if got := acct.DividendsPaid(); got != model.NewDecimal(100.00) {
+		t.Errorf("got dividends paid %s, want 100.00", got)
+	}
+	if got := acct.CapitalGainDistributions(); got != model.NewDecimal(50.00) {
+		t.Errorf("got capital gains %s, want 50.00", got)
+	}
+}
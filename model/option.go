@@ -0,0 +1,283 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kpearce2430/keputils/utils"
+	"github.com/sirupsen/logrus"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	OptionBuyToOpen   TransactionType = "Buy to Open"
+	OptionSellToOpen  TransactionType = "Sell to Open"
+	OptionBuyToClose  TransactionType = "Buy to Close"
+	OptionSellToClose TransactionType = "Sell to Close"
+	OptionExpired     TransactionType = "Expired"
+	OptionAssigned    TransactionType = "Assigned"
+	OptionExercised   TransactionType = "Exercised"
+
+	OptionCall = "call"
+	OptionPut  = "put"
+
+	// OptionMultiplier is the shares of the underlying in a standard contract.
+	OptionMultiplier = 100.00
+
+	pvTypeOption = "Option"
+)
+
+var (
+	errOptionSymbol = errors.New("option symbol is not in OCC format")
+
+	// occPattern matches the OCC symbol, AAPL  240119C00150000, with or without the padding of the root.
+	occPattern = regexp.MustCompile(`^([A-Z][A-Z0-9.]{0,5})\s*(\d{6})([CP])(\d{8})$`)
+
+	// optionTransactionTypes maps the names used by the brokerages to the option transaction types.
+	optionTransactionTypes = map[string]TransactionType{
+		"buy to open":     OptionBuyToOpen,
+		"bought to open":  OptionBuyToOpen,
+		"sell to open":    OptionSellToOpen,
+		"sold to open":    OptionSellToOpen,
+		"buy to close":    OptionBuyToClose,
+		"bought to close": OptionBuyToClose,
+		"sell to close":   OptionSellToClose,
+		"sold to close":   OptionSellToClose,
+		"expire":          OptionExpired,
+		"expired":         OptionExpired,
+		"assign":          OptionAssigned,
+		"assigned":        OptionAssigned,
+		"exercise":        OptionExercised,
+		"exercised":       OptionExercised,
+	}
+)
+
+// OptionContract is an option on Multiplier shares of the Underlying identified by its OCC symbol.
+type OptionContract struct {
+	Symbol     string    `json:"symbol"`
+	Underlying string    `json:"underlying"`
+	Expiration time.Time `json:"expiration"`
+	Right      string    `json:"right"`
+	Strike     float64   `json:"strike"`
+	Multiplier float64   `json:"multiplier"`
+}
+
+func (o *OptionContract) String() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// ParseOCCSymbol reads the underlying, expiration, call or put and strike from the OCC symbol of the
+// option. The strike is the last eight digits in thousandths of a dollar.
+func ParseOCCSymbol(symbol string) (*OptionContract, error) {
+	m := occPattern.FindStringSubmatch(strings.TrimSpace(symbol))
+	if m == nil {
+		return nil, fmt.Errorf("%w: %s", errOptionSymbol, symbol)
+	}
+
+	expiration, err := time.Parse("060102", m[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errOptionSymbol, symbol)
+	}
+	strike, err := utils.FloatParse(m[4])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errOptionSymbol, symbol)
+	}
+
+	o := OptionContract{
+		Symbol:     strings.TrimSpace(symbol),
+		Underlying: m[1],
+		Expiration: expiration,
+		Right:      OptionCall,
+		Strike:     strike / 1000,
+		Multiplier: OptionMultiplier,
+	}
+	if m[3] == "P" {
+		o.Right = OptionPut
+	}
+	return &o, nil
+}
+
+// IsOptionSymbol returns true when the symbol is an OCC option symbol.
+func IsOptionSymbol(symbol string) bool {
+	return occPattern.MatchString(strings.TrimSpace(symbol))
+}
+
+// OCCSymbol returns the padded OCC symbol of the contract.
+func (o *OptionContract) OCCSymbol() string {
+	right := "C"
+	if o.Right == OptionPut {
+		right = "P"
+	}
+	return fmt.Sprintf("%-6s%s%s%08d", o.Underlying, o.Expiration.Format("060102"), right, int(math.Round(o.Strike*1000)))
+}
+
+// isOptionTransaction returns true when the type is one of the option transaction types.
+func isOptionTransaction(t TransactionType) bool {
+	switch t {
+	case OptionBuyToOpen, OptionSellToOpen, OptionBuyToClose, OptionSellToClose, OptionExpired, OptionAssigned, OptionExercised:
+		return true
+	}
+	return false
+}
+
+// optionTransactionType returns the option transaction type for the type in the export.
+func optionTransactionType(t TransactionType) (TransactionType, bool) {
+	optionType, ok := optionTransactionTypes[strings.ToLower(strings.TrimSpace(string(t)))]
+	return optionType, ok
+}
+
+// premiumPerContract returns the premium paid or received for each contract of the opening Entity.
+func (e *Entity) premiumPerContract() Decimal {
+	return e.Amount.Div(e.Shares).Abs()
+}
+
+// closeOptions closes up to contracts of the open lots of the type, first in first out, at the price per
+// contract, and returns the contracts closed and the premium of the closed contracts. A short lot has
+// negative remaining shares.
+func (a *Account) closeOptions(openType TransactionType, contracts, price Decimal, date time.Time) (Decimal, Decimal) {
+	closed, premium := Decimal{}, Decimal{}
+	for _, entry := range a.Entities {
+		if entry.Type != openType || entry.RemainingShares.IsZero() {
+			continue
+		}
+		if contracts.Sign() <= 0 {
+			break
+		}
+		n := MinDecimal(contracts, entry.RemainingShares.Abs())
+		if openType == OptionSellToOpen {
+			entry.RemainingShares = entry.RemainingShares.Add(n)
+		} else {
+			entry.RemainingShares = entry.RemainingShares.Sub(n)
+		}
+		entry.SoldLots = append(entry.SoldLots, &Lot{NumberShares: n, PricePerShare: price, SoldDate: date})
+		closed = closed.Add(n)
+		premium = premium.Add(n.Mul(entry.premiumPerContract()).RoundCents())
+		contracts = contracts.Sub(n)
+	}
+	return closed, premium
+}
+
+// openContracts returns the contracts of the open lots of the type.
+func (a *Account) openContracts(openType TransactionType) Decimal {
+	total := Decimal{}
+	for _, entry := range a.Entities {
+		if entry.Type == openType {
+			total = total.Add(entry.RemainingShares.Abs())
+		}
+	}
+	return total
+}
+
+// AddOptionEntity closes the option lots for a closing, expiring, assigned or exercised Entity, or adds
+// an opening Entity as a lot. An assigned or exercised Entity has the premium of the contracts closed
+// set, received as positive and paid as negative, and its shares set to the contracts closed.
+func (a *Account) AddOptionEntity(e *Entity) {
+	contracts := e.Shares.Abs()
+	switch e.Type {
+	case OptionBuyToOpen:
+		e.RemainingShares = contracts
+		a.Entities = append(a.Entities, e)
+	case OptionSellToOpen:
+		e.RemainingShares = contracts.Neg()
+		a.Entities = append(a.Entities, e)
+	case OptionSellToClose:
+		a.closeOptions(OptionBuyToOpen, contracts, e.PricePerShare, e.Date)
+	case OptionBuyToClose:
+		a.closeOptions(OptionSellToOpen, contracts, e.PricePerShare, e.Date)
+	case OptionExpired:
+		if contracts.IsZero() {
+			contracts = a.openContracts(OptionBuyToOpen).Add(a.openContracts(OptionSellToOpen))
+		}
+		closed, _ := a.closeOptions(OptionBuyToOpen, contracts, Decimal{}, e.Date)
+		a.closeOptions(OptionSellToOpen, contracts.Sub(closed), Decimal{}, e.Date)
+	case OptionAssigned:
+		if contracts.IsZero() {
+			contracts = a.openContracts(OptionSellToOpen)
+		}
+		closed, premium := a.closeOptions(OptionSellToOpen, contracts, Decimal{}, e.Date)
+		e.Shares, e.Premium = closed, premium
+	case OptionExercised:
+		if contracts.IsZero() {
+			contracts = a.openContracts(OptionBuyToOpen)
+		}
+		closed, premium := a.closeOptions(OptionBuyToOpen, contracts, Decimal{}, e.Date)
+		e.Shares, e.Premium = closed, premium.Neg()
+	}
+}
+
+// underlyingEntity returns the purchase or sale of the underlying at the strike for the contracts of an
+// assigned or exercised Entity. The premium is part of the cost of the shares bought, or the proceeds of
+// the shares sold, so an assigned put for a $2.00 premium at a $50.00 strike buys the shares at $48.00.
+func (o *OptionContract) underlyingEntity(e *Entity) *Entity {
+	shares := e.Shares.Mul(NewDecimal(o.Multiplier))
+	if shares.Sign() <= 0 {
+		return nil
+	}
+
+	buy := (e.Type == OptionAssigned && o.Right == OptionPut) || (e.Type == OptionExercised && o.Right == OptionCall)
+	u := Entity{
+		Date:          e.Date,
+		Security:      o.Underlying,
+		Symbol:        o.Underlying,
+		SecurityPayee: e.SecurityPayee,
+		Account:       e.Account,
+		Shares:        shares,
+		Currency:      e.Currency,
+	}
+	if buy {
+		cost := shares.Mul(NewDecimal(o.Strike)).RoundCents().Sub(e.Premium)
+		u.Type = "Buy"
+		u.Amount = cost.Neg()
+		u.InvestmentAmount = cost
+		u.PricePerShare = cost.Div(shares)
+		u.RemainingShares = shares
+	} else {
+		proceeds := shares.Mul(NewDecimal(o.Strike)).RoundCents().Add(e.Premium)
+		u.Type = "Sell"
+		u.Amount = proceeds
+		u.InvestmentAmount = proceeds.Neg()
+		u.PricePerShare = proceeds.Div(shares)
+	}
+	u.Description = fmt.Sprintf("%s shares @ %.4f %s %s", shares, u.PricePerShare.Float64(), e.Type, o.Symbol)
+	logrus.Debug("Option ", e.Type, " ", o.Symbol, ": ", u.Type, " ", shares, " ", o.Underlying, " @ ", u.PricePerShare)
+	return &u
+}
+
+// OptionPosition is the open contracts of an option in an account.
+type OptionPosition struct {
+	Contract  *OptionContract `json:"contract"`
+	Account   string          `json:"account"`
+	Contracts float64         `json:"contracts"` // negative when short
+	Premium   float64         `json:"premium"`   // paid as positive, received as negative
+}
+
+// OptionPositions returns the open contracts of the option in each account of the ticker.
+func (t *Ticker) OptionPositions() []*OptionPosition {
+	contract, err := ParseOCCSymbol(t.Symbol)
+	if err != nil {
+		return nil
+	}
+
+	var positions []*OptionPosition
+	for _, acct := range t.Accounts {
+		p := OptionPosition{Contract: contract, Account: acct.Name}
+		for _, e := range acct.Entities {
+			switch e.Type {
+			case OptionBuyToOpen, OptionSellToOpen:
+				p.Contracts += e.RemainingShares.Float64()
+				p.Premium += e.RemainingShares.Mul(e.premiumPerContract()).RoundCents().Float64()
+			}
+		}
+		if p.Contracts != 0 {
+			positions = append(positions, &p)
+		}
+	}
+	return positions
+}
@@ -0,0 +1,164 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseOCCSymbol(t *testing.T) {
+	tests := []struct {
+		symbol     string
+		underlying string
+		right      string
+		strike     float64
+		valid      bool
+	}{
+		{"AAPL  240119C00150000", "AAPL", model.OptionCall, 150.00, true},
+		{"AAPL240119P00152500", "AAPL", model.OptionPut, 152.50, true},
+		{"BRK.B 250620C00400000", "BRK.B", model.OptionCall, 400.00, true},
+		{"AAPL", "", "", 0, false},
+		{"38149MXQ1", "", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		o, err := model.ParseOCCSymbol(tt.symbol)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v, want valid %v", tt.symbol, err, tt.valid)
+			continue
+		}
+		if !tt.valid {
+			continue
+		}
+		if o.Underlying != tt.underlying || o.Right != tt.right || o.Strike != tt.strike || o.Multiplier != model.OptionMultiplier {
+			t.Errorf("%s: unexpected contract %s", tt.symbol, o.String())
+		}
+	}
+
+	o, _ := model.ParseOCCSymbol("AAPL240119P00152500")
+	if got := o.OCCSymbol(); got != "AAPL  240119P00152500" {
+		t.Errorf("got OCC symbol %q", got)
+	}
+	if !o.Expiration.Equal(time.Date(2024, time.January, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got expiration %s", o.Expiration)
+	}
+}
+
+func TestTickerSet_OptionOpenClose(t *testing.T) {
+	const call = "MSFT  240315C00400000"
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy to Open", Symbol: call, Description: "Bought 3 MSFT Mar 15 2024 400.00 Call @ 2.50", Shares: model.NewDecimal(3), Amount: model.NewDecimal(-750.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2024, time.February, 5, 0, 0, 0, 0, time.UTC), Type: "Sold To Close", Symbol: call, Description: "Sold 2 MSFT Mar 15 2024 400.00 Call @ 4.00", Shares: model.NewDecimal(2), Amount: model.NewDecimal(800.00), Account: "Fidelity IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	ticker, _ := tickerSet.GetTicker(call)
+	positions := ticker.OptionPositions()
+	if len(positions) != 1 || positions[0].Contracts != 1 || math.Abs(positions[0].Premium-250.00) > 0.001 {
+		t.Fatalf("unexpected positions %+v", positions)
+	}
+
+	ts.TransactionRows = append(ts.TransactionRows,
+		&model.Transaction{Id: 3, Date: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Type: "Expired", Symbol: call, Account: "Fidelity IRA"})
+	tickerSet = model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	ticker, _ = tickerSet.GetTicker(call)
+	if shares := ticker.NumberOfShares(); !shares.IsZero() || len(ticker.OptionPositions()) != 0 {
+		t.Errorf("got %s contracts after expiration, want 0", shares)
+	}
+}
+
+func TestTickerSet_OptionAssigned(t *testing.T) {
+	const put = "AAPL  240119P00150000"
+	const call = "AAPL  240216C00160000"
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC), Type: "Sell to Open", Symbol: put, Description: "Sold 2 AAPL Jan 19 2024 150.00 Put @ 1.50", Shares: model.NewDecimal(2), Amount: model.NewDecimal(300.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2024, time.January, 19, 0, 0, 0, 0, time.UTC), Type: "Assigned", Symbol: put, Shares: model.NewDecimal(2), Account: "Fidelity IRA"},
+		{Id: 3, Date: time.Date(2024, time.January, 22, 0, 0, 0, 0, time.UTC), Type: "Sell to Open", Symbol: call, Description: "Sold 1 AAPL Feb 16 2024 160.00 Call @ 2.00", Shares: model.NewDecimal(1), Amount: model.NewDecimal(200.00), Account: "Fidelity IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// The put premium lowers the cost of the 200 shares bought at the strike.
+	aapl, ok := tickerSet.GetTicker("AAPL")
+	if !ok {
+		t.Fatal("missing underlying ticker")
+	}
+	if shares := aapl.NumberOfShares(); shares != model.NewDecimal(200) {
+		t.Errorf("got %s shares, want 200", shares)
+	}
+	if cost := aapl.NetCost(); math.Abs(cost-29700.00) > 0.001 {
+		t.Errorf("got cost %.2f, want 29700.00", cost)
+	}
+
+	putTicker, _ := tickerSet.GetTicker(put)
+	if contracts := putTicker.NumberOfShares(); !contracts.IsZero() {
+		t.Errorf("got %s put contracts, want 0", contracts)
+	}
+
+	callTicker, _ := tickerSet.GetTicker(call)
+	if contracts := callTicker.NumberOfShares(); contracts != model.NewDecimal(-1) {
+		t.Errorf("got %s call contracts, want -1", contracts)
+	}
+	if cost := callTicker.NetCost(); math.Abs(cost+200.00) > 0.001 {
+		t.Errorf("got cost %.2f, want the -200.00 premium received", cost)
+	}
+
+	// The covered call is assigned and the shares are sold at the strike plus the premium.
+	ts.TransactionRows = append(ts.TransactionRows,
+		&model.Transaction{Id: 4, Date: time.Date(2024, time.February, 16, 0, 0, 0, 0, time.UTC), Type: "Assigned", Symbol: call, Account: "Fidelity IRA"})
+	tickerSet = model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	aapl, _ = tickerSet.GetTicker("AAPL")
+	if shares := aapl.NumberOfShares(); shares != model.NewDecimal(100) {
+		t.Errorf("got %s shares after the call was assigned, want 100", shares)
+	}
+}
+
+func TestTickerSet_OptionExercised(t *testing.T) {
+	const call = "NVDA  240621C00100000"
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), Type: "Buy to Open", Symbol: call, Description: "1 contracts @ 5.00", Shares: model.NewDecimal(1), Amount: model.NewDecimal(-500.00), Account: "Schwab"},
+		{Id: 2, Date: time.Date(2024, time.June, 21, 0, 0, 0, 0, time.UTC), Type: "Exercised", Symbol: call, Shares: model.NewDecimal(1), Account: "Schwab"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	nvda, ok := tickerSet.GetTicker("NVDA")
+	if !ok {
+		t.Fatal("missing underlying ticker")
+	}
+	if shares, cost := nvda.NumberOfShares(), nvda.NetCost(); shares != model.NewDecimal(100) || math.Abs(cost-10500.00) > 0.001 {
+		t.Errorf("got %s shares cost %.2f, want 100 and 10500.00", shares, cost)
+	}
+}
+
+func TestNewEntityFromTransaction_PricePerShare(t *testing.T) {
+	tr := model.Transaction{Type: "Buy", Symbol: "CSX", Description: "10 shares @ 35.50 (limit)", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-355.00)}
+	en, err := model.NewEntityFromTransaction(&tr)
+	if err != nil || en.PricePerShare != model.NewDecimal(35.50) {
+		t.Errorf("got price %s %v, want 35.50", en.PricePerShare, err)
+	}
+
+	// Without an OCC symbol the type is left alone.
+	tr = model.Transaction{Type: "Exercise", Symbol: "HD", Shares: model.NewDecimal(10)}
+	if en, _ = model.NewEntityFromTransaction(&tr); en.Type != "Exercise" {
+		t.Errorf("got type %s, want Exercise", en.Type)
+	}
+}
@@ -0,0 +1,202 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"time"
+)
+
+const (
+	// DefaultPortfolio holds the rows loaded before portfolios, and the rows of a server without users.
+	DefaultPortfolio = "default"
+
+	PortfoliosTable       = "portfolios"
+	PortfolioMembersTable = "portfolio_members"
+
+	// portfolioSessionsTable is read by current_portfolio() in the row level security policies of init_db.sql.
+	portfolioSessionsTable = "portfolio_sessions"
+)
+
+var (
+	errPortfolioName   = errors.New("invalid portfolio")
+	errPortfolioExists = errors.New("portfolio already exists")
+	errPortfolioAbsent = errors.New("portfolio not found")
+
+	// portfolioName is the id of a portfolio, which is part of a path: kids-utma or paper_trading.
+	portfolioName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+)
+
+// IsPortfolioNameError returns true when the error is from a portfolio id that is not valid.
+func IsPortfolioNameError(err error) bool {
+	return errors.Is(err, errPortfolioName)
+}
+
+// IsPortfolioExists returns true when the error is from creating a portfolio with the id of another.
+func IsPortfolioExists(err error) bool {
+	return errors.Is(err, errPortfolioExists)
+}
+
+// IsPortfolioNotFound returns true when the error is from a portfolio that was never created.
+func IsPortfolioNotFound(err error) bool {
+	return errors.Is(err, errPortfolioAbsent)
+}
+
+// Portfolio is a set of transactions, lookups, account events and portfolio values kept apart from the
+// others in the same tables.
+type Portfolio struct {
+	Id          string    `json:"id"`
+	Description string    `json:"description,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+// ValidatePortfolio checks the id of a portfolio.
+func ValidatePortfolio(id string) error {
+	if !portfolioName.MatchString(id) {
+		return fmt.Errorf("%w: %s", errPortfolioName, id)
+	}
+	return nil
+}
+
+type portfolioKey struct{}
+
+// WithPortfolio returns the context of the queries of the portfolio.
+func WithPortfolio(ctx context.Context, portfolio string) context.Context {
+	return context.WithValue(ctx, portfolioKey{}, portfolio)
+}
+
+// PortfolioFromContext returns the portfolio of the context, DefaultPortfolio when there is none.
+func PortfolioFromContext(ctx context.Context) string {
+	if portfolio, ok := ctx.Value(portfolioKey{}).(string); ok && portfolio != "" {
+		return portfolio
+	}
+	return DefaultPortfolio
+}
+
+// ScopePortfolios binds each connection taken from the pool to the portfolio of the context, so the row
+// level security policies keep every query to the rows of the portfolio. The connections of the pool log in
+// as a role held to the policies, and owner, a pool of the owner of the tables, writes the binding in
+// portfolioSessionsTable, which that role can neither read nor write, so a query cannot change it.
+func ScopePortfolios(config *pgxpool.Config, owner *pgxpool.Pool) {
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		if _, err := owner.Exec(ctx, fmt.Sprintf("INSERT INTO %s(pid, portfolio_id) VALUES($1,$2)"+
+			" ON CONFLICT (pid) DO UPDATE SET portfolio_id = EXCLUDED.portfolio_id;", portfolioSessionsTable),
+			conn.PgConn().PID(), PortfolioFromContext(ctx)); err != nil {
+			// The connection is closed rather than used in the portfolio it had before.
+			logrus.Error(err.Error())
+			return false
+		}
+		return true
+	}
+	config.BeforeClose = func(conn *pgx.Conn) {
+		// The pid may be that of a later connection.
+		if _, err := owner.Exec(context.Background(), fmt.Sprintf("DELETE FROM %s WHERE pid = $1;", portfolioSessionsTable),
+			conn.PgConn().PID()); err != nil {
+			logrus.Error(err.Error())
+		}
+	}
+}
+
+// PortfoliosGet returns the portfolios the user may select, which are every portfolio for an empty user.
+func PortfoliosGet(ctx context.Context, pg *pgxpool.Pool, user string) ([]*Portfolio, error) {
+	statement := fmt.Sprintf("SELECT portfolio_id, description, created FROM %s ORDER BY portfolio_id;", PortfoliosTable)
+	var args []any
+	if user != "" {
+		statement = fmt.Sprintf("SELECT p.portfolio_id, p.description, p.created FROM %s p WHERE p.portfolio_id IN"+
+			" (SELECT portfolio_id FROM %s WHERE username = $1 UNION SELECT portfolio_id FROM %s WHERE name = $1)"+
+			" ORDER BY p.portfolio_id;", PortfoliosTable, PortfolioMembersTable, UsersTable)
+		args = append(args, user)
+	}
+
+	rows, err := pg.Query(ctx, statement, args...)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	portfolios := []*Portfolio{}
+	for rows.Next() {
+		p := Portfolio{}
+		var description *string
+		if err := rows.Scan(&p.Id, &description, &p.Created); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if description != nil {
+			p.Description = *description
+		}
+		portfolios = append(portfolios, &p)
+	}
+	return portfolios, rows.Err()
+}
+
+// PortfolioGet returns the portfolio with the id.
+func PortfolioGet(ctx context.Context, pg *pgxpool.Pool, id string) (*Portfolio, error) {
+	p := Portfolio{}
+	var description *string
+	err := pg.QueryRow(ctx, fmt.Sprintf("SELECT portfolio_id, description, created FROM %s WHERE portfolio_id = $1;", PortfoliosTable), id).
+		Scan(&p.Id, &description, &p.Created)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", errPortfolioAbsent, id)
+	}
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	if description != nil {
+		p.Description = *description
+	}
+	return &p, nil
+}
+
+// CreatePortfolio stores a new portfolio, with the user as a member when there is one.
+func CreatePortfolio(ctx context.Context, pg *pgxpool.Pool, p *Portfolio, user string) error {
+	if err := ValidatePortfolio(p.Id); err != nil {
+		return err
+	}
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	p.Created = time.Now().UTC()
+	tag, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s(portfolio_id, description, created) VALUES($1,$2,$3) ON CONFLICT DO NOTHING;", PortfoliosTable),
+		p.Id, p.Description, p.Created)
+	if err != nil {
+		logrus.Error(err.Error())
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", errPortfolioExists, p.Id)
+	}
+	if user != "" {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s(portfolio_id, username) VALUES($1,$2);", PortfolioMembersTable), p.Id, user); err != nil {
+			logrus.Error(err.Error())
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// PortfolioMember returns true when the user may select the portfolio, their own or one they are a member of.
+func PortfolioMember(ctx context.Context, pg *pgxpool.Pool, user *User, portfolio string) (bool, error) {
+	if user.Portfolio == portfolio {
+		return true, nil
+	}
+	var member bool
+	if err := pg.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE portfolio_id = $1 AND username = $2);", PortfolioMembersTable),
+		portfolio, user.Name).Scan(&member); err != nil {
+		logrus.Error(err.Error())
+		return false, err
+	}
+	return member, nil
+}
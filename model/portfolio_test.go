@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/model"
+	"testing"
+)
+
+func TestPortfolioFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := model.PortfolioFromContext(ctx); got != model.DefaultPortfolio {
+		t.Errorf("got %s, want the default portfolio", got)
+	}
+	if got := model.PortfolioFromContext(model.WithPortfolio(ctx, "household")); got != "household" {
+		t.Errorf("got %s, want household", got)
+	}
+	if got := model.PortfolioFromContext(model.WithPortfolio(ctx, "")); got != model.DefaultPortfolio {
+		t.Errorf("got %s, want the default portfolio for an empty one", got)
+	}
+}
+
+func TestNewAPIToken(t *testing.T) {
+	token, err := model.NewAPIToken()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !model.IsAPIToken(token) || model.IsAPIToken("eyJhbGciOiJSUzI1NiJ9.e30.c2ln") {
+		t.Errorf("%s is not an API token, or a JWT is", token)
+	}
+	other, _ := model.NewAPIToken()
+	if token == other || model.HashAPIToken(token) == model.HashAPIToken(other) || model.HashAPIToken(token) == token {
+		t.Error("tokens or their hashes are the same")
+	}
+}
+
+func TestValidatePortfolio(t *testing.T) {
+	for _, id := range []string{"default", "family", "kids-utma", "paper_trading", "2024"} {
+		if err := model.ValidatePortfolio(id); err != nil {
+			t.Errorf("%s: %s", id, err.Error())
+		}
+	}
+	for _, id := range []string{"", "Family", "kids utma", "-paper", "../default", "a/b"} {
+		if err := model.ValidatePortfolio(id); !model.IsPortfolioNameError(err) {
+			t.Errorf("%q: got %v, want an invalid portfolio", id, err)
+		}
+	}
+}
@@ -19,7 +19,7 @@ const (
 	pvTypeBond       = "Bond"
 	pvTypeMutualFund = "Mutual Fund"
 	pvTypeOther      = "Other"
-	pvTableFields    = "date, name, symbol, type, quote, pricedaychange, pricedaychangepct, shares, costbasis, marketvalue, averagecostpershare, gainloss12month, gainloss, gaillosspct"
+	pvTableFields    = "date, name, symbol, type, quote, pricedaychange, pricedaychangepct, shares, costbasis, marketvalue, averagecostpershare, gainloss12month, gainloss, gaillosspct, currency"
 )
 
 var errPortfolioTypeUnknown = fmt.Errorf("unknown portfolio type")
@@ -38,6 +38,7 @@ type PortfolioValueRecord struct {
 	GainLoss12Month     float64 `json:"gain_loss_last_12m"`
 	GainLoss            float64 `json:"gain_loss"`
 	GainLossPct         float64 `json:"gain_loss_pct"`
+	Currency            string  `json:"currency,omitempty"` // of the quote and values, DefaultCurrency when empty
 }
 
 type PortfolioValueDatabaseRecord struct {
@@ -85,11 +86,13 @@ func NewPortfolioValue(headers []string, values []string) (*PortfolioValueRecord
 			pv.GainLoss, _ = utils.FloatParse(values[index])
 		case "Gain/Loss (%)":
 			pv.GainLossPct, _ = utils.FloatParse(values[index])
+		case "Currency":
+			pv.Currency, _ = NormalizeCurrency(values[index])
 		} // switch
 	} // for
 
 	switch pv.Type {
-	case pvTypeBond, pvTypeStock, pvTypeMutualFund:
+	case pvTypeBond, pvTypeStock, pvTypeMutualFund, pvTypeOption:
 	case pvTypeOther:
 		pv.Type = pvTypeStock
 	default:
@@ -282,22 +285,22 @@ func GetPortfolioValue(symbol, julDate string) (*PortfolioValueDatabaseRecord, e
 	return pvData, nil
 }
 
-func (p *PortfolioValueRecord) LoadDB(pgxConn *pgxpool.Pool, date time.Time, tableName string) error {
+func (p *PortfolioValueRecord) LoadDB(ctx context.Context, pgxConn *pgxpool.Pool, date time.Time, tableName string) error {
 
 	if pgxConn == nil {
 		return errPGXConnectionNil
 	}
 	insertStatement := fmt.Sprintf(
 		"INSERT INTO %s(%s)"+
-			" VALUES('%s','%s','%s','%s','%.04f','%.04f','%.04f','%.04f','%.04f','%.04f','%.04f','%.04f','%.04f','%.04f') "+
+			" VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) "+
 			" ON CONFLICT DO NOTHING;",
-		tableName, pvTableFields,
+		tableName, pvTableFields)
+
+	rows, err := pgxConn.Query(ctx, insertStatement,
 		date.Format("2006-01-02"), p.Name, p.Symbol, p.Type,
 		p.Quote, p.PriceDayChange, p.PriceDayChangePct, p.Shares,
 		p.CostBasis, p.MarketValue, p.AverageCostPerShare, p.GainLoss12Month,
-		p.GainLoss, p.GainLossPct)
-
-	rows, err := pgxConn.Query(context.Background(), insertStatement)
+		p.GainLoss, p.GainLossPct, currencyOrDefault(p.Currency))
 	defer rows.Close()
 	if err != nil {
 		return err
@@ -305,7 +308,7 @@ func (p *PortfolioValueRecord) LoadDB(pgxConn *pgxpool.Pool, date time.Time, tab
 	return nil
 }
 
-func PortfolioValuesLoadDB(pgxConn *pgxpool.Pool, databaseName, rawData, julDate string, lookups *LookUpSet) (int, error) {
+func PortfolioValuesLoadDB(ctx context.Context, pgxConn *pgxpool.Pool, databaseName, rawData, julDate string, lookups *LookUpSet) (int, error) {
 	r := csv.NewReader(strings.NewReader(rawData))
 	// This sets the reader to not base the number of fields off the first record.
 	r.FieldsPerRecord = -1
@@ -385,7 +388,7 @@ func PortfolioValuesLoadDB(pgxConn *pgxpool.Pool, databaseName, rawData, julDate
 						}
 					}
 				}
-				if err := pvRec.LoadDB(pgxConn, date, databaseName); err != nil {
+				if err := pvRec.LoadDB(ctx, pgxConn, date, databaseName); err != nil {
 					logrus.Error(err.Error())
 					return -1, err
 				}
@@ -401,27 +404,64 @@ func PortfolioValuesLoadDB(pgxConn *pgxpool.Pool, databaseName, rawData, julDate
 	return count, nil
 }
 
-func (p *PortfolioValueRecord) GetDB(pgxConn *pgxpool.Pool, symbol, tableName string, date time.Time) error {
+func (p *PortfolioValueRecord) GetDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol, tableName string, date time.Time) error {
 	selectStatement := fmt.Sprintf(
-		"SELECT %s From %s WHERE symbol = '%s' and date = '%s' ",
-		pvTableFields, tableName, symbol, fmt.Sprintf("%4d-%02d-%02d", date.Year(), date.Month(), date.Day()))
+		"SELECT %s From %s WHERE symbol = $1 and date = $2 ",
+		pvTableFields, tableName)
 
-	return p.getRecord(pgxConn, selectStatement)
+	return p.getRecord(ctx, pgxConn, selectStatement, symbol, fmt.Sprintf("%4d-%02d-%02d", date.Year(), date.Month(), date.Day()))
 
 }
 
-func (p *PortfolioValueRecord) GetLastDB(pgxConn *pgxpool.Pool, symbol, tableName string) error {
+func (p *PortfolioValueRecord) GetLastDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol, tableName string) error {
 	selectStatement := fmt.Sprintf(
-		"SELECT %s From %s WHERE symbol = '%s' order by date desc limit 1 ",
-		pvTableFields, tableName, symbol)
+		"SELECT %s From %s WHERE symbol = $1 order by date desc limit 1 ",
+		pvTableFields, tableName)
+
+	return p.getRecord(ctx, pgxConn, selectStatement, symbol)
 
-	return p.getRecord(pgxConn, selectStatement)
+}
+
+// GetOnOrBeforeDB reads the latest record for the symbol on or before the date.
+func (p *PortfolioValueRecord) GetOnOrBeforeDB(ctx context.Context, pgxConn *pgxpool.Pool, symbol, tableName string, date time.Time) error {
+	selectStatement := fmt.Sprintf(
+		"SELECT %s From %s WHERE symbol = $1 and date <= $2 order by date desc limit 1 ",
+		pvTableFields, tableName)
+
+	return p.getRecord(ctx, pgxConn, selectStatement, symbol, date.Format(dateToPgLayout))
+}
+
+// PortfolioValuesLastDB returns the latest record of each symbol from a single query, a symbol without
+// one is left out.
+func PortfolioValuesLastDB(ctx context.Context, pgxConn *pgxpool.Pool, tableName string, symbols []string) (map[string]*PortfolioValueRecord, error) {
+	rows, err := pgxConn.Query(ctx, fmt.Sprintf("SELECT DISTINCT ON (symbol) %s FROM %s WHERE symbol = ANY($1) ORDER BY symbol, date DESC;",
+		pvTableFields, tableName), symbols)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
 
+	records := make(map[string]*PortfolioValueRecord, len(symbols))
+	for rows.Next() {
+		var date time.Time
+		p := PortfolioValueRecord{}
+		if err := rows.Scan(
+			&date, &p.Name, &p.Symbol, &p.Type,
+			&p.Quote, &p.PriceDayChange, &p.PriceDayChangePct, &p.Shares,
+			&p.CostBasis, &p.MarketValue, &p.AverageCostPerShare, &p.GainLoss12Month,
+			&p.GainLoss, &p.GainLossPct, &p.Currency); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		records[p.Symbol] = &p
+	}
+	return records, rows.Err()
 }
 
-func (p *PortfolioValueRecord) getRecord(pgxConn *pgxpool.Pool, selectStatement string) error {
+func (p *PortfolioValueRecord) getRecord(ctx context.Context, pgxConn *pgxpool.Pool, selectStatement string, args ...any) error {
 
-	rows, err := pgxConn.Query(context.Background(), selectStatement)
+	rows, err := pgxConn.Query(ctx, selectStatement, args...)
 	var date time.Time
 	// Iterate through the result set
 	i := 0
@@ -430,7 +470,7 @@ func (p *PortfolioValueRecord) getRecord(pgxConn *pgxpool.Pool, selectStatement
 			&date, &p.Name, &p.Symbol, &p.Type,
 			&p.Quote, &p.PriceDayChange, &p.PriceDayChangePct, &p.Shares,
 			&p.CostBasis, &p.MarketValue, &p.AverageCostPerShare, &p.GainLoss12Month,
-			&p.GainLoss, &p.GainLossPct)
+			&p.GainLoss, &p.GainLossPct, &p.Currency)
 
 		if err != nil {
 			rows.Close()
@@ -447,13 +487,13 @@ func (p *PortfolioValueRecord) getRecord(pgxConn *pgxpool.Pool, selectStatement
 	return nil
 }
 
-func PortfolioValueGetTypes(pgxConn *pgxpool.Pool, portfolioValueTable string) (map[string]string, error) {
+func PortfolioValueGetTypes(ctx context.Context, pgxConn *pgxpool.Pool, portfolioValueTable string) (map[string]string, error) {
 
 	types := make(map[string]string)
 	sql2 := fmt.Sprintf("SELECT DISTINCT symbol, type FROM %s ORDER BY symbol;",
 		portfolioValueTable)
 
-	rows, err := pgxConn.Query(context.Background(), sql2)
+	rows, err := pgxConn.Query(ctx, sql2)
 	if err != nil {
 		logrus.Error(err.Error())
 		return types, err
@@ -479,11 +519,11 @@ func PortfolioValueGetTypes(pgxConn *pgxpool.Pool, portfolioValueTable string) (
 	return types, nil
 }
 
-func PortfolioValueGetSymbolType(pgxConn *pgxpool.Pool, portfolioValueTable, symbol string) (string, error) {
+func PortfolioValueGetSymbolType(ctx context.Context, pgxConn *pgxpool.Pool, portfolioValueTable, symbol string) (string, error) {
 	var t string
-	sql := fmt.Sprintf("SELECT type FROM %s WHERE symbol = '%s' LIMIT 1;",
-		portfolioValueTable, symbol)
-	if err := pgxConn.QueryRow(context.Background(), sql).Scan(&t); err != nil {
+	sql := fmt.Sprintf("SELECT type FROM %s WHERE symbol = $1 LIMIT 1;",
+		portfolioValueTable)
+	if err := pgxConn.QueryRow(ctx, sql, symbol).Scan(&t); err != nil {
 		logrus.Error(err.Error())
 		return t, err
 	}
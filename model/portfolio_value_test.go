@@ -64,7 +64,7 @@ func TestLoadDBPortfolioValues(t *testing.T) {
 		t.FailNow()
 	}
 
-	rc, err := model.PortfolioValuesLoadDB(pgxConn, portfolioValueTable, string(testPortfolioValues), "", ls)
+	rc, err := model.PortfolioValuesLoadDB(context.Background(), pgxConn, portfolioValueTable, string(testPortfolioValues), "", ls)
 	if err != nil {
 		t.Log(err.Error())
 		t.FailNow()
@@ -81,14 +81,14 @@ func TestLoadDBPortfolioValues(t *testing.T) {
 		t.Fail()
 	}
 
-	types, err := model.PortfolioValueGetTypes(pgxConn, portfolioValueTable)
+	types, err := model.PortfolioValueGetTypes(context.Background(), pgxConn, portfolioValueTable)
 	if err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
 	for k, v := range types {
 		t.Log(k, ":", v)
-		myType, err := model.PortfolioValueGetSymbolType(pgxConn, portfolioValueTable, k)
+		myType, err := model.PortfolioValueGetSymbolType(context.Background(), pgxConn, portfolioValueTable, k)
 		if err != nil {
 			t.Log(err.Error())
 			t.FailNow()
@@ -100,7 +100,7 @@ func TestLoadDBPortfolioValues(t *testing.T) {
 	}
 
 	var pv model.PortfolioValueRecord
-	if err := pv.GetLastDB(pgxConn, "HD", "portfolio_value"); err != nil {
+	if err := pv.GetLastDB(context.Background(), pgxConn, "HD", "portfolio_value"); err != nil {
 		t.Error(err.Error())
 		return
 	}
@@ -0,0 +1,512 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	RebalanceBuy  = "Buy"
+	RebalanceSell = "Sell"
+)
+
+var (
+	errNothingToRebalance = errors.New("no holdings or cash to rebalance")
+	errMissingPrice       = errors.New("missing price")
+)
+
+// taxAdvantagedKeywords are the parts of an account name that mark it as an IRA, 401(k) or HSA.
+var taxAdvantagedKeywords = []string{"IRA", "401(k)", "401K", "ROTH", "HSA"}
+
+// IsTaxAdvantagedAccount returns true when the registered account of the portfolio of the context is tax
+// deferred or tax free. An account that is not registered is tax advantaged when its name looks like a
+// retirement or health savings account.
+func IsTaxAdvantagedAccount(ctx context.Context, account string) bool {
+	if m, ok := lookupAccount(PortfolioFromContext(ctx), account); ok {
+		treatment := m.TaxTreatment
+		if treatment == "" {
+			treatment = defaultTaxTreatment(m.AccountType)
+		}
+		return treatment != TaxTreatmentTaxable
+	}
+
+	name := strings.ToUpper(account)
+	for _, k := range taxAdvantagedKeywords {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// RebalanceHolding is the position of a symbol in one account used as input to Rebalance.
+type RebalanceHolding struct {
+	Account      string  `json:"account"`
+	Symbol       string  `json:"symbol"`
+	SecurityType string  `json:"securityType"`
+	Shares       float64 `json:"shares"`
+	Price        float64 `json:"price"`
+}
+
+func (h *RebalanceHolding) Value() float64 {
+	return h.Shares * h.Price
+}
+
+// RebalanceOptions holds the cash and trade constraints for Rebalance.
+type RebalanceOptions struct {
+	Cash           float64            `json:"cash"`           // positive to invest, negative to withdraw
+	MinTradeAmount float64            `json:"minTradeAmount"` // trades smaller than this are skipped
+	CashAccount    string             `json:"cashAccount"`    // account of the cash, the first of the group by default
+	Prices         map[string]float64 `json:"prices"`         // prices for target symbols not yet held
+}
+
+// RebalanceTrade is a single buy or sell in one account.
+type RebalanceTrade struct {
+	Account       string  `json:"account"`
+	Symbol        string  `json:"symbol"`
+	SecurityType  string  `json:"securityType"`
+	Action        string  `json:"action"`
+	Shares        float64 `json:"shares"`
+	Price         float64 `json:"price"`
+	Amount        float64 `json:"amount"`
+	TaxAdvantaged bool    `json:"taxAdvantaged"`
+}
+
+// RebalancePosition compares the current and target value of an allocation target.
+type RebalancePosition struct {
+	Target        string  `json:"target"`
+	TargetType    string  `json:"type"`
+	TargetWeight  float64 `json:"targetWeight"`
+	CurrentWeight float64 `json:"currentWeight"`
+	CurrentValue  float64 `json:"currentValue"`
+	TargetValue   float64 `json:"targetValue"`
+	Difference    float64 `json:"difference"`
+}
+
+// RebalancePlan is the result of Rebalance.
+type RebalancePlan struct {
+	Group         string               `json:"group"`
+	TotalValue    float64              `json:"totalValue"`
+	Cash          float64              `json:"cash"`
+	CashRemaining float64              `json:"cashRemaining"`
+	AccountCash   map[string]float64   `json:"accountCash,omitempty"` // the cash remaining in each account
+	Positions     []*RebalancePosition `json:"positions"`
+	Trades        []*RebalanceTrade    `json:"trades"`
+	Warnings      []string             `json:"warnings,omitempty"`
+}
+
+func (p *RebalancePlan) String() string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// wholeShares returns true for security types that can only be traded in whole shares.
+func wholeShares(securityType string) bool {
+	return securityType != pvTypeMutualFund
+}
+
+// symbolAmount is the dollar amount to trade of a symbol, positive to buy.
+type symbolAmount struct {
+	symbol       string
+	securityType string
+	price        float64
+	amount       float64
+}
+
+// Rebalance returns the trades needed to move the holdings of the group's accounts to the group's targets.
+// Symbol targets take precedence over class targets, and holdings not covered by a target are sold.
+// Sells come out of tax-advantaged accounts first. Cash cannot move between accounts, so a buy is made in
+// an account with the proceeds of its own sells or the cash of the options, which is in the cash account.
+func Rebalance(ctx context.Context, group *AllocationGroup, holdings []*RebalanceHolding, opts RebalanceOptions) (*RebalancePlan, error) {
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := RebalancePlan{
+		Group: group.Name,
+		Cash:  opts.Cash,
+	}
+
+	// Only the holdings in the group's accounts are rebalanced.
+	var groupHoldings []*RebalanceHolding
+	for _, h := range holdings {
+		if len(group.Accounts) > 0 && !group.HasAccount(h.Account) {
+			continue
+		}
+		if h.Shares <= 0 {
+			continue
+		}
+		groupHoldings = append(groupHoldings, h)
+		plan.TotalValue += h.Value()
+	}
+	plan.TotalValue += opts.Cash
+	if plan.TotalValue <= 0 {
+		return nil, errNothingToRebalance
+	}
+
+	symbolTargets := make(map[string]*AllocationTarget)
+	classTargets := make(map[string]*AllocationTarget)
+	for _, t := range group.Targets {
+		switch t.TargetType {
+		case TargetTypeSymbol:
+			symbolTargets[t.Target] = t
+		case TargetTypeClass:
+			classTargets[t.Target] = t
+		}
+	}
+
+	// Current value of each symbol and the target it falls under.
+	symbolValues := make(map[string]*symbolAmount)
+	symbolKeys := make(map[string]string)
+	for _, h := range groupHoldings {
+		sa, ok := symbolValues[h.Symbol]
+		if !ok {
+			sa = &symbolAmount{symbol: h.Symbol, securityType: h.SecurityType, price: h.Price}
+			symbolValues[h.Symbol] = sa
+		}
+		sa.amount += h.Value()
+
+		switch {
+		case symbolTargets[h.Symbol] != nil:
+			symbolKeys[h.Symbol] = h.Symbol
+		case classTargets[h.SecurityType] != nil:
+			symbolKeys[h.Symbol] = h.SecurityType
+		default:
+			symbolKeys[h.Symbol] = ""
+		}
+	}
+
+	positions := make(map[string]*RebalancePosition)
+	for _, t := range group.Targets {
+		positions[t.Target] = &RebalancePosition{
+			Target:       t.Target,
+			TargetType:   t.TargetType,
+			TargetWeight: t.Weight,
+			TargetValue:  t.Weight * plan.TotalValue,
+		}
+	}
+
+	var amounts []*symbolAmount
+	for symbol, sa := range symbolValues {
+		key := symbolKeys[symbol]
+		if key == "" {
+			// Not targeted, sell it all.
+			pos := &RebalancePosition{Target: symbol, TargetType: TargetTypeSymbol, CurrentValue: sa.amount}
+			positions[symbol] = pos
+			amounts = append(amounts, &symbolAmount{symbol: symbol, securityType: sa.securityType, price: sa.price, amount: -sa.amount})
+			continue
+		}
+		positions[key].CurrentValue += sa.amount
+	}
+
+	for _, t := range group.Targets {
+		pos := positions[t.Target]
+		diff := pos.TargetValue - pos.CurrentValue
+
+		switch t.TargetType {
+		case TargetTypeSymbol:
+			sa, ok := symbolValues[t.Target]
+			if !ok {
+				price, found := opts.Prices[t.Target]
+				if !found || price <= 0 {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %s", errMissingPrice.Error(), t.Target))
+					continue
+				}
+				securityType, found := SymbolTypeMap[t.Target]
+				if !found {
+					securityType = pvTypeStock
+				}
+				sa = &symbolAmount{symbol: t.Target, securityType: securityType, price: price}
+			}
+			amounts = append(amounts, &symbolAmount{symbol: sa.symbol, securityType: sa.securityType, price: sa.price, amount: diff})
+
+		case TargetTypeClass:
+			// Spread the difference over the symbols held in the class by their current value.
+			if pos.CurrentValue <= 0 {
+				if diff > 0 {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("no holdings of class %s to buy", t.Target))
+				}
+				continue
+			}
+			for symbol, sa := range symbolValues {
+				if symbolKeys[symbol] != t.Target {
+					continue
+				}
+				share := sa.amount / pos.CurrentValue
+				amounts = append(amounts, &symbolAmount{symbol: symbol, securityType: sa.securityType, price: sa.price, amount: diff * share})
+			}
+		}
+	}
+
+	for _, pos := range positions {
+		pos.Difference = pos.TargetValue - pos.CurrentValue
+		pos.CurrentWeight = pos.CurrentValue / plan.TotalValue
+		plan.Positions = append(plan.Positions, pos)
+	}
+	sort.Slice(plan.Positions, func(i, j int) bool {
+		return plan.Positions[i].Target < plan.Positions[j].Target
+	})
+
+	// Sells first so the proceeds are available to the buys, largest amounts first.
+	sort.Slice(amounts, func(i, j int) bool {
+		return amounts[i].amount < amounts[j].amount
+	})
+
+	cashAccount := opts.CashAccount
+	if cashAccount == "" && len(group.Accounts) > 0 {
+		cashAccount = group.Accounts[0]
+	}
+	if cashAccount == "" && len(groupHoldings) > 0 {
+		cashAccount = groupHoldings[0].Account
+	}
+	cash := make(map[string]float64)
+	for _, sa := range amounts {
+		if sa.amount < 0 {
+			plan.sell(ctx, sa, groupHoldings, opts.MinTradeAmount, cash)
+		}
+	}
+	plan.withdraw(ctx, cash, cashAccount, opts.Cash)
+
+	sort.Slice(amounts, func(i, j int) bool {
+		return amounts[i].amount > amounts[j].amount
+	})
+	for _, sa := range amounts {
+		if sa.amount > 0 {
+			plan.buy(ctx, sa, groupHoldings, cashAccount, opts.MinTradeAmount, cash)
+		}
+	}
+
+	for account, amount := range cash {
+		if amount == 0 {
+			continue
+		}
+		if plan.AccountCash == nil {
+			plan.AccountCash = make(map[string]float64)
+		}
+		plan.AccountCash[account] = amount
+		plan.CashRemaining += amount
+	}
+	return &plan, nil
+}
+
+// withdraw adds the cash of the options to the cash account, or takes a withdrawal out of the proceeds of
+// the cash account and then of the taxable accounts, since a withdrawal from a tax-advantaged account is a
+// distribution. What the proceeds do not cover is left owed by the cash account.
+func (p *RebalancePlan) withdraw(ctx context.Context, cash map[string]float64, cashAccount string, amount float64) {
+	if amount >= 0 {
+		cash[cashAccount] += amount
+		return
+	}
+
+	accounts := make([]string, 0, len(cash))
+	for account := range cash {
+		if account != cashAccount {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		iTax := IsTaxAdvantagedAccount(ctx, accounts[i])
+		jTax := IsTaxAdvantagedAccount(ctx, accounts[j])
+		if iTax != jTax {
+			return jTax
+		}
+		return accounts[i] < accounts[j]
+	})
+
+	owed := -amount
+	for _, account := range append([]string{cashAccount}, accounts...) {
+		taken := math.Min(owed, math.Max(cash[account], 0))
+		cash[account] -= taken
+		owed -= taken
+	}
+	if owed > 0 {
+		cash[cashAccount] -= owed
+	}
+}
+
+// sell adds the sell trades for the symbol and their proceeds to the cash of the accounts.
+func (p *RebalancePlan) sell(ctx context.Context, sa *symbolAmount, holdings []*RebalanceHolding, minTradeAmount float64, cash map[string]float64) {
+	if math.Abs(sa.amount) < minTradeAmount || sa.price <= 0 {
+		return
+	}
+
+	sharesToSell := math.Abs(sa.amount) / sa.price
+	if wholeShares(sa.securityType) {
+		sharesToSell = math.Ceil(sharesToSell - 0.0001)
+	}
+
+	var accounts []*RebalanceHolding
+	for _, h := range holdings {
+		if h.Symbol == sa.symbol {
+			accounts = append(accounts, h)
+		}
+	}
+
+	// Tax-advantaged accounts first, then the largest positions.
+	sort.SliceStable(accounts, func(i, j int) bool {
+		iTax := IsTaxAdvantagedAccount(ctx, accounts[i].Account)
+		jTax := IsTaxAdvantagedAccount(ctx, accounts[j].Account)
+		if iTax != jTax {
+			return iTax
+		}
+		return accounts[i].Shares > accounts[j].Shares
+	})
+
+	for _, h := range accounts {
+		if sharesToSell <= 0 {
+			break
+		}
+		shares := math.Min(sharesToSell, h.Shares)
+		if wholeShares(sa.securityType) {
+			shares = math.Floor(shares)
+		}
+		if shares <= 0 || shares*sa.price < minTradeAmount {
+			continue
+		}
+
+		trade := RebalanceTrade{
+			Account:       h.Account,
+			Symbol:        sa.symbol,
+			SecurityType:  sa.securityType,
+			Action:        RebalanceSell,
+			Shares:        math.Round(shares*1000) / 1000,
+			Price:         sa.price,
+			TaxAdvantaged: IsTaxAdvantagedAccount(ctx, h.Account),
+		}
+		trade.Amount = trade.Shares * trade.Price
+		p.Trades = append(p.Trades, &trade)
+
+		cash[h.Account] += trade.Amount
+		sharesToSell -= trade.Shares
+	}
+}
+
+// buy adds the buy trades for the symbol, each limited by the cash of its account, and takes their cost
+// out of the cash. The accounts holding the symbol buy first, the largest position first, then the cash
+// account, then the other accounts with the most cash first.
+func (p *RebalancePlan) buy(ctx context.Context, sa *symbolAmount, holdings []*RebalanceHolding, cashAccount string, minTradeAmount float64, cash map[string]float64) {
+	if sa.price <= 0 {
+		return
+	}
+
+	var held []*RebalanceHolding
+	for _, h := range holdings {
+		if h.Symbol == sa.symbol {
+			held = append(held, h)
+		}
+	}
+	sort.SliceStable(held, func(i, j int) bool {
+		return held[i].Shares > held[j].Shares
+	})
+	var accounts []string
+	for _, h := range held {
+		accounts = append(accounts, h.Account)
+	}
+	accounts = append(accounts, cashAccount)
+	var others []string
+	for account := range cash {
+		others = append(others, account)
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if cash[others[i]] != cash[others[j]] {
+			return cash[others[i]] > cash[others[j]]
+		}
+		return others[i] < others[j]
+	})
+	accounts = append(accounts, others...)
+
+	remaining := sa.amount
+	bought := make(map[string]bool)
+	for _, account := range accounts {
+		if bought[account] || account == "" {
+			continue
+		}
+		bought[account] = true
+
+		amount := math.Min(remaining, cash[account])
+		if amount < minTradeAmount || amount <= 0 {
+			continue
+		}
+		shares := amount / sa.price
+		if wholeShares(sa.securityType) {
+			shares = math.Floor(shares)
+		} else {
+			shares = math.Floor(shares*1000) / 1000
+		}
+		if shares <= 0 || shares*sa.price < minTradeAmount {
+			continue
+		}
+
+		trade := RebalanceTrade{
+			Account:       account,
+			Symbol:        sa.symbol,
+			SecurityType:  sa.securityType,
+			Action:        RebalanceBuy,
+			Shares:        shares,
+			Price:         sa.price,
+			Amount:        shares * sa.price,
+			TaxAdvantaged: IsTaxAdvantagedAccount(ctx, account),
+		}
+		p.Trades = append(p.Trades, &trade)
+		cash[account] -= trade.Amount
+		remaining -= trade.Amount
+	}
+}
+
+// RebalanceHoldingsGet returns the current holdings per account for every symbol with shares.
+func RebalanceHoldingsGet(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet) ([]*RebalanceHolding, error) {
+	return RebalanceHoldingsGetAsOf(ctx, pgxConn, lookups, time.Time{})
+}
+
+// RebalanceHoldingsGetAsOf returns the holdings per account on asOf at the prices of AccountInfoGetAsOf.
+func RebalanceHoldingsGetAsOf(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet, asOf time.Time) ([]*RebalanceHolding, error) {
+	symbols, err := SymbolList(ctx, pgxConn, lookups)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	var holdings []*RebalanceHolding
+	for symbol := range symbols {
+		if symbol == "" {
+			continue
+		}
+		acctInfo, err := AccountInfoGetAsOf(ctx, pgxConn, symbol, DefaultCurrency, asOf)
+		if err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+
+		for account, shares := range acctInfo.Accounts {
+			if shares <= 0 {
+				continue
+			}
+			holdings = append(holdings, &RebalanceHolding{
+				Account:      account,
+				Symbol:       symbol,
+				SecurityType: acctInfo.SecurityType,
+				Shares:       shares,
+				Price:        acctInfo.LatestPrice,
+			})
+		}
+	}
+
+	sort.Slice(holdings, func(i, j int) bool {
+		if holdings[i].Symbol == holdings[j].Symbol {
+			return holdings[i].Account < holdings[j].Account
+		}
+		return holdings[i].Symbol < holdings[j].Symbol
+	})
+	return holdings, nil
+}
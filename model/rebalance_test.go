@@ -0,0 +1,197 @@
+package model_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/model"
+	"math"
+	"testing"
+)
+
+func testRebalanceHoldings() []*model.RebalanceHolding {
+	return []*model.RebalanceHolding{
+		{Account: "Fidelity IRA", Symbol: "HD", SecurityType: "Stock", Shares: 20, Price: 300.00},
+		{Account: "HD ML Individual Account", Symbol: "HD", SecurityType: "Stock", Shares: 20, Price: 300.00},
+		{Account: "Fidelity IRA", Symbol: "CSX", SecurityType: "Stock", Shares: 100, Price: 30.00},
+		{Account: "Fidelity IRA", Symbol: "FCNTX", SecurityType: "Mutual Fund", Shares: 100, Price: 15.00},
+		{Account: "Jane IRA", Symbol: "MMM", SecurityType: "Stock", Shares: 10, Price: 100.00},
+	}
+}
+
+func TestAllocationGroup_Validate(t *testing.T) {
+	g := model.AllocationGroup{
+		Name: "family",
+		Targets: []*model.AllocationTarget{
+			{Target: "HD", TargetType: model.TargetTypeSymbol, Weight: 0.5},
+			{Target: "Mutual Fund", TargetType: model.TargetTypeClass, Weight: 0.4},
+		},
+	}
+	if err := g.Validate(); err == nil {
+		t.Error("expected weights error")
+	}
+
+	g.Targets[1].Weight = 0.5
+	if err := g.Validate(); err != nil {
+		t.Error(err.Error())
+	}
+
+	g.Targets[1].TargetType = "sector"
+	if err := g.Validate(); err == nil {
+		t.Error("expected target type error")
+	}
+
+	if _, err := model.NewAllocationGroupFromJSON([]byte(`{"name":"kids","targets":[{"target":"Stock","type":"class","weight":1}]}`)); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestIsTaxAdvantagedAccount(t *testing.T) {
+	ctx := model.WithPortfolio(context.Background(), "tax")
+	model.SetAccountRegistry("tax", map[string]*model.AccountMetadata{
+		"Schwab Rollover": {Name: "Schwab Rollover", AccountType: model.AccountTypeTraditionalIRA},
+		"Jane IRA":        {Name: "Jane IRA", AccountType: model.AccountTypeTaxable, TaxTreatment: model.TaxTreatmentTaxable},
+		"Kids HSA":        {Name: "Kids HSA", AccountType: model.AccountTypeTaxable, TaxTreatment: model.TaxTreatmentFree},
+	})
+	defer model.SetAccountRegistry("tax", map[string]*model.AccountMetadata{})
+
+	// The metadata of a registered account is used over its name, an account without it falls back on the name.
+	for account, want := range map[string]bool{
+		"Schwab Rollover":          true,
+		"Jane IRA":                 false,
+		"Kids HSA":                 true,
+		"Fidelity IRA":             true,
+		"HD ML Individual Account": false,
+	} {
+		if got := model.IsTaxAdvantagedAccount(ctx, account); got != want {
+			t.Errorf("%s: got %t, want %t", account, got, want)
+		}
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	g := model.AllocationGroup{
+		Name:     "family",
+		Accounts: []string{"Fidelity IRA", "HD ML Individual Account"},
+		Targets: []*model.AllocationTarget{
+			{Target: "HD", TargetType: model.TargetTypeSymbol, Weight: 0.5},
+			{Target: "Mutual Fund", TargetType: model.TargetTypeClass, Weight: 0.3},
+			{Target: "Stock", TargetType: model.TargetTypeClass, Weight: 0.2},
+		},
+	}
+
+	// HD 12000, CSX 3000, FCNTX 1500; MMM is not in the group.
+	plan, err := model.Rebalance(context.Background(), &g, testRebalanceHoldings(), model.RebalanceOptions{Cash: 1000.00, MinTradeAmount: 50.00})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Log(plan.String())
+
+	if plan.TotalValue != 17500.00 {
+		t.Errorf("got total %.2f, want 17500.00", plan.TotalValue)
+	}
+
+	var hdSold, fundBought float64
+	for _, trade := range plan.Trades {
+		if trade.Account == "Jane IRA" {
+			t.Error("traded outside of the group")
+		}
+		switch {
+		case trade.Symbol == "HD" && trade.Action == model.RebalanceSell:
+			if !trade.TaxAdvantaged {
+				t.Error("expected HD to be sold from the IRA first")
+			}
+			if trade.Shares != math.Floor(trade.Shares) {
+				t.Error("expected whole shares of a stock:", trade.Shares)
+			}
+			hdSold += trade.Shares
+		case trade.Symbol == "FCNTX" && trade.Action == model.RebalanceBuy:
+			fundBought += trade.Amount
+		}
+	}
+
+	// HD target 8750 from 12000, sell ceil(3250/300) = 11 shares.
+	if hdSold != 11 {
+		t.Errorf("got %.2f HD shares sold, want 11", hdSold)
+	}
+
+	// FCNTX target 5250 from 1500.
+	if math.Abs(fundBought-3750.00) > 1.00 {
+		t.Errorf("got %.2f of FCNTX bought, want 3750.00", fundBought)
+	}
+
+	if plan.CashRemaining < 0 {
+		t.Error("spent more than was available:", plan.CashRemaining)
+	}
+}
+
+func TestRebalance_Withdraw(t *testing.T) {
+	g := model.AllocationGroup{
+		Name: "all",
+		Targets: []*model.AllocationTarget{
+			{Target: "Stock", TargetType: model.TargetTypeClass, Weight: 1.0},
+		},
+	}
+
+	plan, err := model.Rebalance(context.Background(), &g, testRebalanceHoldings(), model.RebalanceOptions{Cash: -2000.00, MinTradeAmount: 100.00})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sold := 0.00
+	for _, trade := range plan.Trades {
+		if trade.Action == model.RebalanceBuy {
+			t.Error("unexpected buy:", trade.Symbol)
+		}
+		sold += trade.Amount
+	}
+
+	// FCNTX is untargeted and sold, the stocks cover the rest of the withdrawal.
+	if sold < 2000.00 {
+		t.Errorf("got %.2f sold, want at least 2000.00", sold)
+	}
+}
+
+func TestRebalance_AccountCash(t *testing.T) {
+	g := model.AllocationGroup{
+		Name:     "family",
+		Accounts: []string{"HD ML Individual Account", "Fidelity IRA"},
+		Targets: []*model.AllocationTarget{
+			{Target: "HD", TargetType: model.TargetTypeSymbol, Weight: 0.5},
+			{Target: "CSX", TargetType: model.TargetTypeSymbol, Weight: 0.5},
+		},
+	}
+	holdings := []*model.RebalanceHolding{
+		{Account: "Fidelity IRA", Symbol: "CSX", SecurityType: "Stock", Shares: 300, Price: 30.00},
+		{Account: "HD ML Individual Account", Symbol: "HD", SecurityType: "Stock", Shares: 10, Price: 300.00},
+	}
+
+	// CSX 9000 and HD 3000 with no new cash, the IRA sells 3000 of CSX.
+	plan, err := model.Rebalance(context.Background(), &g, holdings, model.RebalanceOptions{MinTradeAmount: 50.00})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Log(plan.String())
+
+	cash := make(map[string]float64)
+	for _, trade := range plan.Trades {
+		switch trade.Action {
+		case model.RebalanceSell:
+			cash[trade.Account] += trade.Amount
+		case model.RebalanceBuy:
+			cash[trade.Account] -= trade.Amount
+			if trade.Symbol == "HD" && trade.Account != "Fidelity IRA" {
+				t.Error("bought HD with the proceeds of another account in", trade.Account)
+			}
+		}
+	}
+	for account, amount := range cash {
+		if amount < 0 {
+			t.Errorf("%s: spent %.2f more than its own cash", account, -amount)
+		}
+		if math.Abs(amount-plan.AccountCash[account]) > 0.01 {
+			t.Errorf("%s: got %.2f cash remaining, want %.2f", account, plan.AccountCash[account], amount)
+		}
+	}
+	if cash["Fidelity IRA"] > 300.00 {
+		t.Errorf("got %.2f left in the IRA, want the proceeds spent on HD", cash["Fidelity IRA"])
+	}
+}
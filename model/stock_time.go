@@ -8,6 +8,16 @@ import (
 
 const dateToPgLayout = "2006-01-02"
 
+// addMonths returns the date the months after t, on the last day of the month when it is shorter than
+// the day of t, where AddDate would go on into the next month: August 31 and 3 months is November 30.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); t.Day() > last {
+		return first.AddDate(0, 0, last-1)
+	}
+	return first.AddDate(0, 0, t.Day()-1)
+}
+
 type StockTime struct {
 	time.Time
 }
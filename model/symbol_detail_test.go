@@ -31,7 +31,7 @@ func TestSymbolInformationSet_MutualFund(t *testing.T) {
 	}
 
 	ls := model.LoadLookupSet("1", string(csvLookupData))
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
@@ -46,17 +46,17 @@ func TestSymbolInformationSet_MutualFund(t *testing.T) {
 	for m := 1; m < 13; m++ {
 		sd := model.NewSymbolDetail(fundHistory, fundSymbol, 2023, m)
 
-		if err := sd.SetNumberOfShares(pgxConn); err != nil {
+		if err := sd.SetNumberOfShares(context.Background(), pgxConn); err != nil {
 			t.Log(err.Error())
 			t.Fail()
 			return
 		}
-		if err := sd.SetDividends(pgxConn); err != nil {
+		if err := sd.SetDividends(context.Background(), pgxConn); err != nil {
 			t.Log(err.Error())
 			t.Fail()
 			return
 		}
-		if err := sd.SetPrice(); err != nil {
+		if err := sd.SetPrice(pgxConn); err != nil {
 			t.Log(err.Error())
 			t.Fail()
 			return
@@ -80,24 +80,24 @@ func TestSymbolInformation_Stock(t *testing.T) {
 	}
 
 	ls := model.LoadLookupSet("1", string(csvLookupData))
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
 
 	for m := 1; m < 13; m++ {
 		sd := model.NewSymbolDetail(fundHistory, stockSymbol, 2023, m)
-		if err := sd.SetNumberOfShares(pgxConn); err != nil {
+		if err := sd.SetNumberOfShares(context.Background(), pgxConn); err != nil {
 			t.Log(err.Error())
 			t.Fail()
 			return
 		}
-		if err := sd.SetDividends(pgxConn); err != nil {
+		if err := sd.SetDividends(context.Background(), pgxConn); err != nil {
 			t.Log(err.Error())
 			t.Fail()
 			return
 		}
-		if err := sd.SetPrice(); err != nil {
+		if err := sd.SetPrice(pgxConn); err != nil {
 			t.Log(err.Error())
 			t.Fail()
 			return
@@ -126,14 +126,14 @@ func TestNewSymbolDetailSet(t *testing.T) {
 	}
 
 	ls := model.LoadLookupSet("1", string(csvLookupData))
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
 
 	date := time.Date(2024, time.Month(1), 1, 00, 00, 00, 00, time.UTC)
 	set := model.NewSymbolDetailSet(pgxConn, stockSymbol, fundHistory)
-	if err := set.Create(date, 12); err != nil {
+	if err := set.Create(context.Background(), date, 12); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
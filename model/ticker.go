@@ -15,10 +15,14 @@ type Ticker struct {
 	Accounts        map[string]*Account `json:"accounts,omitempty"`
 	pendingEntities map[string]*Entity
 	events          []Events
+	portfolio       string // of the accounts, for the account registry
 }
 
 type TickerSet struct {
-	Set map[string]*Ticker
+	Set        map[string]*Ticker
+	actions    []*CorporateAction
+	nextAction int
+	portfolio  string // of the transactions, for the account events and registry
 }
 
 func NewTickerSet() *TickerSet {
@@ -27,24 +31,81 @@ func NewTickerSet() *TickerSet {
 	}
 }
 
+// LoadTickerSet adds the transactions to the tickers, applying the corporate actions as their dates are
+// reached and any remaining ones dated before now at the end. The cash transactions without a symbol,
+// such as a Payment/Deposit, are left to the cash ledgers.
 func (s *TickerSet) LoadTickerSet(ts *TransactionSet) error {
+	if ts.portfolio != "" {
+		s.portfolio = ts.portfolio
+	}
 	for _, tr := range ts.TransactionRows {
+		s.applyCorporateActions(tr.Date)
+		if tr.Symbol == "" {
+			continue
+		}
+
 		en, err := NewEntityFromTransaction(tr)
 		if err != nil {
 			logrus.Error("Error:", err.Error())
 			return err
 		}
 
+		if en.Type == "Stock Split" && s.hasSplitAction(en.Symbol, en.Date) {
+			logrus.Debug("Stock Split for ", en.Symbol, " applied from corporate actions")
+			continue
+		}
+
 		ticker, ok := s.Set[en.Symbol]
 		if !ok {
-			ticker = NewTicker(en.Symbol)
+			ticker = s.newTicker(en.Symbol)
 			s.Set[en.Symbol] = ticker
 		}
 		ticker.AddEntity(en)
+
+		if en.Type == OptionAssigned || en.Type == OptionExercised {
+			s.addUnderlying(en)
+		}
 	}
+	s.applyCorporateActions(time.Now())
 	return nil
 }
 
+// addUnderlying adds the shares of the underlying bought or sold at the strike by an assigned or
+// exercised option to its ticker.
+func (s *TickerSet) addUnderlying(en *Entity) {
+	contract, err := ParseOCCSymbol(en.Symbol)
+	if err != nil {
+		logrus.Error(err.Error())
+		return
+	}
+	u := contract.underlyingEntity(en)
+	if u == nil {
+		return
+	}
+	ticker, ok := s.Set[u.Symbol]
+	if !ok {
+		ticker = s.newTicker(u.Symbol)
+		s.Set[u.Symbol] = ticker
+	}
+	ticker.AddEntity(u)
+}
+
+// newTicker creates a ticker in the portfolio of the set.
+func (s *TickerSet) newTicker(symbol string) *Ticker {
+	if s.portfolio == "" {
+		return NewTicker(symbol)
+	}
+	return newTicker(symbol, s.portfolio)
+}
+
+// newTicker creates a ticker in the portfolio the transactions were read from.
+func (ts *TransactionSet) newTicker(symbol string) *Ticker {
+	if ts.portfolio == "" {
+		return NewTicker(symbol)
+	}
+	return newTicker(symbol, ts.portfolio)
+}
+
 func (s *TickerSet) GetTicker(symbol string) (*Ticker, bool) {
 	ticker, ok := s.Set[symbol]
 	return ticker, ok
@@ -58,49 +119,19 @@ type TickerDatabase struct {
 	Key    string  `json:"key"`
 }
 
-// NewTicker creates a new Ticker
+// NewTicker creates a new Ticker with the account events of the default portfolio.
 func NewTicker(symbol string) *Ticker {
+	return newTicker(symbol, DefaultPortfolio)
+}
+
+// newTicker creates a new Ticker with the account events of the portfolio.
+func newTicker(symbol, portfolio string) *Ticker {
 	return &Ticker{
-		Symbol:   symbol,
-		Accounts: make(map[string]*Account),
-		events: []Events{
-			{
-				Date:        time.Date(2020, time.December, 28, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z HD Restricted Stock",
-				ToAccount:   "HD ML Individual Account",
-			},
-			{
-				Date:        time.Date(2021, time.March, 28, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z HD Restricted Stock",
-				ToAccount:   "HD ML Individual Account",
-			},
-			{
-				Date:        time.Date(2022, time.March, 23, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z HD Restricted Stock",
-				ToAccount:   "HD ML Individual Account",
-			},
-			{
-				Date:        time.Date(2023, time.March, 24, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z HD Restricted Stock",
-				ToAccount:   "HD ML Individual Account",
-			},
-			{
-				Date:        time.Date(2023, time.September, 05, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z Ameritrade IRA",
-				ToAccount:   "Schwab Rollover IRA Keith",
-			},
-			{
-				Date:        time.Date(2023, time.September, 05, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z Jane IRA",
-				ToAccount:   "Schwab Contributory IRA Jane",
-			},
-			{
-				Date:        time.Date(2024, time.March, 25, 00, 00, 00, 00, time.UTC),
-				FromAccount: "z HD Restricted Stock",
-				ToAccount:   "HD ML Individual Account",
-			},
-		},
+		Symbol:          symbol,
+		Accounts:        make(map[string]*Account),
+		events:          accountEvents(portfolio),
 		pendingEntities: make(map[string]*Entity),
+		portfolio:       portfolio,
 	}
 }
 
@@ -152,9 +183,9 @@ func (t *Ticker) AddEntity(en *Entity) {
 						fromEntity.RemainingShares == en.RemainingShares {
 						addEn := fromEntity.Copy()
 						addEn.Account = ev.ToAccount
-						fromEntity.RemainingShares = 0
+						fromEntity.RemainingShares = Decimal{}
 						toAcct.AddEntity(addEn)
-						pendingEntity.RemainingShares -= addEn.RemainingShares
+						pendingEntity.RemainingShares = pendingEntity.RemainingShares.Sub(addEn.RemainingShares)
 						return
 					}
 				}
@@ -165,51 +196,110 @@ func (t *Ticker) AddEntity(en *Entity) {
 	logrus.Debug("len of entities:", len(acct.Entities))
 }
 
-func (t *Ticker) NumberOfShares() float64 {
+// portfolioOrDefault returns the portfolio the transactions of the ticker were read from.
+func (t *Ticker) portfolioOrDefault() string {
+	if t.portfolio == "" {
+		return DefaultPortfolio
+	}
+	return t.portfolio
+}
+
+// NumberOfShares returns the shares held in the open accounts.
+func (t *Ticker) NumberOfShares() Decimal {
 	return t.TotalShares(false)
 }
 
-func (t *Ticker) TotalShares(allAccounts bool) float64 {
-	total := 0.00
+// TotalShares returns the shares held in the open accounts, or every account when allAccounts is true.
+func (t *Ticker) TotalShares(allAccounts bool) Decimal {
+	total := Decimal{}
+	now := time.Now()
 	for _, acct := range t.Accounts {
-		if !allAccounts && acct.Name[0] == 'z' {
+		if !allAccounts && accountClosed(t.portfolioOrDefault(), acct.Name, now) {
 			continue
 		}
-		total += acct.NumberOfShares()
+		total = total.Add(acct.NumberOfShares())
 	}
 	return total
 }
 
+// AccountShares returns the number of shares held in each open account with shares.
+func (t *Ticker) AccountShares() map[string]float64 {
+	shares := make(map[string]float64)
+	now := time.Now()
+	for name, acct := range t.Accounts {
+		if accountClosed(t.portfolioOrDefault(), acct.Name, now) {
+			continue
+		}
+		if numShares := acct.NumberOfShares(); numShares.Cmp(NewDecimal(0.01)) > 0 {
+			shares[name] = numShares.Float64()
+		}
+	}
+	return shares
+}
+
 func (t *Ticker) Dividends() float64 {
-	amt := 0.00
+	amt := Decimal{}
 	for _, a := range t.Accounts {
-		amt += a.Dividends()
+		amt = amt.Add(a.Dividends())
 	}
-	return amt
+	return amt.Float64()
 }
 
 func (t *Ticker) DividendsPaid() float64 {
-	amt := 0.00
+	amt := Decimal{}
+	for _, a := range t.Accounts {
+		amt = amt.Add(a.DividendsPaid())
+	}
+	return amt.Float64()
+}
+
+// DividendIncome returns the dividends of the accounts, without the capital gain distributions in DividendsPaid.
+func (t *Ticker) DividendIncome() float64 {
+	amt := Decimal{}
+	for _, a := range t.Accounts {
+		amt = amt.Add(a.DividendIncome())
+	}
+	return amt.Float64()
+}
+
+func (t *Ticker) CapitalGainDistributions() float64 {
+	amt := Decimal{}
+	for _, a := range t.Accounts {
+		amt = amt.Add(a.CapitalGainDistributions())
+	}
+	return amt.Float64()
+}
+
+func (t *Ticker) ReturnOfCapital() float64 {
+	amt := Decimal{}
+	for _, a := range t.Accounts {
+		amt = amt.Add(a.ReturnOfCapital())
+	}
+	return amt.Float64()
+}
+
+func (t *Ticker) ReturnOfCapitalGain() float64 {
+	amt := Decimal{}
 	for _, a := range t.Accounts {
-		amt += a.DividendsPaid()
+		amt = amt.Add(a.ReturnOfCapitalGain())
 	}
-	return amt
+	return amt.Float64()
 }
 
 func (t *Ticker) InterestIncome() float64 {
-	amt := 0.00
+	amt := Decimal{}
 	for _, a := range t.Accounts {
-		amt += a.InterestIncome()
+		amt = amt.Add(a.InterestIncome())
 	}
-	return amt
+	return amt.Float64()
 }
 
 func (t *Ticker) NetCost() float64 {
-	amt := 0.00
+	amt := Decimal{}
 	for _, a := range t.Accounts {
-		amt += a.NetCost()
+		amt = amt.Add(a.NetCost())
 	}
-	return amt
+	return amt.Float64()
 }
 
 func (t *Ticker) FirstBought() time.Time {
@@ -232,10 +322,11 @@ func (t *Ticker) String() string {
 }
 
 func (t *Ticker) AveragePrice() float64 {
-	if t.NumberOfShares() <= 0 {
+	shares := t.NumberOfShares()
+	if shares.Sign() <= 0 {
 		return 0.00
 	}
-	return t.NetCost() / t.NumberOfShares()
+	return t.NetCost() / shares.Float64()
 }
 
 func (t *Ticker) GetAccount(name string) *Account {
@@ -3,6 +3,7 @@ package model_test
 import (
 	"github.com/kpearce2430/stock-tools/model"
 	"testing"
+	"time"
 )
 
 func TestTickerAppl(t *testing.T) {
@@ -84,3 +85,22 @@ func TestTicker_GetAccount(t *testing.T) {
 	t.Log(acct.DividendsPaid())
 	t.Log(len(acct.Entities))
 }
+
+func TestTickerSet_LoadTickerSetDeposit(t *testing.T) {
+	ts := model.NewTransactionSet()
+	ts.TransactionRows = []*model.Transaction{
+		{Id: 1, Date: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), Type: "Payment/Deposit", SecurityPayee: "Payroll", Description: "Contribution", Amount: model.NewDecimal(500.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX", Security: "CSX Corp", Description: "10 shares @ 30.00", Shares: model.NewDecimal(10), Amount: model.NewDecimal(-300.00), Account: "Fidelity IRA"},
+	}
+
+	tickerSet := model.NewTickerSet()
+	if err := tickerSet.LoadTickerSet(ts); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := tickerSet.GetTicker(""); ok {
+		t.Error("got a ticker for the deposit")
+	}
+	if len(tickerSet.Set) != 1 {
+		t.Errorf("got %d tickers, want 1", len(tickerSet.Set))
+	}
+}
@@ -16,7 +16,7 @@ import (
 
 var errUnexpectedNumberOfTransactions = errors.New("unexpected number of transactions found")
 
-const TransactionFields = "id, date, type,  symbol, security, security_payee,  account, description, shares, investment_amount,amount"
+const TransactionFields = "id, date, type,  symbol, security, security_payee,  account, description, shares, investment_amount,amount, currency"
 
 // Transaction is an individual transaction read in from the CSV data provided.
 type Transaction struct {
@@ -27,15 +27,17 @@ type Transaction struct {
 	Symbol           string          `json:"symbol,omitempty"`
 	SecurityPayee    string          `json:"security_payee,omitempty"`
 	Description      string          `json:"description,omitempty"`
-	Shares           float64         `json:"shares,omitempty"`
-	InvestmentAmount float64         `json:"investment_amount,omitempty"`
-	Amount           float64         `json:"amount,omitempty"`
+	Shares           Decimal         `json:"shares,omitempty"`
+	InvestmentAmount Decimal         `json:"investment_amount,omitempty"`
+	Amount           Decimal         `json:"amount,omitempty"`
 	Account          string          `json:"account,omitempty"`
+	Currency         string          `json:"currency,omitempty"` // of the amounts, DefaultCurrency when empty
 }
 
 type TransactionSet struct {
 	TransactionRows []*Transaction
 	Date            time.Time
+	portfolio       string // the rows were read from
 }
 
 // NewTransaction creates a new transaction record from headers and a CSV row.
@@ -65,25 +67,31 @@ func NewTransaction(headers []string, row []string) (*Transaction, error) {
 		case "Description/Category":
 			tr.Description = row[i]
 		case "Shares":
-			shares, err := utils.FloatParse(row[i])
+			shares, err := ParseDecimal(row[i])
 			if err != nil {
 				return nil, fmt.Errorf("NewTransactionRow Shares: %v", err.Error())
 			}
 			tr.Shares = shares
 		case "Invest Amt":
-			iAmt, err := utils.FloatParse(row[i])
+			iAmt, err := ParseDecimal(row[i])
 			if err != nil {
 				return nil, fmt.Errorf("NewTransactionRow Invest Amt: %v", err.Error())
 			}
 			tr.InvestmentAmount = iAmt
 		case "Amount":
-			amt, err := utils.FloatParse(row[i])
+			amt, err := ParseDecimal(row[i])
 			if err != nil {
 				return nil, fmt.Errorf("NewTransactionRow Invest Amt: %v", err.Error())
 			}
 			tr.Amount = amt
 		case "Account":
 			tr.Account = row[i]
+		case "Currency":
+			currency, err := NormalizeCurrency(row[i])
+			if err != nil {
+				return nil, fmt.Errorf("NewTransactionRow Currency: %v", err.Error())
+			}
+			tr.Currency = currency
 		default:
 			if h != "Split" {
 				fmt.Println("Skipping ", h)
@@ -122,10 +130,10 @@ func (ts *TransactionSet) String() string {
 
 func (tr *Transaction) TransactionToDB(ctx context.Context, pg *pgxpool.Pool, tableName string) error {
 	insertStatement := fmt.Sprintf(
-		"INSERT INTO %s( id, date, type, security, security_payee, symbol, account, description, shares, investment_amount,amount)"+
-			" VALUES('%d','%s','%s','%s','%s','%s','%s','%s','%f','%f','%f');",
-		tableName, tr.Id, tr.Date.Format("2006-01-02"), tr.Type, tr.Security, tr.SecurityPayee, tr.Symbol, tr.Account, tr.Description, tr.Shares, tr.InvestmentAmount, tr.Amount)
-	rows, err := pg.Query(ctx, insertStatement)
+		"INSERT INTO %s( id, date, type, security, security_payee, symbol, account, description, shares, investment_amount,amount, currency)"+
+			" VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12);", tableName)
+	rows, err := pg.Query(ctx, insertStatement, tr.Id, tr.Date.Format("2006-01-02"), string(tr.Type), tr.Security, tr.SecurityPayee, tr.Symbol,
+		tr.Account, tr.Description, tr.Shares.String(), tr.InvestmentAmount.String(), tr.Amount.String(), currencyOrDefault(tr.Currency))
 	defer rows.Close()
 	if err != nil {
 		return err
@@ -251,9 +259,7 @@ type TransactionLoadStatus struct {
 	Existing bool
 }
 
-func transactionLoadToDB(tChan chan TransactionLoadStatus, pgxConn *pgxpool.Pool, transTable string, tr *Transaction) {
-
-	ctx := context.Background()
+func transactionLoadToDB(ctx context.Context, tChan chan TransactionLoadStatus, pgxConn *pgxpool.Pool, transTable string, tr *Transaction) {
 	tSet := NewTransactionSet()
 	err := tSet.TransactionSetFromDBbyId(ctx, pgxConn, transTable, tr.Id)
 	if err != nil {
@@ -316,7 +322,7 @@ func transactionLoadToDB(tChan chan TransactionLoadStatus, pgxConn *pgxpool.Pool
 	}
 }
 
-func TransactionSetLoadToDB(pgxConn *pgxpool.Pool, lookups *LookUpSet, transTable string, rawData []byte) error {
+func TransactionSetLoadToDB(ctx context.Context, pgxConn *pgxpool.Pool, lookups *LookUpSet, transTable string, rawData []byte) error {
 	tSet := NewTransactionSet()
 	if err := tSet.Load(rawData); err != nil {
 		return err
@@ -330,10 +336,6 @@ func TransactionSetLoadToDB(pgxConn *pgxpool.Pool, lookups *LookUpSet, transTabl
 
 	logrus.Info("Number of rows :", len(tSet.TransactionRows))
 	for _, tr := range tSet.TransactionRows {
-		if tr.Type == "Payment/Deposit" {
-			logrus.Debug("Skipping ", tr.Id, " ", tr.Type)
-			continue
-		}
 		value, ok := lookups.GetLookUpByName(tr.Security)
 		switch {
 		case value == "DEAD":
@@ -342,7 +344,7 @@ func TransactionSetLoadToDB(pgxConn *pgxpool.Pool, lookups *LookUpSet, transTabl
 			tr.Symbol = value
 		}
 		numProcessed++
-		go transactionLoadToDB(tChan, pgxConn, transTable, tr)
+		go transactionLoadToDB(ctx, tChan, pgxConn, transTable, tr)
 	}
 
 	var responses []TransactionLoadStatus
@@ -382,14 +384,75 @@ func TransactionSetLoadToDB(pgxConn *pgxpool.Pool, lookups *LookUpSet, transTabl
 
 func (ts *TransactionSet) TransactionSetFromDBbyId(ctx context.Context, pg *pgxpool.Pool, tableName string, id int) error {
 	return ts.getTransactions(ctx, pg, fmt.Sprintf(
-		"SELECT %s FROM %s WHERE id = '%d';",
-		TransactionFields, tableName, id))
+		"SELECT %s FROM %s WHERE id = $1;",
+		TransactionFields, tableName), id)
 }
 
 func (ts *TransactionSet) TransactionSetFromDBbySymbol(ctx context.Context, pg *pgxpool.Pool, tableName, symbol string) error {
 	return ts.getTransactions(ctx, pg, fmt.Sprintf(
-		"SELECT %s FROM %s WHERE symbol = '%s' ORDER BY date,id;",
-		TransactionFields, tableName, symbol))
+		"SELECT %s FROM %s WHERE symbol = $1 ORDER BY date,id;",
+		TransactionFields, tableName), symbol)
+}
+
+// TransactionSetWithOptionsBySymbol returns the transactions of the symbol and of the options on it, so
+// the shares bought or sold by an assigned or exercised option are included.
+func (ts *TransactionSet) TransactionSetWithOptionsBySymbol(ctx context.Context, pg *pgxpool.Pool, tableName, symbol string) error {
+	if err := ts.getTransactions(ctx, pg, fmt.Sprintf(
+		"SELECT %s FROM %s WHERE symbol = $1 OR symbol LIKE $2 ORDER BY date,id;",
+		TransactionFields, tableName), symbol, escapeLike(symbol)+"%"); err != nil {
+		return err
+	}
+
+	var rows []*Transaction
+	for _, tr := range ts.TransactionRows {
+		if tr.Symbol == symbol {
+			rows = append(rows, tr)
+			continue
+		}
+		if contract, err := ParseOCCSymbol(tr.Symbol); err == nil && contract.Underlying == symbol {
+			rows = append(rows, tr)
+		}
+	}
+	ts.TransactionRows = rows
+	return nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// TransactionSetsWithOptionsBySymbols returns the transactions of each symbol and of the options on it, as
+// TransactionSetWithOptionsBySymbol does, from a single query for all the symbols.
+func TransactionSetsWithOptionsBySymbols(ctx context.Context, pg *pgxpool.Pool, tableName string, symbols []string) (map[string]*TransactionSet, error) {
+	prefixes := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		prefixes[i] = escapeLike(symbol) + "%"
+	}
+	all := NewTransactionSet()
+	if err := all.getTransactions(ctx, pg, fmt.Sprintf("SELECT %s FROM %s WHERE symbol = ANY($1) OR symbol LIKE ANY($2) ORDER BY date,id;",
+		TransactionFields, tableName), symbols, prefixes); err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]*TransactionSet, len(symbols))
+	for _, symbol := range symbols {
+		ts := NewTransactionSet()
+		ts.portfolio = all.portfolio
+		sets[symbol] = ts
+	}
+	for _, tr := range all.TransactionRows {
+		// An option is in the set of its underlying, and in its own when it is one of the symbols.
+		if ts, ok := sets[tr.Symbol]; ok {
+			ts.TransactionRows = append(ts.TransactionRows, tr)
+		}
+		if contract, err := ParseOCCSymbol(tr.Symbol); err == nil && contract.Underlying != tr.Symbol {
+			if ts, ok := sets[contract.Underlying]; ok {
+				ts.TransactionRows = append(ts.TransactionRows, tr)
+			}
+		}
+	}
+	return sets, nil
 }
 
 func (ts *TransactionSet) TransactionsGetAll(ctx context.Context, pg *pgxpool.Pool) error {
@@ -398,36 +461,54 @@ func (ts *TransactionSet) TransactionsGetAll(ctx context.Context, pg *pgxpool.Po
 	return ts.getTransactions(ctx, pg, queryStatement)
 }
 
+// TransactionsAllGetAsOf returns the transactions on or before asOf in date order, or all of them for a zero
+// asOf.
+func (ts *TransactionSet) TransactionsAllGetAsOf(ctx context.Context, pg *pgxpool.Pool, asOf time.Time) error {
+	if asOf.IsZero() {
+		return ts.getTransactions(ctx, pg, fmt.Sprintf(
+			"SELECT %s From %s order by date, id ", TransactionFields, transactionTable))
+	}
+	return ts.getTransactions(ctx, pg, fmt.Sprintf(
+		"SELECT %s From %s WHERE date <= $1::timestamp order by date, id ",
+		TransactionFields, transactionTable), asOf.Format(dateToPgLayout))
+}
+
 func (ts *TransactionSet) TransactionsAllGetBeforeDate(ctx context.Context, pg *pgxpool.Pool, year, month, day int) error {
 
 	return ts.getTransactions(ctx, pg, fmt.Sprintf(
-		"SELECT %s From %s WHERE date < '%s' order by date ",
-		TransactionFields, transactionTable, fmt.Sprintf("%4d-%02d-%02d", year, month, day)))
+		"SELECT %s From %s WHERE date < $1 order by date ",
+		TransactionFields, transactionTable), fmt.Sprintf("%4d-%02d-%02d", year, month, day))
 }
 
 func (ts *TransactionSet) TransactionsSymbolGetBeforeDate(ctx context.Context, pg *pgxpool.Pool, symbol string, year, month, day int) error {
 
 	return ts.getTransactions(ctx, pg, fmt.Sprintf(
-		"SELECT %s From %s WHERE symbol = '%s' and date < '%s' order by date ",
-		TransactionFields, transactionTable, symbol, fmt.Sprintf("%4d-%02d-%02d", year, month, day)))
+		"SELECT %s From %s WHERE symbol = $1 and date < $2 order by date ",
+		TransactionFields, transactionTable), symbol, fmt.Sprintf("%4d-%02d-%02d", year, month, day))
+}
+
+// TransactionsSymbolGetBetweenDates returns the transactions for the symbol on or after start and before end.
+func (ts *TransactionSet) TransactionsSymbolGetBetweenDates(ctx context.Context, pg *pgxpool.Pool, symbol string, start, end time.Time) error {
+
+	return ts.getTransactions(ctx, pg, fmt.Sprintf(
+		"SELECT %s From %s WHERE symbol = $1 and date >= $2 and date < $3 order by date ",
+		TransactionFields, transactionTable), symbol, start.Format(dateToPgLayout), end.Format(dateToPgLayout))
 }
 
 func (ts *TransactionSet) TransactionsForMonth(ctx context.Context, pg *pgxpool.Pool, symbol string, year, month int) error {
-	var queryStatement string
+	var end string
 	switch month {
 	case 12:
 		endMonth := 01
 		endYear := year + 1
-		queryStatement = fmt.Sprintf(
-			"SELECT %s From %s WHERE symbol = '%s' and date >= '%s' and date < '%s' order by date ",
-			TransactionFields, transactionTable, symbol, fmt.Sprintf("%4d-%02d-01", year, month), fmt.Sprintf("%4d-%02d-01", endYear, endMonth))
+		end = fmt.Sprintf("%4d-%02d-01", endYear, endMonth)
 	default:
 		endMonth := month + 1
-		queryStatement = fmt.Sprintf(
-			"SELECT %s From %s WHERE symbol = '%s' and date >= '%s' and date < '%s' order by date ",
-			TransactionFields, transactionTable, symbol, fmt.Sprintf("%4d-%02d-01", year, month), fmt.Sprintf("%4d-%02d-01", year, endMonth))
+		end = fmt.Sprintf("%4d-%02d-01", year, endMonth)
 	}
-	return ts.getTransactions(ctx, pg, queryStatement)
+	return ts.getTransactions(ctx, pg, fmt.Sprintf(
+		"SELECT %s From %s WHERE symbol = $1 and date >= $2 and date < $3 order by date ",
+		TransactionFields, transactionTable), symbol, fmt.Sprintf("%4d-%02d-01", year, month), end)
 }
 
 func (ts *TransactionSet) GetTransactions(ctx context.Context, pg *pgxpool.Pool, symbol string, year, month int) error {
@@ -445,6 +526,7 @@ func (ts *TransactionSet) GetTransactions(ctx context.Context, pg *pgxpool.Pool,
 	}
 
 	needAnd := false
+	var args []any
 	var sb strings.Builder
 	sb.WriteString("SELECT ")
 	sb.WriteString(TransactionFields)
@@ -452,10 +534,9 @@ func (ts *TransactionSet) GetTransactions(ctx context.Context, pg *pgxpool.Pool,
 	sb.WriteString(transactionTable)
 	sb.WriteString(" WHERE ")
 	if symbol != "" {
-		sb.WriteString(" symbol = '")
-		sb.WriteString(symbol)
+		args = append(args, symbol)
+		sb.WriteString(fmt.Sprintf(" symbol = $%d", len(args)))
 		needAnd = true
-		sb.WriteString("'")
 	}
 
 	if intInRange(year, 1980, now.Year()) && intInRange(month, 1, 12) {
@@ -466,31 +547,28 @@ func (ts *TransactionSet) GetTransactions(ctx context.Context, pg *pgxpool.Pool,
 		case 12:
 			endMonth := 01
 			endYear := year + 1
-			sb.WriteString(" date >= '")
-			sb.WriteString(fmt.Sprintf("%4d-%02d-01' and date < '", year, month))
-			sb.WriteString(fmt.Sprintf("%4d-%02d-01'", endYear, endMonth))
-			// sb.WriteString(fmt.Sprintf(" date >= '%s' and date < '%s'",))
+			args = append(args, fmt.Sprintf("%4d-%02d-01", year, month), fmt.Sprintf("%4d-%02d-01", endYear, endMonth))
 
 		default:
 			endMonth := month + 1
-			sb.WriteString(" date >= '")
-			sb.WriteString(fmt.Sprintf("%4d-%02d-01' and date < '", year, month))
-			sb.WriteString(fmt.Sprintf("%4d-%02d-01'", year, endMonth))
+			args = append(args, fmt.Sprintf("%4d-%02d-01", year, month), fmt.Sprintf("%4d-%02d-01", year, endMonth))
 
 		}
+		sb.WriteString(fmt.Sprintf(" date >= $%d and date < $%d", len(args)-1, len(args)))
 	}
 	sb.WriteString(" order by date ")
-	return ts.getTransactions(ctx, pg, sb.String())
+	return ts.getTransactions(ctx, pg, sb.String(), args...)
 }
 
 // GetTransactions will return the TransactionSet based on the selectStatement passed in.
-func (ts *TransactionSet) getTransactions(ctx context.Context, pg *pgxpool.Pool, selectStatement string) error {
+func (ts *TransactionSet) getTransactions(ctx context.Context, pg *pgxpool.Pool, selectStatement string, args ...any) error {
 
 	if len(ts.TransactionRows) > 0 {
 		clear(ts.TransactionRows)
 	}
 
-	rows, err := pg.Query(ctx, selectStatement)
+	ts.portfolio = PortfolioFromContext(ctx)
+	rows, err := pg.Query(ctx, selectStatement, args...)
 	defer rows.Close()
 	if err != nil {
 		logrus.Error(err.Error())
@@ -500,7 +578,7 @@ func (ts *TransactionSet) getTransactions(ctx context.Context, pg *pgxpool.Pool,
 	// Iterate through the result set
 	for rows.Next() {
 		trans := Transaction{}
-		err = rows.Scan(&trans.Id, &trans.Date, &trans.Type, &trans.Symbol, &trans.Security, &trans.SecurityPayee, &trans.Account, &trans.Description, &trans.Shares, &trans.InvestmentAmount, &trans.Amount)
+		err = rows.Scan(&trans.Id, &trans.Date, &trans.Type, &trans.Symbol, &trans.Security, &trans.SecurityPayee, &trans.Account, &trans.Description, &trans.Shares, &trans.InvestmentAmount, &trans.Amount, &trans.Currency)
 		if err != nil {
 			logrus.Error(err.Error())
 			return err
@@ -0,0 +1,286 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	TransactionAuditTable  = "transaction_audit"
+	transactionAuditFields = "transaction_id, table_name, action, username, changed_at, before, after"
+
+	TransactionCreated = "create"
+	TransactionUpdated = "update"
+	TransactionDeleted = "delete"
+)
+
+var (
+	errTransactionNotFound = errors.New("transaction not found")
+	errTransactionExists   = errors.New("transaction already exists")
+	errTransactionInvalid  = errors.New("invalid transaction")
+)
+
+// IsTransactionNotFound returns true when the error is from a transaction that does not exist.
+func IsTransactionNotFound(err error) bool {
+	return errors.Is(err, errTransactionNotFound)
+}
+
+// IsTransactionExists returns true when the error is from creating a transaction with the id of another.
+func IsTransactionExists(err error) bool {
+	return errors.Is(err, errTransactionExists)
+}
+
+// IsTransactionInvalid returns true when the error is from a transaction that is not valid.
+func IsTransactionInvalid(err error) bool {
+	return errors.Is(err, errTransactionInvalid)
+}
+
+// TransactionAudit is a change to a transaction, Before is nil for a created transaction and After is nil
+// for a deleted one.
+type TransactionAudit struct {
+	TransactionId int          `json:"transaction_id"`
+	Table         string       `json:"table"`
+	Action        string       `json:"action"`
+	User          string       `json:"user"`
+	ChangedAt     time.Time    `json:"changed_at"`
+	Before        *Transaction `json:"before,omitempty"`
+	After         *Transaction `json:"after,omitempty"`
+}
+
+// Validate checks the fields a transaction entered by hand needs and normalizes the currency.
+func (tr *Transaction) Validate() error {
+	switch {
+	case tr.Id <= 0:
+		return fmt.Errorf("%w: id must be more than zero", errTransactionInvalid)
+	case tr.Date.IsZero():
+		return fmt.Errorf("%w: date is required", errTransactionInvalid)
+	case tr.Type == "":
+		return fmt.Errorf("%w: type is required", errTransactionInvalid)
+	case tr.Account == "":
+		return fmt.Errorf("%w: account is required", errTransactionInvalid)
+	}
+	currency, err := NormalizeCurrency(tr.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errTransactionInvalid, err.Error())
+	}
+	tr.Currency = currency
+	return nil
+}
+
+// transactionJSON reads the JSON of a transaction, the date may be a day, 2024-01-05, as well as RFC 3339.
+func transactionJSON(tr *Transaction, data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("%w: %s", errTransactionInvalid, err.Error())
+	}
+	if raw, ok := fields["date"]; ok {
+		var day string
+		if err := json.Unmarshal(raw, &day); err == nil {
+			if date, err := time.Parse(dateToPgLayout, day); err == nil {
+				fields["date"], _ = json.Marshal(date)
+			}
+		}
+	}
+	data, _ = json.Marshal(fields)
+	if err := json.Unmarshal(data, tr); err != nil {
+		return fmt.Errorf("%w: %s", errTransactionInvalid, err.Error())
+	}
+	return nil
+}
+
+// NewTransactionFromJSON returns the transaction with the id in the JSON body.
+func NewTransactionFromJSON(id int, data []byte) (*Transaction, error) {
+	tr := Transaction{}
+	if err := transactionJSON(&tr, data); err != nil {
+		return nil, err
+	}
+	if tr.Id != 0 && tr.Id != id {
+		return nil, fmt.Errorf("%w: id %d does not match %d", errTransactionInvalid, tr.Id, id)
+	}
+	tr.Id = id
+	return &tr, tr.Validate()
+}
+
+// PatchTransaction returns a copy of the transaction with the fields in the JSON body replaced.
+func PatchTransaction(tr *Transaction, patch []byte) (*Transaction, error) {
+	patched := *tr
+	if err := transactionJSON(&patched, patch); err != nil {
+		return nil, err
+	}
+	if patched.Id != tr.Id {
+		return nil, fmt.Errorf("%w: id cannot be changed", errTransactionInvalid)
+	}
+	return &patched, patched.Validate()
+}
+
+// transactionGet returns the transaction with the id, locked for update.
+func transactionGet(ctx context.Context, tx pgx.Tx, tableName string, id int) (*Transaction, error) {
+	tr := Transaction{}
+	err := tx.QueryRow(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 FOR UPDATE;", TransactionFields, tableName), id).
+		Scan(&tr.Id, &tr.Date, &tr.Type, &tr.Symbol, &tr.Security, &tr.SecurityPayee, &tr.Account, &tr.Description, &tr.Shares, &tr.InvestmentAmount, &tr.Amount, &tr.Currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", errTransactionNotFound, id)
+	}
+	return &tr, err
+}
+
+// TransactionGet returns the transaction with the id.
+func TransactionGet(ctx context.Context, pg *pgxpool.Pool, tableName string, id int) (*Transaction, error) {
+	ts := NewTransactionSet()
+	if err := ts.TransactionSetFromDBbyId(ctx, pg, tableName, id); err != nil {
+		return nil, err
+	}
+	if len(ts.TransactionRows) == 0 {
+		return nil, fmt.Errorf("%w: %d", errTransactionNotFound, id)
+	}
+	return ts.TransactionRows[0], nil
+}
+
+// upsert writes the transaction with its values passed as arguments, so a description with a quote is stored.
+func (tr *Transaction) upsert(ctx context.Context, tx pgx.Tx, tableName string) error {
+	_, err := tx.Exec(ctx, fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9::numeric,$10::numeric,$11::numeric,$12)"+
+			" ON CONFLICT (portfolio_id, id) DO UPDATE SET date = EXCLUDED.date, type = EXCLUDED.type, symbol = EXCLUDED.symbol,"+
+			" security = EXCLUDED.security, security_payee = EXCLUDED.security_payee, account = EXCLUDED.account,"+
+			" description = EXCLUDED.description, shares = EXCLUDED.shares, investment_amount = EXCLUDED.investment_amount,"+
+			" amount = EXCLUDED.amount, currency = EXCLUDED.currency;",
+		tableName, TransactionFields),
+		tr.Id, tr.Date.Format(dateToPgLayout), string(tr.Type), tr.Symbol, tr.Security, tr.SecurityPayee, tr.Account, tr.Description,
+		tr.Shares.String(), tr.InvestmentAmount.String(), tr.Amount.String(), currencyOrDefault(tr.Currency))
+	return err
+}
+
+// record stores the change and removes the dividend_history rows of the symbol and month of the transaction
+// before and after the change, which GetDividendEntryForYearMonth then computes again.
+func (a *TransactionAudit) record(ctx context.Context, tx pgx.Tx) error {
+	before, _ := json.Marshal(a.Before)
+	after, _ := json.Marshal(a.After)
+	if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s(%s) VALUES($1,$2,$3,$4,$5,$6,$7);", TransactionAuditTable, transactionAuditFields),
+		a.TransactionId, a.Table, a.Action, a.User, a.ChangedAt, string(before), string(after)); err != nil {
+		return err
+	}
+
+	for _, tr := range []*Transaction{a.Before, a.After} {
+		if tr == nil || tr.Symbol == "" {
+			continue
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE symbol = $1 AND year = $2 AND month = $3;", dividendHistoryTable),
+			tr.Symbol, tr.Date.Year(), int(tr.Date.Month())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editTransaction runs the change in a database transaction with its audit, and returns the audit.
+func editTransaction(ctx context.Context, pg *pgxpool.Pool, tableName string, id int, user, action string,
+	change func(tx pgx.Tx, before *Transaction) (*Transaction, error)) (*TransactionAudit, error) {
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	before, err := transactionGet(ctx, tx, tableName, id)
+	if err != nil && !IsTransactionNotFound(err) {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	if before != nil && before.Currency == "" {
+		before.Currency = DefaultCurrency
+	}
+
+	after, err := change(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := TransactionAudit{TransactionId: id, Table: tableName, Action: action, User: user, ChangedAt: time.Now().UTC(), Before: before, After: after}
+	if err := audit.record(ctx, tx); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	logrus.Info("Transaction ", id, " ", action, " by ", user)
+	return &audit, nil
+}
+
+// CreateTransaction stores a transaction entered by hand. The transactions loaded from an export are
+// numbered from 1 by row, so an entry by hand should use an id above them.
+func CreateTransaction(ctx context.Context, pg *pgxpool.Pool, tableName string, tr *Transaction, user string) (*TransactionAudit, error) {
+	if err := tr.Validate(); err != nil {
+		return nil, err
+	}
+	return editTransaction(ctx, pg, tableName, tr.Id, user, TransactionCreated, func(tx pgx.Tx, before *Transaction) (*Transaction, error) {
+		if before != nil {
+			return nil, fmt.Errorf("%w: %d", errTransactionExists, tr.Id)
+		}
+		return tr, tr.upsert(ctx, tx, tableName)
+	})
+}
+
+// UpdateTransaction replaces the fields of the transaction with the fields in the JSON patch.
+func UpdateTransaction(ctx context.Context, pg *pgxpool.Pool, tableName string, id int, patch []byte, user string) (*TransactionAudit, error) {
+	return editTransaction(ctx, pg, tableName, id, user, TransactionUpdated, func(tx pgx.Tx, before *Transaction) (*Transaction, error) {
+		if before == nil {
+			return nil, fmt.Errorf("%w: %d", errTransactionNotFound, id)
+		}
+		after, err := PatchTransaction(before, patch)
+		if err != nil {
+			return nil, err
+		}
+		return after, after.upsert(ctx, tx, tableName)
+	})
+}
+
+// DeleteTransaction removes the transaction.
+func DeleteTransaction(ctx context.Context, pg *pgxpool.Pool, tableName string, id int, user string) (*TransactionAudit, error) {
+	return editTransaction(ctx, pg, tableName, id, user, TransactionDeleted, func(tx pgx.Tx, before *Transaction) (*Transaction, error) {
+		if before == nil {
+			return nil, fmt.Errorf("%w: %d", errTransactionNotFound, id)
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1;", tableName), id)
+		return nil, err
+	})
+}
+
+// TransactionAuditGet returns the changes to the transaction of the table, oldest first.
+func TransactionAuditGet(ctx context.Context, pg *pgxpool.Pool, tableName string, id int) ([]*TransactionAudit, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE transaction_id = $1 AND table_name = $2 ORDER BY changed_at, id;",
+		transactionAuditFields, TransactionAuditTable), id, tableName)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	audits := []*TransactionAudit{}
+	for rows.Next() {
+		a := TransactionAudit{}
+		var before, after []byte
+		if err := rows.Scan(&a.TransactionId, &a.Table, &a.Action, &a.User, &a.ChangedAt, &before, &after); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		if err := json.Unmarshal(before, &a.Before); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(after, &a.After); err != nil {
+			return nil, err
+		}
+		audits = append(audits, &a)
+	}
+	return audits, rows.Err()
+}
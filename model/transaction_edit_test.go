@@ -0,0 +1,53 @@
+package model_test
+
+import (
+	"github.com/kpearce2430/stock-tools/model"
+	"testing"
+	"time"
+)
+
+func TestNewTransactionFromJSON(t *testing.T) {
+	tr, err := model.NewTransactionFromJSON(90001, []byte(`{"date":"2024-02-01","type":"Dividend Income","symbol":"CSX","account":"Fidelity IRA","amount":"11.05"}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if tr.Id != 90001 || !tr.Date.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)) ||
+		tr.Amount != model.NewDecimal(11.05) || tr.Currency != model.DefaultCurrency {
+		t.Errorf("unexpected transaction %+v", tr)
+	}
+
+	invalid := []string{
+		`{"date":"2024-02-01","type":"Buy","account":"Fidelity IRA","id":2}`,
+		`{"type":"Buy","account":"Fidelity IRA"}`,
+		`{"date":"2024-02-01","account":"Fidelity IRA"}`,
+		`{"date":"2024-02-01","type":"Buy"}`,
+		`{"date":"2024-02-01","type":"Buy","account":"Fidelity IRA","currency":"dollars"}`,
+		`not json`,
+	}
+	for _, body := range invalid {
+		if _, err := model.NewTransactionFromJSON(90001, []byte(body)); !model.IsTransactionInvalid(err) {
+			t.Errorf("%s: got %v, want an invalid transaction", body, err)
+		}
+	}
+}
+
+func TestPatchTransaction(t *testing.T) {
+	tr := testQueryTransactions()[0]
+	patched, err := model.PatchTransaction(tr, []byte(`{"date":"2024-01-08","amount":"-3005.25"}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if patched.Date.Day() != 8 || patched.Amount != model.NewDecimal(-3005.25) || patched.Shares != tr.Shares || patched.Symbol != "CSX" {
+		t.Errorf("unexpected transaction %+v", patched)
+	}
+	if tr.Amount != model.NewDecimal(-3000.00) {
+		t.Errorf("the transaction patched was changed %+v", tr)
+	}
+
+	if _, err := model.PatchTransaction(tr, []byte(`{"id":7}`)); !model.IsTransactionInvalid(err) {
+		t.Errorf("got %v, want an invalid transaction", err)
+	}
+	if _, err := model.PatchTransaction(tr, []byte(`{"account":""}`)); !model.IsTransactionInvalid(err) {
+		t.Errorf("got %v, want an invalid transaction", err)
+	}
+}
@@ -0,0 +1,297 @@
+package model
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTransactionLimit is the transactions in a page when no limit is given.
+	DefaultTransactionLimit = 100
+	// MaxTransactionLimit is the most transactions in a page.
+	MaxTransactionLimit = 1000
+
+	// cursorDateLayout is the date of a cursor, the date column is a timestamp without a time zone.
+	cursorDateLayout = "2006-01-02 15:04:05.999999"
+)
+
+var (
+	errTransactionSort   = errors.New("invalid transaction sort")
+	errTransactionCursor = errors.New("invalid transaction cursor")
+	errTransactionQuery  = errors.New("invalid transaction query")
+
+	// transactionSortColumns are the fields a query is sorted by, ties are sorted by the id.
+	transactionSortColumns = map[string]string{
+		"id":      "id",
+		"date":    "date",
+		"symbol":  "symbol",
+		"account": "account",
+		"type":    "type",
+		"shares":  "shares",
+		"amount":  "amount",
+	}
+
+	// transactionCSVHeaders are the columns written by WriteTransactionsCSV, named as the JSON fields.
+	transactionCSVHeaders = []string{"id", "date", "type", "security", "security_payee", "symbol", "account", "description", "shares", "investment_amount", "amount", "currency"}
+)
+
+// TransactionQuery filters, sorts and pages the transactions. The lists match any of their values, and
+// an empty list or zero value matches every transaction.
+type TransactionQuery struct {
+	Accounts  []string
+	Symbols   []string
+	Types     []string
+	Start     time.Time // on or after
+	End       time.Time // before
+	MinAmount *Decimal
+	MaxAmount *Decimal
+	Text      string // in the description, ignoring case
+	Sort      string // a field of transactionSortColumns, descending with a leading -
+	Limit     int
+	Cursor    string // NextCursor of the previous page
+}
+
+// TransactionPage is a page of the transactions of a query and the cursor of the next page, which is
+// empty on the last page.
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+}
+
+// transactionCursor is the sort value and the id of the last transaction of a page.
+type transactionCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    int    `json:"id"`
+}
+
+// sortColumn returns the column and direction of the sort.
+func (q *TransactionQuery) sortColumn() (string, bool, error) {
+	sort := q.Sort
+	if sort == "" {
+		sort = "date"
+	}
+	descending := strings.HasPrefix(sort, "-")
+	column, ok := transactionSortColumns[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return "", false, fmt.Errorf("%w: %s", errTransactionSort, q.Sort)
+	}
+	return column, descending, nil
+}
+
+// limit returns the page size.
+func (q *TransactionQuery) limit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultTransactionLimit
+	case q.Limit > MaxTransactionLimit:
+		return MaxTransactionLimit
+	}
+	return q.Limit
+}
+
+// sortValue returns the value of the column of the transaction as it is written in a cursor.
+func sortValue(tr *Transaction, column string) string {
+	switch column {
+	case "date":
+		return tr.Date.Format(cursorDateLayout)
+	case "symbol":
+		return tr.Symbol
+	case "account":
+		return tr.Account
+	case "type":
+		return string(tr.Type)
+	case "shares":
+		return tr.Shares.String()
+	case "amount":
+		return tr.Amount.String()
+	}
+	return strconv.Itoa(tr.Id)
+}
+
+func encodeTransactionCursor(sort string, tr *Transaction, column string) string {
+	b, _ := json.Marshal(transactionCursor{Sort: sort, Value: sortValue(tr, column), Id: tr.Id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeTransactionCursor(cursor string) (*transactionCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errTransactionCursor, cursor)
+	}
+	var tc transactionCursor
+	if err := json.Unmarshal(b, &tc); err != nil {
+		return nil, fmt.Errorf("%w: %s", errTransactionCursor, cursor)
+	}
+	return &tc, nil
+}
+
+// sql returns the select statement of the query and its arguments. The values are passed as arguments so
+// the text of a filter is never part of the statement.
+func (q *TransactionQuery) sql(tableName string) (string, []any, error) {
+	column, descending, err := q.sortColumn()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(q.Accounts) > 0 {
+		where = append(where, "account = ANY("+arg(q.Accounts)+")")
+	}
+	if len(q.Symbols) > 0 {
+		where = append(where, "symbol = ANY("+arg(q.Symbols)+")")
+	}
+	if len(q.Types) > 0 {
+		where = append(where, "type = ANY("+arg(q.Types)+")")
+	}
+	if !q.Start.IsZero() {
+		where = append(where, "date >= "+arg(q.Start.Format(dateToPgLayout))+"::timestamp")
+	}
+	if !q.End.IsZero() {
+		where = append(where, "date < "+arg(q.End.Format(dateToPgLayout))+"::timestamp")
+	}
+	if q.MinAmount != nil {
+		where = append(where, "amount >= "+arg(q.MinAmount.String())+"::numeric")
+	}
+	if q.MaxAmount != nil {
+		where = append(where, "amount <= "+arg(q.MaxAmount.String())+"::numeric")
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && q.MinAmount.Cmp(*q.MaxAmount) > 0 {
+		return "", nil, fmt.Errorf("%w: min amount %s is more than max amount %s", errTransactionQuery, q.MinAmount, q.MaxAmount)
+	}
+	if q.Text != "" {
+		where = append(where, "description ILIKE "+arg("%"+escapeLike(q.Text)+"%"))
+	}
+
+	direction, compare := "ASC", ">"
+	if descending {
+		direction, compare = "DESC", "<"
+	}
+	if q.Cursor != "" {
+		tc, err := decodeTransactionCursor(q.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		if tc.Sort != q.Sort {
+			return "", nil, fmt.Errorf("%w: the cursor is for the sort %q", errTransactionCursor, tc.Sort)
+		}
+		cast := ""
+		switch column {
+		case "date":
+			cast = "::timestamp"
+		case "id", "shares", "amount":
+			cast = "::numeric"
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s%s, %s::numeric)", column, compare, arg(tc.Value), cast, arg(tc.Id)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("SELECT %s FROM %s", TransactionFields, tableName))
+	if len(where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+	}
+	// One more than the page is read to know if there is a next page.
+	sb.WriteString(fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %d;", column, direction, direction, q.limit()+1))
+	return sb.String(), args, nil
+}
+
+// QueryTransactions returns the page of the transactions of the table matching the query.
+func QueryTransactions(ctx context.Context, pg *pgxpool.Pool, tableName string, q *TransactionQuery) (*TransactionPage, error) {
+	column, _, err := q.sortColumn()
+	if err != nil {
+		return nil, err
+	}
+	statement, args, err := q.sql(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pg.Query(ctx, statement, args...)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := TransactionPage{Transactions: []*Transaction{}}
+	for rows.Next() {
+		tr := Transaction{}
+		if err := rows.Scan(&tr.Id, &tr.Date, &tr.Type, &tr.Symbol, &tr.Security, &tr.SecurityPayee, &tr.Account, &tr.Description, &tr.Shares, &tr.InvestmentAmount, &tr.Amount, &tr.Currency); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		page.Transactions = append(page.Transactions, &tr)
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	if limit := q.limit(); len(page.Transactions) > limit {
+		page.Transactions = page.Transactions[:limit]
+		page.NextCursor = encodeTransactionCursor(q.Sort, page.Transactions[limit-1], column)
+	}
+	return &page, nil
+}
+
+// WriteTransactionsCSV writes the transactions as csv with a header row.
+func WriteTransactionsCSV(w io.Writer, transactions []*Transaction) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(transactionCSVHeaders); err != nil {
+		return err
+	}
+	for _, tr := range transactions {
+		record := []string{
+			strconv.Itoa(tr.Id),
+			tr.Date.Format(dateToPgLayout),
+			string(tr.Type),
+			tr.Security,
+			tr.SecurityPayee,
+			tr.Symbol,
+			tr.Account,
+			tr.Description,
+			tr.Shares.String(),
+			tr.InvestmentAmount.String(),
+			tr.Amount.String(),
+			currencyOrDefault(tr.Currency),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTransactionsNDJSON writes each transaction as a JSON object on its own line.
+func WriteTransactionsNDJSON(w io.Writer, transactions []*Transaction) error {
+	enc := json.NewEncoder(w)
+	for _, tr := range transactions {
+		if err := enc.Encode(tr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTransactionQueryError returns true when the error is from an invalid sort, cursor or filter.
+func IsTransactionQueryError(err error) bool {
+	return errors.Is(err, errTransactionSort) || errors.Is(err, errTransactionCursor) || errors.Is(err, errTransactionQuery)
+}
@@ -0,0 +1,76 @@
+package model_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/kpearce2430/stock-tools/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryTransactions_Invalid(t *testing.T) {
+	min, max := model.NewDecimal(100), model.NewDecimal(10)
+	tests := []struct {
+		name  string
+		query model.TransactionQuery
+	}{
+		{"sort", model.TransactionQuery{Sort: "description"}},
+		{"cursor", model.TransactionQuery{Cursor: "not a cursor"}},
+		{"amount range", model.TransactionQuery{MinAmount: &min, MaxAmount: &max}},
+	}
+
+	// The query is checked before it is sent, so no connection is needed.
+	for _, tt := range tests {
+		if _, err := model.QueryTransactions(context.Background(), nil, "transactions", &tt.query); !model.IsTransactionQueryError(err) {
+			t.Errorf("%s: got %v, want an invalid query", tt.name, err)
+		}
+	}
+}
+
+func testQueryTransactions() []*model.Transaction {
+	return []*model.Transaction{
+		{Id: 1, Date: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC), Type: "Buy", Symbol: "CSX", Description: "100 shares @ 30.00, limit", Shares: model.NewDecimal(100), Amount: model.NewDecimal(-3000.00), Account: "Fidelity IRA"},
+		{Id: 2, Date: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Type: "Dividend Income", Symbol: "CSX", Amount: model.NewDecimal(11.00), Account: "Fidelity IRA", Currency: "USD"},
+	}
+}
+
+func TestWriteTransactionsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := model.WriteTransactionsCSV(&buf, testQueryTransactions()); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want a header and 2 transactions", len(records))
+	}
+	want := []string{"1", "2024-01-05", "Buy", "", "", "CSX", "Fidelity IRA", "100 shares @ 30.00, limit", "100", "0", "-3000", "USD"}
+	if strings.Join(records[1], "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", records[1], want)
+	}
+}
+
+func TestWriteTransactionsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := model.WriteTransactionsNDJSON(&buf, testQueryTransactions()); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var tr model.Transaction
+	if err := json.Unmarshal([]byte(lines[1]), &tr); err != nil {
+		t.Fatal(err.Error())
+	}
+	if tr.Id != 2 || tr.Amount != model.NewDecimal(11.00) {
+		t.Errorf("unexpected transaction %+v", tr)
+	}
+}
@@ -22,12 +22,12 @@ func TestTransactionSet_Load(t *testing.T) {
 
 	ls := model.LoadLookupSet("1", string(csvLookupData))
 
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, allTransactionsTable, testTransactions3); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, allTransactionsTable, testTransactions3); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
 
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, allTransactionsTable, testTransactions4); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, allTransactionsTable, testTransactions4); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
@@ -53,7 +53,7 @@ func TestTransactionFullLoad(t *testing.T) {
 
 	ls := model.LoadLookupSet("1", string(csvLookupData))
 
-	if err := model.TransactionSetLoadToDB(pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
+	if err := model.TransactionSetLoadToDB(context.Background(), pgxConn, ls, transactionTable, testTransactionsAll); err != nil {
 		t.Log(err.Error())
 		t.FailNow()
 	}
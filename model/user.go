@@ -0,0 +1,195 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	UsersTable     = "users"
+	APITokensTable = "api_tokens"
+
+	// apiTokenPrefix marks an API token, so it is not taken for a bearer token from an OIDC provider.
+	apiTokenPrefix = "st_"
+)
+
+var (
+	errUserNotFound   = errors.New("user not found")
+	errBootstrapToken = errors.New("bootstrap token must start with " + apiTokenPrefix + " and have 32 characters after it")
+)
+
+// IsUserNotFound returns true when the error is from a token or subject without a user.
+func IsUserNotFound(err error) bool {
+	return errors.Is(err, errUserNotFound)
+}
+
+// User is a caller of the server. The Subject is the sub claim of the bearer tokens of the user from an
+// OIDC provider, and the queries of the user are kept to the rows of their Portfolio.
+type User struct {
+	Name      string `json:"name"`
+	Subject   string `json:"subject,omitempty"`
+	Portfolio string `json:"portfolio"`
+}
+
+// APIToken is a token of a user for a script, only its hash is stored.
+type APIToken struct {
+	Name     string     `json:"name"`
+	User     string     `json:"user"`
+	Token    string     `json:"token,omitempty"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// IsAPIToken returns true when the bearer token is an API token rather than a JWT.
+func IsAPIToken(token string) bool {
+	return len(token) > len(apiTokenPrefix) && token[:len(apiTokenPrefix)] == apiTokenPrefix
+}
+
+// HashAPIToken returns the hash the token is stored as.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIToken returns a random API token.
+func NewAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + hex.EncodeToString(b), nil
+}
+
+func userGet(ctx context.Context, pg *pgxpool.Pool, where string, arg any) (*User, error) {
+	u := User{}
+	var subject *string
+	err := pg.QueryRow(ctx, fmt.Sprintf("SELECT name, subject, portfolio_id FROM %s WHERE %s = $1;", UsersTable, where), arg).
+		Scan(&u.Name, &subject, &u.Portfolio)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	if subject != nil {
+		u.Subject = *subject
+	}
+	return &u, nil
+}
+
+// UserGetBySubject returns the user with the subject of an OIDC bearer token.
+func UserGetBySubject(ctx context.Context, pg *pgxpool.Pool, subject string) (*User, error) {
+	return userGet(ctx, pg, "subject", subject)
+}
+
+// UserGetByToken returns the user of the API token and records when it was used.
+func UserGetByToken(ctx context.Context, pg *pgxpool.Pool, token string) (*User, error) {
+	var name string
+	err := pg.QueryRow(ctx, fmt.Sprintf("UPDATE %s SET last_used = $2 WHERE token_hash = $1 RETURNING username;", APITokensTable),
+		HashAPIToken(token), time.Now().UTC()).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	return userGet(ctx, pg, "name", name)
+}
+
+// CreateAPIToken stores a new API token of the user and returns it with the token, which is not kept.
+func CreateAPIToken(ctx context.Context, pg *pgxpool.Pool, user, name string) (*APIToken, error) {
+	token, err := NewAPIToken()
+	if err != nil {
+		return nil, err
+	}
+	t := APIToken{Name: name, User: user, Token: token, Created: time.Now().UTC()}
+	if _, err := pg.Exec(ctx, fmt.Sprintf("INSERT INTO %s(token_hash, username, name, created) VALUES($1,$2,$3,$4);", APITokensTable),
+		HashAPIToken(token), user, name, t.Created); err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	return &t, nil
+}
+
+// APITokensGet returns the API tokens of the user, without the tokens.
+func APITokensGet(ctx context.Context, pg *pgxpool.Pool, user string) ([]*APIToken, error) {
+	rows, err := pg.Query(ctx, fmt.Sprintf("SELECT name, username, created, last_used FROM %s WHERE username = $1 ORDER BY created;", APITokensTable), user)
+	if err != nil {
+		logrus.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*APIToken{}
+	for rows.Next() {
+		t := APIToken{}
+		if err := rows.Scan(&t.Name, &t.User, &t.Created, &t.LastUsed); err != nil {
+			logrus.Error(err.Error())
+			return nil, err
+		}
+		tokens = append(tokens, &t)
+	}
+	return tokens, rows.Err()
+}
+
+// UsersExist returns true when there is a user a bearer token can be of.
+func UsersExist(ctx context.Context, pg *pgxpool.Pool) (bool, error) {
+	var exists bool
+	if err := pg.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s);", UsersTable)).Scan(&exists); err != nil {
+		logrus.Error(err.Error())
+		return false, err
+	}
+	return exists, nil
+}
+
+// BootstrapUser adds the first user, a member of the default portfolio with the API token, so a server
+// with authentication can be used without editing the tables by hand. It returns false and does nothing
+// once there is a user.
+func BootstrapUser(ctx context.Context, pg *pgxpool.Pool, name, token string) (bool, error) {
+	if !IsAPIToken(token) || len(token) < len(apiTokenPrefix)+32 {
+		return false, errBootstrapToken
+	}
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		logrus.Error(err.Error())
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var exists bool
+	if err := tx.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s);", UsersTable)).Scan(&exists); err != nil {
+		logrus.Error(err.Error())
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	for _, statement := range []struct {
+		sql  string
+		args []any
+	}{
+		{fmt.Sprintf("INSERT INTO %s(name, portfolio_id) VALUES($1,$2);", UsersTable), []any{name, DefaultPortfolio}},
+		{fmt.Sprintf("INSERT INTO %s(token_hash, username, name, created) VALUES($1,$2,$3,$4);", APITokensTable),
+			[]any{HashAPIToken(token), name, "bootstrap", time.Now().UTC()}},
+		{fmt.Sprintf("INSERT INTO %s(portfolio_id, username) VALUES($1,$2);", PortfolioMembersTable), []any{DefaultPortfolio, name}},
+	} {
+		if _, err := tx.Exec(ctx, statement.sql, statement.args...); err != nil {
+			logrus.Error(err.Error())
+			return false, err
+		}
+	}
+	return true, tx.Commit(ctx)
+}
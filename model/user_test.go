@@ -0,0 +1,17 @@
+package model_test
+
+import (
+	"context"
+	"github.com/kpearce2430/stock-tools/model"
+	"strings"
+	"testing"
+)
+
+func TestBootstrapUser_Token(t *testing.T) {
+	// A token that is not a long API token is refused before the tables are read.
+	for _, token := range []string{"password", "st_short", strings.Repeat("a", 40)} {
+		if added, err := model.BootstrapUser(context.Background(), nil, "admin", token); err == nil || added {
+			t.Errorf("%s: got %t, %v, want an error", token, added, err)
+		}
+	}
+}
@@ -37,9 +37,18 @@ func (p *PolygonClient) GetData(symbol string, args ...string) ([]byte, error) {
 	return p.GetDailyOpenCloseAgg(symbol, args...)
 }
 
+// GetDataSet returns the dividends declared for the symbol in the last year, or since the date
+// (YYYY-MM-DD) in the first argument.
 func (p *PolygonClient) GetDataSet(symbol string, args ...string) ([]byte, error) {
 	start := time.Now()
 	divDate := time.Date(start.Year()-1, start.Month(), start.Day(), 00, 00, 00, 00, time.UTC)
+	if len(args) > 0 && args[0] != "" {
+		since, err := time.Parse("2006-01-02", args[0])
+		if err != nil {
+			return []byte("{}"), err
+		}
+		divDate = since
+	}
 	params := models.ListDividendsParams{}.WithTicker(models.EQ, symbol).WithDeclarationDate(models.GT, models.Date(divDate))
 	iter := p.Client.ListDividends(context.Background(), params)
 
@@ -57,6 +66,23 @@ func (p *PolygonClient) GetDataSet(symbol string, args ...string) ([]byte, error
 	return json.Marshal(dividends)
 }
 
+// GetSplits returns the splits for the symbol.
+func (p *PolygonClient) GetSplits(symbol string) ([]byte, error) {
+	params := models.ListSplitsParams{}.WithTicker(models.EQ, symbol)
+	iter := p.Client.ListSplits(context.Background(), params)
+
+	var splits []models.Split
+	for iter.Next() {
+		splits = append(splits, iter.Item())
+	}
+
+	if iter.Err() != nil {
+		return []byte("[]"), iter.Err()
+	}
+
+	return json.Marshal(splits)
+}
+
 func (p *PolygonClient) GetPreviousClose(symbol string) ([]byte, error) {
 	params := models.GetPreviousCloseAggParams{
 		Ticker: symbol,
@@ -0,0 +1,5 @@
+// Package stocktoolsv1 is the gRPC api of stocktools.proto, generated with protoc-gen-go and
+// protoc-gen-go-grpc.
+package stocktoolsv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative stocktools/v1/stocktools.proto
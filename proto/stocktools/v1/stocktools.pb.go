@@ -0,0 +1,1072 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: stocktools/v1/stocktools.proto
+
+package stocktoolsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListHoldingsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The symbol of the lots, every symbol when empty.
+	Symbol string `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The lots as they were on the date, today when empty.
+	AsOf          string `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHoldingsRequest) Reset() {
+	*x = ListHoldingsRequest{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHoldingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHoldingsRequest) ProtoMessage() {}
+
+func (x *ListHoldingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHoldingsRequest.ProtoReflect.Descriptor instead.
+func (*ListHoldingsRequest) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListHoldingsRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ListHoldingsRequest) GetAsOf() string {
+	if x != nil {
+		return x.AsOf
+	}
+	return ""
+}
+
+type ListHoldingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Holdings      []*Holding             `protobuf:"bytes,1,rep,name=holdings,proto3" json:"holdings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHoldingsResponse) Reset() {
+	*x = ListHoldingsResponse{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHoldingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHoldingsResponse) ProtoMessage() {}
+
+func (x *ListHoldingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHoldingsResponse.ProtoReflect.Descriptor instead.
+func (*ListHoldingsResponse) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListHoldingsResponse) GetHoldings() []*Holding {
+	if x != nil {
+		return x.Holdings
+	}
+	return nil
+}
+
+// Holding is an open lot, the shares left of a buy in an account.
+type Holding struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Symbol          string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Security        string                 `protobuf:"bytes,2,opt,name=security,proto3" json:"security,omitempty"`
+	Account         string                 `protobuf:"bytes,3,opt,name=account,proto3" json:"account,omitempty"`
+	Type            string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Acquired        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=acquired,proto3" json:"acquired,omitempty"`
+	OriginalShares  string                 `protobuf:"bytes,6,opt,name=original_shares,json=originalShares,proto3" json:"original_shares,omitempty"`
+	RemainingShares string                 `protobuf:"bytes,7,opt,name=remaining_shares,json=remainingShares,proto3" json:"remaining_shares,omitempty"`
+	CostPerShare    string                 `protobuf:"bytes,8,opt,name=cost_per_share,json=costPerShare,proto3" json:"cost_per_share,omitempty"`
+	Cost            string                 `protobuf:"bytes,9,opt,name=cost,proto3" json:"cost,omitempty"`
+	// short or long.
+	HoldingPeriod string `protobuf:"bytes,10,opt,name=holding_period,json=holdingPeriod,proto3" json:"holding_period,omitempty"`
+	DaysHeld      int32  `protobuf:"varint,11,opt,name=days_held,json=daysHeld,proto3" json:"days_held,omitempty"`
+	// The price and value are empty when there is no price for the symbol.
+	LatestPrice    string `protobuf:"bytes,12,opt,name=latest_price,json=latestPrice,proto3" json:"latest_price,omitempty"`
+	MarketValue    string `protobuf:"bytes,13,opt,name=market_value,json=marketValue,proto3" json:"market_value,omitempty"`
+	UnrealizedGain string `protobuf:"bytes,14,opt,name=unrealized_gain,json=unrealizedGain,proto3" json:"unrealized_gain,omitempty"`
+	Currency       string `protobuf:"bytes,15,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Holding) Reset() {
+	*x = Holding{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Holding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Holding) ProtoMessage() {}
+
+func (x *Holding) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Holding.ProtoReflect.Descriptor instead.
+func (*Holding) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Holding) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Holding) GetSecurity() string {
+	if x != nil {
+		return x.Security
+	}
+	return ""
+}
+
+func (x *Holding) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *Holding) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Holding) GetAcquired() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Acquired
+	}
+	return nil
+}
+
+func (x *Holding) GetOriginalShares() string {
+	if x != nil {
+		return x.OriginalShares
+	}
+	return ""
+}
+
+func (x *Holding) GetRemainingShares() string {
+	if x != nil {
+		return x.RemainingShares
+	}
+	return ""
+}
+
+func (x *Holding) GetCostPerShare() string {
+	if x != nil {
+		return x.CostPerShare
+	}
+	return ""
+}
+
+func (x *Holding) GetCost() string {
+	if x != nil {
+		return x.Cost
+	}
+	return ""
+}
+
+func (x *Holding) GetHoldingPeriod() string {
+	if x != nil {
+		return x.HoldingPeriod
+	}
+	return ""
+}
+
+func (x *Holding) GetDaysHeld() int32 {
+	if x != nil {
+		return x.DaysHeld
+	}
+	return 0
+}
+
+func (x *Holding) GetLatestPrice() string {
+	if x != nil {
+		return x.LatestPrice
+	}
+	return ""
+}
+
+func (x *Holding) GetMarketValue() string {
+	if x != nil {
+		return x.MarketValue
+	}
+	return ""
+}
+
+func (x *Holding) GetUnrealizedGain() string {
+	if x != nil {
+		return x.UnrealizedGain
+	}
+	return ""
+}
+
+func (x *Holding) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type ListTransactionsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Accounts []string               `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	Symbols  []string               `protobuf:"bytes,2,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	Types    []string               `protobuf:"bytes,3,rep,name=types,proto3" json:"types,omitempty"`
+	// On or after the start and before the end.
+	Start string `protobuf:"bytes,4,opt,name=start,proto3" json:"start,omitempty"`
+	End   string `protobuf:"bytes,5,opt,name=end,proto3" json:"end,omitempty"`
+	// In the description, ignoring case.
+	Text string `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`
+	// A field of the transaction, descending with a leading -.
+	Sort  string `protobuf:"bytes,7,opt,name=sort,proto3" json:"sort,omitempty"`
+	Limit int32  `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"`
+	// The next_cursor of the previous page.
+	Cursor        string `protobuf:"bytes,9,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsRequest) Reset() {
+	*x = ListTransactionsRequest{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsRequest) ProtoMessage() {}
+
+func (x *ListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListTransactionsRequest) GetAccounts() []string {
+	if x != nil {
+		return x.Accounts
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *ListTransactionsRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListTransactionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTransactionsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListTransactionsResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Transactions []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	// Empty on the last page.
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransactionsResponse) Reset() {
+	*x = ListTransactionsResponse{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransactionsResponse) ProtoMessage() {}
+
+func (x *ListTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ListTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListTransactionsResponse) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *ListTransactionsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type Transaction struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Date             *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Type             string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Security         string                 `protobuf:"bytes,4,opt,name=security,proto3" json:"security,omitempty"`
+	Symbol           string                 `protobuf:"bytes,5,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	SecurityPayee    string                 `protobuf:"bytes,6,opt,name=security_payee,json=securityPayee,proto3" json:"security_payee,omitempty"`
+	Description      string                 `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	Shares           string                 `protobuf:"bytes,8,opt,name=shares,proto3" json:"shares,omitempty"`
+	InvestmentAmount string                 `protobuf:"bytes,9,opt,name=investment_amount,json=investmentAmount,proto3" json:"investment_amount,omitempty"`
+	Amount           string                 `protobuf:"bytes,10,opt,name=amount,proto3" json:"amount,omitempty"`
+	Account          string                 `protobuf:"bytes,11,opt,name=account,proto3" json:"account,omitempty"`
+	Currency         string                 `protobuf:"bytes,12,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Transaction) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transaction) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Transaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Transaction) GetSecurity() string {
+	if x != nil {
+		return x.Security
+	}
+	return ""
+}
+
+func (x *Transaction) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Transaction) GetSecurityPayee() string {
+	if x != nil {
+		return x.SecurityPayee
+	}
+	return ""
+}
+
+func (x *Transaction) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Transaction) GetShares() string {
+	if x != nil {
+		return x.Shares
+	}
+	return ""
+}
+
+func (x *Transaction) GetInvestmentAmount() string {
+	if x != nil {
+		return x.InvestmentAmount
+	}
+	return ""
+}
+
+func (x *Transaction) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Transaction) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *Transaction) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type GetQuoteRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Symbol string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The quote of the business day of the date, the latest when empty.
+	Date          string `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQuoteRequest) Reset() {
+	*x = GetQuoteRequest{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuoteRequest) ProtoMessage() {}
+
+func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuoteRequest.ProtoReflect.Descriptor instead.
+func (*GetQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetQuoteRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *GetQuoteRequest) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+type Quote struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Symbol string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The day of the quote.
+	From          string  `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	Open          float64 `protobuf:"fixed64,3,opt,name=open,proto3" json:"open,omitempty"`
+	High          float64 `protobuf:"fixed64,4,opt,name=high,proto3" json:"high,omitempty"`
+	Low           float64 `protobuf:"fixed64,5,opt,name=low,proto3" json:"low,omitempty"`
+	Close         float64 `protobuf:"fixed64,6,opt,name=close,proto3" json:"close,omitempty"`
+	Volume        float64 `protobuf:"fixed64,7,opt,name=volume,proto3" json:"volume,omitempty"`
+	AfterHours    float64 `protobuf:"fixed64,8,opt,name=after_hours,json=afterHours,proto3" json:"after_hours,omitempty"`
+	PreMarket     float64 `protobuf:"fixed64,9,opt,name=pre_market,json=preMarket,proto3" json:"pre_market,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Quote) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Quote) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Quote) GetOpen() float64 {
+	if x != nil {
+		return x.Open
+	}
+	return 0
+}
+
+func (x *Quote) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *Quote) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *Quote) GetClose() float64 {
+	if x != nil {
+		return x.Close
+	}
+	return 0
+}
+
+func (x *Quote) GetVolume() float64 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+func (x *Quote) GetAfterHours() float64 {
+	if x != nil {
+		return x.AfterHours
+	}
+	return 0
+}
+
+func (x *Quote) GetPreMarket() float64 {
+	if x != nil {
+		return x.PreMarket
+	}
+	return 0
+}
+
+type GetDividendHistoryRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Symbol string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The year and month of the dividends, every one when zero.
+	Year          int32 `protobuf:"varint,2,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32 `protobuf:"varint,3,opt,name=month,proto3" json:"month,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDividendHistoryRequest) Reset() {
+	*x = GetDividendHistoryRequest{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDividendHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDividendHistoryRequest) ProtoMessage() {}
+
+func (x *GetDividendHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDividendHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetDividendHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetDividendHistoryRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *GetDividendHistoryRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *GetDividendHistoryRequest) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+type DividendHistory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Entries       []*DividendEntry       `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DividendHistory) Reset() {
+	*x = DividendHistory{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DividendHistory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DividendHistory) ProtoMessage() {}
+
+func (x *DividendHistory) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DividendHistory.ProtoReflect.Descriptor instead.
+func (*DividendHistory) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DividendHistory) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *DividendHistory) GetEntries() []*DividendEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type DividendEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"`
+	Amount        float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DividendEntry) Reset() {
+	*x = DividendEntry{}
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DividendEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DividendEntry) ProtoMessage() {}
+
+func (x *DividendEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_stocktools_v1_stocktools_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DividendEntry.ProtoReflect.Descriptor instead.
+func (*DividendEntry) Descriptor() ([]byte, []int) {
+	return file_stocktools_v1_stocktools_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DividendEntry) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *DividendEntry) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+func (x *DividendEntry) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+var File_stocktools_v1_stocktools_proto protoreflect.FileDescriptor
+
+const file_stocktools_v1_stocktools_proto_rawDesc = "" +
+	"\n" +
+	"\x1estocktools/v1/stocktools.proto\x12\rstocktools.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"B\n" +
+	"\x13ListHoldingsRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x13\n" +
+	"\x05as_of\x18\x02 \x01(\tR\x04asOf\"J\n" +
+	"\x14ListHoldingsResponse\x122\n" +
+	"\bholdings\x18\x01 \x03(\v2\x16.stocktools.v1.HoldingR\bholdings\"\x80\x04\n" +
+	"\aHolding\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\bsecurity\x18\x02 \x01(\tR\bsecurity\x12\x18\n" +
+	"\aaccount\x18\x03 \x01(\tR\aaccount\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x126\n" +
+	"\bacquired\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bacquired\x12'\n" +
+	"\x0foriginal_shares\x18\x06 \x01(\tR\x0eoriginalShares\x12)\n" +
+	"\x10remaining_shares\x18\a \x01(\tR\x0fremainingShares\x12$\n" +
+	"\x0ecost_per_share\x18\b \x01(\tR\fcostPerShare\x12\x12\n" +
+	"\x04cost\x18\t \x01(\tR\x04cost\x12%\n" +
+	"\x0eholding_period\x18\n" +
+	" \x01(\tR\rholdingPeriod\x12\x1b\n" +
+	"\tdays_held\x18\v \x01(\x05R\bdaysHeld\x12!\n" +
+	"\flatest_price\x18\f \x01(\tR\vlatestPrice\x12!\n" +
+	"\fmarket_value\x18\r \x01(\tR\vmarketValue\x12'\n" +
+	"\x0funrealized_gain\x18\x0e \x01(\tR\x0eunrealizedGain\x12\x1a\n" +
+	"\bcurrency\x18\x0f \x01(\tR\bcurrency\"\xe3\x01\n" +
+	"\x17ListTransactionsRequest\x12\x1a\n" +
+	"\baccounts\x18\x01 \x03(\tR\baccounts\x12\x18\n" +
+	"\asymbols\x18\x02 \x03(\tR\asymbols\x12\x14\n" +
+	"\x05types\x18\x03 \x03(\tR\x05types\x12\x14\n" +
+	"\x05start\x18\x04 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x05 \x01(\tR\x03end\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\x12\x12\n" +
+	"\x04sort\x18\a \x01(\tR\x04sort\x12\x14\n" +
+	"\x05limit\x18\b \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\t \x01(\tR\x06cursor\"{\n" +
+	"\x18ListTransactionsResponse\x12>\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x1a.stocktools.v1.TransactionR\ftransactions\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\xf1\x02\n" +
+	"\vTransaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12.\n" +
+	"\x04date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1a\n" +
+	"\bsecurity\x18\x04 \x01(\tR\bsecurity\x12\x16\n" +
+	"\x06symbol\x18\x05 \x01(\tR\x06symbol\x12%\n" +
+	"\x0esecurity_payee\x18\x06 \x01(\tR\rsecurityPayee\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12\x16\n" +
+	"\x06shares\x18\b \x01(\tR\x06shares\x12+\n" +
+	"\x11investment_amount\x18\t \x01(\tR\x10investmentAmount\x12\x16\n" +
+	"\x06amount\x18\n" +
+	" \x01(\tR\x06amount\x12\x18\n" +
+	"\aaccount\x18\v \x01(\tR\aaccount\x12\x1a\n" +
+	"\bcurrency\x18\f \x01(\tR\bcurrency\"=\n" +
+	"\x0fGetQuoteRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04date\x18\x02 \x01(\tR\x04date\"\xdb\x01\n" +
+	"\x05Quote\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x12\n" +
+	"\x04open\x18\x03 \x01(\x01R\x04open\x12\x12\n" +
+	"\x04high\x18\x04 \x01(\x01R\x04high\x12\x10\n" +
+	"\x03low\x18\x05 \x01(\x01R\x03low\x12\x14\n" +
+	"\x05close\x18\x06 \x01(\x01R\x05close\x12\x16\n" +
+	"\x06volume\x18\a \x01(\x01R\x06volume\x12\x1f\n" +
+	"\vafter_hours\x18\b \x01(\x01R\n" +
+	"afterHours\x12\x1d\n" +
+	"\n" +
+	"pre_market\x18\t \x01(\x01R\tpreMarket\"]\n" +
+	"\x19GetDividendHistoryRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04year\x18\x02 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x03 \x01(\x05R\x05month\"a\n" +
+	"\x0fDividendHistory\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x126\n" +
+	"\aentries\x18\x02 \x03(\v2\x1c.stocktools.v1.DividendEntryR\aentries\"Q\n" +
+	"\rDividendEntry\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount2\xec\x02\n" +
+	"\n" +
+	"StockTools\x12W\n" +
+	"\fListHoldings\x12\".stocktools.v1.ListHoldingsRequest\x1a#.stocktools.v1.ListHoldingsResponse\x12c\n" +
+	"\x10ListTransactions\x12&.stocktools.v1.ListTransactionsRequest\x1a'.stocktools.v1.ListTransactionsResponse\x12@\n" +
+	"\bGetQuote\x12\x1e.stocktools.v1.GetQuoteRequest\x1a\x14.stocktools.v1.Quote\x12^\n" +
+	"\x12GetDividendHistory\x12(.stocktools.v1.GetDividendHistoryRequest\x1a\x1e.stocktools.v1.DividendHistoryBEZCgithub.com/kpearce2430/stock-tools/proto/stocktools/v1;stocktoolsv1b\x06proto3"
+
+var (
+	file_stocktools_v1_stocktools_proto_rawDescOnce sync.Once
+	file_stocktools_v1_stocktools_proto_rawDescData []byte
+)
+
+func file_stocktools_v1_stocktools_proto_rawDescGZIP() []byte {
+	file_stocktools_v1_stocktools_proto_rawDescOnce.Do(func() {
+		file_stocktools_v1_stocktools_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stocktools_v1_stocktools_proto_rawDesc), len(file_stocktools_v1_stocktools_proto_rawDesc)))
+	})
+	return file_stocktools_v1_stocktools_proto_rawDescData
+}
+
+var file_stocktools_v1_stocktools_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_stocktools_v1_stocktools_proto_goTypes = []any{
+	(*ListHoldingsRequest)(nil),       // 0: stocktools.v1.ListHoldingsRequest
+	(*ListHoldingsResponse)(nil),      // 1: stocktools.v1.ListHoldingsResponse
+	(*Holding)(nil),                   // 2: stocktools.v1.Holding
+	(*ListTransactionsRequest)(nil),   // 3: stocktools.v1.ListTransactionsRequest
+	(*ListTransactionsResponse)(nil),  // 4: stocktools.v1.ListTransactionsResponse
+	(*Transaction)(nil),               // 5: stocktools.v1.Transaction
+	(*GetQuoteRequest)(nil),           // 6: stocktools.v1.GetQuoteRequest
+	(*Quote)(nil),                     // 7: stocktools.v1.Quote
+	(*GetDividendHistoryRequest)(nil), // 8: stocktools.v1.GetDividendHistoryRequest
+	(*DividendHistory)(nil),           // 9: stocktools.v1.DividendHistory
+	(*DividendEntry)(nil),             // 10: stocktools.v1.DividendEntry
+	(*timestamppb.Timestamp)(nil),     // 11: google.protobuf.Timestamp
+}
+var file_stocktools_v1_stocktools_proto_depIdxs = []int32{
+	2,  // 0: stocktools.v1.ListHoldingsResponse.holdings:type_name -> stocktools.v1.Holding
+	11, // 1: stocktools.v1.Holding.acquired:type_name -> google.protobuf.Timestamp
+	5,  // 2: stocktools.v1.ListTransactionsResponse.transactions:type_name -> stocktools.v1.Transaction
+	11, // 3: stocktools.v1.Transaction.date:type_name -> google.protobuf.Timestamp
+	10, // 4: stocktools.v1.DividendHistory.entries:type_name -> stocktools.v1.DividendEntry
+	0,  // 5: stocktools.v1.StockTools.ListHoldings:input_type -> stocktools.v1.ListHoldingsRequest
+	3,  // 6: stocktools.v1.StockTools.ListTransactions:input_type -> stocktools.v1.ListTransactionsRequest
+	6,  // 7: stocktools.v1.StockTools.GetQuote:input_type -> stocktools.v1.GetQuoteRequest
+	8,  // 8: stocktools.v1.StockTools.GetDividendHistory:input_type -> stocktools.v1.GetDividendHistoryRequest
+	1,  // 9: stocktools.v1.StockTools.ListHoldings:output_type -> stocktools.v1.ListHoldingsResponse
+	4,  // 10: stocktools.v1.StockTools.ListTransactions:output_type -> stocktools.v1.ListTransactionsResponse
+	7,  // 11: stocktools.v1.StockTools.GetQuote:output_type -> stocktools.v1.Quote
+	9,  // 12: stocktools.v1.StockTools.GetDividendHistory:output_type -> stocktools.v1.DividendHistory
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_stocktools_v1_stocktools_proto_init() }
+func file_stocktools_v1_stocktools_proto_init() {
+	if File_stocktools_v1_stocktools_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stocktools_v1_stocktools_proto_rawDesc), len(file_stocktools_v1_stocktools_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stocktools_v1_stocktools_proto_goTypes,
+		DependencyIndexes: file_stocktools_v1_stocktools_proto_depIdxs,
+		MessageInfos:      file_stocktools_v1_stocktools_proto_msgTypes,
+	}.Build()
+	File_stocktools_v1_stocktools_proto = out.File
+	file_stocktools_v1_stocktools_proto_goTypes = nil
+	file_stocktools_v1_stocktools_proto_depIdxs = nil
+}
@@ -0,0 +1,255 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: stocktools/v1/stocktools.proto
+
+package stocktoolsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StockTools_ListHoldings_FullMethodName       = "/stocktools.v1.StockTools/ListHoldings"
+	StockTools_ListTransactions_FullMethodName   = "/stocktools.v1.StockTools/ListTransactions"
+	StockTools_GetQuote_FullMethodName           = "/stocktools.v1.StockTools/GetQuote"
+	StockTools_GetDividendHistory_FullMethodName = "/stocktools.v1.StockTools/GetDividendHistory"
+)
+
+// StockToolsClient is the client API for StockTools service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// StockTools serves the holdings, transactions, quotes and dividend history of a portfolio to other
+// services. The portfolio is that of the user of the bearer token in the authorization metadata, or the one
+// the x-portfolio metadata selects when the user is a member of it, as for the HTTP api.
+//
+// Dates in requests are 2006-01-02, and amounts are decimal strings so they keep their precision.
+type StockToolsClient interface {
+	// ListHoldings returns the open lots of a symbol, or of every symbol, valued at the price of as_of.
+	ListHoldings(ctx context.Context, in *ListHoldingsRequest, opts ...grpc.CallOption) (*ListHoldingsResponse, error)
+	// ListTransactions returns a page of the transactions that match the request.
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	// GetQuote returns the daily quote of a symbol from the quote cache.
+	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	// GetDividendHistory returns the dividends of a symbol by month.
+	GetDividendHistory(ctx context.Context, in *GetDividendHistoryRequest, opts ...grpc.CallOption) (*DividendHistory, error)
+}
+
+type stockToolsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStockToolsClient(cc grpc.ClientConnInterface) StockToolsClient {
+	return &stockToolsClient{cc}
+}
+
+func (c *stockToolsClient) ListHoldings(ctx context.Context, in *ListHoldingsRequest, opts ...grpc.CallOption) (*ListHoldingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListHoldingsResponse)
+	err := c.cc.Invoke(ctx, StockTools_ListHoldings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stockToolsClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, StockTools_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stockToolsClient) GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, StockTools_GetQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stockToolsClient) GetDividendHistory(ctx context.Context, in *GetDividendHistoryRequest, opts ...grpc.CallOption) (*DividendHistory, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DividendHistory)
+	err := c.cc.Invoke(ctx, StockTools_GetDividendHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StockToolsServer is the server API for StockTools service.
+// All implementations must embed UnimplementedStockToolsServer
+// for forward compatibility.
+//
+// StockTools serves the holdings, transactions, quotes and dividend history of a portfolio to other
+// services. The portfolio is that of the user of the bearer token in the authorization metadata, or the one
+// the x-portfolio metadata selects when the user is a member of it, as for the HTTP api.
+//
+// Dates in requests are 2006-01-02, and amounts are decimal strings so they keep their precision.
+type StockToolsServer interface {
+	// ListHoldings returns the open lots of a symbol, or of every symbol, valued at the price of as_of.
+	ListHoldings(context.Context, *ListHoldingsRequest) (*ListHoldingsResponse, error)
+	// ListTransactions returns a page of the transactions that match the request.
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	// GetQuote returns the daily quote of a symbol from the quote cache.
+	GetQuote(context.Context, *GetQuoteRequest) (*Quote, error)
+	// GetDividendHistory returns the dividends of a symbol by month.
+	GetDividendHistory(context.Context, *GetDividendHistoryRequest) (*DividendHistory, error)
+	mustEmbedUnimplementedStockToolsServer()
+}
+
+// UnimplementedStockToolsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStockToolsServer struct{}
+
+func (UnimplementedStockToolsServer) ListHoldings(context.Context, *ListHoldingsRequest) (*ListHoldingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHoldings not implemented")
+}
+func (UnimplementedStockToolsServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedStockToolsServer) GetQuote(context.Context, *GetQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuote not implemented")
+}
+func (UnimplementedStockToolsServer) GetDividendHistory(context.Context, *GetDividendHistoryRequest) (*DividendHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDividendHistory not implemented")
+}
+func (UnimplementedStockToolsServer) mustEmbedUnimplementedStockToolsServer() {}
+func (UnimplementedStockToolsServer) testEmbeddedByValue()                    {}
+
+// UnsafeStockToolsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StockToolsServer will
+// result in compilation errors.
+type UnsafeStockToolsServer interface {
+	mustEmbedUnimplementedStockToolsServer()
+}
+
+func RegisterStockToolsServer(s grpc.ServiceRegistrar, srv StockToolsServer) {
+	// If the following call pancis, it indicates UnimplementedStockToolsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StockTools_ServiceDesc, srv)
+}
+
+func _StockTools_ListHoldings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHoldingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockToolsServer).ListHoldings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StockTools_ListHoldings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockToolsServer).ListHoldings(ctx, req.(*ListHoldingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StockTools_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockToolsServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StockTools_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockToolsServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StockTools_GetQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockToolsServer).GetQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StockTools_GetQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockToolsServer).GetQuote(ctx, req.(*GetQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StockTools_GetDividendHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDividendHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockToolsServer).GetDividendHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StockTools_GetDividendHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockToolsServer).GetDividendHistory(ctx, req.(*GetDividendHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StockTools_ServiceDesc is the grpc.ServiceDesc for StockTools service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StockTools_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stocktools.v1.StockTools",
+	HandlerType: (*StockToolsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListHoldings",
+			Handler:    _StockTools_ListHoldings_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _StockTools_ListTransactions_Handler,
+		},
+		{
+			MethodName: "GetQuote",
+			Handler:    _StockTools_GetQuote_Handler,
+		},
+		{
+			MethodName: "GetDividendHistory",
+			Handler:    _StockTools_GetDividendHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "stocktools/v1/stocktools.proto",
+}
@@ -12,6 +12,7 @@ CREATE TABLE IF NOT EXISTS transactions (
     investment_amount NUMERIC,
     amount NUMERIC,
     account varchar(255),
+    currency VARCHAR(3) DEFAULT 'USD',
     PRIMARY KEY(id)
 );
 
@@ -27,6 +28,7 @@ CREATE TABLE IF NOT EXISTS all_transactions (
     investment_amount NUMERIC,
     amount NUMERIC,
     account varchar(255),
+    currency VARCHAR(3) DEFAULT 'USD',
     PRIMARY KEY(id)
 );
 
@@ -40,6 +42,7 @@ CREATE TABLE IF NOT EXISTS fund_history (
     close NUMERIC,
     adj_close NUMERIC,
     volume NUMERIC,
+    currency VARCHAR(3) DEFAULT 'USD',
     PRIMARY KEY(symbol,date)
 );
 
@@ -53,6 +56,7 @@ CREATE TABLE IF NOT EXISTS test_history (
     close NUMERIC,
     adj_close NUMERIC,
     volume NUMERIC,
+    currency VARCHAR(3) DEFAULT 'USD',
     PRIMARY KEY(symbol,date)
     );
 
@@ -71,6 +75,7 @@ CREATE TABLE IF NOT EXISTS portfolio_value (
     gainloss12month NUMERIC,
     gainloss NUMERIC,
     gaillosspct NUMERIC,
+    currency VARCHAR(3) DEFAULT 'USD',
     PRIMARY KEY(symbol,date)
 );
 
@@ -102,3 +107,222 @@ CREATE TABLE IF NOT EXISTS dividend_history (
 
 -- CREATE INDEX IF NOT EXISTS ON dividend_history  USING (year,month);
 -- CREATE INDEX IF NOT EXISTS year_mo_index ON dividend_history(year,month);
+
+CREATE TABLE IF NOT EXISTS allocation_groups (
+    name VARCHAR(100),
+    account VARCHAR(255),
+    PRIMARY KEY(name,account)
+);
+
+CREATE TABLE IF NOT EXISTS allocation_targets (
+    account_group VARCHAR(100),
+    target VARCHAR(100),
+    target_type VARCHAR(25),
+    weight NUMERIC,
+    PRIMARY KEY(account_group,target)
+);
+
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    symbol VARCHAR(25),
+    action_date TIMESTAMP,
+    action_type VARCHAR(25),
+    split_from NUMERIC,
+    split_to NUMERIC,
+    new_symbol VARCHAR(25),
+    cash_per_share NUMERIC,
+    basis_percent NUMERIC,
+    source VARCHAR(25),
+    PRIMARY KEY(symbol,action_date,action_type)
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+    name VARCHAR(255),
+    account_type VARCHAR(25),
+    owner VARCHAR(100),
+    tax_treatment VARCHAR(25),
+    owner_birth_year INTEGER,
+    display_name VARCHAR(255),
+    institution VARCHAR(100),
+    open_date TIMESTAMP,
+    closed_date TIMESTAMP,
+    hidden BOOLEAN,
+    currency VARCHAR(3) DEFAULT 'USD',
+    PRIMARY KEY(name)
+);
+
+CREATE TABLE IF NOT EXISTS contribution_limits (
+    year INTEGER,
+    limit_type VARCHAR(25),
+    amount NUMERIC,
+    catch_up NUMERIC,
+    catch_up_age INTEGER,
+    PRIMARY KEY(year,limit_type)
+);
+
+CREATE TABLE IF NOT EXISTS fixed_income (
+    cusip VARCHAR(25),
+    description VARCHAR(255),
+    coupon_rate NUMERIC,
+    frequency INTEGER,
+    issue_date TIMESTAMP,
+    maturity_date TIMESTAMP,
+    par NUMERIC,
+    day_count VARCHAR(25),
+    call_schedule TEXT,
+    PRIMARY KEY(cusip)
+);
+
+CREATE TABLE IF NOT EXISTS fund_distributions (
+    symbol VARCHAR(10),
+    date TIMESTAMP,
+    distribution_type VARCHAR(50),
+    per_share NUMERIC,
+    source VARCHAR(50),
+    PRIMARY KEY(symbol, date, distribution_type)
+);
+
+CREATE TABLE IF NOT EXISTS fund_profiles (
+    symbol VARCHAR(10),
+    name VARCHAR(255),
+    family VARCHAR(255),
+    expense_ratio NUMERIC,
+    PRIMARY KEY(symbol)
+);
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base VARCHAR(3),
+    quote VARCHAR(3),
+    date TIMESTAMP,
+    rate NUMERIC,
+    source VARCHAR(50),
+    PRIMARY KEY(base, quote, date)
+);
+
+CREATE TABLE IF NOT EXISTS transaction_audit (
+    id SERIAL,
+    transaction_id NUMERIC,
+    table_name VARCHAR(50),
+    action VARCHAR(10),
+    username VARCHAR(255),
+    changed_at TIMESTAMP,
+    before JSONB,
+    after JSONB,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS transaction_audit_transaction ON transaction_audit(table_name, transaction_id);
+
+CREATE TABLE IF NOT EXISTS lookup_history (
+    table_name VARCHAR(50),
+    security VARCHAR(255),
+    symbol VARCHAR(25),
+    loaded TIMESTAMP,
+    PRIMARY KEY(table_name, security, loaded)
+);
+
+-- Amounts and prices loaded before currencies were tracked are in USD.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+ALTER TABLE all_transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+ALTER TABLE fund_history ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+ALTER TABLE test_history ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+ALTER TABLE portfolio_value ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+
+-- Users sign in with an API token, stored as its SHA-256, or a bearer token from an OIDC provider whose
+-- subject is the subject of the user. Each user sees the rows of their portfolio.
+CREATE TABLE IF NOT EXISTS users (
+    name VARCHAR(100),
+    subject VARCHAR(255) UNIQUE,
+    portfolio_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    created TIMESTAMP DEFAULT now(),
+    PRIMARY KEY(name)
+);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    token_hash VARCHAR(64),
+    username VARCHAR(100) REFERENCES users(name) ON DELETE CASCADE,
+    name VARCHAR(100),
+    created TIMESTAMP DEFAULT now(),
+    last_used TIMESTAMP,
+    PRIMARY KEY(token_hash)
+);
+
+-- The portfolios kept side by side, and the users who may select each besides their own.
+CREATE TABLE IF NOT EXISTS portfolios (
+    portfolio_id VARCHAR(64),
+    description VARCHAR(255),
+    created TIMESTAMP DEFAULT now(),
+    PRIMARY KEY(portfolio_id)
+);
+
+INSERT INTO portfolios(portfolio_id, description) VALUES('default', 'The portfolio loaded before portfolios') ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS portfolio_members (
+    portfolio_id VARCHAR(64) REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    username VARCHAR(100) REFERENCES users(name) ON DELETE CASCADE,
+    PRIMARY KEY(portfolio_id, username)
+);
+
+-- Shares moved between accounts on a date, removed from one and added to the other.
+CREATE TABLE IF NOT EXISTS account_events (
+    event_date TIMESTAMP,
+    event_type VARCHAR(32),
+    from_account VARCHAR(255),
+    to_account VARCHAR(255),
+    PRIMARY KEY(event_date, from_account, to_account)
+);
+
+-- The portfolio of each connection of the server, written by the owner of the tables before the queries of a
+-- request run on the connection. portfolio_user can neither read nor write it, so a query of a request cannot
+-- move its connection to another portfolio the way it could change a setting.
+CREATE TABLE IF NOT EXISTS portfolio_sessions (
+    pid INTEGER PRIMARY KEY,
+    portfolio_id VARCHAR(64) NOT NULL
+);
+
+-- current_portfolio is the portfolio of the connection, the default one for a connection the server has not
+-- bound to a portfolio. It is left parallel unsafe, as a parallel worker has a pid of its own.
+CREATE OR REPLACE FUNCTION current_portfolio() RETURNS VARCHAR AS $$
+    SELECT COALESCE((SELECT portfolio_id FROM portfolio_sessions WHERE pid = pg_backend_pid()), 'default')
+$$ LANGUAGE SQL STABLE SECURITY DEFINER SET search_path = public, pg_temp;
+
+-- The rows of a household belong to a portfolio, the rows loaded before portfolios are in the default one.
+-- The market data (prices, dividends, fund profiles, corporate actions, bonds, rates and limits) is shared.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['transactions', 'all_transactions', 'portfolio_value', 'lookups', 'lookup_history',
+        'dividend_history', 'allocation_groups', 'allocation_targets', 'accounts', 'transaction_audit', 'account_events'] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS portfolio_id VARCHAR(64) NOT NULL DEFAULT current_portfolio()', t);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS portfolio_scope ON %I', t);
+        EXECUTE format('CREATE POLICY portfolio_scope ON %I USING (portfolio_id = (SELECT current_portfolio())) WITH CHECK (portfolio_id = (SELECT current_portfolio()))', t);
+    END LOOP;
+END $$;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_pkey, ADD PRIMARY KEY(portfolio_id, id);
+ALTER TABLE all_transactions DROP CONSTRAINT IF EXISTS all_transactions_pkey, ADD PRIMARY KEY(portfolio_id, id);
+ALTER TABLE portfolio_value DROP CONSTRAINT IF EXISTS portfolio_value_pkey, ADD PRIMARY KEY(portfolio_id, symbol, date);
+ALTER TABLE lookups DROP CONSTRAINT IF EXISTS lookups_pkey, ADD PRIMARY KEY(portfolio_id, security);
+ALTER TABLE lookup_history DROP CONSTRAINT IF EXISTS lookup_history_pkey, ADD PRIMARY KEY(portfolio_id, table_name, security, loaded);
+ALTER TABLE dividend_history DROP CONSTRAINT IF EXISTS dividend_history_pkey, ADD PRIMARY KEY(portfolio_id, symbol, year, month);
+ALTER TABLE allocation_groups DROP CONSTRAINT IF EXISTS allocation_groups_pkey, ADD PRIMARY KEY(portfolio_id, name, account);
+ALTER TABLE allocation_targets DROP CONSTRAINT IF EXISTS allocation_targets_pkey, ADD PRIMARY KEY(portfolio_id, account_group, target);
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_pkey, ADD PRIMARY KEY(portfolio_id, name);
+ALTER TABLE account_events DROP CONSTRAINT IF EXISTS account_events_pkey, ADD PRIMARY KEY(portfolio_id, event_date, from_account, to_account);
+
+-- A superuser and the owner of a table are not held to its policies, so the server logs in as portfolio_user
+-- (PG_PORTFOLIO_DATABASE_URL) for the queries of a request. A role the connection switched to could be reset
+-- by a query, the user it logged in as cannot. Change the password of a server that is not for development.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'portfolio_user') THEN
+        CREATE ROLE portfolio_user LOGIN PASSWORD 'portfolio_user';
+    END IF;
+END $$;
+ALTER ROLE portfolio_user LOGIN;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO portfolio_user;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO portfolio_user;
+REVOKE ALL ON portfolio_sessions FROM portfolio_user;
@@ -19,6 +19,9 @@ type CacheClient interface {
 type Cache[T any] struct {
 	couchdatabase.DatabaseStore[T]
 	client CacheClient
+
+	// OnRefresh is called with each value read from the client into the cache when it is set.
+	OnRefresh func(ticker string, value *T)
 }
 
 func NewCache[T any](dataConfig *couchdatabase.DatabaseConfig, client CacheClient) (*Cache[T], error) {
@@ -68,6 +71,9 @@ func (c *Cache[T]) GetCache(ticker string, args ...string) (*T, error) {
 		return nil, err
 	}
 	logrus.Debug("Added ", id)
+	if c.OnRefresh != nil {
+		c.OnRefresh(ticker, &response)
+	}
 	return &response, nil
 }
 
@@ -114,6 +120,9 @@ func (c *Cache[T]) GetCacheSet(ticker string, args ...string) (*T, error) {
 				return nil, err
 			}
 			logrus.Debug("Added ", id)
+			if c.OnRefresh != nil {
+				c.OnRefresh(ticker, &r)
+			}
 			break //TODO - Handle more than one record
 		}
 		return &responses[0], nil